@@ -78,6 +78,10 @@ type Interface interface {
 
 	// Statistics returns the current, tracked Statistics instance for this device
 	Statistics() Statistics
+
+	// Services returns the on-device services this device has registered and which are still
+	// sending ServiceAlive messages, sorted by name
+	Services() []Service
 }
 
 // device is the internal Interface implementation.  This type holds the internal
@@ -90,6 +94,7 @@ type device struct {
 	debugLog log.Logger
 
 	statistics Statistics
+	services   *services
 
 	state int32
 
@@ -99,10 +104,12 @@ type device struct {
 }
 
 type deviceOptions struct {
-	ID          ID
-	QueueSize   int
-	ConnectedAt time.Time
-	Logger      log.Logger
+	ID                  ID
+	QueueSize           int
+	ConnectedAt         time.Time
+	Logger              log.Logger
+	ServiceAliveTimeout time.Duration
+	Now                 func() time.Time
 }
 
 // newDevice is an internal factory function for devices
@@ -125,6 +132,7 @@ func newDevice(o deviceOptions) *device {
 		infoLog:      logging.Info(o.Logger, "id", o.ID),
 		debugLog:     logging.Debug(o.Logger, "id", o.ID),
 		statistics:   NewStatistics(nil, o.ConnectedAt),
+		services:     newServices(o.ServiceAliveTimeout, o.Now),
 		state:        stateOpen,
 		shutdown:     make(chan struct{}),
 		messages:     make(chan *envelope, o.QueueSize),
@@ -262,3 +270,7 @@ func (d *device) Send(request *Request) (*Response, error) {
 func (d *device) Statistics() Statistics {
 	return d.statistics
 }
+
+func (d *device) Services() []Service {
+	return d.services.list()
+}
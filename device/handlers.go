@@ -3,6 +3,7 @@ package device
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
@@ -183,6 +184,8 @@ func (mh *MessageHandler) ServeHTTP(httpResponse http.ResponseWriter, httpReques
 			code = http.StatusBadRequest
 		case ErrorDeviceNotFound:
 			code = http.StatusNotFound
+		case ErrorServiceNotRegistered:
+			code = http.StatusNotFound
 		case ErrorNonUniqueID:
 			code = http.StatusBadRequest
 		case ErrorInvalidTransactionKey:
@@ -367,3 +370,56 @@ func (sh *StatHandler) ServeHTTP(response http.ResponseWriter, request *http.Req
 	response.Header().Set("Content-Type", "application/json")
 	response.Write(data)
 }
+
+// ServicesHandler is an http.Handler that returns the on-device services registered by a device.  The device
+// name is specified as a gorilla path variable.  The response is a JSON object of the form
+// {"id": "mac:112233445566", "services": [{"name": "config", "url": "...", ...}]}.
+type ServicesHandler struct {
+	Logger   log.Logger
+	Registry Registry
+	Variable string
+}
+
+func (sh *ServicesHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	sh.Logger.Log(level.Key(), level.DebugValue(), "handler", "ServicesHandler", logging.MessageKey(), "ServeHTTP")
+	vars := mux.Vars(request)
+	if len(vars) == 0 {
+		sh.Logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "no path variables present for request")
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	name, ok := vars[sh.Variable]
+	if !ok {
+		sh.Logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "missing path variable", "variable", sh.Variable)
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	id, err := ParseID(name)
+	if err != nil {
+		sh.Logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "unable to parse identifier", "deviceName", name, logging.ErrorKey(), err)
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	d, ok := sh.Registry.Get(id)
+	if !ok {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(struct {
+		ID       ID        `json:"id"`
+		Services []Service `json:"services"`
+	}{d.ID(), d.Services()})
+
+	if err != nil {
+		sh.Logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "unable to marshal services as JSON", "deviceName", name, logging.ErrorKey(), err)
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Write(data)
+}
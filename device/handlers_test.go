@@ -866,3 +866,110 @@ func TestStatHandler(t *testing.T) {
 	t.Run("MarshalJSONFailed", testStatHandlerMarshalJSONFailed)
 	t.Run("Success", testStatHandlerSuccess)
 }
+
+func testServicesHandlerNoPathVariables(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		registry = new(mockRegistry)
+
+		handler = ServicesHandler{
+			Logger:   logging.NewTestLogger(nil, t),
+			Registry: registry,
+		}
+
+		request  = httptest.NewRequest("GET", "/", nil)
+		response = httptest.NewRecorder()
+	)
+
+	handler.ServeHTTP(response, request)
+	assert.Equal(http.StatusInternalServerError, response.Code)
+	registry.AssertExpectations(t)
+}
+
+func testServicesHandlerInvalidDeviceName(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		registry = new(mockRegistry)
+
+		handler = ServicesHandler{
+			Logger:   logging.NewTestLogger(nil, t),
+			Registry: registry,
+			Variable: "deviceID",
+		}
+
+		router   = mux.NewRouter()
+		request  = httptest.NewRequest("GET", "/asdfqwer:thisisnotvalidasdfasdf/services", nil)
+		response = httptest.NewRecorder()
+	)
+
+	router.Handle("/{deviceID}/services", &handler)
+	router.ServeHTTP(response, request)
+	assert.Equal(http.StatusBadRequest, response.Code)
+	registry.AssertExpectations(t)
+}
+
+func testServicesHandlerMissingDevice(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		registry = new(mockRegistry)
+
+		handler = ServicesHandler{
+			Logger:   logging.NewTestLogger(nil, t),
+			Registry: registry,
+			Variable: "deviceID",
+		}
+
+		router   = mux.NewRouter()
+		request  = httptest.NewRequest("GET", "/mac:112233445566/services", nil)
+		response = httptest.NewRecorder()
+	)
+
+	router.Handle("/{deviceID}/services", &handler)
+	registry.On("Get", ID("mac:112233445566")).Return(nil, false).Once()
+
+	router.ServeHTTP(response, request)
+	assert.Equal(http.StatusNotFound, response.Code)
+	registry.AssertExpectations(t)
+}
+
+func testServicesHandlerSuccess(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		registry = new(mockRegistry)
+		device   = new(mockDevice)
+		now      = time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		handler = ServicesHandler{
+			Logger:   logging.NewTestLogger(nil, t),
+			Registry: registry,
+			Variable: "deviceID",
+		}
+
+		router   = mux.NewRouter()
+		request  = httptest.NewRequest("GET", "/mac:112233445566/services", nil)
+		response = httptest.NewRecorder()
+	)
+
+	router.Handle("/{deviceID}/services", &handler)
+	registry.On("Get", ID("mac:112233445566")).Return(device, true).Once()
+	device.On("ID").Return(ID("mac:112233445566")).Once()
+	device.On("Services").Return([]Service{{Name: "config", URL: "tcp://127.0.0.1:6666", RegisteredAt: now, LastAlive: now}}).Once()
+
+	router.ServeHTTP(response, request)
+	assert.Equal(http.StatusOK, response.Code)
+	assert.Equal("application/json", response.Header().Get("Content-Type"))
+	assert.JSONEq(
+		`{"id": "mac:112233445566", "services": [{"name": "config", "url": "tcp://127.0.0.1:6666", "registeredAt": "2018-06-01T12:00:00Z", "lastAlive": "2018-06-01T12:00:00Z"}]}`,
+		response.Body.String(),
+	)
+
+	registry.AssertExpectations(t)
+	device.AssertExpectations(t)
+}
+
+func TestServicesHandler(t *testing.T) {
+	t.Run("NoPathVariables", testServicesHandlerNoPathVariables)
+	t.Run("InvalidDeviceName", testServicesHandlerInvalidDeviceName)
+	t.Run("MissingDevice", testServicesHandlerMissingDevice)
+	t.Run("Success", testServicesHandlerSuccess)
+}
@@ -115,6 +115,10 @@ func NewManager(o *Options) Manager {
 		pingPeriod:             o.pingPeriod(),
 		authDelay:              o.authDelay(),
 
+		serviceAliveTimeout:        o.serviceAliveTimeout(),
+		requireServiceRegistration: o.requireServiceRegistration(),
+		now:                        o.now(),
+
 		listeners: o.listeners(),
 		measures:  measures,
 	}
@@ -137,6 +141,10 @@ type manager struct {
 	pingPeriod             time.Duration
 	authDelay              time.Duration
 
+	serviceAliveTimeout        time.Duration
+	requireServiceRegistration bool
+	now                        func() time.Time
+
 	listeners []Listener
 	measures  Measures
 }
@@ -154,7 +162,14 @@ func (m *manager) Connect(response http.ResponseWriter, request *http.Request, r
 		return nil, ErrorMissingDeviceNameContext
 	}
 
-	d := newDevice(deviceOptions{ID: id, QueueSize: m.deviceMessageQueueSize, Logger: m.logger})
+	d := newDevice(deviceOptions{
+		ID:                  id,
+		QueueSize:           m.deviceMessageQueueSize,
+		Logger:              m.logger,
+		ServiceAliveTimeout: m.serviceAliveTimeout,
+		Now:                 m.now,
+	})
+
 	convey, conveyErr := m.conveyTranslator.FromHeader(request.Header)
 	if conveyErr == nil {
 		d.infoLog.Log("convey", convey)
@@ -285,8 +300,12 @@ func (m *manager) readPump(d *device, r ReadCloser, closeOnce *sync.Once) {
 			continue
 		}
 
-		if message.Type == wrp.SimpleRequestResponseMessageType {
+		switch message.Type {
+		case wrp.SimpleRequestResponseMessageType:
 			m.measures.RequestResponse.Add(1.0)
+
+		case wrp.ServiceRegistrationMessageType, wrp.ServiceAliveMessageType:
+			d.services.update(message)
 		}
 
 		// update any waiting transaction
@@ -459,7 +478,13 @@ func (m *manager) Route(request *Request) (*Response, error) {
 	if destination, err := request.ID(); err != nil {
 		return nil, err
 	} else if d, ok := m.devices.get(destination); ok {
-		return d.Send(request)
+		if m.requireServiceRegistration {
+			if service := destinationService(request); len(service) > 0 && !d.services.registered(service) {
+				return nil, ErrorServiceNotRegistered
+			}
+		}
+
+		return d.Send(withTraceParent(request))
 	} else {
 		return nil, ErrorDeviceNotFound
 	}
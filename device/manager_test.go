@@ -12,8 +12,10 @@ import (
 
 	"github.com/Comcast/webpa-common/logging"
 	"github.com/Comcast/webpa-common/wrp"
+	"github.com/gorilla/websocket"
 	"github.com/justinas/alice"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -352,6 +354,71 @@ func testManagerConnectIncludesConvey(t *testing.T) {
 	assert.Equal("WebPA-1.6", convey["webpa-protocol"])
 }
 
+func testManagerRouteServiceNotRegistered(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		connectWait  = new(sync.WaitGroup)
+		registerWait = new(sync.WaitGroup)
+
+		options = &Options{
+			Logger:                     logging.NewTestLogger(nil, t),
+			RequireServiceRegistration: true,
+			Listeners: []Listener{
+				func(event *Event) {
+					switch event.Type {
+					case Connect:
+						connectWait.Done()
+					case MessageReceived:
+						if event.Message.MessageType() == wrp.ServiceRegistrationMessageType {
+							registerWait.Done()
+						}
+					}
+				},
+			},
+		}
+	)
+
+	connectWait.Add(1)
+	registerWait.Add(1)
+
+	manager, server, connectURL := startWebsocketServer(options)
+	defer server.Close()
+
+	deviceConnection, _, err := DefaultDialer().DialDevice(string(testDeviceIDs[0]), connectURL, nil)
+	require.NoError(err)
+	defer deviceConnection.Close()
+	connectWait.Wait()
+
+	var registration []byte
+	require.NoError(
+		wrp.NewEncoderBytes(&registration, wrp.Msgpack).Encode(
+			&wrp.ServiceRegistration{ServiceName: "config", URL: "tcp://127.0.0.1:6666"},
+		),
+	)
+
+	require.NoError(deviceConnection.WriteMessage(websocket.BinaryMessage, registration))
+	registerWait.Wait()
+
+	d, ok := manager.Get(testDeviceIDs[0])
+	require.True(ok)
+	services := d.Services()
+	require.Len(services, 1)
+	assert.Equal("config", services[0].Name)
+	assert.Equal("tcp://127.0.0.1:6666", services[0].URL)
+
+	response, err := manager.Route(&Request{
+		Message: &wrp.SimpleEvent{
+			Source:      "dns:somewhere.comcast.net",
+			Destination: string(testDeviceIDs[0]) + "/nosuch",
+		},
+	})
+
+	assert.Nil(response)
+	assert.Equal(ErrorServiceNotRegistered, err)
+}
+
 func TestManager(t *testing.T) {
 	t.Run("Connect", func(t *testing.T) {
 		t.Run("MissingDeviceContext", testManagerConnectMissingDeviceContext)
@@ -363,6 +430,7 @@ func TestManager(t *testing.T) {
 	t.Run("Route", func(t *testing.T) {
 		t.Run("BadDestination", testManagerRouteBadDestination)
 		t.Run("DeviceNotFound", testManagerRouteDeviceNotFound)
+		t.Run("ServiceNotRegistered", testManagerRouteServiceNotRegistered)
 	})
 
 	t.Run("Disconnect", testManagerDisconnect)
@@ -103,6 +103,12 @@ func (m *mockDevice) Statistics() Statistics {
 	return first
 }
 
+func (m *mockDevice) Services() []Service {
+	arguments := m.Called()
+	first, _ := arguments.Get(0).([]Service)
+	return first
+}
+
 func (m *mockDevice) Send(request *Request) (*Response, error) {
 	arguments := m.Called(request)
 	first, _ := arguments.Get(0).(*Response)
@@ -23,6 +23,8 @@ const (
 	DefaultPingPeriod     time.Duration = 45 * time.Second
 	DefaultAuthDelay      time.Duration = 1 * time.Second
 
+	DefaultServiceAliveTimeout time.Duration = 5 * time.Minute
+
 	DefaultReadBufferSize         = 0
 	DefaultWriteBufferSize        = 0
 	DefaultDeviceMessageQueueSize = 100
@@ -59,6 +61,15 @@ type Options struct {
 	// DefaultWriteTimeout is used.
 	WriteTimeout time.Duration
 
+	// ServiceAliveTimeout is the length of time an on-device service remains registered without
+	// the device sending a ServiceAlive message for it.  If not supplied, DefaultServiceAliveTimeout is used.
+	ServiceAliveTimeout time.Duration
+
+	// RequireServiceRegistration indicates whether requests addressed to an on-device service, e.g.
+	// mac:112233445566/config, are rejected with ErrorServiceNotRegistered unless the device has registered
+	// that service.  Requests addressed to the device itself, with no service, are always routed.
+	RequireServiceRegistration bool
+
 	// Listeners contains the event sinks for managers created using these options
 	Listeners []Listener
 
@@ -138,6 +149,18 @@ func (o *Options) writeTimeout() time.Duration {
 	return DefaultWriteTimeout
 }
 
+func (o *Options) serviceAliveTimeout() time.Duration {
+	if o != nil && o.ServiceAliveTimeout > 0 {
+		return o.ServiceAliveTimeout
+	}
+
+	return DefaultServiceAliveTimeout
+}
+
+func (o *Options) requireServiceRegistration() bool {
+	return o != nil && o.RequireServiceRegistration
+}
+
 func (o *Options) logger() log.Logger {
 	if o != nil && o.Logger != nil {
 		return o.Logger
@@ -23,6 +23,8 @@ func TestOptionsDefault(t *testing.T) {
 		assert.Equal(DefaultPingPeriod, o.pingPeriod())
 		assert.Equal(DefaultAuthDelay, o.authDelay())
 		assert.Equal(DefaultWriteTimeout, o.writeTimeout())
+		assert.Equal(DefaultServiceAliveTimeout, o.serviceAliveTimeout())
+		assert.False(o.requireServiceRegistration())
 		assert.NotNil(o.logger())
 		assert.Empty(o.listeners())
 		assert.Equal(provider.NewDiscardProvider(), o.metricsProvider())
@@ -42,15 +44,17 @@ func TestOptions(t *testing.T) {
 				WriteBufferSize:  DefaultWriteBufferSize + 926,
 				Subprotocols:     []string{"foobar"},
 			},
-			MaxDevices:             20000,
-			DeviceMessageQueueSize: DefaultDeviceMessageQueueSize + 287342,
-			IdlePeriod:             DefaultIdlePeriod + 3472*time.Minute,
-			PingPeriod:             DefaultPingPeriod + 384*time.Millisecond,
-			AuthDelay:              DefaultAuthDelay + 88*time.Millisecond,
-			WriteTimeout:           DefaultWriteTimeout + 327193*time.Second,
-			Logger:                 expectedLogger,
-			Listeners:              []Listener{func(*Event) {}},
-			MetricsProvider:        expectedMetricsProvider,
+			MaxDevices:                 20000,
+			DeviceMessageQueueSize:     DefaultDeviceMessageQueueSize + 287342,
+			IdlePeriod:                 DefaultIdlePeriod + 3472*time.Minute,
+			PingPeriod:                 DefaultPingPeriod + 384*time.Millisecond,
+			AuthDelay:                  DefaultAuthDelay + 88*time.Millisecond,
+			WriteTimeout:               DefaultWriteTimeout + 327193*time.Second,
+			ServiceAliveTimeout:        DefaultServiceAliveTimeout + 17*time.Second,
+			RequireServiceRegistration: true,
+			Logger:                     expectedLogger,
+			Listeners:                  []Listener{func(*Event) {}},
+			MetricsProvider:            expectedMetricsProvider,
 		}
 	)
 
@@ -70,6 +74,8 @@ func TestOptions(t *testing.T) {
 	assert.Equal(o.PingPeriod, o.pingPeriod())
 	assert.Equal(o.AuthDelay, o.authDelay())
 	assert.Equal(o.WriteTimeout, o.writeTimeout())
+	assert.Equal(o.ServiceAliveTimeout, o.serviceAliveTimeout())
+	assert.True(o.requireServiceRegistration())
 	assert.Equal(expectedLogger, o.logger())
 	assert.Equal(o.Listeners, o.listeners())
 	assert.Equal(expectedMetricsProvider, o.metricsProvider())
@@ -6,11 +6,13 @@ import (
 )
 
 const (
-	RehashKeepDevice           = "rehash_keep_device"
-	RehashDisconnectDevice     = "rehash_disconnect_device"
-	RehashDisconnectAllCounter = "rehash_disconnect_all_count"
-	RehashTimestamp            = "rehash_timestamp"
-	RehashDurationMilliseconds = "rehash_duration_ms"
+	RehashKeepDevice               = "rehash_keep_device"
+	RehashDisconnectDevice         = "rehash_disconnect_device"
+	RehashDeferredDisconnectDevice = "rehash_deferred_disconnect_device"
+	RehashDryRunDisconnectDevice   = "rehash_dry_run_disconnect_device"
+	RehashDisconnectAllCounter     = "rehash_disconnect_all_count"
+	RehashTimestamp                = "rehash_timestamp"
+	RehashDurationMilliseconds     = "rehash_duration_ms"
 
 	ReasonLabel = "reason"
 
@@ -32,6 +34,16 @@ func Metrics() []xmetrics.Metric {
 			Type:       "gauge",
 			LabelNames: []string{service.ServiceLabel},
 		},
+		{
+			Name:       RehashDeferredDisconnectDevice,
+			Type:       "gauge",
+			LabelNames: []string{service.ServiceLabel},
+		},
+		{
+			Name:       RehashDryRunDisconnectDevice,
+			Type:       "gauge",
+			LabelNames: []string{service.ServiceLabel},
+		},
 		{
 			Name:       RehashDisconnectAllCounter,
 			Type:       "counter",
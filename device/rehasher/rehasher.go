@@ -1,6 +1,8 @@
 package rehasher
 
 import (
+	"math"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -14,6 +16,9 @@ import (
 	"github.com/Comcast/webpa-common/service/monitor"
 )
 
+// DefaultDeferredInterval is the default wait before rehashing again when a rehash deferred disconnects
+const DefaultDeferredInterval = 10 * time.Second
+
 // Option is a configuration option for a rehasher
 type Option func(*rehasher)
 
@@ -48,6 +53,72 @@ func WithIsRegistered(f func(string) bool) Option {
 	}
 }
 
+// WithReplicas configures the number of instances, in hash preference order, to which a device may hash and
+// remain connected.  When n is greater than 1, a device is only disconnected if this process is not among the
+// device's first n instances, which requires an AccessorFactory that produces service.OrderedAccessor objects.
+// If n is nonpositive, a device must hash to this process, which is the default.
+func WithReplicas(n int) Option {
+	return func(r *rehasher) {
+		if n > 0 {
+			r.replicas = n
+		} else {
+			r.replicas = 1
+		}
+	}
+}
+
+// WithDebounce configures a rehasher to wait for service discovery to settle before rehashing.  Each update to
+// the instances restarts the wait, and only the most recent instances are used to rehash.  If d is nonpositive,
+// devices are rehashed on every update, which is the default.
+func WithDebounce(d time.Duration) Option {
+	return func(r *rehasher) {
+		r.debounce = d
+	}
+}
+
+// WithErrorHold configures how long a rehasher keeps its devices connected after a service discovery error or an
+// update with no instances.  If service discovery recovers within this period, devices are rehashed against the new
+// instances instead of being disconnected.  If d is nonpositive, all devices are disconnected immediately, which is the default.
+func WithErrorHold(d time.Duration) Option {
+	return func(r *rehasher) {
+		r.errorHold = d
+	}
+}
+
+// WithMaxDisconnectFraction configures the largest fraction of connected devices that a single rehash will disconnect.
+// Devices beyond this limit remain connected until a subsequent rehash, which is scheduled after the deferred interval
+// for as long as any disconnects are deferred.  If f is nonpositive or at least 1, there is no limit, which is the default.
+func WithMaxDisconnectFraction(f float64) Option {
+	return func(r *rehasher) {
+		if f > 0.0 && f < 1.0 {
+			r.maxDisconnectFraction = f
+		} else {
+			r.maxDisconnectFraction = 0.0
+		}
+	}
+}
+
+// WithDeferredInterval configures how long a rehasher waits before rehashing again when the max disconnect fraction
+// deferred some disconnects.  Any update to the instances in the meantime supersedes the follow-up rehash.  If d is
+// nonpositive, DefaultDeferredInterval is used.
+func WithDeferredInterval(d time.Duration) Option {
+	return func(r *rehasher) {
+		if d > 0 {
+			r.deferredInterval = d
+		} else {
+			r.deferredInterval = DefaultDeferredInterval
+		}
+	}
+}
+
+// WithDryRun configures a rehasher to only log and report, via metrics, the devices that would be disconnected.
+// No devices are disconnected in dry run mode.
+func WithDryRun(dryRun bool) Option {
+	return func(r *rehasher) {
+		r.dryRun = dryRun
+	}
+}
+
 // WithEnvironment configures a rehasher to use a service discovery environment.
 func WithEnvironment(e service.Environment) Option {
 	return func(r *rehasher) {
@@ -66,6 +137,8 @@ func WithMetricsProvider(p provider.Provider) Option {
 
 		r.keep = p.NewGauge(RehashKeepDevice)
 		r.disconnect = p.NewGauge(RehashDisconnectDevice)
+		r.deferred = p.NewGauge(RehashDeferredDisconnectDevice)
+		r.dryRunDisconnect = p.NewGauge(RehashDryRunDisconnectDevice)
 		r.disconnectAllCounter = p.NewCounter(RehashDisconnectAllCounter)
 		r.timestamp = p.NewGauge(RehashTimestamp)
 		r.duration = p.NewGauge(RehashDurationMilliseconds)
@@ -75,9 +148,9 @@ func WithMetricsProvider(p provider.Provider) Option {
 // New creates a monitor Listener which will rehash and disconnect devices in response to service discovery events.
 // This function panics if the connector is nil or if no IsRegistered strategy is configured.
 //
-// If the returned listener encounters any service discovery error, all devices are disconnected.  Otherwise,
-// the IsRegistered strategy is used to determine which devices should still be connected to the Connector.  Devices
-// that hash to instances not registered in this environment are disconnected.
+// If the returned listener encounters any service discovery error, all devices are disconnected, possibly after an
+// error hold.  Otherwise, the IsRegistered strategy is used to determine which devices should still be connected to the
+// Connector.  Devices that hash to instances not registered in this environment are disconnected.
 func New(connector device.Connector, options ...Option) monitor.Listener {
 	if connector == nil {
 		panic("A device Connector is required")
@@ -87,13 +160,20 @@ func New(connector device.Connector, options ...Option) monitor.Listener {
 		defaultProvider = provider.NewDiscardProvider()
 
 		r = &rehasher{
-			logger:          logging.DefaultLogger(),
-			accessorFactory: service.DefaultAccessorFactory,
-			connector:       connector,
-			now:             time.Now,
+			logger:           logging.DefaultLogger(),
+			accessorFactory:  service.DefaultAccessorFactory,
+			replicas:         1,
+			connector:        connector,
+			now:              time.Now,
+			afterFunc:        defaultAfterFunc,
+			deferredInterval: DefaultDeferredInterval,
+			pending:          make(map[string]*pendingAction),
+			generations:      make(map[string]uint64),
 
 			keep:                 defaultProvider.NewGauge(RehashKeepDevice),
 			disconnect:           defaultProvider.NewGauge(RehashDisconnectDevice),
+			deferred:             defaultProvider.NewGauge(RehashDeferredDisconnectDevice),
+			dryRunDisconnect:     defaultProvider.NewGauge(RehashDryRunDisconnectDevice),
 			disconnectAllCounter: defaultProvider.NewCounter(RehashDisconnectAllCounter),
 			timestamp:            defaultProvider.NewGauge(RehashTimestamp),
 			duration:             defaultProvider.NewGauge(RehashDurationMilliseconds),
@@ -114,63 +194,312 @@ func New(connector device.Connector, options ...Option) monitor.Listener {
 // rehasher implements monitor.Listener and (1) disconnects all devices when any service discovery error occurs,
 // and (2) rehashes devices in response to updated instances.
 type rehasher struct {
-	logger          log.Logger
-	accessorFactory service.AccessorFactory
-	isRegistered    func(string) bool
-	connector       device.Connector
-	now             func() time.Time
+	logger                log.Logger
+	accessorFactory       service.AccessorFactory
+	replicas              int
+	isRegistered          func(string) bool
+	connector             device.Connector
+	now                   func() time.Time
+	afterFunc             func(time.Duration, func()) func() bool
+	debounce              time.Duration
+	errorHold             time.Duration
+	maxDisconnectFraction float64
+	deferredInterval      time.Duration
+	dryRun                bool
+
+	lock        sync.Mutex
+	pending     map[string]*pendingAction
+	generations map[string]uint64
+
+	// rehashLock serializes rehashes, so that a follow-up or debounced rehash never runs
+	// concurrently with the rehash for a newer update
+	rehashLock sync.Mutex
 
 	keep                 metrics.Gauge
 	disconnect           metrics.Gauge
+	deferred             metrics.Gauge
+	dryRunDisconnect     metrics.Gauge
 	disconnectAllCounter metrics.Counter
 	timestamp            metrics.Gauge
 	duration             metrics.Gauge
 }
 
-func (r *rehasher) rehash(key string, logger log.Logger, accessor service.Accessor) {
-	logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "rehash starting")
+// pendingAction is a delayed response to a service discovery event, either a debounced rehash or
+// a disconnect held in the hope that service discovery recovers
+type pendingAction struct {
+	hold bool
+	stop func() bool
+}
+
+// defaultAfterFunc is the production strategy for delaying actions
+func defaultAfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// schedule arranges for f to run after the given delay, replacing any pending action for the key.
+// The caller must hold the lock.
+func (r *rehasher) schedule(key string, hold bool, d time.Duration, f func()) {
+	r.cancel(key)
+
+	p := &pendingAction{hold: hold}
+	p.stop = r.afterFunc(d, func() {
+		r.lock.Lock()
+		current := r.pending[key] == p
+		if current {
+			delete(r.pending, key)
+		}
+
+		r.lock.Unlock()
+		if current {
+			f()
+		}
+	})
+
+	r.pending[key] = p
+}
+
+// nextGeneration records a new service discovery event for the key, which supersedes any rehash for
+// earlier events.  The caller must hold the lock.
+func (r *rehasher) nextGeneration(key string) uint64 {
+	r.generations[key]++
+	return r.generations[key]
+}
+
+// isCurrent tests if no service discovery event for the key has occurred since the given generation
+func (r *rehasher) isCurrent(key string, generation uint64) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.generations[key] == generation
+}
+
+// cancel stops any pending action for the key.  The caller must hold the lock.
+func (r *rehasher) cancel(key string) {
+	if p, ok := r.pending[key]; ok {
+		p.stop()
+		delete(r.pending, key)
+	}
+}
+
+// instances returns the instances to which a device hashes
+func (r *rehasher) instances(accessor service.Accessor, id device.ID) ([]string, error) {
+	if r.replicas > 1 {
+		return service.GetN(accessor, id.Bytes(), r.replicas)
+	}
+
+	instance, err := accessor.Get(id.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{instance}, nil
+}
+
+// isRegisteredAny tests if any of the given instances refers to this process
+func (r *rehasher) isRegisteredAny(instances []string) bool {
+	for _, i := range instances {
+		if r.isRegistered(i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shouldDisconnect determines whether the given device no longer belongs to this process
+func (r *rehasher) shouldDisconnect(logger log.Logger, accessor service.Accessor, candidate device.ID) bool {
+	instances, err := r.instances(accessor, candidate)
+	switch {
+	case err != nil:
+		logger.Log(level.Key(), level.ErrorValue(),
+			logging.MessageKey(), "disconnecting device: error during rehash",
+			logging.ErrorKey(), err,
+			"id", candidate,
+		)
+
+		return true
+
+	case !r.isRegisteredAny(instances):
+		logger.Log(level.Key(), level.InfoValue(),
+			logging.MessageKey(), "disconnecting device: rehashed to another instance",
+			"instances", instances,
+			"id", candidate,
+		)
+
+		return true
+
+	default:
+		logger.Log(level.Key(), level.DebugValue(), logging.MessageKey(), "device hashed to this instance", "id", candidate)
+		return false
+	}
+}
+
+// disconnectLimit counts the connected devices and returns the maximum number that a single rehash may disconnect
+func (r *rehasher) disconnectLimit() int {
+	total := 0
+	r.connector.DisconnectIf(func(device.ID) bool {
+		total++
+		return false
+	})
+
+	return int(math.Ceil(r.maxDisconnectFraction * float64(total)))
+}
+
+// rehash disconnects the devices that no longer hash to this process.  A rehash for a generation that has
+// been superseded, e.g. a follow-up that started just as a new update arrived, does nothing.
+func (r *rehasher) rehash(key string, generation uint64, logger log.Logger, accessor service.Accessor) {
+	r.rehashLock.Lock()
+	defer r.rehashLock.Unlock()
+	if !r.isCurrent(key, generation) {
+		logger.Log(level.Key(), level.DebugValue(), logging.MessageKey(), "skipping rehash: superseded by a newer service discovery event")
+		return
+	}
+
+	logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "rehash starting", "dryRun", r.dryRun)
 
 	start := r.now()
 	r.timestamp.With(service.ServiceLabel, key).Set(float64(start.UTC().Unix()))
 
 	var (
-		keepCount = 0
+		keepCount       = 0
+		disconnectCount = 0
+		deferredCount   = 0
+	)
 
-		disconnectCount = r.connector.DisconnectIf(func(candidate device.ID) bool {
-			instance, err := accessor.Get(candidate.Bytes())
-			switch {
-			case err != nil:
-				logger.Log(level.Key(), level.ErrorValue(),
-					logging.MessageKey(), "disconnecting device: error during rehash",
-					logging.ErrorKey(), err,
-					"id", candidate,
-				)
+	switch {
+	case r.dryRun:
+		r.connector.DisconnectIf(func(candidate device.ID) bool {
+			if r.shouldDisconnect(logger, accessor, candidate) {
+				disconnectCount++
+			} else {
+				keepCount++
+			}
 
-				return true
+			return false
+		})
 
-			case !r.isRegistered(instance):
-				logger.Log(level.Key(), level.InfoValue(),
-					logging.MessageKey(), "disconnecting device: rehashed to another instance",
-					"instance", instance,
-					"id", candidate,
-				)
+	case r.maxDisconnectFraction > 0.0:
+		limit := r.disconnectLimit()
+		disconnectCount = r.connector.DisconnectIf(func(candidate device.ID) bool {
+			switch {
+			case !r.shouldDisconnect(logger, accessor, candidate):
+				keepCount++
+				return false
 
+			case limit > 0:
+				limit--
 				return true
 
 			default:
-				logger.Log(level.Key(), level.DebugValue(), logging.MessageKey(), "device hashed to this instance", "id", candidate)
-				keepCount++
+				logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "deferring disconnect: rehash disconnect limit reached", "id", candidate)
+				deferredCount++
 				return false
 			}
 		})
 
-		duration = r.now().Sub(start)
-	)
+	default:
+		disconnectCount = r.connector.DisconnectIf(func(candidate device.ID) bool {
+			if r.shouldDisconnect(logger, accessor, candidate) {
+				return true
+			}
+
+			keepCount++
+			return false
+		})
+	}
 
+	duration := r.now().Sub(start)
 	r.keep.With(service.ServiceLabel, key).Set(float64(keepCount))
-	r.disconnect.With(service.ServiceLabel, key).Set(float64(disconnectCount))
+	if r.dryRun {
+		r.dryRunDisconnect.With(service.ServiceLabel, key).Set(float64(disconnectCount))
+	} else {
+		r.disconnect.With(service.ServiceLabel, key).Set(float64(disconnectCount))
+		r.deferred.With(service.ServiceLabel, key).Set(float64(deferredCount))
+	}
+
 	r.duration.With(service.ServiceLabel, key).Set(float64(duration / time.Millisecond))
-	logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "rehash complete", "disconnectCount", disconnectCount, "duration", duration)
+	logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "rehash complete",
+		"disconnectCount", disconnectCount,
+		"deferredCount", deferredCount,
+		"dryRun", r.dryRun,
+		"duration", duration,
+	)
+
+	if deferredCount > 0 {
+		r.followUp(key, generation, logger, accessor)
+	}
+}
+
+// followUp schedules another rehash against the same accessor to disconnect devices that a rehash deferred.
+// Any action that became pending during the rehash, such as a newer update or an error hold, takes precedence.
+// The follow-up belongs to the same generation, so any later service discovery event supersedes it.
+func (r *rehasher) followUp(key string, generation uint64, logger log.Logger, accessor service.Accessor) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.pending[key]; ok || r.generations[key] != generation {
+		return
+	}
+
+	logger.Log(level.Key(), level.DebugValue(), logging.MessageKey(), "scheduling rehash for deferred disconnects", "interval", r.deferredInterval)
+	r.schedule(key, false, r.deferredInterval, func() {
+		r.rehash(key, generation, logger, accessor)
+	})
+}
+
+func (r *rehasher) disconnectAll(key string, logger log.Logger, reason, message string) {
+	if r.dryRun {
+		logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "dry run: "+message, ReasonLabel, reason)
+		return
+	}
+
+	logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), message)
+	r.connector.DisconnectAll()
+	r.disconnectAllCounter.With(service.ServiceLabel, key, ReasonLabel, reason).Add(1.0)
+}
+
+// hold responds to a service discovery failure.  Without an error hold, all devices are disconnected immediately.
+// Otherwise, the current devices are kept for the hold period, after which all devices are disconnected unless
+// service discovery has recovered.  Further failures during a hold do not extend it.
+func (r *rehasher) hold(key string, logger log.Logger, reason, message string) {
+	if r.errorHold <= 0 {
+		r.lock.Lock()
+		r.nextGeneration(key)
+		r.cancel(key)
+		r.lock.Unlock()
+		r.disconnectAll(key, logger, reason, message)
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.nextGeneration(key)
+	if p, ok := r.pending[key]; ok && p.hold {
+		logger.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "service discovery failure during hold", ReasonLabel, reason)
+		return
+	}
+
+	logger.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "holding devices after service discovery failure", ReasonLabel, reason, "hold", r.errorHold)
+	r.schedule(key, true, r.errorHold, func() {
+		r.disconnectAll(key, logger, reason, message)
+	})
+}
+
+// update responds to a new set of instances, cancelling any hold and debouncing the rehash if configured
+func (r *rehasher) update(key string, logger log.Logger, instances []string) {
+	r.lock.Lock()
+	generation := r.nextGeneration(key)
+	if r.debounce <= 0 {
+		r.cancel(key)
+		r.lock.Unlock()
+		r.rehash(key, generation, logger, r.accessorFactory(instances))
+		return
+	}
+
+	logger.Log(level.Key(), level.DebugValue(), logging.MessageKey(), "debouncing rehash", "debounce", r.debounce)
+	r.schedule(key, false, r.debounce, func() {
+		r.rehash(key, generation, logger, r.accessorFactory(instances))
+	})
+
+	r.lock.Unlock()
 }
 
 func (r *rehasher) MonitorEvent(e monitor.Event) {
@@ -184,24 +513,22 @@ func (r *rehasher) MonitorEvent(e monitor.Event) {
 
 	switch {
 	case e.Err != nil:
-		logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "disconnecting all devices: service discovery error", logging.ErrorKey(), e.Err)
-		r.connector.DisconnectAll()
-		r.disconnectAllCounter.With(service.ServiceLabel, e.Key, ReasonLabel, DisconnectAllServiceDiscoveryError).Add(1.0)
+		r.hold(e.Key, log.With(logger, logging.ErrorKey(), e.Err), DisconnectAllServiceDiscoveryError, "disconnecting all devices: service discovery error")
 
 	case e.Stopped:
-		logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "disconnecting all devices: service discovery monitor being stopped")
-		r.connector.DisconnectAll()
-		r.disconnectAllCounter.With(service.ServiceLabel, e.Key, ReasonLabel, DisconnectAllServiceDiscoveryStopped).Add(1.0)
+		r.lock.Lock()
+		r.nextGeneration(e.Key)
+		r.cancel(e.Key)
+		r.lock.Unlock()
+		r.disconnectAll(e.Key, logger, DisconnectAllServiceDiscoveryStopped, "disconnecting all devices: service discovery monitor being stopped")
 
 	case e.EventCount == 1:
 		logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "ignoring initial instances")
 
 	case len(e.Instances) > 0:
-		r.rehash(e.Key, logger, r.accessorFactory(e.Instances))
+		r.update(e.Key, logger, e.Instances)
 
 	default:
-		logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "disconnecting all devices: service discovery updated with no instances")
-		r.connector.DisconnectAll()
-		r.disconnectAllCounter.With(service.ServiceLabel, e.Key, ReasonLabel, DisconnectAllServiceDiscoveryNoInstances).Add(1.0)
+		r.hold(e.Key, logger, DisconnectAllServiceDiscoveryNoInstances, "disconnecting all devices: service discovery updated with no instances")
 	}
 }
@@ -206,9 +206,405 @@ func testNewWithEnvironment(t *testing.T) {
 	i.AssertExpectations(t)
 }
 
+func testNewWithReplicas(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		c = new(device.MockConnector)
+		a = new(service.MockOrderedAccessor)
+
+		errorID          = device.ID("error")
+		primaryID        = device.ID("primary")
+		secondaryID      = device.ID("secondary")
+		disconnectID     = device.ID("disconnect")
+		predicateCapture = make(chan func(device.ID) bool, 1)
+
+		isRegistered = func(instance string) bool { return instance == "this" }
+	)
+
+	a.On("GetN", errorID.Bytes(), 2).Return(nil, errors.New("expected")).Once()
+	a.On("GetN", primaryID.Bytes(), 2).Return([]string{"this", "other"}, error(nil)).Once()
+	a.On("GetN", secondaryID.Bytes(), 2).Return([]string{"other", "this"}, error(nil)).Once()
+	a.On("GetN", disconnectID.Bytes(), 2).Return([]string{"other", "another"}, error(nil)).Once()
+
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(2).Once().
+		Run(func(arguments mock.Arguments) {
+			predicateCapture <- arguments.Get(0).(func(device.ID) bool)
+		})
+
+	l := New(c, WithAccessorFactory(func([]string) service.Accessor { return a }), WithIsRegistered(isRegistered), WithReplicas(2))
+	require.NotNil(l)
+
+	l.MonitorEvent(monitor.Event{Key: "testNewWithReplicas", EventCount: 2, Instances: []string{"this", "other", "another"}})
+
+	select {
+	case predicate := <-predicateCapture:
+		assert.True(predicate(errorID))
+		assert.False(predicate(primaryID))
+		assert.False(predicate(secondaryID))
+		assert.True(predicate(disconnectID))
+	case <-time.After(time.Second):
+		require.Fail("No predicate sent")
+	}
+
+	a.AssertExpectations(t)
+	c.AssertExpectations(t)
+}
+
+// testTimers captures the actions scheduled by a rehasher
+type testTimers struct {
+	delays  []time.Duration
+	actions []func()
+	stopped []bool
+}
+
+func (tt *testTimers) afterFunc(d time.Duration, f func()) func() bool {
+	i := len(tt.actions)
+	tt.delays = append(tt.delays, d)
+	tt.actions = append(tt.actions, f)
+	tt.stopped = append(tt.stopped, false)
+	return func() bool {
+		tt.stopped[i] = true
+		return true
+	}
+}
+
+func testNewWithDebounce(t *testing.T) {
+	const key = "testNewWithDebounce"
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		c      = new(device.MockConnector)
+		a      = new(service.MockAccessor)
+		timers = new(testTimers)
+
+		keepID    = device.ID("keep")
+		instances [][]string
+	)
+
+	a.On("Get", keepID.Bytes()).Return("keep", error(nil)).Once()
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(0).Once().
+		Run(func(arguments mock.Arguments) {
+			assert.False(arguments.Get(0).(func(device.ID) bool)(keepID))
+		})
+
+	l := New(
+		c,
+		WithLogger(logging.NewTestLogger(nil, t)),
+		WithAccessorFactory(func(i []string) service.Accessor {
+			instances = append(instances, i)
+			return a
+		}),
+		WithIsRegistered(func(instance string) bool { return instance == "keep" }),
+		WithDebounce(time.Minute),
+	)
+
+	require.NotNil(l)
+	l.(*rehasher).afterFunc = timers.afterFunc
+
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 2, Instances: []string{"keep", "other"}})
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 3, Instances: []string{"keep"}})
+	require.Len(timers.actions, 2)
+	assert.Equal([]time.Duration{time.Minute, time.Minute}, timers.delays)
+	assert.Equal([]bool{true, false}, timers.stopped)
+	assert.Empty(instances)
+
+	// a superseded action has no effect, even if its timer fires
+	timers.actions[0]()
+	assert.Empty(instances)
+
+	timers.actions[1]()
+	assert.Equal([][]string{{"keep"}}, instances)
+
+	a.AssertExpectations(t)
+	c.AssertExpectations(t)
+}
+
+func testNewWithErrorHold(t *testing.T) {
+	const key = "testNewWithErrorHold"
+
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+
+		c      = new(device.MockConnector)
+		a      = new(service.MockAccessor)
+		timers = new(testTimers)
+	)
+
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(0).Once()
+	c.On("DisconnectAll").Return(0).Once()
+
+	l := New(
+		c,
+		WithLogger(logging.NewTestLogger(nil, t)),
+		WithAccessorFactory(func([]string) service.Accessor { return a }),
+		WithIsRegistered(func(string) bool { return true }),
+		WithMetricsProvider(provider),
+		WithErrorHold(time.Minute),
+	)
+
+	require.NotNil(l)
+	l.(*rehasher).afterFunc = timers.afterFunc
+
+	// further errors during a hold do not extend it
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 2, Err: errors.New("expected")})
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 3, Err: errors.New("expected")})
+	require.Len(timers.actions, 1)
+	assert.Equal(time.Minute, timers.delays[0])
+	provider.Assert(t, RehashDisconnectAllCounter, service.ServiceLabel, key, ReasonLabel, DisconnectAllServiceDiscoveryError)(xmetricstest.Value(0.0))
+
+	// recovery cancels the hold
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 4, Instances: []string{"keep"}})
+	assert.True(timers.stopped[0])
+	timers.actions[0]()
+	provider.Assert(t, RehashDisconnectAllCounter, service.ServiceLabel, key, ReasonLabel, DisconnectAllServiceDiscoveryError)(xmetricstest.Value(0.0))
+
+	// an expired hold disconnects all devices
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 5})
+	require.Len(timers.actions, 2)
+	timers.actions[1]()
+	provider.Assert(t, RehashDisconnectAllCounter, service.ServiceLabel, key, ReasonLabel, DisconnectAllServiceDiscoveryNoInstances)(xmetricstest.Value(1.0))
+	provider.Assert(t, RehashDisconnectAllCounter, service.ServiceLabel, key, ReasonLabel, DisconnectAllServiceDiscoveryError)(xmetricstest.Value(0.0))
+
+	a.AssertExpectations(t)
+	c.AssertExpectations(t)
+}
+
+func testNewWithMaxDisconnectFraction(t *testing.T) {
+	const key = "testNewWithMaxDisconnectFraction"
+
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+
+		c      = new(device.MockConnector)
+		a      = new(service.MockAccessor)
+		timers = new(testTimers)
+
+		keepID        = device.ID("keep")
+		disconnectIDs = []device.ID{"disconnect1", "disconnect2", "disconnect3"}
+	)
+
+	a.On("Get", keepID.Bytes()).Return("keep", error(nil)).Twice()
+	for _, id := range disconnectIDs {
+		a.On("Get", id.Bytes()).Return("other", error(nil)).Once()
+	}
+
+	a.On("Get", disconnectIDs[2].Bytes()).Return("other", error(nil)).Once()
+
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(0).Once().
+		Run(func(arguments mock.Arguments) {
+			f := arguments.Get(0).(func(device.ID) bool)
+			assert.False(f(keepID))
+			for _, id := range disconnectIDs {
+				assert.False(f(id))
+			}
+		})
+
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(2).Once().
+		Run(func(arguments mock.Arguments) {
+			f := arguments.Get(0).(func(device.ID) bool)
+			assert.False(f(keepID))
+			assert.True(f(disconnectIDs[0]))
+			assert.True(f(disconnectIDs[1]))
+			assert.False(f(disconnectIDs[2]))
+		})
+
+	// the follow-up rehash only sees the devices that remain connected
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(0).Once().
+		Run(func(arguments mock.Arguments) {
+			f := arguments.Get(0).(func(device.ID) bool)
+			assert.False(f(keepID))
+			assert.False(f(disconnectIDs[2]))
+		})
+
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(1).Once().
+		Run(func(arguments mock.Arguments) {
+			f := arguments.Get(0).(func(device.ID) bool)
+			assert.False(f(keepID))
+			assert.True(f(disconnectIDs[2]))
+		})
+
+	l := New(
+		c,
+		WithLogger(logging.NewTestLogger(nil, t)),
+		WithAccessorFactory(func([]string) service.Accessor { return a }),
+		WithIsRegistered(func(instance string) bool { return instance == "keep" }),
+		WithMetricsProvider(provider),
+		WithMaxDisconnectFraction(0.5),
+		WithDeferredInterval(time.Minute),
+	)
+
+	require.NotNil(l)
+	l.(*rehasher).afterFunc = timers.afterFunc
+
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 2, Instances: []string{"keep", "other"}})
+	provider.Assert(t, RehashKeepDevice, service.ServiceLabel, key)(xmetricstest.Value(1.0))
+	provider.Assert(t, RehashDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(2.0))
+	provider.Assert(t, RehashDeferredDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(1.0))
+
+	// deferred disconnects schedule a follow-up rehash, which stops once nothing is deferred
+	require.Len(timers.actions, 1)
+	assert.Equal(time.Minute, timers.delays[0])
+	timers.actions[0]()
+	provider.Assert(t, RehashKeepDevice, service.ServiceLabel, key)(xmetricstest.Value(1.0))
+	provider.Assert(t, RehashDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(1.0))
+	provider.Assert(t, RehashDeferredDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(0.0))
+	assert.Len(timers.actions, 1)
+
+	a.AssertExpectations(t)
+	c.AssertExpectations(t)
+}
+
+func testNewWithMaxDisconnectFractionSuperseded(t *testing.T) {
+	const key = "testNewWithMaxDisconnectFractionSuperseded"
+
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+
+		c      = new(device.MockConnector)
+		first  = new(service.MockAccessor)
+		second = new(service.MockAccessor)
+		timers = new(testTimers)
+
+		disconnectIDs = []device.ID{"disconnect1", "disconnect2"}
+	)
+
+	for _, id := range disconnectIDs {
+		first.On("Get", id.Bytes()).Return("other", error(nil)).Once()
+	}
+
+	second.On("Get", disconnectIDs[1].Bytes()).Return("keep", error(nil)).Once()
+
+	// counting the devices, then the first rehash, which defers a disconnect
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(0).Once().
+		Run(func(arguments mock.Arguments) {
+			f := arguments.Get(0).(func(device.ID) bool)
+			for _, id := range disconnectIDs {
+				assert.False(f(id))
+			}
+		})
+
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(1).Once().
+		Run(func(arguments mock.Arguments) {
+			f := arguments.Get(0).(func(device.ID) bool)
+			assert.True(f(disconnectIDs[0]))
+			assert.False(f(disconnectIDs[1]))
+		})
+
+	// counting the devices, then the rehash for a newer update, which keeps the remaining device
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(0).Twice().
+		Run(func(arguments mock.Arguments) {
+			f := arguments.Get(0).(func(device.ID) bool)
+			assert.False(f(disconnectIDs[1]))
+		})
+
+	l := New(
+		c,
+		WithLogger(logging.NewTestLogger(nil, t)),
+		WithAccessorFactory(func(i []string) service.Accessor {
+			if len(i) > 1 {
+				return first
+			}
+
+			return second
+		}),
+		WithIsRegistered(func(instance string) bool { return instance == "keep" }),
+		WithMetricsProvider(provider),
+		WithMaxDisconnectFraction(0.5),
+		WithDeferredInterval(time.Minute),
+	)
+
+	require.NotNil(l)
+	r := l.(*rehasher)
+	r.afterFunc = timers.afterFunc
+
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 2, Instances: []string{"keep", "other"}})
+	provider.Assert(t, RehashDeferredDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(1.0))
+	require.Len(timers.actions, 1)
+
+	r.lock.Lock()
+	followUpGeneration := r.generations[key]
+	r.lock.Unlock()
+
+	// a newer update supersedes the follow-up, even one whose timer has already fired
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 3, Instances: []string{"keep"}})
+	assert.True(timers.stopped[0])
+	r.rehash(key, followUpGeneration, r.logger, first)
+	provider.Assert(t, RehashDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(0.0))
+	provider.Assert(t, RehashDeferredDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(0.0))
+	assert.Len(timers.actions, 1)
+
+	first.AssertExpectations(t)
+	second.AssertExpectations(t)
+	c.AssertExpectations(t)
+}
+
+func testNewWithDryRun(t *testing.T) {
+	const key = "testNewWithDryRun"
+
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+
+		c = new(device.MockConnector)
+		a = new(service.MockAccessor)
+
+		keepID       = device.ID("keep")
+		disconnectID = device.ID("disconnect")
+	)
+
+	a.On("Get", keepID.Bytes()).Return("keep", error(nil)).Once()
+	a.On("Get", disconnectID.Bytes()).Return("other", error(nil)).Once()
+	c.On("DisconnectIf", mock.AnythingOfType("func(device.ID) bool")).Return(0).Once().
+		Run(func(arguments mock.Arguments) {
+			f := arguments.Get(0).(func(device.ID) bool)
+			assert.False(f(keepID))
+			assert.False(f(disconnectID))
+		})
+
+	l := New(
+		c,
+		WithLogger(logging.NewTestLogger(nil, t)),
+		WithAccessorFactory(func([]string) service.Accessor { return a }),
+		WithIsRegistered(func(instance string) bool { return instance == "keep" }),
+		WithMetricsProvider(provider),
+		WithDryRun(true),
+	)
+
+	require.NotNil(l)
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 2, Instances: []string{"keep", "other"}})
+	provider.Assert(t, RehashKeepDevice, service.ServiceLabel, key)(xmetricstest.Value(1.0))
+	provider.Assert(t, RehashDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(0.0))
+	provider.Assert(t, RehashDryRunDisconnectDevice, service.ServiceLabel, key)(xmetricstest.Value(1.0))
+
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 3, Err: errors.New("expected")})
+	l.MonitorEvent(monitor.Event{Key: key, EventCount: 4, Stopped: true})
+	provider.Assert(t, RehashDisconnectAllCounter, service.ServiceLabel, key, ReasonLabel, DisconnectAllServiceDiscoveryError)(xmetricstest.Value(0.0))
+	provider.Assert(t, RehashDisconnectAllCounter, service.ServiceLabel, key, ReasonLabel, DisconnectAllServiceDiscoveryStopped)(xmetricstest.Value(0.0))
+
+	a.AssertExpectations(t)
+	c.AssertExpectations(t)
+}
+
 func TestNew(t *testing.T) {
 	t.Run("NilConnector", testNewNilConnector)
 	t.Run("MissingIsRegistered", testNewMissingIsRegistered)
 	t.Run("WithIsRegistered", testNewWithIsRegistered)
 	t.Run("WithEnvironment", testNewWithEnvironment)
+	t.Run("WithReplicas", testNewWithReplicas)
+	t.Run("WithDebounce", testNewWithDebounce)
+	t.Run("WithErrorHold", testNewWithErrorHold)
+	t.Run("WithMaxDisconnectFraction", testNewWithMaxDisconnectFraction)
+	t.Run("WithMaxDisconnectFractionSuperseded", testNewWithMaxDisconnectFractionSuperseded)
+	t.Run("WithDryRun", testNewWithDryRun)
 }
@@ -0,0 +1,138 @@
+package device
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+// Service describes an on-device service, registered by the device via a WRP ServiceRegistration message.
+type Service struct {
+	// Name is the service name, which corresponds to the service part of WRP locators addressed to the device
+	Name string `json:"name"`
+
+	// URL is the URL the device reported for the service
+	URL string `json:"url"`
+
+	// RegisteredAt is the time of the most recent registration of this service
+	RegisteredAt time.Time `json:"registeredAt"`
+
+	// LastAlive is the time of the most recent ServiceAlive message for this service.  Registration
+	// counts as a sign of life.
+	LastAlive time.Time `json:"lastAlive"`
+}
+
+// services tracks the on-device services for a single device.  Services that have not been
+// registered or sent a ServiceAlive within the alive timeout are expired.
+type services struct {
+	lock         sync.RWMutex
+	aliveTimeout time.Duration
+	now          func() time.Time
+	entries      map[string]Service
+}
+
+func newServices(aliveTimeout time.Duration, now func() time.Time) *services {
+	if aliveTimeout < 1 {
+		aliveTimeout = DefaultServiceAliveTimeout
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &services{
+		aliveTimeout: aliveTimeout,
+		now:          now,
+		entries:      make(map[string]Service),
+	}
+}
+
+// expired tests if the given service has stopped sending ServiceAlive messages
+func (s *services) expired(svc Service, now time.Time) bool {
+	return now.Sub(svc.LastAlive) > s.aliveTimeout
+}
+
+// update examines a message received from a device, updating service state as appropriate.
+// Registration replaces any previous registration for the same service name.  A ServiceAlive
+// refreshes the service named by the message's ServiceName, or failing that the service part
+// of its source.  A ServiceAlive that names no service refreshes all of the device's services.
+func (s *services) update(m *wrp.Message) {
+	switch m.Type {
+	case wrp.ServiceRegistrationMessageType:
+		if len(m.ServiceName) == 0 {
+			return
+		}
+
+		now := s.now()
+		s.lock.Lock()
+		s.entries[m.ServiceName] = Service{
+			Name:         m.ServiceName,
+			URL:          m.URL,
+			RegisteredAt: now,
+			LastAlive:    now,
+		}
+
+		s.lock.Unlock()
+
+	case wrp.ServiceAliveMessageType:
+		name := m.ServiceName
+		if len(name) == 0 {
+			if l, err := wrp.ParseLocator(m.Source); err == nil {
+				name = l.Service
+			}
+		}
+
+		now := s.now()
+		s.lock.Lock()
+		for k, svc := range s.entries {
+			if len(name) == 0 || k == name {
+				svc.LastAlive = now
+				s.entries[k] = svc
+			}
+		}
+
+		s.lock.Unlock()
+	}
+}
+
+// registered tests if the given service is registered and has not expired
+func (s *services) registered(name string) bool {
+	s.lock.RLock()
+	svc, ok := s.entries[name]
+	s.lock.RUnlock()
+
+	return ok && !s.expired(svc, s.now())
+}
+
+// list returns the unexpired services, sorted by name.  Expired services are removed.
+func (s *services) list() []Service {
+	now := s.now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	list := make([]Service, 0, len(s.entries))
+	for name, svc := range s.entries {
+		if s.expired(svc, now) {
+			delete(s.entries, name)
+		} else {
+			list = append(list, svc)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// destinationService extracts the service name from the destination of a request, returning the
+// empty string if the request has no service
+func destinationService(request *Request) string {
+	if routable, ok := request.Message.(wrp.Routable); ok {
+		if l, err := wrp.ParseLocator(routable.To()); err == nil {
+			return l.Service
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,87 @@
+package device
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServices(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		now    = time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+		s      = newServices(time.Minute, func() time.Time { return now })
+		start  = now
+	)
+
+	assert.Empty(s.list())
+	assert.False(s.registered("config"))
+
+	s.update(&wrp.Message{Type: wrp.ServiceRegistrationMessageType, ServiceName: "config", URL: "tcp://127.0.0.1:6666"})
+	s.update(&wrp.Message{Type: wrp.ServiceRegistrationMessageType, ServiceName: "iot", URL: "tcp://127.0.0.1:6667"})
+	s.update(&wrp.Message{Type: wrp.ServiceRegistrationMessageType, URL: "no service name is ignored"})
+	s.update(&wrp.Message{Type: wrp.SimpleEventMessageType, ServiceName: "event"})
+
+	assert.True(s.registered("config"))
+	assert.True(s.registered("iot"))
+	assert.False(s.registered("event"))
+	assert.Equal(
+		[]Service{
+			{Name: "config", URL: "tcp://127.0.0.1:6666", RegisteredAt: start, LastAlive: start},
+			{Name: "iot", URL: "tcp://127.0.0.1:6667", RegisteredAt: start, LastAlive: start},
+		},
+		s.list(),
+	)
+
+	// a ServiceAlive identifies the service by ServiceName or by the source's service
+	now = start.Add(45 * time.Second)
+	s.update(&wrp.Message{Type: wrp.ServiceAliveMessageType, Source: "mac:112233445566/iot"})
+
+	now = start.Add(90 * time.Second)
+	assert.False(s.registered("config"))
+	assert.True(s.registered("iot"))
+	assert.Equal(
+		[]Service{
+			{Name: "iot", URL: "tcp://127.0.0.1:6667", RegisteredAt: start, LastAlive: start.Add(45 * time.Second)},
+		},
+		s.list(),
+	)
+
+	// re-registering brings back an expired service
+	s.update(&wrp.Message{Type: wrp.ServiceRegistrationMessageType, ServiceName: "config", URL: "tcp://127.0.0.1:7777"})
+	assert.True(s.registered("config"))
+
+	// a ServiceAlive with no service refreshes everything
+	now = start.Add(120 * time.Second)
+	s.update(&wrp.Message{Type: wrp.ServiceAliveMessageType, Source: "mac:112233445566"})
+
+	now = start.Add(170 * time.Second)
+	assert.Equal(
+		[]Service{
+			{Name: "config", URL: "tcp://127.0.0.1:7777", RegisteredAt: start.Add(90 * time.Second), LastAlive: start.Add(120 * time.Second)},
+			{Name: "iot", URL: "tcp://127.0.0.1:6667", RegisteredAt: start, LastAlive: start.Add(120 * time.Second)},
+		},
+		s.list(),
+	)
+}
+
+func TestNewServicesDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newServices(0, nil)
+	assert.Equal(DefaultServiceAliveTimeout, s.aliveTimeout)
+	assert.NotNil(s.now)
+}
+
+func TestDestinationService(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("config", destinationService(&Request{Message: &wrp.Message{Destination: "mac:112233445566/config/foo"}}))
+	assert.Empty(destinationService(&Request{Message: &wrp.Message{Destination: "mac:112233445566"}}))
+	assert.Empty(destinationService(&Request{Message: &wrp.Message{Destination: "not a locator"}}))
+	assert.Empty(destinationService(&Request{Message: &wrp.AuthorizationStatus{}}))
+	assert.Empty(destinationService((&Request{}).WithContext(context.Background())))
+}
@@ -7,10 +7,18 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/Comcast/webpa-common/tracing"
 	"github.com/Comcast/webpa-common/wrp"
 	"github.com/Comcast/webpa-common/xhttp"
 )
 
+// TraceParentKey is the WRP metadata key carrying the W3C traceparent of a request routed to a device
+const TraceParentKey = "/traceparent"
+
+// signatureKey is the WRP metadata key holding the signature of a signed message.  This is the same as
+// wrpsecure.SignatureKey, which cannot be imported here because wrpsecure itself imports this package.
+const signatureKey = "/wrp-signature"
+
 // Request represents a single device Request, carrying routing information and message contents.
 type Request struct {
 	// Message is the original, decoded WRP message containing the routing information.  When sending a request
@@ -75,6 +83,40 @@ func (r *Request) ID() (i ID, err error) {
 	return
 }
 
+// withTraceParent adds the W3C traceparent of the request's context, if any, to the metadata of the
+// request's message.  The returned request has a copy of the message and no Contents, so that the
+// message is re-encoded when sent.  Requests without a SpanContext, whose message already has a
+// traceparent, or whose message is signed are returned as is.  Signed messages are sent exactly as
+// they were signed, without the cost of decoding and re-encoding them.
+func withTraceParent(r *Request) *Request {
+	sc, ok := tracing.SpanContextFromContext(r.Context())
+	if !ok {
+		return r
+	}
+
+	m, ok := r.Message.(*wrp.Message)
+	if !ok || len(m.Metadata[TraceParentKey]) > 0 {
+		return r
+	}
+
+	if _, signed := m.Metadata[signatureKey]; signed {
+		return r
+	}
+
+	copyOfMessage := *m
+	copyOfMessage.Metadata = make(map[string]string, len(m.Metadata)+1)
+	for k, v := range m.Metadata {
+		copyOfMessage.Metadata[k] = v
+	}
+
+	copyOfMessage.Metadata[TraceParentKey] = sc.TraceParent()
+
+	copyOf := *r
+	copyOf.Message = &copyOfMessage
+	copyOf.Contents = nil
+	return &copyOf
+}
+
 // DecodeRequest decodes a WRP source into a device Request.  Typically, this is used
 // to produce a device Request from an http.Request.
 //
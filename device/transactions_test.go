@@ -6,6 +6,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/Comcast/webpa-common/tracing"
 	"github.com/Comcast/webpa-common/wrp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -59,11 +60,88 @@ func testRequestID(t *testing.T) {
 	assert.Error(err)
 }
 
+func testWithTraceParentNoSpanContext(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		request = &Request{Message: new(wrp.Message), Contents: []byte("some contents")}
+	)
+
+	assert.True(request == withTraceParent(request))
+}
+
+func testWithTraceParentExisting(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		sc     = tracing.SpanContext{TraceID: tracing.NewTraceID(), SpanID: tracing.NewSpanID()}
+
+		request = (&Request{
+			Message: &wrp.Message{Metadata: map[string]string{TraceParentKey: "existing"}},
+		}).WithContext(tracing.WithSpanContext(context.Background(), sc))
+	)
+
+	assert.True(request == withTraceParent(request))
+}
+
+func testWithTraceParentSigned(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		sc     = tracing.SpanContext{TraceID: tracing.NewTraceID(), SpanID: tracing.NewSpanID()}
+
+		request = (&Request{
+			Message:  &wrp.Message{Metadata: map[string]string{signatureKey: "signature"}},
+			Contents: []byte("signed contents"),
+		}).WithContext(tracing.WithSpanContext(context.Background(), sc))
+	)
+
+	assert.True(request == withTraceParent(request))
+}
+
+func testWithTraceParent(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+		sc      = tracing.SpanContext{TraceID: tracing.NewTraceID(), SpanID: tracing.NewSpanID(), Sampled: true}
+
+		message = &wrp.Message{
+			Destination: "mac:112233445566/config",
+			Metadata:    map[string]string{"foo": "bar"},
+		}
+
+		request = (&Request{
+			Message:  message,
+			Format:   wrp.Msgpack,
+			Contents: []byte("some contents"),
+		}).WithContext(tracing.WithSpanContext(context.Background(), sc))
+	)
+
+	traced := withTraceParent(request)
+	require.NotNil(traced)
+	assert.False(request == traced)
+	assert.Nil(traced.Contents)
+	assert.Equal(wrp.Msgpack, traced.Format)
+	assert.Equal(request.Context(), traced.Context())
+
+	tracedMessage, ok := traced.Message.(*wrp.Message)
+	require.True(ok)
+	assert.Equal("mac:112233445566/config", tracedMessage.Destination)
+	assert.Equal(map[string]string{"foo": "bar", TraceParentKey: sc.TraceParent()}, tracedMessage.Metadata)
+
+	assert.Equal(map[string]string{"foo": "bar"}, message.Metadata)
+	assert.Equal([]byte("some contents"), request.Contents)
+}
+
 func TestRequest(t *testing.T) {
 	t.Run("Context", testRequestContext)
 	t.Run("ID", testRequestID)
 }
 
+func TestWithTraceParent(t *testing.T) {
+	t.Run("NoSpanContext", testWithTraceParentNoSpanContext)
+	t.Run("Existing", testWithTraceParentExisting)
+	t.Run("Signed", testWithTraceParentSigned)
+	t.Run("TraceParent", testWithTraceParent)
+}
+
 func testDecodeRequest(t *testing.T, message wrp.Routable, format wrp.Format) {
 	var (
 		assert   = assert.New(t)
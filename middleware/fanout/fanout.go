@@ -51,8 +51,9 @@ func New(spanner tracing.Spanner, endpoints Components) endpoint.Endpoint {
 		for name, e := range endpoints {
 			go func(name string, e endpoint.Endpoint) {
 				var (
-					finisher               = spanner.Start(name)
-					componentResponse, err = e(ctx, v)
+					// each component's context carries its span's SpanContext, for propagation to other services
+					componentCtx, finisher = tracing.StartSpan(ctx, spanner, name)
+					componentResponse, err = e(componentCtx, v)
 				)
 
 				results <- response{
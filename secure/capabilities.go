@@ -0,0 +1,274 @@
+package secure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultCapabilityPrefix is the prefix that capabilities must have when no prefix is configured
+	DefaultCapabilityPrefix = "x1:webpa"
+
+	// DefaultAllMethod is the method component of a capability that matches any HTTP method
+	// when no all method is configured
+	DefaultAllMethod = "all"
+
+	// maxCapabilityPatterns bounds the number of compiled capability patterns retained by a checker
+	maxCapabilityPatterns = 1024
+)
+
+var (
+	ErrorNoCapabilities             = errors.New("Token has no capabilities")
+	ErrorNoRequestInfo              = errors.New("No request method and path available for capability checking")
+	ErrorCapabilityMismatch         = errors.New("No capability matches the request")
+	ErrorRouteCapabilityMissing     = errors.New("Token does not have a capability required by the route")
+	ErrorInvalidRouteCapabilityPath = errors.New("Invalid route path pattern")
+)
+
+// RequestInfo describes the HTTP request that a token is being validated for.  Capability
+// checking uses this information to decide whether a token authorizes the request.
+type RequestInfo struct {
+	Method string
+	Path   string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context carrying the given RequestInfo
+func WithRequestInfo(ctx context.Context, ri RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, ri)
+}
+
+// RequestInfoFromContext returns the RequestInfo previously stored with WithRequestInfo, if any
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	if ctx == nil {
+		return RequestInfo{}, false
+	}
+
+	ri, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return ri, ok
+}
+
+// CapabilityChecker determines whether any of a token's capabilities authorizes a request
+type CapabilityChecker interface {
+	// CheckCapabilities returns the configured capability pattern that authorizes the given request.
+	// The returned pattern only ever takes on configured values, never arbitrary values from a token,
+	// which makes it safe to use as a metric label.  If no capability authorizes the request, a non-nil
+	// error describes why.
+	CheckCapabilities(capabilities []string, request RequestInfo) (string, error)
+}
+
+// RouteCapabilities describes the capabilities required by a particular route.  A request for a route
+// is authorized only when the token has at least one of the route's capabilities, verbatim.
+type RouteCapabilities struct {
+	// Methods are the HTTP methods this route applies to.  If empty, the route applies to all methods.
+	Methods []string `json:"methods"`
+
+	// Path is the regular expression the request path must match, in its entirety, for this route to apply
+	Path string `json:"path"`
+
+	// Capabilities are the capabilities, any one of which authorizes requests for this route
+	Capabilities []string `json:"capabilities"`
+}
+
+// CapabilityConfig is the configurable description of capability-based authorization.
+//
+// Capabilities not covered by a route take the form <prefix>:<service>:<path pattern>:<method>, e.g.
+// x1:webpa:api:device/.*/stat:get.  Such a capability matches requests whose path begins with
+// /<service>/<version>/<path pattern>, where the path pattern is a regular expression.  The method
+// is compared case-insensitively, and the all method matches any request method.
+type CapabilityConfig struct {
+	// Prefix is the prefix capabilities must have.  If unset, DefaultCapabilityPrefix is used.
+	Prefix string `json:"prefix"`
+
+	// AllMethod is the method component that matches any HTTP method.  If unset, DefaultAllMethod is used.
+	AllMethod string `json:"allMethod"`
+
+	// Routes are the routes with specific capability requirements.  The first route that matches a
+	// request takes precedence over pattern-based capability matching.
+	Routes []RouteCapabilities `json:"routes"`
+}
+
+func (c *CapabilityConfig) prefix() string {
+	if c != nil && len(c.Prefix) > 0 {
+		return strings.TrimSuffix(c.Prefix, ":") + ":"
+	}
+
+	return DefaultCapabilityPrefix + ":"
+}
+
+func (c *CapabilityConfig) allMethod() string {
+	if c != nil && len(c.AllMethod) > 0 {
+		return c.AllMethod
+	}
+
+	return DefaultAllMethod
+}
+
+// NewCapabilityChecker produces a CapabilityChecker from configuration.  A nil configuration
+// produces a checker with all the defaults and no routes.
+func NewCapabilityChecker(c *CapabilityConfig) (CapabilityChecker, error) {
+	cc := newCapabilityChecker(c.prefix(), c.allMethod())
+	if c != nil {
+		for _, rc := range c.Routes {
+			path, err := regexp.Compile("^(?:" + rc.Path + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("%s [%s]: %s", ErrorInvalidRouteCapabilityPath, rc.Path, err)
+			}
+
+			r := route{
+				methods:      make(map[string]bool, len(rc.Methods)),
+				path:         path,
+				capabilities: make(map[string]bool, len(rc.Capabilities)),
+			}
+
+			for _, m := range rc.Methods {
+				r.methods[strings.ToUpper(m)] = true
+			}
+
+			for _, capability := range rc.Capabilities {
+				r.capabilities[capability] = true
+			}
+
+			cc.routes = append(cc.routes, r)
+		}
+	}
+
+	return cc, nil
+}
+
+var defaultCapabilityChecker = newCapabilityChecker(DefaultCapabilityPrefix+":", DefaultAllMethod)
+
+// DefaultCapabilityChecker returns the CapabilityChecker used when a JWSValidator has none configured.
+// The same instance is always returned, so that compiled capability patterns are shared.
+func DefaultCapabilityChecker() CapabilityChecker {
+	return defaultCapabilityChecker
+}
+
+type route struct {
+	methods      map[string]bool
+	path         *regexp.Regexp
+	capabilities map[string]bool
+}
+
+func (r *route) matches(request RequestInfo) bool {
+	return (len(r.methods) == 0 || r.methods[strings.ToUpper(request.Method)]) && r.path.MatchString(request.Path)
+}
+
+// capabilityPattern is the parsed form of a capability that is not covered by a route
+type capabilityPattern struct {
+	method string
+	path   *regexp.Regexp
+}
+
+type capabilityChecker struct {
+	prefix    string
+	allMethod string
+	routes    []route
+
+	// patterns holds the parsed form of each capability seen so far.  A nil entry indicates
+	// a capability that is malformed or does not have the configured prefix.
+	patternsLock sync.RWMutex
+	patterns     map[string]*capabilityPattern
+}
+
+func newCapabilityChecker(prefix, allMethod string) *capabilityChecker {
+	return &capabilityChecker{
+		prefix:    prefix,
+		allMethod: allMethod,
+		patterns:  make(map[string]*capabilityPattern),
+	}
+}
+
+// CheckCapabilities reports the matching route capability for requests covered by a route.  Every other
+// authorized request reports the configured prefix followed by a wildcard, e.g. x1:webpa:*, since the rest
+// of such a capability comes from the token.
+func (cc *capabilityChecker) CheckCapabilities(capabilities []string, request RequestInfo) (string, error) {
+	if len(capabilities) == 0 {
+		return "", ErrorNoCapabilities
+	}
+
+	for i := range cc.routes {
+		if cc.routes[i].matches(request) {
+			for _, capability := range capabilities {
+				if cc.routes[i].capabilities[capability] {
+					return capability, nil
+				}
+			}
+
+			return "", ErrorRouteCapabilityMissing
+		}
+	}
+
+	for _, capability := range capabilities {
+		if cc.matches(capability, request) {
+			return cc.prefix + "*", nil
+		}
+	}
+
+	return "", ErrorCapabilityMismatch
+}
+
+// parse produces the capabilityPattern for a capability of the form <prefix>:<service>:<path pattern>:<method>.
+// The path pattern is allowed to contain colons.  If the capability is malformed, this method returns nil.
+func (cc *capabilityChecker) parse(capability string) *capabilityPattern {
+	if !strings.HasPrefix(capability, cc.prefix) {
+		return nil
+	}
+
+	var (
+		remaining = capability[len(cc.prefix):]
+		first     = strings.IndexByte(remaining, ':')
+		last      = strings.LastIndexByte(remaining, ':')
+	)
+
+	if first < 1 || last <= first {
+		return nil
+	}
+
+	service, pattern, method := remaining[:first], remaining[first+1:last], remaining[last+1:]
+	path, err := regexp.Compile(fmt.Sprintf("^/%s/[^/]+/%s", regexp.QuoteMeta(service), pattern))
+	if err != nil {
+		return nil
+	}
+
+	return &capabilityPattern{method: method, path: path}
+}
+
+// pattern returns the capabilityPattern for a capability, parsing it only if it has not been seen before.
+// Once maxCapabilityPatterns have been retained, further capabilities are parsed each time they are checked.
+func (cc *capabilityChecker) pattern(capability string) *capabilityPattern {
+	cc.patternsLock.RLock()
+	cp, ok := cc.patterns[capability]
+	cc.patternsLock.RUnlock()
+	if ok {
+		return cp
+	}
+
+	cp = cc.parse(capability)
+	cc.patternsLock.Lock()
+	if len(cc.patterns) < maxCapabilityPatterns {
+		cc.patterns[capability] = cp
+	}
+
+	cc.patternsLock.Unlock()
+	return cp
+}
+
+// matches tests a single capability against a request
+func (cc *capabilityChecker) matches(capability string, request RequestInfo) bool {
+	cp := cc.pattern(capability)
+	if cp == nil {
+		return false
+	}
+
+	if cp.method != cc.allMethod && !strings.EqualFold(cp.method, request.Method) {
+		return false
+	}
+
+	return cp.path.MatchString(request.Path)
+}
@@ -0,0 +1,206 @@
+package secure
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestInfoFromContext(t *testing.T) {
+	assert := assert.New(t)
+
+	ri, ok := RequestInfoFromContext(nil)
+	assert.False(ok)
+	assert.Equal(RequestInfo{}, ri)
+
+	ri, ok = RequestInfoFromContext(context.Background())
+	assert.False(ok)
+	assert.Equal(RequestInfo{}, ri)
+
+	ri, ok = RequestInfoFromContext(WithRequestInfo(context.Background(), RequestInfo{Method: "GET", Path: "/api/v2/foo"}))
+	assert.True(ok)
+	assert.Equal(RequestInfo{Method: "GET", Path: "/api/v2/foo"}, ri)
+}
+
+func testNewCapabilityCheckerDefault(t *testing.T, c *CapabilityConfig) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	checker, err := NewCapabilityChecker(c)
+	require.NoError(err)
+	require.NotNil(checker)
+
+	capability, err := checker.CheckCapabilities(
+		[]string{"x1:webpa:api:hook:get", "x1:webpa:api:hook:post"},
+		RequestInfo{Method: "POST", Path: "/api/v2/hook"},
+	)
+
+	assert.Equal("x1:webpa:*", capability)
+	assert.NoError(err)
+
+	capability, err = checker.CheckCapabilities(
+		[]string{"x1:webpa:api:hook:all"},
+		RequestInfo{Method: "DELETE", Path: "/api/v2/hook"},
+	)
+
+	assert.Equal("x1:webpa:*", capability)
+	assert.NoError(err)
+
+	capability, err = checker.CheckCapabilities(nil, RequestInfo{Method: "POST", Path: "/api/v2/hook"})
+	assert.Empty(capability)
+	assert.Equal(ErrorNoCapabilities, err)
+}
+
+func testNewCapabilityCheckerCustom(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	checker, err := NewCapabilityChecker(&CapabilityConfig{
+		Prefix:    "comcast:xmidt:",
+		AllMethod: "any",
+	})
+
+	require.NoError(err)
+	require.NotNil(checker)
+
+	testData := []struct {
+		capabilities       []string
+		request            RequestInfo
+		expectedCapability string
+		expectedError      error
+	}{
+		{[]string{"comcast:xmidt:api:device/.*/stat:get"}, RequestInfo{"GET", "/api/v2/device/mac:112233445566/stat"}, "comcast:xmidt:*", nil},
+		{[]string{"comcast:xmidt:api:device/mac:[0-9a-f]+/stat:get"}, RequestInfo{"GET", "/api/v2/device/mac:112233445566/stat"}, "comcast:xmidt:*", nil},
+		{[]string{"comcast:xmidt:api:device/.*/stat:any"}, RequestInfo{"PUT", "/api/v2/device/mac:112233445566/stat"}, "comcast:xmidt:*", nil},
+		{[]string{"comcast:xmidt:api:device/.*/stat:all"}, RequestInfo{"PUT", "/api/v2/device/mac:112233445566/stat"}, "", ErrorCapabilityMismatch},
+		{[]string{"x1:webpa:api:.*:all"}, RequestInfo{"GET", "/api/v2/device/mac:112233445566/stat"}, "", ErrorCapabilityMismatch},
+		{[]string{"comcast:xmidt:api:.*:get"}, RequestInfo{"GET", "/foo/api/v2/device"}, "", ErrorCapabilityMismatch},
+		{[]string{"comcast:xmidt:api"}, RequestInfo{"GET", "/api/v2/device"}, "", ErrorCapabilityMismatch},
+		{[]string{"comcast:xmidt::.*:get"}, RequestInfo{"GET", "/api/v2/device"}, "", ErrorCapabilityMismatch},
+		{[]string{"comcast:xmidt:api:(:get"}, RequestInfo{"GET", "/api/v2/device"}, "", ErrorCapabilityMismatch},
+	}
+
+	for _, record := range testData {
+		t.Logf("%#v", record)
+		capability, err := checker.CheckCapabilities(record.capabilities, record.request)
+		assert.Equal(record.expectedCapability, capability)
+		assert.Equal(record.expectedError, err)
+	}
+}
+
+func testNewCapabilityCheckerRoutes(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	checker, err := NewCapabilityChecker(&CapabilityConfig{
+		Routes: []RouteCapabilities{
+			{
+				Methods:      []string{"post", "put"},
+				Path:         "/api/v2/hook",
+				Capabilities: []string{"x1:webpa:hook:write", "x1:webpa:admin"},
+			},
+			{
+				Path:         "/api/v2/device/[^/]+/config.*",
+				Capabilities: []string{"x1:webpa:config"},
+			},
+		},
+	})
+
+	require.NoError(err)
+	require.NotNil(checker)
+
+	testData := []struct {
+		capabilities       []string
+		request            RequestInfo
+		expectedCapability string
+		expectedError      error
+	}{
+		{[]string{"x1:webpa:hook:write"}, RequestInfo{"POST", "/api/v2/hook"}, "x1:webpa:hook:write", nil},
+		{[]string{"x1:webpa:api:.*:all", "x1:webpa:admin"}, RequestInfo{"PUT", "/api/v2/hook"}, "x1:webpa:admin", nil},
+		{[]string{"x1:webpa:api:.*:all"}, RequestInfo{"POST", "/api/v2/hook"}, "", ErrorRouteCapabilityMissing},
+		{[]string{"x1:webpa:api:.*:all"}, RequestInfo{"POST", "/api/v2/hooks"}, "x1:webpa:*", nil},
+		{[]string{"x1:webpa:api:.*:all"}, RequestInfo{"GET", "/api/v2/hook"}, "x1:webpa:*", nil},
+		{[]string{"x1:webpa:config"}, RequestInfo{"GET", "/api/v2/device/mac:112233445566/config"}, "x1:webpa:config", nil},
+		{[]string{"x1:webpa:api:.*:all"}, RequestInfo{"DELETE", "/api/v2/device/mac:112233445566/config"}, "", ErrorRouteCapabilityMissing},
+	}
+
+	for _, record := range testData {
+		t.Logf("%#v", record)
+		capability, err := checker.CheckCapabilities(record.capabilities, record.request)
+		assert.Equal(record.expectedCapability, capability)
+		assert.Equal(record.expectedError, err)
+	}
+}
+
+func testNewCapabilityCheckerInvalidRoute(t *testing.T) {
+	assert := assert.New(t)
+
+	checker, err := NewCapabilityChecker(&CapabilityConfig{
+		Routes: []RouteCapabilities{
+			{Path: "/api/v2/(hook"},
+		},
+	})
+
+	assert.Nil(checker)
+	assert.Error(err)
+}
+
+func TestNewCapabilityChecker(t *testing.T) {
+	t.Run("Nil", func(t *testing.T) { testNewCapabilityCheckerDefault(t, nil) })
+	t.Run("Empty", func(t *testing.T) { testNewCapabilityCheckerDefault(t, new(CapabilityConfig)) })
+	t.Run("Custom", testNewCapabilityCheckerCustom)
+	t.Run("Routes", testNewCapabilityCheckerRoutes)
+	t.Run("InvalidRoute", testNewCapabilityCheckerInvalidRoute)
+}
+
+func TestDefaultCapabilityChecker(t *testing.T) {
+	testNewCapabilityCheckerDefault(t, nil)
+
+	var (
+		assert  = assert.New(t)
+		checker = DefaultCapabilityChecker()
+	)
+
+	capability, err := checker.CheckCapabilities([]string{"x1:webpa:api:.*:post"}, RequestInfo{"GET", "/api/v2/hook"})
+	assert.Empty(capability)
+	assert.Equal(ErrorCapabilityMismatch, err)
+}
+
+func TestCapabilityCheckerPatterns(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+		checker = newCapabilityChecker(DefaultCapabilityPrefix+":", DefaultAllMethod)
+	)
+
+	for repeat := 0; repeat < 2; repeat++ {
+		capability, err := checker.CheckCapabilities(
+			[]string{"x1:webpa:api:(:get", "x1:webpa:api:device/.*/stat:get"},
+			RequestInfo{Method: "GET", Path: "/api/v2/device/mac:112233445566/stat"},
+		)
+
+		assert.Equal("x1:webpa:*", capability)
+		assert.NoError(err)
+	}
+
+	require.Len(checker.patterns, 2)
+	assert.Nil(checker.patterns["x1:webpa:api:(:get"])
+	require.NotNil(checker.patterns["x1:webpa:api:device/.*/stat:get"])
+	assert.Equal("get", checker.patterns["x1:webpa:api:device/.*/stat:get"].method)
+
+	for i := len(checker.patterns); i < maxCapabilityPatterns+10; i++ {
+		checker.CheckCapabilities([]string{fmt.Sprintf("x1:webpa:api:path%d:get", i)}, RequestInfo{Method: "GET", Path: "/api/v2/path"})
+	}
+
+	assert.Len(checker.patterns, maxCapabilityPatterns)
+	assert.True(DefaultCapabilityChecker() == DefaultCapabilityChecker())
+}
@@ -0,0 +1,302 @@
+package secure
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/SermoDigital/jose/jws"
+)
+
+const (
+	// SPIFFEScheme is the URI scheme of SPIFFE IDs, e.g. spiffe://example.org/service/foo
+	SPIFFEScheme = "spiffe"
+)
+
+var (
+	// oidSubjectAltName is the identifier of the subject alternative name certificate extension
+	oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+	ErrorNoClientCertificate    = errors.New("No verified client certificate")
+	ErrorCertificateNotAllowed  = errors.New("Client certificate identity is not allowed")
+	ErrorNoCertificateAllowlist = errors.New("No client certificate identities are allowed")
+)
+
+type connectionStateKey struct{}
+
+// WithConnectionState returns a context carrying the TLS state of the connection a request arrived on
+func WithConnectionState(ctx context.Context, state *tls.ConnectionState) context.Context {
+	return context.WithValue(ctx, connectionStateKey{}, state)
+}
+
+// ConnectionStateFromContext returns the TLS state previously stored with WithConnectionState, if any
+func ConnectionStateFromContext(ctx context.Context) (*tls.ConnectionState, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	state, ok := ctx.Value(connectionStateKey{}).(*tls.ConnectionState)
+	return state, ok && state != nil
+}
+
+// VerifiedCertificate returns the client certificate of a connection, provided the server verified it.
+// Unverified peer certificates, such as those presented when the server does not require client
+// certificates, are never returned.
+func VerifiedCertificate(state *tls.ConnectionState) (*x509.Certificate, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	return state.VerifiedChains[0][0], true
+}
+
+// CertificateIdentity is the identity asserted by a client certificate
+type CertificateIdentity struct {
+	// Subject is the common name of the certificate's subject
+	Subject string
+
+	// DNSNames and EmailAddresses are the corresponding subject alternative names
+	DNSNames       []string
+	EmailAddresses []string
+
+	// URIs are the URI subject alternative names, which include any SPIFFE ID
+	URIs []string
+
+	// SPIFFEID is the first URI with the spiffe scheme, if any
+	SPIFFEID string
+
+	// TrustDomain is the trust domain, i.e. the host, of the SPIFFE ID
+	TrustDomain string
+}
+
+// NewCertificateIdentity extracts the identity from a client certificate
+func NewCertificateIdentity(certificate *x509.Certificate) CertificateIdentity {
+	identity := CertificateIdentity{
+		Subject:        certificate.Subject.CommonName,
+		DNSNames:       certificate.DNSNames,
+		EmailAddresses: certificate.EmailAddresses,
+	}
+
+	for _, uri := range uriSANs(certificate) {
+		identity.URIs = append(identity.URIs, uri.String())
+		if len(identity.SPIFFEID) == 0 && strings.EqualFold(uri.Scheme, SPIFFEScheme) {
+			identity.SPIFFEID = uri.String()
+			identity.TrustDomain = uri.Host
+		}
+	}
+
+	return identity
+}
+
+// uriSANs returns the URI subject alternative names of a certificate.  These are parsed here from the raw
+// extension rather than taken from x509.Certificate.URIs, which only exists as of Go 1.10.  Malformed names
+// are skipped.
+func uriSANs(certificate *x509.Certificate) []*url.URL {
+	var uris []*url.URL
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+
+		// the extension is a SEQUENCE of GeneralName, where a URI is the context-specific, primitive tag 6
+		var names asn1.RawValue
+		if rest, err := asn1.Unmarshal(extension.Value, &names); err != nil || len(rest) > 0 || !names.IsCompound || names.Tag != asn1.TagSequence {
+			continue
+		}
+
+		for remaining := names.Bytes; len(remaining) > 0; {
+			var (
+				name asn1.RawValue
+				err  error
+			)
+
+			if remaining, err = asn1.Unmarshal(remaining, &name); err != nil {
+				break
+			}
+
+			if name.Class != asn1.ClassContextSpecific || name.Tag != 6 {
+				continue
+			}
+
+			if uri, err := url.Parse(string(name.Bytes)); err == nil {
+				uris = append(uris, uri)
+			}
+		}
+	}
+
+	return uris
+}
+
+// Name returns the preferred name for this identity: the SPIFFE ID if present, otherwise the subject
+func (ci CertificateIdentity) Name() string {
+	if len(ci.SPIFFEID) > 0 {
+		return ci.SPIFFEID
+	}
+
+	return ci.Subject
+}
+
+// Names returns all the names this identity can be matched by
+func (ci CertificateIdentity) Names() []string {
+	names := make([]string, 0, 1+len(ci.DNSNames)+len(ci.EmailAddresses)+len(ci.URIs))
+	if len(ci.Subject) > 0 {
+		names = append(names, ci.Subject)
+	}
+
+	names = append(names, ci.DNSNames...)
+	names = append(names, ci.EmailAddresses...)
+	return append(names, ci.URIs...)
+}
+
+func contains(values []string, candidate string, fold bool) bool {
+	for _, v := range values {
+		if v == candidate || (fold && strings.EqualFold(v, candidate)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CertificateValidator authorizes requests based on the verified client certificate of the connection,
+// obtained from the context via ConnectionStateFromContext.  The token passed to Validate is ignored and
+// may be nil.  An identity is authorized if it matches any of the allowlists, or if the capabilities mapped
+// to any of its names authorize the request.  A CertificateValidator with no allowlists and no capability
+// mappings rejects all certificates.
+//
+// The claims of an authorized identity are recorded in the validation context via SetClaims.  The sub claim
+// is the SPIFFE ID if present, otherwise the subject common name.
+type CertificateValidator struct {
+	// Subjects is the allowlist of subject common names
+	Subjects []string `json:"subjects"`
+
+	// DNSNames is the allowlist of DNS subject alternative names, matched case-insensitively
+	DNSNames []string `json:"dnsNames"`
+
+	// URIs is the allowlist of URI subject alternative names, including SPIFFE IDs
+	URIs []string `json:"uris"`
+
+	// TrustDomains allows any SPIFFE ID in the given trust domains, e.g. example.org
+	TrustDomains []string `json:"trustDomains"`
+
+	// Capabilities maps identity names, i.e. a subject or any subject alternative name, onto capabilities.
+	// These capabilities are checked against the request in the same way as JWT capabilities.
+	Capabilities map[string][]string `json:"capabilities"`
+
+	// Checker is the CapabilityChecker used for mapped capabilities.  If unset, DefaultCapabilityChecker is used.
+	Checker CapabilityChecker `json:"-"`
+
+	measures *JWTValidationMeasures
+}
+
+// DefineMeasures defines the metrics tool used by CertificateValidator
+func (v *CertificateValidator) DefineMeasures(m *JWTValidationMeasures) {
+	v.measures = m
+}
+
+func (v *CertificateValidator) reason(reason string) {
+	if v.measures != nil {
+		v.measures.ValidationReason.With("reason", reason).Add(1)
+	}
+}
+
+func (v *CertificateValidator) hasAllowlist() bool {
+	return len(v.Subjects) > 0 || len(v.DNSNames) > 0 || len(v.URIs) > 0 || len(v.TrustDomains) > 0
+}
+
+// allowed tests if an identity matches any allowlist
+func (v *CertificateValidator) allowed(identity CertificateIdentity) bool {
+	if len(identity.Subject) > 0 && contains(v.Subjects, identity.Subject, false) {
+		return true
+	}
+
+	for _, dnsName := range identity.DNSNames {
+		if contains(v.DNSNames, dnsName, true) {
+			return true
+		}
+	}
+
+	for _, uri := range identity.URIs {
+		if contains(v.URIs, uri, false) {
+			return true
+		}
+	}
+
+	return len(identity.TrustDomain) > 0 && contains(v.TrustDomains, identity.TrustDomain, true)
+}
+
+// capabilities returns the capabilities mapped to any of an identity's names
+func (v *CertificateValidator) capabilities(identity CertificateIdentity) []string {
+	var capabilities []string
+	for _, name := range identity.Names() {
+		capabilities = append(capabilities, v.Capabilities[name]...)
+	}
+
+	return capabilities
+}
+
+func (v *CertificateValidator) Validate(ctx context.Context, _ *Token) (bool, error) {
+	if !v.hasAllowlist() && len(v.Capabilities) == 0 {
+		v.reason("no_certificate_allowlist")
+		return false, ErrorNoCertificateAllowlist
+	}
+
+	state, _ := ConnectionStateFromContext(ctx)
+	certificate, ok := VerifiedCertificate(state)
+	if !ok {
+		v.reason("no_client_certificate")
+		return false, ErrorNoClientCertificate
+	}
+
+	var (
+		identity     = NewCertificateIdentity(certificate)
+		capabilities = v.capabilities(identity)
+	)
+
+	if !v.allowed(identity) {
+		if len(capabilities) == 0 {
+			v.reason("certificate_not_allowed")
+			return false, ErrorCertificateNotAllowed
+		}
+
+		request, ok := RequestInfoFromContext(ctx)
+		if !ok {
+			v.reason(capabilityReason(ErrorNoRequestInfo))
+			return false, ErrorNoRequestInfo
+		}
+
+		checker := v.Checker
+		if checker == nil {
+			checker = DefaultCapabilityChecker()
+		}
+
+		capability, err := checker.CheckCapabilities(capabilities, request)
+		if err != nil {
+			v.reason(capabilityReason(err))
+			return false, err
+		}
+
+		if v.measures != nil && v.measures.CapabilityMatch != nil {
+			v.measures.CapabilityMatch.With("capability", capability).Add(1)
+		}
+	}
+
+	claims := jws.Claims{"sub": identity.Name()}
+	if len(capabilities) > 0 {
+		// stored as []interface{}, the same as capabilities decoded from a JWT
+		values := make([]interface{}, len(capabilities))
+		for i, c := range capabilities {
+			values[i] = c
+		}
+
+		claims["capabilities"] = values
+	}
+
+	SetClaims(ctx, claims)
+	v.reason("ok")
+	return true, nil
+}
@@ -0,0 +1,225 @@
+package secure
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/Comcast/webpa-common/xmetrics/xmetricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCertificate(t *testing.T, commonName string, dnsNames []string, uris ...string) *x509.Certificate {
+	certificate := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: dnsNames,
+	}
+
+	if len(uris) > 0 {
+		certificate.Extensions = append(certificate.Extensions, testURISANExtension(t, uris...))
+	}
+
+	return certificate
+}
+
+// testURISANExtension produces a subject alternative name extension holding the given URIs
+func testURISANExtension(t *testing.T, uris ...string) pkix.Extension {
+	var names []asn1.RawValue
+	for _, uri := range uris {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte(uri)})
+	}
+
+	value, err := asn1.Marshal(names)
+	require.NoError(t, err)
+	return pkix.Extension{Id: oidSubjectAltName, Value: value}
+}
+
+func verifiedState(certificate *x509.Certificate) *tls.ConnectionState {
+	return &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{certificate},
+		VerifiedChains:   [][]*x509.Certificate{{certificate}},
+	}
+}
+
+func TestConnectionStateFromContext(t *testing.T) {
+	assert := assert.New(t)
+
+	state, ok := ConnectionStateFromContext(nil)
+	assert.Nil(state)
+	assert.False(ok)
+
+	state, ok = ConnectionStateFromContext(context.Background())
+	assert.Nil(state)
+	assert.False(ok)
+
+	state, ok = ConnectionStateFromContext(WithConnectionState(context.Background(), nil))
+	assert.Nil(state)
+	assert.False(ok)
+
+	expected := new(tls.ConnectionState)
+	state, ok = ConnectionStateFromContext(WithConnectionState(context.Background(), expected))
+	assert.Equal(expected, state)
+	assert.True(ok)
+}
+
+func TestVerifiedCertificate(t *testing.T) {
+	var (
+		assert      = assert.New(t)
+		certificate = testCertificate(t, "test", nil)
+	)
+
+	actual, ok := VerifiedCertificate(nil)
+	assert.Nil(actual)
+	assert.False(ok)
+
+	// peer certificates which were not verified are ignored
+	actual, ok = VerifiedCertificate(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}})
+	assert.Nil(actual)
+	assert.False(ok)
+
+	actual, ok = VerifiedCertificate(verifiedState(certificate))
+	assert.Equal(certificate, actual)
+	assert.True(ok)
+}
+
+func TestURISANs(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	// a DNS name, tag 2, alongside the URI names
+	value, err := asn1.Marshal([]asn1.RawValue{
+		{Class: asn1.ClassContextSpecific, Tag: 2, Bytes: []byte("test.example.org")},
+		{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte("spiffe://example.org/service/test")},
+		{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte("%zz")},
+		{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte("https://example.org/test")},
+	})
+
+	require.NoError(err)
+	certificate := &x509.Certificate{
+		Extensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{2, 5, 29, 15}, Value: []byte{0x03, 0x02, 0x07, 0x80}},
+			{Id: oidSubjectAltName, Value: []byte("garbage")},
+			{Id: oidSubjectAltName, Value: value},
+		},
+	}
+
+	uris := uriSANs(certificate)
+	require.Len(uris, 2)
+	assert.Equal("spiffe://example.org/service/test", uris[0].String())
+	assert.Equal("https://example.org/test", uris[1].String())
+
+	assert.Empty(uriSANs(&x509.Certificate{}))
+}
+
+func TestNewCertificateIdentity(t *testing.T) {
+	assert := assert.New(t)
+
+	identity := NewCertificateIdentity(testCertificate(t, "test", []string{"test.example.org"}, "https://example.org/test", "spiffe://example.org/service/test"))
+	assert.Equal("test", identity.Subject)
+	assert.Equal([]string{"test.example.org"}, identity.DNSNames)
+	assert.Equal([]string{"https://example.org/test", "spiffe://example.org/service/test"}, identity.URIs)
+	assert.Equal("spiffe://example.org/service/test", identity.SPIFFEID)
+	assert.Equal("example.org", identity.TrustDomain)
+	assert.Equal("spiffe://example.org/service/test", identity.Name())
+	assert.Equal(
+		[]string{"test", "test.example.org", "https://example.org/test", "spiffe://example.org/service/test"},
+		identity.Names(),
+	)
+
+	identity = NewCertificateIdentity(testCertificate(t, "test", nil))
+	assert.Empty(identity.SPIFFEID)
+	assert.Equal("test", identity.Name())
+}
+
+func TestCertificateValidator(t *testing.T) {
+	var (
+		spiffe = testCertificate(t, "spiffe", nil, "spiffe://example.org/service/spiffe")
+		dns    = testCertificate(t, "dns", []string{"Device.Example.Org"})
+		other  = testCertificate(t, "other", []string{"other.example.com"}, "spiffe://other.com/service/other")
+
+		request = RequestInfo{Method: "GET", Path: "/api/v2/device/mac:112233445566/stat"}
+	)
+
+	testData := []struct {
+		validator     CertificateValidator
+		state         *tls.ConnectionState
+		request       *RequestInfo
+		expectedError error
+		expectedSub   string
+		reason        string
+	}{
+		{CertificateValidator{}, verifiedState(spiffe), &request, ErrorNoCertificateAllowlist, "", "no_certificate_allowlist"},
+		{CertificateValidator{Subjects: []string{"spiffe"}}, nil, &request, ErrorNoClientCertificate, "", "no_client_certificate"},
+		{CertificateValidator{Subjects: []string{"spiffe"}}, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{spiffe}}, &request, ErrorNoClientCertificate, "", "no_client_certificate"},
+		{CertificateValidator{Subjects: []string{"spiffe"}}, verifiedState(spiffe), &request, nil, "spiffe://example.org/service/spiffe", "ok"},
+		{CertificateValidator{Subjects: []string{"spiffe"}}, verifiedState(other), &request, ErrorCertificateNotAllowed, "", "certificate_not_allowed"},
+		{CertificateValidator{DNSNames: []string{"device.example.org"}}, verifiedState(dns), &request, nil, "dns", "ok"},
+		{CertificateValidator{URIs: []string{"spiffe://example.org/service/spiffe"}}, verifiedState(spiffe), &request, nil, "spiffe://example.org/service/spiffe", "ok"},
+		{CertificateValidator{TrustDomains: []string{"example.org"}}, verifiedState(spiffe), &request, nil, "spiffe://example.org/service/spiffe", "ok"},
+		{CertificateValidator{TrustDomains: []string{"example.org"}}, verifiedState(other), &request, ErrorCertificateNotAllowed, "", "certificate_not_allowed"},
+		{
+			CertificateValidator{Capabilities: map[string][]string{"device.example.org": {"x1:webpa:api:device/.*/stat:get"}}},
+			verifiedState(testCertificate(t, "capable", []string{"device.example.org"})),
+			&request,
+			nil,
+			"capable",
+			"ok",
+		},
+		{
+			CertificateValidator{Capabilities: map[string][]string{"dns": {"x1:webpa:api:device/.*/config:all"}}},
+			verifiedState(dns),
+			&request,
+			ErrorCapabilityMismatch,
+			"",
+			"capability_mismatch",
+		},
+		{
+			CertificateValidator{Capabilities: map[string][]string{"dns": {"x1:webpa:api:.*:all"}}},
+			verifiedState(dns),
+			nil,
+			ErrorNoRequestInfo,
+			"",
+			"no_request_info",
+		},
+	}
+
+	for i, record := range testData {
+		t.Logf("#%d", i)
+
+		var (
+			assert   = assert.New(t)
+			provider = xmetricstest.NewProvider(nil, Metrics)
+			ctx      = WithClaimsHolder(context.Background())
+		)
+
+		if record.state != nil {
+			ctx = WithConnectionState(ctx, record.state)
+		}
+
+		if record.request != nil {
+			ctx = WithRequestInfo(ctx, *record.request)
+		}
+
+		record.validator.DefineMeasures(&JWTValidationMeasures{
+			ValidationReason: provider.NewCounter(JWTValidationReasonCounter),
+			CapabilityMatch:  provider.NewCounter(JWTCapabilityMatchCounter),
+		})
+
+		valid, err := record.validator.Validate(ctx, nil)
+		assert.Equal(record.expectedError, err)
+		assert.Equal(record.expectedError == nil, valid)
+		provider.Assert(t, JWTValidationReasonCounter, "reason", record.reason)(xmetricstest.Value(1.0))
+
+		claims, ok := ClaimsFromContext(ctx)
+		assert.Equal(len(record.expectedSub) > 0, ok)
+		if ok {
+			assert.Equal(record.expectedSub, claims.Get("sub"))
+		}
+	}
+}
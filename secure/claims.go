@@ -0,0 +1,58 @@
+package secure
+
+import (
+	"context"
+	"sync"
+
+	"github.com/SermoDigital/jose/jws"
+)
+
+// claimsHolder is the mutable slot, carried by a context, into which a Validator records the
+// claims of the token it validated.  Contexts are immutable, so this is how validators that do
+// not produce a JWS, such as IntrospectionValidator, share claims with their callers.
+type claimsHolder struct {
+	lock   sync.Mutex
+	claims jws.Claims
+}
+
+type claimsHolderKey struct{}
+
+// WithClaimsHolder returns a context in which validators can record the claims of a validated token
+// via SetClaims.  Callers retrieve those claims with ClaimsFromContext after validation.
+func WithClaimsHolder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, claimsHolderKey{}, new(claimsHolder))
+}
+
+// SetClaims records the claims of a validated token in the given context.  This function returns
+// false if the context was not created with WithClaimsHolder.
+func SetClaims(ctx context.Context, claims jws.Claims) bool {
+	if ctx == nil {
+		return false
+	}
+
+	holder, ok := ctx.Value(claimsHolderKey{}).(*claimsHolder)
+	if !ok {
+		return false
+	}
+
+	holder.lock.Lock()
+	holder.claims = claims
+	holder.lock.Unlock()
+	return true
+}
+
+// ClaimsFromContext returns the claims recorded via SetClaims, if any
+func ClaimsFromContext(ctx context.Context) (jws.Claims, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	holder, ok := ctx.Value(claimsHolderKey{}).(*claimsHolder)
+	if !ok {
+		return nil, false
+	}
+
+	holder.lock.Lock()
+	defer holder.lock.Unlock()
+	return holder.claims, holder.claims != nil
+}
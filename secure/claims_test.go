@@ -0,0 +1,44 @@
+package secure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SermoDigital/jose/jws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimsHolder(t *testing.T) {
+	t.Run("NoHolder", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.False(SetClaims(nil, jws.Claims{"sub": "test"}))
+		assert.False(SetClaims(context.Background(), jws.Claims{"sub": "test"}))
+
+		claims, ok := ClaimsFromContext(nil)
+		assert.Nil(claims)
+		assert.False(ok)
+
+		claims, ok = ClaimsFromContext(context.Background())
+		assert.Nil(claims)
+		assert.False(ok)
+	})
+
+	t.Run("Holder", func(t *testing.T) {
+		var (
+			assert         = assert.New(t)
+			ctx            = WithClaimsHolder(context.Background())
+			expectedClaims = jws.Claims{"sub": "test"}
+		)
+
+		claims, ok := ClaimsFromContext(ctx)
+		assert.Nil(claims)
+		assert.False(ok)
+
+		// claims set through a derived context are visible through the original
+		assert.True(SetClaims(context.WithValue(ctx, "unrelated", "value"), expectedClaims))
+		claims, ok = ClaimsFromContext(ctx)
+		assert.Equal(expectedClaims, claims)
+		assert.True(ok)
+	})
+}
@@ -0,0 +1,59 @@
+package secure
+
+import (
+	"crypto"
+	_ "crypto/sha512"
+	"errors"
+
+	josecrypto "github.com/SermoDigital/jose/crypto"
+	"github.com/SermoDigital/jose/jws"
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	ErrorInvalidEdDSAKey       = errors.New("EdDSA requires an Ed25519 key")
+	ErrorInvalidEdDSASignature = errors.New("EdDSA signature verification failed")
+)
+
+// SigningMethodEdDSA implements the EdDSA JWS algorithm from RFC 8037 for Ed25519 keys.  The
+// SermoDigital library has no EdDSA support, so this package registers this signing method
+// under the "EdDSA" alg.
+var SigningMethodEdDSA = signingMethodEdDSA{}
+
+func init() {
+	jws.RegisterSigningMethod(SigningMethodEdDSA)
+}
+
+type signingMethodEdDSA struct{}
+
+func (m signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Hasher returns the hash Ed25519 uses internally.  Ed25519 signs messages in their entirety, so
+// this hash is never applied by callers.
+func (m signingMethodEdDSA) Hasher() crypto.Hash {
+	return crypto.SHA512
+}
+
+func (m signingMethodEdDSA) Verify(raw []byte, signature josecrypto.Signature, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return ErrorInvalidEdDSAKey
+	}
+
+	if !ed25519.Verify(publicKey, raw, signature) {
+		return ErrorInvalidEdDSASignature
+	}
+
+	return nil
+}
+
+func (m signingMethodEdDSA) Sign(data []byte, key interface{}) (josecrypto.Signature, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return nil, ErrorInvalidEdDSAKey
+	}
+
+	return josecrypto.Signature(ed25519.Sign(privateKey, data)), nil
+}
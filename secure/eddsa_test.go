@@ -0,0 +1,44 @@
+package secure
+
+import (
+	"crypto"
+	"crypto/rand"
+	"testing"
+
+	"github.com/SermoDigital/jose/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestSigningMethodEdDSA(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+		data    = []byte("header.payload")
+	)
+
+	assert.Equal("EdDSA", SigningMethodEdDSA.Alg())
+	assert.Equal(crypto.SHA512, SigningMethodEdDSA.Hasher())
+	assert.Equal(SigningMethodEdDSA, jws.GetSigningMethod("EdDSA"))
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+
+	otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+
+	signature, err := SigningMethodEdDSA.Sign(data, privateKey)
+	require.NoError(err)
+	assert.Len(signature, ed25519.SignatureSize)
+
+	assert.NoError(SigningMethodEdDSA.Verify(data, signature, publicKey))
+	assert.Equal(ErrorInvalidEdDSASignature, SigningMethodEdDSA.Verify([]byte("tampered"), signature, publicKey))
+	assert.Equal(ErrorInvalidEdDSASignature, SigningMethodEdDSA.Verify(data, signature, otherPublicKey))
+	assert.Equal(ErrorInvalidEdDSAKey, SigningMethodEdDSA.Verify(data, signature, privateKey))
+	assert.Equal(ErrorInvalidEdDSAKey, SigningMethodEdDSA.Verify(data, signature, "not a key"))
+
+	signature, err = SigningMethodEdDSA.Sign(data, publicKey)
+	assert.Nil(signature)
+	assert.Equal(ErrorInvalidEdDSAKey, err)
+}
@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
 	"github.com/Comcast/webpa-common/logging"
 	"github.com/Comcast/webpa-common/secure"
+	"github.com/Comcast/webpa-common/secure/policy"
 	"github.com/Comcast/webpa-common/xhttp"
 	"github.com/SermoDigital/jose/jws"
 	"github.com/go-kit/kit/log"
@@ -29,12 +31,22 @@ const (
 // AuthorizationHandler provides decoration for http.Handler instances and will
 // ensure that requests pass the validator.  Note that secure.Validators is a Validator
 // implementation that allows chaining validators together via logical OR.
+//
+// If a CertificateValidator is supplied, requests arriving with a verified client certificate
+// are first offered to it, with a nil token.  Requests it rejects fall back to the Authorization
+// header, so that a service can accept either an mTLS identity or a token.
+//
+// If a Policy is supplied, it is evaluated for each request that passes validation, using the
+// claims of the validated token.  Requests the Policy denies are rejected with the status code
+// of the deciding rule, or the ForbiddenStatusCode if the rule has none.
 type AuthorizationHandler struct {
-	HeaderName          string
-	ForbiddenStatusCode int
-	Validator           secure.Validator
-	Logger              log.Logger
-	measures            *secure.JWTValidationMeasures
+	HeaderName           string
+	ForbiddenStatusCode  int
+	Validator            secure.Validator
+	CertificateValidator secure.Validator
+	Policy               *policy.Policy
+	Logger               log.Logger
+	measures             *secure.JWTValidationMeasures
 }
 
 // headerName returns the authorization header to use, either a.HeaderName
@@ -69,7 +81,7 @@ func (a AuthorizationHandler) logger() log.Logger {
 // using the configuration specified.
 func (a AuthorizationHandler) Decorate(delegate http.Handler) http.Handler {
 	// if there is no validator, there's no point in decorating anything
-	if a.Validator == nil {
+	if a.Validator == nil && a.CertificateValidator == nil {
 		return delegate
 	}
 
@@ -81,6 +93,33 @@ func (a AuthorizationHandler) Decorate(delegate http.Handler) http.Handler {
 	)
 
 	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if a.CertificateValidator != nil && request.TLS != nil {
+			contextValues := &ContextValues{
+				Method: request.Method,
+				Path:   request.URL.Path,
+			}
+
+			certificateContext := secure.WithConnectionState(NewContextWithValue(request.Context(), contextValues), request.TLS)
+			valid, err := a.CertificateValidator.Validate(certificateContext, nil)
+			if err == nil && valid {
+				if statusCode, allowed := a.authorize(certificateContext, nil, contextValues, logger); !allowed {
+					xhttp.WriteError(response, statusCode, "request denied")
+					return
+				}
+
+				delegate.ServeHTTP(response, request.WithContext(certificateContext))
+				return
+			}
+
+			logger.Log(level.Key(), level.DebugValue(), logging.MessageKey(), "client certificate not accepted", logging.ErrorKey(), err)
+		}
+
+		if a.Validator == nil {
+			errorLog.Log(logging.MessageKey(), "request denied", "reason", "client certificate required", "url", request.URL)
+			xhttp.WriteError(response, forbiddenStatusCode, "request denied")
+			return
+		}
+
 		headerValue := request.Header.Get(headerName)
 		if len(headerValue) == 0 {
 			errorLog.Log(logging.MessageKey(), "missing header", "name", headerName)
@@ -112,8 +151,9 @@ func (a AuthorizationHandler) Decorate(delegate http.Handler) http.Handler {
 
 		valid, err := a.Validator.Validate(sharedContext, token)
 		if err == nil && valid {
-			if err := populateContextValues(token, contextValues); err != nil {
-				logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "unable to populate context", logging.ErrorKey(), err)
+			if statusCode, allowed := a.authorize(sharedContext, token, contextValues, logger); !allowed {
+				xhttp.WriteError(response, statusCode, "request denied")
+				return
 			}
 
 			delegate.ServeHTTP(response, request.WithContext(sharedContext))
@@ -137,30 +177,77 @@ func (a AuthorizationHandler) Decorate(delegate http.Handler) http.Handler {
 	})
 }
 
+// authorize populates the context values for a validated request and applies the Policy, if any.
+// If the request is denied, the status code to respond with is returned along with false.
+func (a AuthorizationHandler) authorize(ctx context.Context, token *secure.Token, values *ContextValues, logger log.Logger) (int, bool) {
+	claims, err := populateContextValues(ctx, token, values)
+	if err != nil {
+		logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "unable to populate context", logging.ErrorKey(), err)
+	}
+
+	if a.Policy == nil {
+		return 0, true
+	}
+
+	decision := a.Policy.Evaluate(policy.Request{Method: values.Method, Path: values.Path, Claims: claims})
+	if decision.Allowed() {
+		return 0, true
+	}
+
+	if decision.DryRun {
+		logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "policy would deny request", "rule", decision.Rule, "sat-client-id", values.SatClientID, "method", values.Method, "path", values.Path)
+		return 0, true
+	}
+
+	logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "request denied by policy", "rule", decision.Rule, "sat-client-id", values.SatClientID, "method", values.Method, "path", values.Path)
+	if decision.StatusCode > 0 {
+		return decision.StatusCode, false
+	}
+
+	return a.forbiddenStatusCode(), false
+}
+
 //DefineMeasures facilitates clients to define authHandler metrics tools
 func (a *AuthorizationHandler) DefineMeasures(m *secure.JWTValidationMeasures) {
 	a.measures = m
 }
 
-func populateContextValues(token *secure.Token, values *ContextValues) error {
+// populateContextValues fills in the values derived from a validated token's claims.  Claims
+// recorded by the validator, e.g. from token introspection or a client certificate, take
+// precedence over the claims of a JWS token.  The claims used, if any, are returned.
+func populateContextValues(ctx context.Context, token *secure.Token, values *ContextValues) (jws.Claims, error) {
 	values.SatClientID = "N/A"
 
-	if token.Type() != secure.Bearer {
-		return nil
+	if claims, ok := secure.ClaimsFromContext(ctx); ok {
+		populateClaims(claims, values)
+		return claims, nil
+	}
+
+	// a nil token means the request was authorized by some other means, e.g. a client certificate
+	if token == nil || token.Type() != secure.Bearer {
+		return nil, nil
 	}
 
 	jwsToken, err := secure.DefaultJWSParser.ParseJWS(token)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	claims, ok := jwsToken.Payload().(jws.Claims)
 	if !ok {
-		return errors.New("no claims")
+		return nil, errors.New("no claims")
 	}
 
+	populateClaims(claims, values)
+	return claims, nil
+}
+
+func populateClaims(claims jws.Claims, values *ContextValues) {
 	if sub, ok := claims.Get("sub").(string); ok {
 		values.SatClientID = sub
+	} else if clientID, ok := claims.Get("client_id").(string); ok {
+		// introspection responses (RFC 7662) may identify the client without a subject
+		values.SatClientID = clientID
 	}
 
 	if allowedResources, ok := claims.Get("allowedResources").(map[string]interface{}); ok {
@@ -173,6 +260,4 @@ func populateContextValues(token *secure.Token, values *ContextValues) error {
 			}
 		}
 	}
-
-	return nil
 }
@@ -1,6 +1,10 @@
 package handler
 
-import "context"
+import (
+	"context"
+
+	"github.com/Comcast/webpa-common/secure"
+)
 
 type contextKey struct{}
 
@@ -12,8 +16,12 @@ type ContextValues struct {
 	PartnerIDs  []string
 }
 
-//NewContextWithValue returns a context with the specified context values
+//NewContextWithValue returns a context with the specified context values.  The method and path
+//are also made available to secure.Validator implementations via secure.RequestInfoFromContext,
+//and validators may record token claims in the returned context via secure.SetClaims.
 func NewContextWithValue(ctx context.Context, vals *ContextValues) context.Context {
+	ctx = secure.WithRequestInfo(ctx, secure.RequestInfo{Method: vals.Method, Path: vals.Path})
+	ctx = secure.WithClaimsHolder(ctx)
 	return context.WithValue(ctx, contextKey{}, vals)
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/Comcast/webpa-common/secure"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,8 +39,23 @@ func TestNewContext(t *testing.T) {
 		Path:        "foo",
 		Method:      "GET",
 	}
-	expectedContext := context.WithValue(context.Background(), contextKey{}, inputCtxValues)
-	actualContext := NewContextWithValue(context.Background(), inputCtxValues)
+	expectedContext := context.WithValue(
+		secure.WithClaimsHolder(
+			secure.WithRequestInfo(context.Background(), secure.RequestInfo{Method: "GET", Path: "foo"}),
+		),
+		contextKey{},
+		inputCtxValues,
+	)
 
+	actualContext := NewContextWithValue(context.Background(), inputCtxValues)
 	assert.EqualValues(expectedContext, actualContext)
+
+	requestInfo, ok := secure.RequestInfoFromContext(actualContext)
+	assert.True(ok)
+	assert.Equal(secure.RequestInfo{Method: "GET", Path: "foo"}, requestInfo)
+
+	claims, ok := secure.ClaimsFromContext(actualContext)
+	assert.False(ok)
+	assert.Nil(claims)
+	assert.True(secure.SetClaims(actualContext, map[string]interface{}{"sub": "test"}))
 }
@@ -0,0 +1,291 @@
+package secure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Comcast/webpa-common/types"
+	"github.com/SermoDigital/jose/jws"
+	"github.com/SermoDigital/jose/jwt"
+)
+
+const (
+	// DefaultIntrospectionTimeout is the timeout used for introspection requests when no timeout
+	// or HTTP client is configured
+	DefaultIntrospectionTimeout = 10 * time.Second
+
+	// DefaultIntrospectionCacheSize is the maximum number of cached introspection responses when
+	// no cache size is configured
+	DefaultIntrospectionCacheSize = 10000
+)
+
+var (
+	ErrorNoIntrospectionEndpoint = errors.New("An introspection endpoint is required")
+	ErrorInactiveToken           = errors.New("Token is not active")
+)
+
+// IntrospectionValidatorFactory is the configurable factory for IntrospectionValidator instances
+type IntrospectionValidatorFactory struct {
+	// Endpoint is the URL of the OAuth2 token introspection endpoint (RFC 7662)
+	Endpoint string `json:"endpoint"`
+
+	// ClientID and ClientSecret are the credentials used to authenticate to the introspection
+	// endpoint via HTTP Basic authentication.  If ClientID is unset, no credentials are sent.
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+
+	// TokenTypeHint is sent as the token_type_hint parameter.  If unset, no hint is sent.
+	TokenTypeHint string `json:"tokenTypeHint"`
+
+	// Timeout is the timeout for introspection requests.  If unset, DefaultIntrospectionTimeout is used.
+	// Ignored if HTTPClient is set.
+	Timeout types.Duration `json:"timeout"`
+
+	// CacheTTL is the maximum time an introspection response is cached.  Responses for active tokens
+	// are never cached beyond the token's exp.  If nonpositive, responses are not cached.
+	CacheTTL types.Duration `json:"cacheTTL"`
+
+	// CacheSize is the maximum number of cached introspection responses.  Once the cache is full, responses
+	// for further tokens are not cached until expired responses are discarded.  If nonpositive,
+	// DefaultIntrospectionCacheSize is used.
+	CacheSize int `json:"cacheSize"`
+
+	// HTTPClient is the client used to make introspection requests.  If unset, a client with the
+	// configured timeout is used.
+	HTTPClient *http.Client `json:"-"`
+}
+
+func (f *IntrospectionValidatorFactory) httpClient() *http.Client {
+	if f.HTTPClient != nil {
+		return f.HTTPClient
+	}
+
+	timeout := time.Duration(f.Timeout)
+	if timeout < 1 {
+		timeout = DefaultIntrospectionTimeout
+	}
+
+	return &http.Client{Timeout: timeout}
+}
+
+func (f *IntrospectionValidatorFactory) cacheSize() int {
+	if f.CacheSize > 0 {
+		return f.CacheSize
+	}
+
+	return DefaultIntrospectionCacheSize
+}
+
+// New creates an IntrospectionValidator from this factory's configuration
+func (f *IntrospectionValidatorFactory) New() (*IntrospectionValidator, error) {
+	if len(f.Endpoint) == 0 {
+		return nil, ErrorNoIntrospectionEndpoint
+	}
+
+	if _, err := url.Parse(f.Endpoint); err != nil {
+		return nil, err
+	}
+
+	return &IntrospectionValidator{
+		endpoint:      f.Endpoint,
+		clientID:      f.ClientID,
+		clientSecret:  f.ClientSecret,
+		tokenTypeHint: f.TokenTypeHint,
+		cacheTTL:      time.Duration(f.CacheTTL),
+		cacheSize:     f.cacheSize(),
+		client:        f.httpClient(),
+		now:           time.Now,
+		cache:         make(map[[sha256.Size]byte]introspectionEntry),
+	}, nil
+}
+
+// introspectionEntry is a cached introspection response
+type introspectionEntry struct {
+	claims  jws.Claims
+	active  bool
+	expires time.Time
+}
+
+// IntrospectionValidator validates opaque Bearer tokens using an OAuth2 token introspection
+// endpoint, as described by RFC 7662.  Responses are cached by a hash of the token, so the
+// tokens themselves are never held in memory beyond a request.
+//
+// The claims of a valid token are recorded in the validation context via SetClaims.
+type IntrospectionValidator struct {
+	endpoint      string
+	clientID      string
+	clientSecret  string
+	tokenTypeHint string
+	cacheTTL      time.Duration
+	cacheSize     int
+	client        *http.Client
+	now           func() time.Time
+	measures      *JWTValidationMeasures
+
+	cacheLock sync.RWMutex
+	cache     map[[sha256.Size]byte]introspectionEntry
+	nextSweep time.Time
+}
+
+// DefineMeasures defines the metrics tool used by IntrospectionValidator
+func (v *IntrospectionValidator) DefineMeasures(m *JWTValidationMeasures) {
+	v.measures = m
+}
+
+func (v *IntrospectionValidator) reason(reason string) {
+	if v.measures != nil {
+		v.measures.ValidationReason.With("reason", reason).Add(1)
+	}
+}
+
+// expiration extracts the exp claim, which is a number of seconds since the epoch
+func expiration(claims jws.Claims) (time.Time, bool) {
+	switch exp := claims.Get("exp").(type) {
+	case float64:
+		return time.Unix(int64(exp), 0), true
+	case json.Number:
+		if seconds, err := exp.Int64(); err == nil {
+			return time.Unix(seconds, 0), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (v *IntrospectionValidator) cached(hash [sha256.Size]byte, now time.Time) (introspectionEntry, bool) {
+	v.cacheLock.RLock()
+	entry, ok := v.cache[hash]
+	v.cacheLock.RUnlock()
+
+	if ok && now.Before(entry.expires) {
+		return entry, true
+	}
+
+	return introspectionEntry{}, false
+}
+
+func (v *IntrospectionValidator) store(hash [sha256.Size]byte, entry introspectionEntry, now time.Time) {
+	if v.cacheTTL < 1 || !now.Before(entry.expires) {
+		return
+	}
+
+	v.cacheLock.Lock()
+	defer v.cacheLock.Unlock()
+
+	// periodically discard expired responses, so that a full cache makes room for new responses
+	if !now.Before(v.nextSweep) {
+		for h, e := range v.cache {
+			if !now.Before(e.expires) {
+				delete(v.cache, h)
+			}
+		}
+
+		v.nextSweep = now.Add(v.cacheTTL)
+	}
+
+	// a full cache only accepts responses for tokens it already holds
+	if _, ok := v.cache[hash]; !ok && len(v.cache) >= v.cacheSize {
+		return
+	}
+
+	v.cache[hash] = entry
+}
+
+// introspect submits a token to the introspection endpoint
+func (v *IntrospectionValidator) introspect(ctx context.Context, token *Token) (jws.Claims, error) {
+	form := url.Values{"token": {token.value}}
+	if len(v.tokenTypeHint) > 0 {
+		form.Set("token_type_hint", v.tokenTypeHint)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx != nil {
+		request = request.WithContext(ctx)
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if len(v.clientID) > 0 {
+		request.SetBasicAuth(v.clientID, v.clientSecret)
+	}
+
+	response, err := v.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Introspection endpoint returned status %d", response.StatusCode)
+	}
+
+	var claims jws.Claims
+	if err := json.NewDecoder(response.Body).Decode(&claims); err != nil {
+		return nil, err
+	}
+
+	if claims == nil {
+		claims = jws.Claims{}
+	}
+
+	return claims, nil
+}
+
+func (v *IntrospectionValidator) Validate(ctx context.Context, token *Token) (bool, error) {
+	if token.Type() != Bearer {
+		return false, nil
+	}
+
+	var (
+		hash       = sha256.Sum256(token.Bytes())
+		now        = v.now()
+		entry, hit = v.cached(hash, now)
+	)
+
+	if !hit {
+		claims, err := v.introspect(ctx, token)
+		if err != nil {
+			v.reason("introspection_error")
+			return false, err
+		}
+
+		active, _ := claims.Get("active").(bool)
+		entry = introspectionEntry{
+			claims:  claims,
+			active:  active,
+			expires: now.Add(v.cacheTTL),
+		}
+
+		if exp, ok := expiration(claims); ok && active && exp.Before(entry.expires) {
+			entry.expires = exp
+		}
+
+		v.store(hash, entry, now)
+	}
+
+	if !entry.active {
+		v.reason("inactive_token")
+		return false, ErrorInactiveToken
+	}
+
+	if exp, ok := expiration(entry.claims); ok && !now.Before(exp) {
+		v.reason("expired_token")
+		return false, jwt.ErrTokenIsExpired
+	}
+
+	SetClaims(ctx, entry.claims)
+	v.reason("ok")
+	return true, nil
+}
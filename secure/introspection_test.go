@@ -0,0 +1,299 @@
+package secure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/types"
+	"github.com/Comcast/webpa-common/xmetrics/xmetricstest"
+	"github.com/SermoDigital/jose/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// introspectionServer is an httptest stand-in for an OAuth2 introspection endpoint
+type introspectionServer struct {
+	*httptest.Server
+	requests  int32
+	responses map[string]map[string]interface{}
+}
+
+func newIntrospectionServer(t *testing.T, responses map[string]map[string]interface{}) *introspectionServer {
+	s := &introspectionServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, "application/x-www-form-urlencoded", request.Header.Get("Content-Type"))
+
+		clientID, clientSecret, ok := request.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "secret" {
+			response.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		assert.Equal(t, "access_token", request.FormValue("token_type_hint"))
+		body, ok := s.responses[request.FormValue("token")]
+		if !ok {
+			body = map[string]interface{}{"active": false}
+		}
+
+		response.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(response).Encode(body)
+	}))
+
+	return s
+}
+
+func (s *introspectionServer) requestCount() int {
+	return int(atomic.LoadInt32(&s.requests))
+}
+
+func newTestIntrospectionValidator(t *testing.T, endpoint string, cacheTTL time.Duration) *IntrospectionValidator {
+	factory := IntrospectionValidatorFactory{
+		Endpoint:      endpoint,
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		TokenTypeHint: "access_token",
+		CacheTTL:      types.Duration(cacheTTL),
+	}
+
+	validator, err := factory.New()
+	require.NoError(t, err)
+	require.NotNil(t, validator)
+	return validator
+}
+
+func TestIntrospectionValidatorFactory(t *testing.T) {
+	assert := assert.New(t)
+
+	validator, err := (&IntrospectionValidatorFactory{}).New()
+	assert.Nil(validator)
+	assert.Equal(ErrorNoIntrospectionEndpoint, err)
+
+	validator, err = (&IntrospectionValidatorFactory{Endpoint: "http://bad host/"}).New()
+	assert.Nil(validator)
+	assert.Error(err)
+
+	validator, err = (&IntrospectionValidatorFactory{Endpoint: "http://localhost/introspect"}).New()
+	assert.NoError(err)
+	assert.Equal(DefaultIntrospectionTimeout, validator.client.Timeout)
+	assert.Equal(DefaultIntrospectionCacheSize, validator.cacheSize)
+
+	validator, err = (&IntrospectionValidatorFactory{Endpoint: "http://localhost/introspect", CacheSize: 5}).New()
+	assert.NoError(err)
+	assert.Equal(5, validator.cacheSize)
+
+	client := new(http.Client)
+	validator, err = (&IntrospectionValidatorFactory{Endpoint: "http://localhost/introspect", HTTPClient: client}).New()
+	assert.NoError(err)
+	assert.Equal(client, validator.client)
+}
+
+func testIntrospectionValidatorActive(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		server = newIntrospectionServer(t, map[string]map[string]interface{}{
+			"active": {
+				"active":    true,
+				"client_id": "client",
+				"sub":       "subject",
+				"exp":       time.Now().Add(time.Hour).Unix(),
+			},
+		})
+	)
+
+	defer server.Close()
+	validator := newTestIntrospectionValidator(t, server.URL, time.Minute)
+
+	for repeat := 0; repeat < 3; repeat++ {
+		ctx := WithClaimsHolder(context.Background())
+		valid, err := validator.Validate(ctx, &Token{tokenType: Bearer, value: "active"})
+		assert.True(valid)
+		assert.NoError(err)
+
+		claims, ok := ClaimsFromContext(ctx)
+		require.True(ok)
+		assert.Equal("subject", claims.Get("sub"))
+	}
+
+	// subsequent validations are served from the cache
+	assert.Equal(1, server.requestCount())
+}
+
+func testIntrospectionValidatorInactive(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		server = newIntrospectionServer(t, nil)
+	)
+
+	defer server.Close()
+	validator := newTestIntrospectionValidator(t, server.URL, time.Minute)
+
+	valid, err := validator.Validate(context.Background(), &Token{tokenType: Bearer, value: "inactive"})
+	assert.False(valid)
+	assert.Equal(ErrorInactiveToken, err)
+
+	valid, err = validator.Validate(context.Background(), &Token{tokenType: Bearer, value: "inactive"})
+	assert.False(valid)
+	assert.Equal(ErrorInactiveToken, err)
+	assert.Equal(1, server.requestCount())
+}
+
+func testIntrospectionValidatorExpiration(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		now    = time.Now()
+		server = newIntrospectionServer(t, map[string]map[string]interface{}{
+			"active": {
+				"active": true,
+				"exp":    now.Add(30 * time.Second).Unix(),
+			},
+		})
+	)
+
+	defer server.Close()
+	validator := newTestIntrospectionValidator(t, server.URL, time.Hour)
+	validator.now = func() time.Time { return now }
+
+	valid, err := validator.Validate(context.Background(), &Token{tokenType: Bearer, value: "active"})
+	assert.True(valid)
+	assert.NoError(err)
+
+	valid, err = validator.Validate(context.Background(), &Token{tokenType: Bearer, value: "active"})
+	assert.True(valid)
+	assert.NoError(err)
+	assert.Equal(1, server.requestCount())
+
+	// the cached response is not honored past the token's exp, even though the cache TTL is longer
+	now = now.Add(time.Minute)
+	valid, err = validator.Validate(context.Background(), &Token{tokenType: Bearer, value: "active"})
+	assert.False(valid)
+	assert.Equal(jwt.ErrTokenIsExpired, err)
+	assert.Equal(2, server.requestCount())
+}
+
+func testIntrospectionValidatorNoCache(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		server = newIntrospectionServer(t, map[string]map[string]interface{}{
+			"active": {"active": true},
+		})
+	)
+
+	defer server.Close()
+	validator := newTestIntrospectionValidator(t, server.URL, 0)
+
+	for repeat := 0; repeat < 2; repeat++ {
+		valid, err := validator.Validate(context.Background(), &Token{tokenType: Bearer, value: "active"})
+		assert.True(valid)
+		assert.NoError(err)
+	}
+
+	assert.Equal(2, server.requestCount())
+}
+
+func testIntrospectionValidatorCacheSize(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		now    = time.Now()
+		server = newIntrospectionServer(t, map[string]map[string]interface{}{
+			"first":  {"active": true},
+			"second": {"active": true},
+			"third":  {"active": true},
+		})
+	)
+
+	defer server.Close()
+	validator := newTestIntrospectionValidator(t, server.URL, time.Minute)
+	validator.cacheSize = 2
+	validator.now = func() time.Time { return now }
+
+	validate := func(value string) {
+		valid, err := validator.Validate(context.Background(), &Token{tokenType: Bearer, value: value})
+		assert.True(valid)
+		assert.NoError(err)
+	}
+
+	for repeat := 0; repeat < 2; repeat++ {
+		validate("first")
+		validate("second")
+		validate("third")
+	}
+
+	// the cache is full after the first two tokens, so the third is introspected every time
+	assert.Len(validator.cache, 2)
+	assert.Equal(4, server.requestCount())
+
+	// once the cached responses expire, they are discarded to make room
+	now = now.Add(2 * time.Minute)
+	validate("third")
+	validate("third")
+	assert.Len(validator.cache, 1)
+	assert.Equal(5, server.requestCount())
+}
+
+func testIntrospectionValidatorNotBearer(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		server = newIntrospectionServer(t, nil)
+	)
+
+	defer server.Close()
+	validator := newTestIntrospectionValidator(t, server.URL, time.Minute)
+
+	valid, err := validator.Validate(context.Background(), &Token{tokenType: Basic, value: "dXNlcjpwYXNz"})
+	assert.False(valid)
+	assert.NoError(err)
+	assert.Zero(server.requestCount())
+}
+
+func testIntrospectionValidatorError(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		server   = newIntrospectionServer(t, nil)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+		measures = &JWTValidationMeasures{
+			ValidationReason: provider.NewCounter(JWTValidationReasonCounter),
+		}
+	)
+
+	defer server.Close()
+
+	factory := IntrospectionValidatorFactory{
+		Endpoint: server.URL,
+		ClientID: "wrong",
+		CacheTTL: types.Duration(time.Minute),
+	}
+
+	validator, err := factory.New()
+	require.NoError(t, err)
+	validator.DefineMeasures(measures)
+
+	valid, err := validator.Validate(context.Background(), &Token{tokenType: Bearer, value: "active"})
+	assert.False(valid)
+	assert.Error(err)
+	provider.Assert(t, JWTValidationReasonCounter, "reason", "introspection_error")(xmetricstest.Value(1.0))
+
+	// failures are never cached
+	valid, err = validator.Validate(context.Background(), &Token{tokenType: Bearer, value: "active"})
+	assert.False(valid)
+	assert.Error(err)
+	assert.Equal(2, server.requestCount())
+}
+
+func TestIntrospectionValidator(t *testing.T) {
+	t.Run("Active", testIntrospectionValidatorActive)
+	t.Run("Inactive", testIntrospectionValidatorInactive)
+	t.Run("Expiration", testIntrospectionValidatorExpiration)
+	t.Run("NoCache", testIntrospectionValidatorNoCache)
+	t.Run("CacheSize", testIntrospectionValidatorCacheSize)
+	t.Run("NotBearer", testIntrospectionValidatorNotBearer)
+	t.Run("Error", testIntrospectionValidatorError)
+}
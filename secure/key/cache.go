@@ -2,6 +2,7 @@ package key
 
 import (
 	"github.com/Comcast/webpa-common/concurrent"
+	"github.com/go-kit/kit/metrics"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,6 +11,10 @@ import (
 const (
 	// dummyKeyId is used when no actual keyID is necessary.
 	dummyKeyId = ""
+
+	// maxNegatives bounds the number of cached failures.  Key ids come from untrusted tokens,
+	// so without a bound the negative cache could grow without limit.
+	maxNegatives = 1024
 )
 
 // Cache is a Resolver type which provides caching for keys based on keyID.
@@ -36,11 +41,49 @@ type Cache interface {
 	UpdateKeys() (int, []error)
 }
 
+// flight is an in-progress attempt to resolve a key id.  Concurrent misses for the same key id
+// wait on the same flight rather than each hitting the delegate.
+type flight struct {
+	done chan struct{}
+	pair Pair
+	err  error
+}
+
+// negativeEntry is a cached failure to resolve a key id
+type negativeEntry struct {
+	err     error
+	expires time.Time
+}
+
 // basicCache contains the internal members common to all cache implementations
 type basicCache struct {
 	delegate   Resolver
 	value      atomic.Value
 	updateLock sync.Mutex
+
+	// negativeTTL is how long failures to resolve a key id are cached.  If nonpositive,
+	// failures are not cached.
+	negativeTTL time.Duration
+
+	// maxStaleness is how long a key continues to be served after it was last successfully
+	// resolved, when refreshes fail.  If nonpositive, keys are served indefinitely.
+	maxStaleness time.Duration
+
+	now      func() time.Time
+	measures *Measures
+
+	// refreshed holds the time each key was last successfully resolved.  Guarded by updateLock.
+	refreshed map[string]time.Time
+
+	flightLock sync.Mutex
+	flights    map[string]*flight
+
+	negativeLock sync.Mutex
+	negatives    map[string]negativeEntry
+
+	// evicted is nonzero when a singleCache's key has been evicted for staleness.  The key itself
+	// cannot be cleared, as atomic.Value permits neither nil nor values of a different type.
+	evicted int32
 }
 
 func (b *basicCache) load() interface{} {
@@ -58,39 +101,228 @@ func (b *basicCache) update(operation func()) {
 	operation()
 }
 
+func (b *basicCache) currentTime() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+
+	return time.Now()
+}
+
+func (b *basicCache) count(counter func(*Measures) metrics.Counter, delta int) {
+	if b.measures != nil && delta > 0 {
+		if c := counter(b.measures); c != nil {
+			c.Add(float64(delta))
+		}
+	}
+}
+
+func hitCounter(m *Measures) metrics.Counter           { return m.Hit }
+func missCounter(m *Measures) metrics.Counter          { return m.Miss }
+func negativeHitCounter(m *Measures) metrics.Counter   { return m.NegativeHit }
+func refreshCounter(m *Measures) metrics.Counter       { return m.Refresh }
+func failureCounter(m *Measures) metrics.Counter       { return m.Failure }
+func staleEvictionCounter(m *Measures) metrics.Counter { return m.StaleEviction }
+
+// setRefreshed records a successful resolution of a key.  Must be called under updateLock.
+func (b *basicCache) setRefreshed(keyID string, when time.Time) {
+	if b.refreshed == nil {
+		b.refreshed = make(map[string]time.Time)
+	}
+
+	b.refreshed[keyID] = when
+}
+
+// stale tests if a key that could not be refreshed should be evicted.  Must be called under updateLock.
+func (b *basicCache) stale(keyID string, now time.Time) bool {
+	if b.maxStaleness < 1 {
+		return false
+	}
+
+	refreshed, ok := b.refreshed[keyID]
+	return ok && now.Sub(refreshed) > b.maxStaleness
+}
+
+// negative returns the cached failure for a key id, or nil if no unexpired failure exists
+func (b *basicCache) negative(keyID string) error {
+	if b.negativeTTL < 1 {
+		return nil
+	}
+
+	b.negativeLock.Lock()
+	defer b.negativeLock.Unlock()
+
+	entry, ok := b.negatives[keyID]
+	if !ok {
+		return nil
+	}
+
+	if !b.currentTime().Before(entry.expires) {
+		delete(b.negatives, keyID)
+		return nil
+	}
+
+	return entry.err
+}
+
+func (b *basicCache) addNegative(keyID string, err error) {
+	if b.negativeTTL < 1 {
+		return
+	}
+
+	b.negativeLock.Lock()
+	defer b.negativeLock.Unlock()
+
+	now := b.currentTime()
+	if b.negatives == nil {
+		b.negatives = make(map[string]negativeEntry)
+	} else {
+		b.sweepNegatives(now)
+	}
+
+	if _, ok := b.negatives[keyID]; !ok && len(b.negatives) >= maxNegatives {
+		b.evictOldestNegative()
+	}
+
+	b.negatives[keyID] = negativeEntry{err: err, expires: now.Add(b.negativeTTL)}
+}
+
+// sweepNegatives removes expired failures.  Must be called under negativeLock.
+func (b *basicCache) sweepNegatives(now time.Time) {
+	for keyID, entry := range b.negatives {
+		if !now.Before(entry.expires) {
+			delete(b.negatives, keyID)
+		}
+	}
+}
+
+// evictOldestNegative removes the failure that will expire soonest.  Since every failure is cached
+// for the same TTL, this is also the oldest failure.  Must be called under negativeLock.
+func (b *basicCache) evictOldestNegative() {
+	var (
+		oldestKeyID string
+		oldest      time.Time
+		found       bool
+	)
+
+	for keyID, entry := range b.negatives {
+		if !found || entry.expires.Before(oldest) {
+			oldestKeyID, oldest, found = keyID, entry.expires, true
+		}
+	}
+
+	if found {
+		delete(b.negatives, oldestKeyID)
+	}
+}
+
+// resolve handles a cache miss for keyID, which is cached under cacheKey.  Cached failures are returned
+// without consulting the delegate, and concurrent misses for the same cacheKey share a single call to
+// the delegate.  The lookup closure is consulted before the delegate in case another goroutine has
+// already stored the key, and the store closure is invoked under the update lock with any newly resolved key.
+func (b *basicCache) resolve(cacheKey, keyID string, lookup func() (Pair, bool), store func(Pair)) (Pair, error) {
+	if err := b.negative(cacheKey); err != nil {
+		b.count(negativeHitCounter, 1)
+		return nil, err
+	}
+
+	b.flightLock.Lock()
+	if existing, ok := b.flights[cacheKey]; ok {
+		b.flightLock.Unlock()
+		<-existing.done
+		return existing.pair, existing.err
+	}
+
+	if b.flights == nil {
+		b.flights = make(map[string]*flight)
+	}
+
+	f := &flight{done: make(chan struct{})}
+	b.flights[cacheKey] = f
+	b.flightLock.Unlock()
+
+	defer func() {
+		b.flightLock.Lock()
+		delete(b.flights, cacheKey)
+		b.flightLock.Unlock()
+		close(f.done)
+	}()
+
+	if pair, ok := lookup(); ok {
+		f.pair = pair
+		return f.pair, nil
+	}
+
+	b.count(missCounter, 1)
+	f.pair, f.err = b.delegate.ResolveKey(keyID)
+	if f.err != nil {
+		b.count(failureCounter, 1)
+		b.addNegative(cacheKey, f.err)
+		return nil, f.err
+	}
+
+	b.update(func() {
+		store(f.pair)
+		b.setRefreshed(cacheKey, b.currentTime())
+	})
+
+	return f.pair, nil
+}
+
 // singleCache assumes that the delegate Resolver
 // only returns (1) key.
 type singleCache struct {
 	basicCache
 }
 
-func (cache *singleCache) ResolveKey(keyID string) (pair Pair, err error) {
-	var ok bool
-	pair, ok = cache.load().(Pair)
-	if !ok {
-		cache.update(func() {
-			pair, ok = cache.load().(Pair)
-			if !ok {
-				pair, err = cache.delegate.ResolveKey(keyID)
-				if err == nil {
-					cache.store(pair)
-				}
-			}
-		})
+func (cache *singleCache) fetchPair() (pair Pair, ok bool) {
+	if atomic.LoadInt32(&cache.evicted) != 0 {
+		return nil, false
 	}
 
+	pair, ok = cache.load().(Pair)
 	return
 }
 
+// storePair stores a newly resolved key, clearing any prior eviction
+func (cache *singleCache) storePair(pair Pair) {
+	cache.store(pair)
+	atomic.StoreInt32(&cache.evicted, 0)
+}
+
+func (cache *singleCache) ResolveKey(keyID string) (Pair, error) {
+	if pair, ok := cache.fetchPair(); ok {
+		cache.count(hitCounter, 1)
+		return pair, nil
+	}
+
+	// every key id maps to the same key, so all misses share the same flight and negative entry
+	return cache.resolve(
+		dummyKeyId,
+		keyID,
+		cache.fetchPair,
+		cache.storePair,
+	)
+}
+
 func (cache *singleCache) UpdateKeys() (count int, errors []error) {
 	count = 1
 	cache.update(func() {
 		// this type of cache is specifically for resolvers which don't use the keyID,
 		// so just pass an empty string in
+		now := cache.currentTime()
 		if pair, err := cache.delegate.ResolveKey(dummyKeyId); err == nil {
-			cache.store(pair)
+			cache.storePair(pair)
+			cache.setRefreshed(dummyKeyId, now)
+			cache.count(refreshCounter, 1)
 		} else {
 			errors = []error{err}
+			cache.count(failureCounter, 1)
+			if _, ok := cache.fetchPair(); ok && cache.stale(dummyKeyId, now) {
+				atomic.StoreInt32(&cache.evicted, 1)
+				delete(cache.refreshed, dummyKeyId)
+				cache.count(staleEvictionCounter, 1)
+			}
 		}
 	})
 
@@ -133,46 +365,60 @@ func (cache *multiCache) copyPairs() map[string]Pair {
 	return newPairs
 }
 
-func (cache *multiCache) ResolveKey(keyID string) (pair Pair, err error) {
-	var ok bool
-	pair, ok = cache.fetchPair(keyID)
-	if !ok {
-		cache.update(func() {
-			pair, ok = cache.fetchPair(keyID)
-			if !ok {
-				pair, err = cache.delegate.ResolveKey(keyID)
-				if err == nil {
-					newPairs := cache.copyPairs()
-					newPairs[keyID] = pair
-					cache.store(newPairs)
-				}
-			}
-		})
+func (cache *multiCache) ResolveKey(keyID string) (Pair, error) {
+	if pair, ok := cache.fetchPair(keyID); ok {
+		cache.count(hitCounter, 1)
+		return pair, nil
 	}
 
-	return
+	return cache.resolve(
+		keyID,
+		keyID,
+		func() (Pair, bool) { return cache.fetchPair(keyID) },
+		func(pair Pair) {
+			newPairs := cache.copyPairs()
+			newPairs[keyID] = pair
+			cache.store(newPairs)
+		},
+	)
 }
 
 func (cache *multiCache) UpdateKeys() (count int, errors []error) {
 	if existingPairs, ok := cache.load().(map[string]Pair); ok {
 		count = len(existingPairs)
 		cache.update(func() {
-			newCount := 0
+			// reload the map under the lock, as keys may have been added since
+			existingPairs, _ = cache.load().(map[string]Pair)
+			count = len(existingPairs)
+
+			newCount, evictedCount := 0, 0
 			newPairs := make(map[string]Pair, len(existingPairs))
 			for keyID, oldPair := range existingPairs {
+				now := cache.currentTime()
 				if newPair, err := cache.delegate.ResolveKey(keyID); err == nil {
 					newCount++
 					newPairs[keyID] = newPair
+					cache.setRefreshed(keyID, now)
 				} else {
-					// keep the old key in the event of an error
-					newPairs[keyID] = oldPair
 					errors = append(errors, err)
+					if cache.stale(keyID, now) {
+						// serving this key any longer is worse than failing to resolve it
+						evictedCount++
+						delete(cache.refreshed, keyID)
+					} else {
+						// keep the old key in the event of an error
+						newPairs[keyID] = oldPair
+					}
 				}
 			}
 
+			cache.count(refreshCounter, newCount)
+			cache.count(failureCounter, len(errors))
+			cache.count(staleEvictionCounter, evictedCount)
+
 			// small optimization: don't bother doing the atomic swap
-			// if every key operation failed
-			if newCount > 0 {
+			// if every key operation failed and nothing was evicted
+			if newCount > 0 || evictedCount > 0 {
 				cache.store(newPairs)
 			}
 		})
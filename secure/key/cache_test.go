@@ -3,8 +3,10 @@ package key
 import (
 	"errors"
 	"fmt"
+	"github.com/Comcast/webpa-common/xmetrics/xmetricstest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"sync"
 	"testing"
 	"time"
@@ -80,7 +82,7 @@ func TestSingleCacheResolveKeyError(t *testing.T) {
 	const keyID = "TestSingleCacheResolveKeyError"
 	expectedError := errors.New("TestSingleCacheResolveKeyError")
 	resolver := &MockResolver{}
-	resolver.On("ResolveKey", keyID).Return(nil, expectedError).Twice()
+	resolver.On("ResolveKey", keyID).Return(nil, expectedError)
 
 	cache := singleCache{
 		basicCache{
@@ -243,7 +245,7 @@ func TestMultiCacheResolveKeyError(t *testing.T) {
 	expectedKeyIDs, _ := makeExpectedPairs(2)
 	resolver := &MockResolver{}
 	for _, keyID := range expectedKeyIDs {
-		resolver.On("ResolveKey", keyID).Return(nil, expectedError).Twice()
+		resolver.On("ResolveKey", keyID).Return(nil, expectedError)
 	}
 
 	cache := multiCache{
@@ -393,6 +395,214 @@ func TestMultiCacheUpdateKeysSequence(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, resolver.Mock, oldPair.Mock, newPair.Mock)
 }
 
+// testClock is a settable time source for caches
+type testClock struct {
+	lock    sync.Mutex
+	current time.Time
+}
+
+func (c *testClock) now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.current
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.lock.Lock()
+	c.current = c.current.Add(d)
+	c.lock.Unlock()
+}
+
+func testBasicCacheNegativeTTL(t *testing.T, newCache func(basicCache) Resolver) {
+	var (
+		assert        = assert.New(t)
+		provider      = xmetricstest.NewProvider(nil, Metrics)
+		clock         = &testClock{current: time.Now()}
+		expectedError = errors.New("expected")
+		expectedPair  = &MockPair{}
+		resolver      = &MockResolver{}
+	)
+
+	resolver.On("ResolveKey", "test").Return(nil, expectedError).Once()
+	resolver.On("ResolveKey", "test").Return(expectedPair, nil).Once()
+
+	cache := newCache(basicCache{
+		delegate:    resolver,
+		negativeTTL: time.Minute,
+		now:         clock.now,
+		measures:    NewMeasures(provider),
+	})
+
+	pair, err := cache.ResolveKey("test")
+	assert.Nil(pair)
+	assert.Equal(expectedError, err)
+
+	// the failure is cached, so the delegate is not consulted
+	clock.advance(30 * time.Second)
+	pair, err = cache.ResolveKey("test")
+	assert.Nil(pair)
+	assert.Equal(expectedError, err)
+
+	// once the failure expires, the delegate is consulted again
+	clock.advance(30 * time.Second)
+	pair, err = cache.ResolveKey("test")
+	assert.Equal(expectedPair, pair)
+	assert.NoError(err)
+
+	pair, err = cache.ResolveKey("test")
+	assert.Equal(expectedPair, pair)
+	assert.NoError(err)
+
+	mock.AssertExpectationsForObjects(t, resolver.Mock)
+	provider.Assert(t, CacheMissCounter)(xmetricstest.Value(2.0))
+	provider.Assert(t, CacheFailureCounter)(xmetricstest.Value(1.0))
+	provider.Assert(t, CacheNegativeHitCounter)(xmetricstest.Value(1.0))
+	provider.Assert(t, CacheHitCounter)(xmetricstest.Value(1.0))
+}
+
+func TestBasicCacheNegativeBound(t *testing.T) {
+	var (
+		assert        = assert.New(t)
+		clock         = &testClock{current: time.Now()}
+		expectedError = errors.New("expected")
+		cache         = basicCache{negativeTTL: time.Minute, now: clock.now}
+	)
+
+	for i := 0; i < maxNegatives; i++ {
+		cache.addNegative(fmt.Sprint(i), expectedError)
+		clock.advance(time.Millisecond)
+	}
+
+	assert.Len(cache.negatives, maxNegatives)
+
+	// at capacity, the oldest failure is evicted
+	cache.addNegative("new", expectedError)
+	assert.Len(cache.negatives, maxNegatives)
+	assert.Nil(cache.negative("0"))
+	assert.Equal(expectedError, cache.negative("1"))
+	assert.Equal(expectedError, cache.negative("new"))
+
+	// expired failures are swept on insert
+	clock.advance(time.Minute)
+	cache.addNegative("another", expectedError)
+	assert.Len(cache.negatives, 1)
+	assert.Equal(expectedError, cache.negative("another"))
+}
+
+func testBasicCacheMaxStaleness(t *testing.T, newCache func(basicCache) Resolver, refreshKeyID string) {
+	var (
+		assert        = assert.New(t)
+		require       = require.New(t)
+		provider      = xmetricstest.NewProvider(nil, Metrics)
+		clock         = &testClock{current: time.Now()}
+		expectedError = errors.New("expected")
+		expectedPair  = &MockPair{}
+		resolver      = &MockResolver{}
+	)
+
+	resolver.On("ResolveKey", "test").Return(expectedPair, nil).Once()
+	resolver.On("ResolveKey", refreshKeyID).Return(nil, expectedError).Twice()
+
+	cache := newCache(basicCache{
+		delegate:     resolver,
+		maxStaleness: time.Minute,
+		now:          clock.now,
+		measures:     NewMeasures(provider),
+	})
+
+	pair, err := cache.ResolveKey("test")
+	require.NoError(err)
+	assert.Equal(expectedPair, pair)
+
+	// within the maximum staleness, the old key is still served
+	clock.advance(30 * time.Second)
+	count, errors := cache.(Cache).UpdateKeys()
+	assert.Equal(1, count)
+	assert.Equal([]error{expectedError}, errors)
+
+	pair, err = cache.ResolveKey("test")
+	require.NoError(err)
+	assert.Equal(expectedPair, pair)
+
+	// past the maximum staleness, the key is evicted
+	clock.advance(31 * time.Second)
+	count, errors = cache.(Cache).UpdateKeys()
+	assert.Equal(1, count)
+	assert.Equal([]error{expectedError}, errors)
+
+	resolver.On("ResolveKey", "test").Return(nil, expectedError).Once()
+	pair, err = cache.ResolveKey("test")
+	assert.Nil(pair)
+	assert.Equal(expectedError, err)
+
+	mock.AssertExpectationsForObjects(t, resolver.Mock)
+	provider.Assert(t, CacheFailureCounter)(xmetricstest.Value(3.0))
+	provider.Assert(t, CacheStaleEvictionCounter)(xmetricstest.Value(1.0))
+	provider.Assert(t, CacheRefreshCounter)(xmetricstest.Value(0.0))
+}
+
+func testBasicCacheSingleflight(t *testing.T, newCache func(basicCache) Resolver) {
+	var (
+		assert       = assert.New(t)
+		provider     = xmetricstest.NewProvider(nil, Metrics)
+		expectedPair = &MockPair{}
+		resolver     = &MockResolver{}
+		called       = make(chan struct{})
+		release      = make(chan struct{})
+	)
+
+	resolver.On("ResolveKey", "test").Return(expectedPair, nil).Once().Run(func(mock.Arguments) {
+		close(called)
+		<-release
+	})
+
+	cache := newCache(basicCache{
+		delegate: resolver,
+		measures: NewMeasures(provider),
+	})
+
+	const routines = 5
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(routines)
+	for repeat := 0; repeat < routines; repeat++ {
+		go func() {
+			defer waitGroup.Done()
+			pair, err := cache.ResolveKey("test")
+			assert.Equal(expectedPair, pair)
+			assert.NoError(err)
+		}()
+	}
+
+	// hold the delegate until the other goroutines have had a chance to pile up
+	<-called
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	waitGroup.Wait()
+
+	mock.AssertExpectationsForObjects(t, resolver.Mock)
+	provider.Assert(t, CacheMissCounter)(xmetricstest.Value(1.0))
+}
+
+func TestSingleCache(t *testing.T) {
+	newCache := func(b basicCache) Resolver {
+		return &singleCache{b}
+	}
+
+	t.Run("NegativeTTL", func(t *testing.T) { testBasicCacheNegativeTTL(t, newCache) })
+	t.Run("MaxStaleness", func(t *testing.T) { testBasicCacheMaxStaleness(t, newCache, dummyKeyId) })
+	t.Run("Singleflight", func(t *testing.T) { testBasicCacheSingleflight(t, newCache) })
+}
+
+func TestMultiCache(t *testing.T) {
+	newCache := func(b basicCache) Resolver {
+		return &multiCache{b}
+	}
+
+	t.Run("NegativeTTL", func(t *testing.T) { testBasicCacheNegativeTTL(t, newCache) })
+	t.Run("MaxStaleness", func(t *testing.T) { testBasicCacheMaxStaleness(t, newCache, "test") })
+	t.Run("Singleflight", func(t *testing.T) { testBasicCacheSingleflight(t, newCache) })
+}
+
 func TestNewUpdaterNoRunnable(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,57 @@
+package key
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// oidEd25519 is the algorithm identifier for Ed25519 keys, as defined by RFC 8410
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+// ed25519PublicKeyInfo is the PKIX SubjectPublicKeyInfo structure for an Ed25519 public key
+type ed25519PublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// ed25519PrivateKeyInfo is the PKCS8 structure for an Ed25519 private key.  Optional attributes are ignored.
+type ed25519PrivateKeyInfo struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// parseEd25519PublicKey parses a DER-encoded PKIX Ed25519 public key.  Parsing is done here rather than
+// by crypto/x509, which only supports Ed25519 as of Go 1.13.  If the DER is not an Ed25519 key, the
+// returned flag is false.
+func parseEd25519PublicKey(der []byte) (ed25519.PublicKey, bool) {
+	var info ed25519PublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) > 0 || !info.Algorithm.Algorithm.Equal(oidEd25519) {
+		return nil, false
+	}
+
+	if len(info.PublicKey.Bytes) != ed25519.PublicKeySize {
+		return nil, false
+	}
+
+	return ed25519.PublicKey(info.PublicKey.RightAlign()), true
+}
+
+// parseEd25519PrivateKey parses a DER-encoded PKCS8 Ed25519 private key.  If the DER is not an Ed25519
+// key, the returned flag is false.
+func parseEd25519PrivateKey(der []byte) (ed25519.PrivateKey, bool) {
+	var info ed25519PrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) > 0 || !info.Algorithm.Algorithm.Equal(oidEd25519) {
+		return nil, false
+	}
+
+	// the PKCS8 private key is itself a DER-encoded octet string holding the seed
+	var seed []byte
+	if rest, err := asn1.Unmarshal(info.PrivateKey, &seed); err != nil || len(rest) > 0 || len(seed) != ed25519.SeedSize {
+		return nil, false
+	}
+
+	return ed25519.NewKeyFromSeed(seed), true
+}
@@ -0,0 +1,326 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// JWKTypeRSA is the JWK kty value for RSA keys
+	JWKTypeRSA = "RSA"
+
+	// JWKTypeEC is the JWK kty value for elliptic curve keys
+	JWKTypeEC = "EC"
+
+	// JWKTypeOKP is the JWK kty value for octet key pairs, such as Ed25519 keys
+	JWKTypeOKP = "OKP"
+)
+
+var (
+	ErrorInvalidJWK         = errors.New("Invalid JSON Web Key")
+	ErrorUnsupportedJWKType = errors.New("Only RSA, EC, and OKP (Ed25519) JSON Web Keys are supported")
+	ErrorPrivateKeyRequired = errors.New("A private key is required for this key purpose")
+	ErrorKeyNotFound        = errors.New("No key exists with the given key id")
+)
+
+// JWK is a JSON Web Key, as defined by RFC 7517.  Only the members relevant to RSA, EC, and OKP
+// (RFC 8037) keys are supported.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// Curve is the curve for EC and OKP keys
+	Curve string `json:"crv,omitempty"`
+
+	// N and E are the RSA modulus and public exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// X and Y are the EC point coordinates.  For OKP keys, X is the public key.
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+
+	// D is the private exponent for RSA, the private scalar for EC, or the private seed for OKP keys
+	D string `json:"d,omitempty"`
+
+	// P and Q are the RSA prime factors, required for RSA private keys
+	P string `json:"p,omitempty"`
+	Q string `json:"q,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set, as served by an endpoint such as /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Find returns the JWK with the given key id.  If keyID is empty and this set contains
+// exactly one key, that key is returned.
+func (s *JWKS) Find(keyID string) (*JWK, error) {
+	if len(keyID) == 0 && len(s.Keys) == 1 {
+		return &s.Keys[0], nil
+	}
+
+	for i := range s.Keys {
+		if s.Keys[i].KeyID == keyID {
+			return &s.Keys[i], nil
+		}
+	}
+
+	return nil, ErrorKeyNotFound
+}
+
+func decodeBase64URL(name, value string) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, fmt.Errorf("%s: missing %s", ErrorInvalidJWK, name)
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid %s: %s", ErrorInvalidJWK, name, err)
+	}
+
+	return decoded, nil
+}
+
+func decodeBigInt(name, value string) (*big.Int, error) {
+	decoded, err := decodeBase64URL(name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+func (k *JWK) rsaKeys(includePrivate bool) (crypto.PublicKey, crypto.PrivateKey, error) {
+	n, err := decodeBigInt("n", k.N)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e, err := decodeBigInt("e", k.E)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !e.IsInt64() || e.Int64() > int64(^uint32(0)>>1) {
+		return nil, nil, fmt.Errorf("%s: RSA exponent too large", ErrorInvalidJWK)
+	}
+
+	publicKey := &rsa.PublicKey{N: n, E: int(e.Int64())}
+	if !includePrivate {
+		return publicKey, nil, nil
+	}
+
+	d, err := decodeBigInt("d", k.D)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := decodeBigInt("p", k.P)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	q, err := decodeBigInt("q", k.Q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKey := &rsa.PrivateKey{
+		PublicKey: *publicKey,
+		D:         d,
+		Primes:    []*big.Int{p, q},
+	}
+
+	if err := privateKey.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", ErrorInvalidJWK, err)
+	}
+
+	privateKey.Precompute()
+	return &privateKey.PublicKey, privateKey, nil
+}
+
+func (k *JWK) ecKeys(includePrivate bool) (crypto.PublicKey, crypto.PrivateKey, error) {
+	var curve elliptic.Curve
+	switch k.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, nil, ErrorUnsupportedCurve
+	}
+
+	x, err := decodeBigInt("x", k.X)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	y, err := decodeBigInt("y", k.Y)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil, fmt.Errorf("%s: point is not on curve %s", ErrorInvalidJWK, k.Curve)
+	}
+
+	publicKey := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if !includePrivate {
+		return publicKey, nil, nil
+	}
+
+	d, err := decodeBigInt("d", k.D)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKey := &ecdsa.PrivateKey{PublicKey: *publicKey, D: d}
+	return &privateKey.PublicKey, privateKey, nil
+}
+
+func (k *JWK) okpKeys(includePrivate bool) (crypto.PublicKey, crypto.PrivateKey, error) {
+	if k.Curve != "Ed25519" {
+		return nil, nil, ErrorUnsupportedCurve
+	}
+
+	x, err := decodeBase64URL("x", k.X)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(x) != ed25519.PublicKeySize {
+		return nil, nil, fmt.Errorf("%s: invalid Ed25519 public key size", ErrorInvalidJWK)
+	}
+
+	publicKey := ed25519.PublicKey(x)
+	if !includePrivate {
+		return publicKey, nil, nil
+	}
+
+	d, err := decodeBase64URL("d", k.D)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(d) != ed25519.SeedSize {
+		return nil, nil, fmt.Errorf("%s: invalid Ed25519 private key size", ErrorInvalidJWK)
+	}
+
+	return publicKey, ed25519.NewKeyFromSeed(d), nil
+}
+
+// Pair converts this JWK into a Pair with the given purpose.  If the purpose requires a private key,
+// this JWK must contain the private key members.
+func (k *JWK) Pair(purpose Purpose) (Pair, error) {
+	includePrivate := purpose.RequiresPrivateKey()
+	if includePrivate && len(k.D) == 0 {
+		return nil, ErrorPrivateKeyRequired
+	}
+
+	var (
+		publicKey  crypto.PublicKey
+		privateKey crypto.PrivateKey
+		err        error
+	)
+
+	switch k.KeyType {
+	case JWKTypeRSA:
+		publicKey, privateKey, err = k.rsaKeys(includePrivate)
+	case JWKTypeEC:
+		publicKey, privateKey, err = k.ecKeys(includePrivate)
+	case JWKTypeOKP:
+		publicKey, privateKey, err = k.okpKeys(includePrivate)
+	default:
+		err = ErrorUnsupportedJWKType
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPair(purpose, publicKey, privateKey)
+}
+
+// encodeFixed encodes a big integer as base64url, left-padded with zeroes to the given size.
+// RFC 7518 requires EC coordinates to be the full size of the curve.
+func encodeFixed(value *big.Int, size int) string {
+	encoded := make([]byte, size)
+	valueBytes := value.Bytes()
+	copy(encoded[size-len(valueBytes):], valueBytes)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// NewPublicJWK creates the JWK for a public key, suitable for publishing in a JWKS.  RSA, ECDSA,
+// and Ed25519 public keys are supported.
+func NewPublicJWK(keyID string, publicKey crypto.PublicKey) (JWK, error) {
+	switch pk := publicKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: JWKTypeRSA,
+			KeyID:   keyID,
+			Use:     "sig",
+			N:       base64.RawURLEncoding.EncodeToString(pk.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pk.E)).Bytes()),
+		}, nil
+
+	case *ecdsa.PublicKey:
+		if err := checkCurve(pk); err != nil {
+			return JWK{}, err
+		}
+
+		size := (pk.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			KeyType: JWKTypeEC,
+			KeyID:   keyID,
+			Use:     "sig",
+			Curve:   pk.Curve.Params().Name,
+			X:       encodeFixed(pk.X, size),
+			Y:       encodeFixed(pk.Y, size),
+		}, nil
+
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: JWKTypeOKP,
+			KeyID:   keyID,
+			Use:     "sig",
+			Curve:   "Ed25519",
+			X:       base64.RawURLEncoding.EncodeToString(pk),
+		}, nil
+
+	default:
+		return JWK{}, ErrorUnsupportedKeyType
+	}
+}
+
+// jwkParser is the Parser implementation for single JSON Web Keys
+type jwkParser int
+
+func (p jwkParser) String() string {
+	return "jwkParser"
+}
+
+func (p jwkParser) ParseKey(purpose Purpose, data []byte) (Pair, error) {
+	var k JWK
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, err
+	}
+
+	return k.Pair(purpose)
+}
+
+// JWKParser is the global, singleton Parser for resources containing a single JSON Web Key
+var JWKParser Parser = jwkParser(0)
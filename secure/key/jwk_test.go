@@ -0,0 +1,354 @@
+package key
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Comcast/webpa-common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func rsaJWK(t *testing.T, keyID string) (*rsa.PrivateKey, JWK) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	return privateKey, JWK{
+		KeyType: JWKTypeRSA,
+		KeyID:   keyID,
+		N:       encodeBigInt(privateKey.N),
+		E:       encodeBigInt(big.NewInt(int64(privateKey.E))),
+		D:       encodeBigInt(privateKey.D),
+		P:       encodeBigInt(privateKey.Primes[0]),
+		Q:       encodeBigInt(privateKey.Primes[1]),
+	}
+}
+
+func ecJWK(t *testing.T, keyID string, curve elliptic.Curve) (*ecdsa.PrivateKey, JWK) {
+	privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+
+	return privateKey, JWK{
+		KeyType: JWKTypeEC,
+		KeyID:   keyID,
+		Curve:   curve.Params().Name,
+		X:       encodeBigInt(privateKey.X),
+		Y:       encodeBigInt(privateKey.Y),
+		D:       encodeBigInt(privateKey.D),
+	}
+}
+
+func okpJWK(t *testing.T, keyID string) (ed25519.PrivateKey, JWK) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return privateKey, JWK{
+		KeyType: JWKTypeOKP,
+		KeyID:   keyID,
+		Curve:   "Ed25519",
+		X:       base64.RawURLEncoding.EncodeToString(publicKey),
+		D:       base64.RawURLEncoding.EncodeToString(privateKey.Seed()),
+	}
+}
+
+func testJWKPair(t *testing.T, jwk JWK, expectedPublic, expectedPrivate interface{}) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	pair, err := jwk.Pair(PurposeVerify)
+	require.NoError(err)
+	require.NotNil(pair)
+	assert.Equal(PurposeVerify, pair.Purpose())
+	assert.Equal(expectedPublic, pair.Public())
+	assert.False(pair.HasPrivate())
+	assert.Nil(pair.Private())
+
+	pair, err = jwk.Pair(PurposeSign)
+	require.NoError(err)
+	require.NotNil(pair)
+	assert.Equal(PurposeSign, pair.Purpose())
+	assert.True(pair.HasPrivate())
+
+	switch expected := expectedPrivate.(type) {
+	case *rsa.PrivateKey:
+		actual, ok := pair.Private().(*rsa.PrivateKey)
+		require.True(ok)
+		assert.Zero(expected.D.Cmp(actual.D))
+		assert.Zero(expected.N.Cmp(actual.N))
+	default:
+		assert.Equal(expectedPrivate, pair.Private())
+	}
+
+	// a public-only JWK cannot be used for a private purpose
+	jwk.D = ""
+	pair, err = jwk.Pair(PurposeSign)
+	assert.Nil(pair)
+	assert.Equal(ErrorPrivateKeyRequired, err)
+
+	// the JWK parser handles the JSON form of the key
+	data, err := json.Marshal(jwk)
+	require.NoError(err)
+
+	pair, err = JWKParser.ParseKey(PurposeVerify, data)
+	require.NoError(err)
+	require.NotNil(pair)
+	assert.Equal(expectedPublic, pair.Public())
+}
+
+func TestJWKPair(t *testing.T) {
+	t.Run("RSA", func(t *testing.T) {
+		privateKey, jwk := rsaJWK(t, "rsa")
+		testJWKPair(t, jwk, &privateKey.PublicKey, privateKey)
+	})
+
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			privateKey, jwk := ecJWK(t, "ec", curve)
+			testJWKPair(t, jwk, &privateKey.PublicKey, privateKey)
+		})
+	}
+
+	t.Run("Ed25519", func(t *testing.T) {
+		privateKey, jwk := okpJWK(t, "okp")
+		testJWKPair(t, jwk, privateKey.Public(), privateKey)
+	})
+}
+
+func TestJWKPairInvalid(t *testing.T) {
+	var (
+		_, validRSA = rsaJWK(t, "rsa")
+		_, validEC  = ecJWK(t, "ec", elliptic.P256())
+		_, validOKP = okpJWK(t, "okp")
+	)
+
+	testData := []struct {
+		jwk     JWK
+		modify  func(*JWK)
+		purpose Purpose
+	}{
+		{validRSA, func(k *JWK) { k.KeyType = "oct" }, PurposeVerify},
+		{validRSA, func(k *JWK) { k.N = "" }, PurposeVerify},
+		{validRSA, func(k *JWK) { k.E = "!!!" }, PurposeVerify},
+		{validRSA, func(k *JWK) { k.E = encodeBigInt(new(big.Int).Lsh(big.NewInt(1), 64)) }, PurposeVerify},
+		{validRSA, func(k *JWK) { k.P = "" }, PurposeSign},
+		{validRSA, func(k *JWK) { k.Q = encodeBigInt(big.NewInt(7)) }, PurposeSign},
+		{validEC, func(k *JWK) { k.Curve = "P-224" }, PurposeVerify},
+		{validEC, func(k *JWK) { k.Y = encodeBigInt(big.NewInt(1)) }, PurposeVerify},
+		{validEC, func(k *JWK) { k.X = "" }, PurposeVerify},
+		{validOKP, func(k *JWK) { k.Curve = "X25519" }, PurposeVerify},
+		{validOKP, func(k *JWK) { k.X = base64.RawURLEncoding.EncodeToString([]byte("too short")) }, PurposeVerify},
+		{validOKP, func(k *JWK) { k.D = base64.RawURLEncoding.EncodeToString([]byte("too short")) }, PurposeSign},
+	}
+
+	for i, record := range testData {
+		t.Logf("#%d", i)
+		jwk := record.jwk
+		record.modify(&jwk)
+
+		pair, err := jwk.Pair(record.purpose)
+		assert.Nil(t, pair)
+		assert.Error(t, err)
+	}
+}
+
+func TestNewPublicJWK(t *testing.T) {
+	var (
+		rsaKey, _ = rsaJWK(t, "rsa")
+		ecKey, _  = ecJWK(t, "ec", elliptic.P521())
+		okpKey, _ = okpJWK(t, "okp")
+	)
+
+	for _, publicKey := range []interface{}{&rsaKey.PublicKey, &ecKey.PublicKey, okpKey.Public()} {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+		)
+
+		jwk, err := NewPublicJWK("test", publicKey)
+		require.NoError(err)
+		assert.Equal("test", jwk.KeyID)
+		assert.Equal("sig", jwk.Use)
+		assert.Empty(jwk.D)
+
+		pair, err := jwk.Pair(PurposeVerify)
+		require.NoError(err)
+		assert.Equal(publicKey, pair.Public())
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		assert := assert.New(t)
+
+		p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+		require.NoError(t, err)
+
+		_, err = NewPublicJWK("test", &p224.PublicKey)
+		assert.Equal(ErrorUnsupportedCurve, err)
+
+		_, err = NewPublicJWK("test", "not a key")
+		assert.Equal(ErrorUnsupportedKeyType, err)
+	})
+}
+
+func TestEncodeFixed(t *testing.T) {
+	assert := assert.New(t)
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encodeFixed(big.NewInt(0x0102), 4))
+	assert.NoError(err)
+	assert.Equal([]byte{0, 0, 1, 2}, decoded)
+
+	decoded, err = base64.RawURLEncoding.DecodeString(encodeFixed(big.NewInt(0), 2))
+	assert.NoError(err)
+	assert.Equal([]byte{0, 0}, decoded)
+}
+
+func TestJWKParserInvalidJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	pair, err := JWKParser.ParseKey(PurposeVerify, []byte("this is not JSON"))
+	assert.Nil(pair)
+	assert.Error(err)
+}
+
+func TestJWKSFind(t *testing.T) {
+	assert := assert.New(t)
+
+	single := JWKS{Keys: []JWK{{KeyID: "one"}}}
+	k, err := single.Find("")
+	assert.Equal(&single.Keys[0], k)
+	assert.NoError(err)
+
+	k, err = single.Find("nosuch")
+	assert.Nil(k)
+	assert.Equal(ErrorKeyNotFound, err)
+
+	multiple := JWKS{Keys: []JWK{{KeyID: "one"}, {KeyID: "two"}}}
+	k, err = multiple.Find("two")
+	assert.Equal(&multiple.Keys[1], k)
+	assert.NoError(err)
+
+	k, err = multiple.Find("")
+	assert.Nil(k)
+	assert.Equal(ErrorKeyNotFound, err)
+}
+
+func TestJWKSResolverFactory(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		rsaKey, rsaPublic = rsaJWK(t, "rsa")
+		ecKey, ecPublic   = ecJWK(t, "ec", elliptic.P384())
+		okpKey, okpPublic = okpJWK(t, "okp")
+	)
+
+	rsaPublic.D, rsaPublic.P, rsaPublic.Q = "", "", ""
+	ecPublic.D = ""
+	okpPublic.D = ""
+
+	data, err := json.Marshal(JWKS{Keys: []JWK{rsaPublic, ecPublic, okpPublic}})
+	require.NoError(err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/.well-known/jwks.json" {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		response.Header().Set("Content-Type", "application/json")
+		response.Write(data)
+	}))
+
+	defer server.Close()
+
+	factory := ResolverFactory{
+		Factory: resource.Factory{URI: server.URL + "/.well-known/jwks.json"},
+		Format:  FormatJWKS,
+	}
+
+	resolver, err := factory.NewResolver()
+	require.NoError(err)
+	require.NotNil(resolver)
+	_, ok := resolver.(Cache)
+	assert.True(ok)
+
+	pair, err := resolver.ResolveKey("rsa")
+	require.NoError(err)
+	assert.Equal(&rsaKey.PublicKey, pair.Public())
+
+	pair, err = resolver.ResolveKey("ec")
+	require.NoError(err)
+	assert.Equal(&ecKey.PublicKey, pair.Public())
+
+	pair, err = resolver.ResolveKey("okp")
+	require.NoError(err)
+	assert.Equal(okpKey.Public(), pair.Public())
+
+	pair, err = resolver.ResolveKey("nosuch")
+	assert.Nil(pair)
+	assert.Equal(ErrorKeyNotFound, err)
+
+	// a JWKS document holds public keys only
+	factory.Purpose = PurposeSign
+	resolver, err = factory.NewResolver()
+	require.NoError(err)
+
+	pair, err = resolver.ResolveKey("rsa")
+	assert.Nil(pair)
+	assert.Equal(ErrorPrivateKeyRequired, err)
+}
+
+func TestResolverFactoryFormats(t *testing.T) {
+	assert := assert.New(t)
+
+	resolver, err := (&ResolverFactory{
+		Factory: resource.Factory{URI: publicKeyURLTemplate},
+		Format:  FormatJWKS,
+	}).NewResolver()
+
+	assert.Nil(resolver)
+	assert.Equal(ErrorInvalidJWKSTemplate, err)
+
+	resolver, err = (&ResolverFactory{
+		Factory: resource.Factory{URI: publicKeyURL},
+		Format:  "xml",
+	}).NewResolver()
+
+	assert.Nil(resolver)
+	assert.Equal(ErrorUnsupportedFormat, err)
+
+	privateKey, jwk := ecJWK(t, "ec", elliptic.P256())
+	data, err := json.Marshal(jwk)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.Write(data)
+	}))
+
+	defer server.Close()
+
+	resolver, err = (&ResolverFactory{
+		Factory: resource.Factory{URI: server.URL + "/ec.json"},
+		Format:  FormatJWK,
+		Purpose: PurposeSign,
+	}).NewResolver()
+
+	require.NoError(t, err)
+	pair, err := resolver.ResolveKey("")
+	require.NoError(t, err)
+	assert.Equal(privateKey, pair.Private())
+}
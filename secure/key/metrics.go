@@ -0,0 +1,74 @@
+package key
+
+import (
+	"github.com/Comcast/webpa-common/xmetrics"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+)
+
+const (
+	CacheHitCounter           = "key_cache_hit_count"
+	CacheMissCounter          = "key_cache_miss_count"
+	CacheNegativeHitCounter   = "key_cache_negative_hit_count"
+	CacheRefreshCounter       = "key_cache_refresh_count"
+	CacheFailureCounter       = "key_cache_failure_count"
+	CacheStaleEvictionCounter = "key_cache_stale_eviction_count"
+)
+
+// Metrics is the key module function that adds the key cache metrics
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name: CacheHitCounter,
+			Type: "counter",
+			Help: "The number of keys resolved from a cache",
+		},
+		{
+			Name: CacheMissCounter,
+			Type: "counter",
+			Help: "The number of keys that were not cached and had to be loaded",
+		},
+		{
+			Name: CacheNegativeHitCounter,
+			Type: "counter",
+			Help: "The number of key ids rejected due to a cached failure",
+		},
+		{
+			Name: CacheRefreshCounter,
+			Type: "counter",
+			Help: "The number of cached keys successfully refreshed",
+		},
+		{
+			Name: CacheFailureCounter,
+			Type: "counter",
+			Help: "The number of failures to load or refresh keys",
+		},
+		{
+			Name: CacheStaleEvictionCounter,
+			Type: "counter",
+			Help: "The number of cached keys discarded because they could not be refreshed within the maximum staleness",
+		},
+	}
+}
+
+// Measures holds the metric objects used by key caches
+type Measures struct {
+	Hit           metrics.Counter
+	Miss          metrics.Counter
+	NegativeHit   metrics.Counter
+	Refresh       metrics.Counter
+	Failure       metrics.Counter
+	StaleEviction metrics.Counter
+}
+
+// NewMeasures constructs a Measures given a go-kit metrics Provider
+func NewMeasures(p provider.Provider) *Measures {
+	return &Measures{
+		Hit:           p.NewCounter(CacheHitCounter),
+		Miss:          p.NewCounter(CacheMissCounter),
+		NegativeHit:   p.NewCounter(CacheNegativeHitCounter),
+		Refresh:       p.NewCounter(CacheRefreshCounter),
+		Failure:       p.NewCounter(CacheFailureCounter),
+		StaleEviction: p.NewCounter(CacheStaleEvictionCounter),
+	}
+}
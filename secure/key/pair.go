@@ -1,7 +1,11 @@
 package key
 
 import (
+	"crypto"
+	"crypto/ecdsa"
 	"crypto/rsa"
+
+	"golang.org/x/crypto/ed25519"
 )
 
 // Pair represents a resolved key pair.  For all Pair instances, the private key is optional,
@@ -47,3 +51,117 @@ func (rp *rsaPair) Private() interface{} {
 
 	return nil
 }
+
+// ecdsaPair is an ECDSA key Pair implementation
+type ecdsaPair struct {
+	purpose Purpose
+	public  *ecdsa.PublicKey
+	private *ecdsa.PrivateKey
+}
+
+func (ep *ecdsaPair) Purpose() Purpose {
+	return ep.purpose
+}
+
+func (ep *ecdsaPair) Public() interface{} {
+	return ep.public
+}
+
+func (ep *ecdsaPair) HasPrivate() bool {
+	return ep.private != nil
+}
+
+func (ep *ecdsaPair) Private() interface{} {
+	if ep.private != nil {
+		return ep.private
+	}
+
+	return nil
+}
+
+// ed25519Pair is an Ed25519 key Pair implementation
+type ed25519Pair struct {
+	purpose Purpose
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+func (ep *ed25519Pair) Purpose() Purpose {
+	return ep.purpose
+}
+
+func (ep *ed25519Pair) Public() interface{} {
+	return ep.public
+}
+
+func (ep *ed25519Pair) HasPrivate() bool {
+	return ep.private != nil
+}
+
+func (ep *ed25519Pair) Private() interface{} {
+	if ep.private != nil {
+		return ep.private
+	}
+
+	return nil
+}
+
+// NewPair creates a Pair from a public key and an optional private key.  RSA, ECDSA, and Ed25519
+// keys are supported.  If private is not nil, it must correspond to the type of public key.
+func NewPair(purpose Purpose, public crypto.PublicKey, private crypto.PrivateKey) (Pair, error) {
+	switch pk := public.(type) {
+	case *rsa.PublicKey:
+		rp := &rsaPair{purpose: purpose, public: pk}
+		if private != nil {
+			var ok bool
+			if rp.private, ok = private.(*rsa.PrivateKey); !ok {
+				return nil, ErrorKeyTypeMismatch
+			}
+		}
+
+		return rp, nil
+
+	case *ecdsa.PublicKey:
+		if err := checkCurve(pk); err != nil {
+			return nil, err
+		}
+
+		ep := &ecdsaPair{purpose: purpose, public: pk}
+		if private != nil {
+			var ok bool
+			if ep.private, ok = private.(*ecdsa.PrivateKey); !ok {
+				return nil, ErrorKeyTypeMismatch
+			}
+		}
+
+		return ep, nil
+
+	case ed25519.PublicKey:
+		ep := &ed25519Pair{purpose: purpose, public: pk}
+		if private != nil {
+			var ok bool
+			if ep.private, ok = private.(ed25519.PrivateKey); !ok {
+				return nil, ErrorKeyTypeMismatch
+			}
+		}
+
+		return ep, nil
+
+	default:
+		return nil, ErrorUnsupportedKeyType
+	}
+}
+
+// newPrivatePair creates a Pair from a private key, deriving the public key from it
+func newPrivatePair(purpose Purpose, private crypto.PrivateKey) (Pair, error) {
+	switch pk := private.(type) {
+	case *rsa.PrivateKey:
+		return NewPair(purpose, &pk.PublicKey, pk)
+	case *ecdsa.PrivateKey:
+		return NewPair(purpose, &pk.PublicKey, pk)
+	case ed25519.PrivateKey:
+		return NewPair(purpose, pk.Public(), pk)
+	default:
+		return nil, ErrorUnsupportedKeyType
+	}
+}
@@ -1,7 +1,8 @@
 package key
 
 import (
-	"crypto/rsa"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -9,9 +10,20 @@ import (
 
 var (
 	ErrorPEMRequired                 = errors.New("Keys must be PEM-encoded")
-	ErrorUnsupportedPrivateKeyFormat = errors.New("Private keys must be in PKCS1 or PKCS8 format")
-	ErrorNotRSAPrivateKey            = errors.New("Only RSA private keys are supported")
-	ErrorNotRSAPublicKey             = errors.New("Only RSA public keys or certificates are suppored")
+	ErrorUnsupportedPrivateKeyFormat = errors.New("Private keys must be in PKCS1, PKCS8, or SEC 1 format")
+	ErrorUnsupportedKeyType          = errors.New("Only RSA, ECDSA, and Ed25519 keys or certificates are supported")
+	ErrorUnsupportedCurve            = errors.New("Only the P-256, P-384, and P-521 elliptic curves are supported")
+	ErrorKeyTypeMismatch             = errors.New("The private key does not match the type of the public key")
+
+	// ErrorNotRSAPrivateKey is no longer returned by this package, as private keys other than RSA are supported.
+	//
+	// Deprecated: use ErrorUnsupportedKeyType
+	ErrorNotRSAPrivateKey = ErrorUnsupportedKeyType
+
+	// ErrorNotRSAPublicKey is no longer returned by this package, as public keys other than RSA are supported.
+	//
+	// Deprecated: use ErrorUnsupportedKeyType
+	ErrorNotRSAPublicKey = ErrorUnsupportedKeyType
 )
 
 // Parser parses a chunk of bytes into a Pair.  Parser implementations must
@@ -23,6 +35,16 @@ type Parser interface {
 	ParseKey(Purpose, []byte) (Pair, error)
 }
 
+// checkCurve verifies that an ECDSA key uses one of the curves supported for signing
+func checkCurve(publicKey *ecdsa.PublicKey) error {
+	switch publicKey.Curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return nil
+	default:
+		return ErrorUnsupportedCurve
+	}
+}
+
 // defaultParser is the internal default Parser implementation
 type defaultParser int
 
@@ -30,50 +52,43 @@ func (p defaultParser) String() string {
 	return "defaultParser"
 }
 
-func (p defaultParser) parseRSAPrivateKey(purpose Purpose, decoded []byte) (Pair, error) {
+func (p defaultParser) parsePrivateKey(purpose Purpose, decoded []byte) (Pair, error) {
 	var (
 		parsedKey interface{}
 		err       error
 	)
 
+	if privateKey, ok := parseEd25519PrivateKey(decoded); ok {
+		return newPrivatePair(purpose, privateKey)
+	}
+
 	if parsedKey, err = x509.ParsePKCS1PrivateKey(decoded); err != nil {
 		if parsedKey, err = x509.ParsePKCS8PrivateKey(decoded); err != nil {
-			return nil, ErrorUnsupportedPrivateKeyFormat
+			if parsedKey, err = x509.ParseECPrivateKey(decoded); err != nil {
+				return nil, ErrorUnsupportedPrivateKeyFormat
+			}
 		}
 	}
 
-	privateKey, ok := parsedKey.(*rsa.PrivateKey)
-	if !ok {
-		return nil, ErrorNotRSAPrivateKey
-	}
-
-	return &rsaPair{
-		purpose: purpose,
-		public:  privateKey.Public(),
-		private: privateKey,
-	}, nil
+	return newPrivatePair(purpose, parsedKey)
 }
 
-func (p defaultParser) parseRSAPublicKey(purpose Purpose, decoded []byte) (Pair, error) {
-	var (
-		parsedKey interface{}
-		err       error
-	)
-
-	if parsedKey, err = x509.ParsePKIXPublicKey(decoded); err != nil {
-		return nil, err
+func (p defaultParser) parsePublicKey(purpose Purpose, decoded []byte) (Pair, error) {
+	if publicKey, ok := parseEd25519PublicKey(decoded); ok {
+		return NewPair(purpose, publicKey, nil)
 	}
 
-	publicKey, ok := parsedKey.(*rsa.PublicKey)
-	if !ok {
-		return nil, ErrorNotRSAPublicKey
+	parsedKey, err := x509.ParsePKIXPublicKey(decoded)
+	if err != nil {
+		certificate, certificateErr := x509.ParseCertificate(decoded)
+		if certificateErr != nil {
+			return nil, err
+		}
+
+		parsedKey = certificate.PublicKey
 	}
 
-	return &rsaPair{
-		purpose: purpose,
-		public:  publicKey,
-		private: nil,
-	}, nil
+	return NewPair(purpose, parsedKey, nil)
 }
 
 func (p defaultParser) ParseKey(purpose Purpose, data []byte) (Pair, error) {
@@ -83,12 +98,13 @@ func (p defaultParser) ParseKey(purpose Purpose, data []byte) (Pair, error) {
 	}
 
 	if purpose.RequiresPrivateKey() {
-		return p.parseRSAPrivateKey(purpose, block.Bytes)
+		return p.parsePrivateKey(purpose, block.Bytes)
 	} else {
-		return p.parseRSAPublicKey(purpose, block.Bytes)
+		return p.parsePublicKey(purpose, block.Bytes)
 	}
 }
 
 // DefaultParser is the global, singleton default parser.  All keys submitted to
-// this parser must be PEM-encoded.
+// this parser must be PEM-encoded.  RSA, ECDSA (P-256, P-384, and P-521), and Ed25519
+// keys are supported.  Public keys may also be supplied as X.509 certificates.
 var DefaultParser Parser = defaultParser(0)
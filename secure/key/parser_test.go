@@ -1,11 +1,22 @@
 package key
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
 	"io/ioutil"
+	"math/big"
 	"testing"
+	"time"
 )
 
 func makeNonKeyPEMBlock() []byte {
@@ -86,3 +97,170 @@ func TestDefaultParserInvalidPrivateKey(t *testing.T) {
 		assert.Equal(ErrorUnsupportedPrivateKeyFormat, err)
 	}
 }
+
+func encodePEM(t *testing.T, blockType string, der []byte, err error) []byte {
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+// marshalPKIXPublicKey produces the PKIX form of a public key.  Ed25519 keys are handled here, as
+// crypto/x509 only supports them as of Go 1.13.
+func marshalPKIXPublicKey(publicKey crypto.PublicKey) ([]byte, error) {
+	if k, ok := publicKey.(ed25519.PublicKey); ok {
+		return asn1.Marshal(ed25519PublicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+			PublicKey: asn1.BitString{Bytes: k, BitLength: 8 * len(k)},
+		})
+	}
+
+	return x509.MarshalPKIXPublicKey(publicKey)
+}
+
+// marshalPKCS8PrivateKey produces the PKCS8 form of an ECDSA or Ed25519 private key.  crypto/x509 has
+// no PKCS8 marshaling prior to Go 1.10.
+func marshalPKCS8PrivateKey(privateKey crypto.Signer) ([]byte, error) {
+	switch k := privateKey.(type) {
+	case ed25519.PrivateKey:
+		seed, err := asn1.Marshal(k.Seed())
+		if err != nil {
+			return nil, err
+		}
+
+		return asn1.Marshal(ed25519PrivateKeyInfo{
+			Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+			PrivateKey: seed,
+		})
+
+	case *ecdsa.PrivateKey:
+		curves := map[elliptic.Curve]asn1.ObjectIdentifier{
+			elliptic.P256(): {1, 2, 840, 10045, 3, 1, 7},
+			elliptic.P384(): {1, 3, 132, 0, 34},
+			elliptic.P521(): {1, 3, 132, 0, 35},
+		}
+
+		parameters, err := asn1.Marshal(curves[k.Curve])
+		if err != nil {
+			return nil, err
+		}
+
+		sec1, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		// the PKCS8 structure is the same for all key types
+		return asn1.Marshal(ed25519PrivateKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1},
+				Parameters: asn1.RawValue{FullBytes: parameters},
+			},
+			PrivateKey: sec1,
+		})
+
+	default:
+		return nil, fmt.Errorf("Unsupported private key type: %T", privateKey)
+	}
+}
+
+func testDefaultParserKeyType(t *testing.T, privateKey crypto.Signer, sec1 bool) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	publicDER, err := marshalPKIXPublicKey(privateKey.Public())
+	publicPEM := encodePEM(t, "PUBLIC KEY", publicDER, err)
+
+	pkcs8DER, err := marshalPKCS8PrivateKey(privateKey)
+	privatePEMs := [][]byte{encodePEM(t, "PRIVATE KEY", pkcs8DER, err)}
+
+	if sec1 {
+		sec1DER, err := x509.MarshalECPrivateKey(privateKey.(*ecdsa.PrivateKey))
+		privatePEMs = append(privatePEMs, encodePEM(t, "EC PRIVATE KEY", sec1DER, err))
+	}
+
+	for _, purpose := range []Purpose{PurposeVerify, PurposeDecrypt} {
+		pair, err := DefaultParser.ParseKey(purpose, publicPEM)
+		require.NoError(err)
+		require.NotNil(pair)
+
+		assert.Equal(privateKey.Public(), pair.Public())
+		assert.False(pair.HasPrivate())
+		assert.Nil(pair.Private())
+		assert.Equal(purpose, pair.Purpose())
+	}
+
+	for _, privatePEM := range privatePEMs {
+		for _, purpose := range []Purpose{PurposeSign, PurposeEncrypt} {
+			pair, err := DefaultParser.ParseKey(purpose, privatePEM)
+			require.NoError(err)
+			require.NotNil(pair)
+
+			assert.Equal(privateKey.Public(), pair.Public())
+			assert.True(pair.HasPrivate())
+			assert.Equal(privateKey, pair.Private())
+			assert.Equal(purpose, pair.Purpose())
+		}
+	}
+}
+
+func TestDefaultParserKeyTypes(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+			require.NoError(t, err)
+			testDefaultParserKeyType(t, privateKey, true)
+		})
+	}
+
+	t.Run("Ed25519", func(t *testing.T) {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		testDefaultParserKeyType(t, privateKey, false)
+	})
+}
+
+func TestDefaultParserUnsupportedCurve(t *testing.T) {
+	assert := assert.New(t)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	publicPEM := encodePEM(t, "PUBLIC KEY", publicDER, err)
+	pair, err := DefaultParser.ParseKey(PurposeVerify, publicPEM)
+	assert.Nil(pair)
+	assert.Equal(ErrorUnsupportedCurve, err)
+
+	privateDER, err := x509.MarshalECPrivateKey(privateKey)
+	privatePEM := encodePEM(t, "EC PRIVATE KEY", privateDER, err)
+	pair, err = DefaultParser.ParseKey(PurposeSign, privatePEM)
+	assert.Nil(pair)
+	assert.Equal(ErrorUnsupportedCurve, err)
+}
+
+func TestDefaultParserCertificate(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	certificatePEM := encodePEM(t, "CERTIFICATE", certificateDER, err)
+
+	pair, err := DefaultParser.ParseKey(PurposeVerify, certificatePEM)
+	require.NoError(err)
+	require.NotNil(pair)
+	assert.Equal(&privateKey.PublicKey, pair.Public())
+	assert.False(pair.HasPrivate())
+}
@@ -1,6 +1,7 @@
 package key
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/Comcast/webpa-common/resource"
@@ -81,3 +82,36 @@ func (r *multiResolver) ResolveKey(keyId string) (Pair, error) {
 
 	return r.parseKey(data)
 }
+
+// jwksResolver is a Resolver which loads a JSON Web Key Set and resolves keys by kid.
+type jwksResolver struct {
+	purpose Purpose
+	loader  resource.Loader
+}
+
+func (r *jwksResolver) String() string {
+	return fmt.Sprintf(
+		"jwksResolver{purpose: %v, loader: %v}",
+		r.purpose,
+		r.loader,
+	)
+}
+
+func (r *jwksResolver) ResolveKey(keyId string) (Pair, error) {
+	data, err := resource.ReadAll(r.loader)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks JWKS
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+
+	jwk, err := jwks.Find(keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwk.Pair(r.purpose)
+}
@@ -1,10 +1,12 @@
 package key
 
 import (
+	"errors"
 	"fmt"
 	"github.com/Comcast/webpa-common/concurrent"
 	"github.com/Comcast/webpa-common/resource"
 	"github.com/Comcast/webpa-common/types"
+	"github.com/go-kit/kit/metrics/provider"
 	"time"
 )
 
@@ -13,6 +15,16 @@ const (
 	// if there are any parameters.  URI templates accepted by this package have either no parameters
 	// or exactly one (1) parameter with this name.
 	KeyIdParameterName = "keyId"
+
+	// FormatPEM indicates that key resources are PEM-encoded.  This is the default format.
+	FormatPEM = "pem"
+
+	// FormatJWK indicates that each key resource is a single JSON Web Key
+	FormatJWK = "jwk"
+
+	// FormatJWKS indicates that the key resource is a JSON Web Key Set, such as a /.well-known/jwks.json
+	// endpoint.  Keys are resolved by matching the key id against each key's kid.
+	FormatJWKS = "jwks"
 )
 
 var (
@@ -21,6 +33,15 @@ var (
 		"Key resource template must support either no parameters are the %s parameter",
 		KeyIdParameterName,
 	)
+
+	// ErrorInvalidJWKSTemplate is the error returned when a JWKS resource has template parameters
+	ErrorInvalidJWKSTemplate = errors.New("A JWKS key resource cannot have template parameters")
+
+	// ErrorUnsupportedFormat is the error returned when the factory's format is not recognized
+	ErrorUnsupportedFormat = fmt.Errorf(
+		"Key resource format must be one of %s, %s, or %s",
+		FormatPEM, FormatJWK, FormatJWKS,
+	)
 )
 
 // ResolverFactory provides a JSON representation of a collection of keys together
@@ -41,8 +62,27 @@ type ResolverFactory struct {
 	// If negative or zero, keys are never refreshed and are cached forever.
 	UpdateInterval types.Duration `json:"updateInterval"`
 
-	// Parser is a custom key parser.  If omitted, DefaultParser is used.
+	// Format is the encoding of the key resource.  If omitted, FormatPEM is assumed.
+	// With FormatJWKS, the URI must have no template parameters, as all keys come from
+	// the same key set.
+	Format string `json:"format"`
+
+	// Parser is a custom key parser.  If omitted, DefaultParser is used for FormatPEM and
+	// JWKParser is used for FormatJWK.  Ignored for FormatJWKS.
 	Parser Parser `json:"-"`
+
+	// NegativeTTL is how long a failure to resolve a key id is cached.  While a failure is cached,
+	// attempts to resolve that key id fail immediately without hitting the key resource.
+	// If negative or zero, failures are not cached.
+	NegativeTTL types.Duration `json:"negativeTTL"`
+
+	// MaxStaleness is how long a cached key continues to be served after its last successful
+	// refresh.  Once this much time has elapsed and a refresh fails, the key is evicted.
+	// If negative or zero, keys are served indefinitely when refreshes fail.
+	MaxStaleness types.Duration `json:"maxStaleness"`
+
+	// MetricsProvider is used to create the cache metrics.  If omitted, metrics are discarded.
+	MetricsProvider provider.Provider `json:"-"`
 }
 
 func (factory *ResolverFactory) parser() Parser {
@@ -50,11 +90,30 @@ func (factory *ResolverFactory) parser() Parser {
 		return factory.Parser
 	}
 
+	if factory.Format == FormatJWK {
+		return JWKParser
+	}
+
 	return DefaultParser
 }
 
+// newBasicCache creates the common cache state for a delegate Resolver
+func (factory *ResolverFactory) newBasicCache(delegate Resolver) basicCache {
+	metricsProvider := factory.MetricsProvider
+	if metricsProvider == nil {
+		metricsProvider = provider.NewDiscardProvider()
+	}
+
+	return basicCache{
+		delegate:     delegate,
+		negativeTTL:  time.Duration(factory.NegativeTTL),
+		maxStaleness: time.Duration(factory.MaxStaleness),
+		measures:     NewMeasures(metricsProvider),
+	}
+}
+
 // NewResolver() creates a Resolver using this factory's configuration.  The
-// returned Resolver always caches keys forever once they have been loaded.
+// returned Resolver caches keys once they have been loaded, subject to MaxStaleness.
 func (factory *ResolverFactory) NewResolver() (Resolver, error) {
 	expander, err := factory.NewExpander()
 	if err != nil {
@@ -63,6 +122,30 @@ func (factory *ResolverFactory) NewResolver() (Resolver, error) {
 
 	names := expander.Names()
 	nameCount := len(names)
+
+	switch factory.Format {
+	case "", FormatPEM, FormatJWK:
+	case FormatJWKS:
+		if nameCount > 0 {
+			return nil, ErrorInvalidJWKSTemplate
+		}
+
+		loader, err := factory.NewLoader()
+		if err != nil {
+			return nil, err
+		}
+
+		return &multiCache{
+			factory.newBasicCache(&jwksResolver{
+				purpose: factory.Purpose,
+				loader:  loader,
+			}),
+		}, nil
+
+	default:
+		return nil, ErrorUnsupportedFormat
+	}
+
 	if nameCount == 0 {
 		// the template had no parameters, so we can create a simpler object
 		loader, err := factory.NewLoader()
@@ -71,27 +154,23 @@ func (factory *ResolverFactory) NewResolver() (Resolver, error) {
 		}
 
 		return &singleCache{
-			basicCache{
-				delegate: &singleResolver{
-					basicResolver: basicResolver{
-						parser:  factory.parser(),
-						purpose: factory.Purpose,
-					},
-					loader: loader,
+			factory.newBasicCache(&singleResolver{
+				basicResolver: basicResolver{
+					parser:  factory.parser(),
+					purpose: factory.Purpose,
 				},
-			},
+				loader: loader,
+			}),
 		}, nil
 	} else if nameCount == 1 && names[0] == KeyIdParameterName {
 		return &multiCache{
-			basicCache{
-				delegate: &multiResolver{
-					basicResolver: basicResolver{
-						parser:  factory.parser(),
-						purpose: factory.Purpose,
-					},
-					expander: expander,
+			factory.newBasicCache(&multiResolver{
+				basicResolver: basicResolver{
+					parser:  factory.parser(),
+					purpose: factory.Purpose,
 				},
-			},
+				expander: expander,
+			}),
 		}, nil
 	}
 
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"github.com/Comcast/webpa-common/resource"
 	"github.com/Comcast/webpa-common/types"
+	"github.com/Comcast/webpa-common/xmetrics/xmetricstest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"sync"
 	"testing"
 	"time"
@@ -177,3 +179,40 @@ func TestResolverFactoryCustomParser(t *testing.T) {
 	assert.Equal(parser, resolverFactory.parser())
 	mock.AssertExpectationsForObjects(t, parser.Mock)
 }
+
+func TestResolverFactoryCacheConfiguration(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		provider = xmetricstest.NewProvider(nil, Metrics)
+		factory  = ResolverFactory{
+			Factory: resource.Factory{
+				URI: publicKeyURL,
+			},
+			NegativeTTL:     types.Duration(time.Minute),
+			MaxStaleness:    types.Duration(time.Hour),
+			MetricsProvider: provider,
+		}
+	)
+
+	resolver, err := factory.NewResolver()
+	require.NoError(err)
+
+	cache, ok := resolver.(*singleCache)
+	require.True(ok)
+	assert.Equal(time.Minute, cache.negativeTTL)
+	assert.Equal(time.Hour, cache.maxStaleness)
+	require.NotNil(cache.measures)
+
+	_, err = resolver.ResolveKey(keyId)
+	require.NoError(err)
+	provider.Assert(t, CacheMissCounter)(xmetricstest.Value(1.0))
+
+	// without a provider, metrics are discarded
+	factory.MetricsProvider = nil
+	resolver, err = factory.NewResolver()
+	require.NoError(err)
+	_, err = resolver.ResolveKey(keyId)
+	assert.NoError(err)
+}
@@ -9,6 +9,7 @@ import (
 //Names for our metrics
 const (
 	JWTValidationReasonCounter = "jwt_validation_reason"
+	JWTCapabilityMatchCounter  = "jwt_capability_match"
 	NBFHistogram               = "jwt_from_nbf_seconds"
 	EXPHistogram               = "jwt_from_exp_seconds"
 )
@@ -22,6 +23,12 @@ func Metrics() []xmetrics.Metric {
 			Help:       "Counter for validation resolutions per reason",
 			LabelNames: []string{"reason"},
 		},
+		xmetrics.Metric{
+			Name:       JWTCapabilityMatchCounter,
+			Type:       xmetrics.CounterType,
+			Help:       "Counter for authorized requests per configured capability pattern",
+			LabelNames: []string{"capability"},
+		},
 		xmetrics.Metric{
 			Name:    NBFHistogram,
 			Type:    xmetrics.HistogramType,
@@ -42,6 +49,7 @@ type JWTValidationMeasures struct {
 	NBFHistogram     *gokitprometheus.Histogram
 	ExpHistogram     *gokitprometheus.Histogram
 	ValidationReason metrics.Counter
+	CapabilityMatch  metrics.Counter
 }
 
 //NewJWTValidationMeasures realizes desired metrics
@@ -50,5 +58,6 @@ func NewJWTValidationMeasures(r xmetrics.Registry) *JWTValidationMeasures {
 		NBFHistogram:     gokitprometheus.NewHistogram(r.NewHistogramVec(NBFHistogram)),
 		ExpHistogram:     gokitprometheus.NewHistogram(r.NewHistogramVec(EXPHistogram)),
 		ValidationReason: r.NewCounter(JWTValidationReasonCounter),
+		CapabilityMatch:  r.NewCounter(JWTCapabilityMatchCounter),
 	}
 }
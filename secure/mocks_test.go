@@ -104,3 +104,12 @@ func (j *mockJWS) IsJWT() bool {
 	arguments := j.Called()
 	return arguments.Bool(0)
 }
+
+type mockRevocationList struct {
+	mock.Mock
+}
+
+func (r *mockRevocationList) Revoked(claims jws.Claims) (string, bool) {
+	arguments := r.Called(claims)
+	return arguments.String(0), arguments.Bool(1)
+}
@@ -0,0 +1,8 @@
+/*
+Package policy provides declarative, per-request authorization rules which are evaluated after a
+token has been validated.  Rules match on the request method and path and on the claims of the
+token, and each matching rule either allows or denies the request.  Rules can be rolled out in
+a dry-run mode, where denials are only recorded, so that access rules can change through
+configuration alone.
+*/
+package policy
@@ -0,0 +1,47 @@
+package policy
+
+import (
+	"github.com/Comcast/webpa-common/xmetrics"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+)
+
+const (
+	DecisionCounter = "authorization_policy_decision_count"
+
+	// RuleLabel is the label for the name of the rule which made a decision
+	RuleLabel = "rule"
+
+	// EffectLabel is the label for the effect of a decision, either Allow or Deny
+	EffectLabel = "effect"
+
+	// ModeLabel is the label indicating whether a decision was enforced or only audited
+	ModeLabel = "mode"
+
+	EnforceMode = "enforce"
+	AuditMode   = "audit"
+)
+
+// Metrics is the policy module function that adds authorization policy metrics
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name:       DecisionCounter,
+			Type:       "counter",
+			Help:       "The number of authorization policy decisions, per rule",
+			LabelNames: []string{RuleLabel, EffectLabel, ModeLabel},
+		},
+	}
+}
+
+// Measures is the set of metrics used by a Policy
+type Measures struct {
+	Decision metrics.Counter
+}
+
+// NewMeasures constructs a Measures given a go-kit metrics Provider
+func NewMeasures(p provider.Provider) *Measures {
+	return &Measures{
+		Decision: p.NewCounter(DecisionCounter),
+	}
+}
@@ -0,0 +1,326 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SermoDigital/jose/jws"
+)
+
+const (
+	// Allow is the effect of a rule that authorizes a request
+	Allow = "allow"
+
+	// Deny is the effect of a rule that rejects a request
+	Deny = "deny"
+
+	// DefaultRuleName is the name reported for decisions made when no rule matches a request
+	DefaultRuleName = "default"
+
+	// AnyValue is the claim value that matches any value, so long as the claim is present
+	AnyValue = "*"
+)
+
+var (
+	ErrorInvalidEffect = errors.New("A rule's effect must be either allow or deny")
+	ErrorInvalidPath   = errors.New("Invalid rule path pattern")
+)
+
+// RuleConfig is the configurable description of a single authorization rule.  A rule matches a
+// request when the method, the path, and every configured claim match.
+type RuleConfig struct {
+	// Name identifies this rule in logs and metrics.  If unset, the rule is named for its position, e.g. rule-0.
+	Name string `json:"name"`
+
+	// Methods are the HTTP methods this rule applies to.  If empty, the rule applies to all methods.
+	Methods []string `json:"methods"`
+
+	// Path is the regular expression the request path must match, in its entirety, for this rule to apply.
+	// If empty, the rule applies to all paths.  Named groups, e.g. (?P<deviceID>[^/]+), capture request
+	// attributes which can be referenced in claim values.
+	Path string `json:"path"`
+
+	// Claims maps claim names onto values, any one of which the claim must have for this rule to apply.
+	// Nested claims are named with dots, e.g. allowedResources.allowedPartners, and claims with multiple
+	// values, such as capabilities, match if any of their values match.  Values may reference groups
+	// captured from the path as ${name}, and the value "*" matches any value.
+	Claims map[string][]string `json:"claims"`
+
+	// Effect is either Allow or Deny.  If unset, a rule with a StatusCode denies requests.
+	Effect string `json:"effect"`
+
+	// StatusCode is the HTTP status code for requests this rule denies.  If unset, the
+	// status code is chosen by the enforcing code, typically 403.
+	StatusCode int `json:"statusCode"`
+
+	// DryRun indicates that denials by this rule are only recorded, and are not enforced
+	DryRun bool `json:"dryRun"`
+}
+
+// Config is the configurable description of an authorization Policy
+type Config struct {
+	// Rules are evaluated in order, and the first rule that matches a request decides it
+	Rules []RuleConfig `json:"rules"`
+
+	// DefaultEffect is the effect for requests no rule matches.  If unset, such requests are allowed.
+	DefaultEffect string `json:"defaultEffect"`
+
+	// DefaultStatusCode is the status code for requests denied by the DefaultEffect
+	DefaultStatusCode int `json:"defaultStatusCode"`
+
+	// DryRun indicates that no denials are enforced.  Decisions are still recorded, which allows a
+	// new policy to be audited against live traffic before it is enforced.
+	DryRun bool `json:"dryRun"`
+}
+
+// Request is the information about an HTTP request that a Policy evaluates
+type Request struct {
+	Method string
+	Path   string
+
+	// Claims are the claims of the validated token
+	Claims jws.Claims
+}
+
+// Decision is the result of evaluating a Policy against a Request
+type Decision struct {
+	// Rule is the name of the rule that decided the request, or DefaultRuleName
+	Rule string
+
+	// Effect is either Allow or Deny
+	Effect string
+
+	// StatusCode is the status code for a denied request.  This will be zero if the rule did not specify one.
+	StatusCode int
+
+	// DryRun indicates that this decision should only be recorded, not enforced
+	DryRun bool
+}
+
+// Allowed tests if this decision authorizes the request
+func (d Decision) Allowed() bool {
+	return d.Effect == Allow
+}
+
+// Denied tests if this decision denies the request, as opposed to allowing it or only auditing the denial
+func (d Decision) Denied() bool {
+	return !d.Allowed() && !d.DryRun
+}
+
+func effect(configured string, statusCode int) (string, error) {
+	switch strings.ToLower(configured) {
+	case Allow:
+		return Allow, nil
+	case Deny:
+		return Deny, nil
+	case "":
+		if statusCode > 0 {
+			return Deny, nil
+		}
+	}
+
+	return "", ErrorInvalidEffect
+}
+
+type rule struct {
+	name       string
+	methods    map[string]bool
+	path       *regexp.Regexp
+	claims     map[string][]string
+	effect     string
+	statusCode int
+	dryRun     bool
+}
+
+func newRule(index int, rc RuleConfig) (*rule, error) {
+	r := &rule{
+		name:       rc.Name,
+		methods:    make(map[string]bool, len(rc.Methods)),
+		claims:     rc.Claims,
+		statusCode: rc.StatusCode,
+		dryRun:     rc.DryRun,
+	}
+
+	if len(r.name) == 0 {
+		r.name = "rule-" + strconv.Itoa(index)
+	}
+
+	var err error
+	if r.effect, err = effect(rc.Effect, rc.StatusCode); err != nil {
+		return nil, fmt.Errorf("%s [%s]", err, r.name)
+	}
+
+	if len(rc.Path) > 0 {
+		if r.path, err = regexp.Compile("^(?:" + rc.Path + ")$"); err != nil {
+			return nil, fmt.Errorf("%s [%s]: %s", ErrorInvalidPath, rc.Path, err)
+		}
+	}
+
+	for _, m := range rc.Methods {
+		r.methods[strings.ToUpper(m)] = true
+	}
+
+	return r, nil
+}
+
+// matches tests if this rule applies to the given request
+func (r *rule) matches(request Request) bool {
+	if len(r.methods) > 0 && !r.methods[strings.ToUpper(request.Method)] {
+		return false
+	}
+
+	var submatches []int
+	if r.path != nil {
+		if submatches = r.path.FindStringSubmatchIndex(request.Path); submatches == nil {
+			return false
+		}
+	}
+
+	for name, values := range r.claims {
+		actual := claimValues(request.Claims, name)
+		if len(actual) == 0 || !r.anyMatch(actual, values, request.Path, submatches) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyMatch tests if any of the actual claim values matches any expected value, after expanding
+// references to groups captured from the path
+func (r *rule) anyMatch(actual, expected []string, path string, submatches []int) bool {
+	for _, e := range expected {
+		if e == AnyValue {
+			return true
+		}
+
+		if r.path != nil && strings.Contains(e, "$") {
+			e = string(r.path.ExpandString(nil, e, path, submatches))
+		}
+
+		for _, a := range actual {
+			if a == e {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// claimValues returns the values of a possibly nested claim as strings.  Array claims produce
+// one value per element.
+func claimValues(claims jws.Claims, name string) []string {
+	var value interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		if value, ok = object[part]; !ok {
+			return nil
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, element := range v {
+			if element != nil {
+				values = append(values, fmt.Sprint(element))
+			}
+		}
+
+		return values
+
+	case []string:
+		return v
+
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// Policy is an ordered set of authorization rules.  A Policy is immutable and safe for concurrent use.
+type Policy struct {
+	rules             []*rule
+	defaultEffect     string
+	defaultStatusCode int
+	dryRun            bool
+	measures          *Measures
+}
+
+// NewPolicy produces a Policy from configuration.  The measures may be nil, in which case no metrics are recorded.
+func NewPolicy(c Config, measures *Measures) (*Policy, error) {
+	p := &Policy{
+		rules:             make([]*rule, 0, len(c.Rules)),
+		defaultEffect:     Allow,
+		defaultStatusCode: c.DefaultStatusCode,
+		dryRun:            c.DryRun,
+		measures:          measures,
+	}
+
+	if len(c.DefaultEffect) > 0 || c.DefaultStatusCode > 0 {
+		var err error
+		if p.defaultEffect, err = effect(c.DefaultEffect, c.DefaultStatusCode); err != nil {
+			return nil, fmt.Errorf("%s [%s]", err, DefaultRuleName)
+		}
+	}
+
+	for i, rc := range c.Rules {
+		r, err := newRule(i, rc)
+		if err != nil {
+			return nil, err
+		}
+
+		p.rules = append(p.rules, r)
+	}
+
+	return p, nil
+}
+
+// Evaluate decides the given request using the first rule that matches it, or the default
+// effect if no rule matches.  Each decision is recorded in this Policy's metrics.
+func (p *Policy) Evaluate(request Request) Decision {
+	decision := Decision{
+		Rule:       DefaultRuleName,
+		Effect:     p.defaultEffect,
+		StatusCode: p.defaultStatusCode,
+		DryRun:     p.dryRun,
+	}
+
+	for _, r := range p.rules {
+		if r.matches(request) {
+			decision = Decision{
+				Rule:       r.name,
+				Effect:     r.effect,
+				StatusCode: r.statusCode,
+				DryRun:     p.dryRun || r.dryRun,
+			}
+
+			break
+		}
+	}
+
+	if decision.Allowed() {
+		decision.StatusCode = 0
+	}
+
+	if p.measures != nil {
+		mode := EnforceMode
+		if decision.DryRun {
+			mode = AuditMode
+		}
+
+		p.measures.Decision.With(RuleLabel, decision.Rule, EffectLabel, decision.Effect, ModeLabel, mode).Add(1)
+	}
+
+	return decision
+}
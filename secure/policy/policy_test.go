@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Comcast/webpa-common/xmetrics/xmetricstest"
+	"github.com/SermoDigital/jose/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNewPolicyInvalid(t *testing.T) {
+	testData := []Config{
+		{Rules: []RuleConfig{{Path: "/test"}}},
+		{Rules: []RuleConfig{{Path: "/test", Effect: "permit"}}},
+		{Rules: []RuleConfig{{Path: "/test(", Effect: Allow}}},
+		{DefaultEffect: "permit"},
+	}
+
+	for i, record := range testData {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			p, err := NewPolicy(record, nil)
+			assert.Nil(t, p)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func testPolicyEvaluate(t *testing.T) {
+	var (
+		require = require.New(t)
+		claims  = jws.Claims{
+			"sub":          "mac:112233445566",
+			"capabilities": []interface{}{"x1:webpa:api:.*:get", "x1:webpa:api:hooks:post"},
+			"allowedResources": map[string]interface{}{
+				"allowedPartners": []interface{}{"comcast"},
+			},
+		}
+	)
+
+	p, err := NewPolicy(
+		Config{
+			Rules: []RuleConfig{
+				{
+					Name:   "own-device",
+					Path:   "/api/v2/device/(?P<deviceID>[^/]+)/.*",
+					Claims: map[string][]string{"sub": {"${deviceID}"}},
+					Effect: Allow,
+				},
+				{Name: "other-device", Path: "/api/v2/device/.*", StatusCode: http.StatusNotFound},
+				{
+					Name:    "hooks",
+					Methods: []string{"post"},
+					Path:    "/api/v2/hooks",
+					Claims:  map[string][]string{"capabilities": {"x1:webpa:api:hooks:post"}, "allowedResources.allowedPartners": {AnyValue}},
+					Effect:  Allow,
+				},
+				{Name: "partners", Path: "/api/v2/partner/.*", Claims: map[string][]string{"allowedResources.allowedPartners": {"cox"}}, Effect: Allow},
+				{Name: "audit", Path: "/api/v2/audit", Effect: Deny, DryRun: true},
+			},
+			DefaultEffect:     Deny,
+			DefaultStatusCode: http.StatusUnauthorized,
+		},
+		nil,
+	)
+
+	require.NoError(err)
+	require.NotNil(p)
+
+	testData := []struct {
+		request  Request
+		expected Decision
+	}{
+		{
+			Request{Method: "GET", Path: "/api/v2/device/mac:112233445566/stat", Claims: claims},
+			Decision{Rule: "own-device", Effect: Allow},
+		},
+		{
+			Request{Method: "GET", Path: "/api/v2/device/mac:665544332211/stat", Claims: claims},
+			Decision{Rule: "other-device", Effect: Deny, StatusCode: http.StatusNotFound},
+		},
+		{
+			Request{Method: "POST", Path: "/api/v2/hooks", Claims: claims},
+			Decision{Rule: "hooks", Effect: Allow},
+		},
+		{
+			Request{Method: "GET", Path: "/api/v2/hooks", Claims: claims},
+			Decision{Rule: DefaultRuleName, Effect: Deny, StatusCode: http.StatusUnauthorized},
+		},
+		{
+			Request{Method: "POST", Path: "/api/v2/hooks"},
+			Decision{Rule: DefaultRuleName, Effect: Deny, StatusCode: http.StatusUnauthorized},
+		},
+		{
+			Request{Method: "GET", Path: "/api/v2/partner/cox", Claims: claims},
+			Decision{Rule: DefaultRuleName, Effect: Deny, StatusCode: http.StatusUnauthorized},
+		},
+		{
+			Request{Method: "PUT", Path: "/api/v2/audit", Claims: claims},
+			Decision{Rule: "audit", Effect: Deny, DryRun: true},
+		},
+	}
+
+	for i, record := range testData {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert := assert.New(t)
+			actual := p.Evaluate(record.request)
+			assert.Equal(record.expected, actual)
+			assert.Equal(record.expected.Effect == Allow, actual.Allowed())
+			assert.Equal(record.expected.Effect == Deny && !record.expected.DryRun, actual.Denied())
+		})
+	}
+}
+
+func testPolicyEvaluateDefault(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	p, err := NewPolicy(Config{}, nil)
+	require.NoError(err)
+	assert.Equal(
+		Decision{Rule: DefaultRuleName, Effect: Allow},
+		p.Evaluate(Request{Method: "GET", Path: "/test"}),
+	)
+}
+
+func testPolicyEvaluateDryRun(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+	)
+
+	p, err := NewPolicy(
+		Config{
+			Rules: []RuleConfig{
+				{Path: "/allowed", Effect: Allow},
+				{Path: "/denied", Effect: Deny},
+			},
+			DryRun: true,
+		},
+		NewMeasures(provider),
+	)
+
+	require.NoError(err)
+
+	allowed := p.Evaluate(Request{Method: "GET", Path: "/allowed"})
+	assert.True(allowed.Allowed())
+	assert.Equal("rule-0", allowed.Rule)
+
+	denied := p.Evaluate(Request{Method: "GET", Path: "/denied"})
+	assert.False(denied.Allowed())
+	assert.False(denied.Denied())
+	assert.True(denied.DryRun)
+	assert.Equal("rule-1", denied.Rule)
+
+	provider.Assert(t, DecisionCounter, RuleLabel, "rule-0", EffectLabel, Allow, ModeLabel, AuditMode)(xmetricstest.Value(1.0))
+	provider.Assert(t, DecisionCounter, RuleLabel, "rule-1", EffectLabel, Deny, ModeLabel, AuditMode)(xmetricstest.Value(1.0))
+}
+
+func testPolicyEvaluateMetrics(t *testing.T) {
+	var (
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+	)
+
+	p, err := NewPolicy(
+		Config{
+			Rules: []RuleConfig{
+				{Name: "test", Path: "/test", Effect: Deny},
+			},
+		},
+		NewMeasures(provider),
+	)
+
+	require.NoError(err)
+	p.Evaluate(Request{Method: "GET", Path: "/test"})
+	p.Evaluate(Request{Method: "GET", Path: "/test"})
+	p.Evaluate(Request{Method: "GET", Path: "/other"})
+
+	provider.Assert(t, DecisionCounter, RuleLabel, "test", EffectLabel, Deny, ModeLabel, EnforceMode)(xmetricstest.Value(2.0))
+	provider.Assert(t, DecisionCounter, RuleLabel, DefaultRuleName, EffectLabel, Allow, ModeLabel, EnforceMode)(xmetricstest.Value(1.0))
+}
+
+func TestNewPolicy(t *testing.T) {
+	t.Run("Invalid", testNewPolicyInvalid)
+}
+
+func TestPolicy(t *testing.T) {
+	t.Run("Evaluate", testPolicyEvaluate)
+	t.Run("EvaluateDefault", testPolicyEvaluateDefault)
+	t.Run("EvaluateDryRun", testPolicyEvaluateDryRun)
+	t.Run("EvaluateMetrics", testPolicyEvaluateMetrics)
+}
+
+func TestClaimValues(t *testing.T) {
+	claims := jws.Claims{
+		"sub":     "test",
+		"version": 1.0,
+		"nested":  map[string]interface{}{"values": []interface{}{"a", nil, 2.0}},
+		"strings": []string{"x", "y"},
+		"null":    nil,
+	}
+
+	testData := []struct {
+		name     string
+		expected []string
+	}{
+		{"sub", []string{"test"}},
+		{"version", []string{"1"}},
+		{"nested.values", []string{"a", "2"}},
+		{"strings", []string{"x", "y"}},
+		{"null", nil},
+		{"missing", nil},
+		{"sub.missing", nil},
+		{"nested.missing", nil},
+	}
+
+	for _, record := range testData {
+		t.Run(record.name, func(t *testing.T) {
+			assert.Equal(t, record.expected, claimValues(claims, record.name))
+		})
+	}
+}
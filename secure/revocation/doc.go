@@ -0,0 +1,5 @@
+/*
+Package revocation provides revocation lists for tokens which are otherwise valid until they expire.
+Tokens can be revoked by token id (jti), subject (sub), or client id (client_id).
+*/
+package revocation
@@ -0,0 +1,89 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Comcast/webpa-common/concurrent"
+	"github.com/Comcast/webpa-common/resource"
+	"github.com/Comcast/webpa-common/types"
+	"github.com/go-kit/kit/metrics/provider"
+)
+
+// Factory is the configurable factory for revocation Lists.  The embedded resource.Factory
+// describes where the list is loaded from, e.g. a file or an HTTP endpoint.  If neither a URI nor
+// Data is configured, the created List only holds local additions.
+type Factory struct {
+	resource.Factory
+
+	// UpdateInterval specifies how often the list is reloaded.  If negative or zero,
+	// the list is only loaded once.
+	UpdateInterval types.Duration `json:"updateInterval"`
+
+	// MetricsProvider is used to create the revocation metrics.  If omitted, metrics are discarded.
+	MetricsProvider provider.Provider `json:"-"`
+}
+
+// NewList creates a List using this factory's configuration.  The List is loaded before it is
+// returned, so a resource that cannot be loaded results in an error.
+func (f *Factory) NewList() (*List, error) {
+	var loader resource.Loader
+	if len(f.URI) > 0 || len(f.Data) > 0 {
+		var err error
+		if loader, err = f.NewLoader(); err != nil {
+			return nil, err
+		}
+	}
+
+	metricsProvider := f.MetricsProvider
+	if metricsProvider == nil {
+		metricsProvider = provider.NewDiscardProvider()
+	}
+
+	list := NewList(loader, NewMeasures(metricsProvider))
+	if err := list.Load(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// NewUpdater uses this factory's configuration to conditionally create a Runnable updater
+// for the given list.  This method delegates to the NewUpdater function.
+func (f *Factory) NewUpdater(list *List) concurrent.Runnable {
+	return NewUpdater(time.Duration(f.UpdateInterval), list)
+}
+
+// NewUpdater conditionally creates a Runnable which reloads the given list on the
+// configured updateInterval.  If updateInterval is nonpositive or list is nil,
+// this function returns nil.
+func NewUpdater(updateInterval time.Duration, list *List) (updater concurrent.Runnable) {
+	if updateInterval < 1 || list == nil {
+		return
+	}
+
+	updater = concurrent.RunnableFunc(func(waitGroup *sync.WaitGroup, shutdown <-chan struct{}) error {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			ticker := time.NewTicker(updateInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-shutdown:
+					return
+				case <-ticker.C:
+					// failures retain the previous entries, and are reflected in metrics
+					list.Load()
+				}
+			}
+		}()
+
+		return nil
+	})
+
+	return
+}
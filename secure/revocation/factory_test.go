@@ -0,0 +1,92 @@
+package revocation
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/resource"
+	"github.com/Comcast/webpa-common/types"
+	"github.com/Comcast/webpa-common/xmetrics/xmetricstest"
+	"github.com/SermoDigital/jose/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory(t *testing.T) {
+	t.Run("LocalOnly", func(t *testing.T) {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+			factory = Factory{}
+		)
+
+		list, err := factory.NewList()
+		require.NoError(err)
+		require.NotNil(list)
+		assert.Nil(list.loader)
+		assert.Nil(factory.NewUpdater(list))
+	})
+
+	t.Run("Data", func(t *testing.T) {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+			factory = Factory{Factory: resource.Factory{Data: `{"jti": ["revoked"]}`}}
+		)
+
+		list, err := factory.NewList()
+		require.NoError(err)
+		require.NotNil(list)
+
+		_, revoked := list.Revoked(jws.Claims{"jti": "revoked"})
+		assert.True(revoked)
+	})
+
+	t.Run("LoadError", func(t *testing.T) {
+		assert := assert.New(t)
+
+		list, err := (&Factory{Factory: resource.Factory{Data: "this is not JSON"}}).NewList()
+		assert.Nil(list)
+		assert.Error(err)
+
+		list, err = (&Factory{Factory: resource.Factory{URI: "badscheme://foo"}}).NewList()
+		assert.Nil(list)
+		assert.Error(err)
+	})
+}
+
+func TestNewUpdater(t *testing.T) {
+	t.Run("NoRunnable", func(t *testing.T) {
+		assert := assert.New(t)
+		assert.Nil(NewUpdater(0, NewList(nil, nil)))
+		assert.Nil(NewUpdater(-1, NewList(nil, nil)))
+		assert.Nil(NewUpdater(time.Second, nil))
+	})
+
+	t.Run("Reload", func(t *testing.T) {
+		var (
+			require  = require.New(t)
+			provider = xmetricstest.NewProvider(nil, Metrics)
+			loader   = &testLoader{data: `{"jti": ["revoked"]}`}
+			list     = NewList(loader, NewMeasures(provider))
+			factory  = Factory{UpdateInterval: types.Duration(10 * time.Millisecond)}
+		)
+
+		updater := factory.NewUpdater(list)
+		require.NotNil(updater)
+
+		waitGroup := &sync.WaitGroup{}
+		shutdown := make(chan struct{})
+		require.NoError(updater.Run(waitGroup, shutdown))
+
+		deadline := time.Now().Add(5 * time.Second)
+		for len(list.Entries().TokenIDs) == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		close(shutdown)
+		waitGroup.Wait()
+		assert.Equal(t, []string{"revoked"}, list.Entries().TokenIDs)
+	})
+}
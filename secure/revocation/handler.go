@@ -0,0 +1,85 @@
+package revocation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/secure/handler"
+	"github.com/Comcast/webpa-common/xhttp"
+	"github.com/go-kit/kit/log"
+)
+
+// ErrNoAuthorization is returned by NewHandler when the supplied AuthorizationHandler has no validators,
+// in which case it would allow every request through.
+var ErrNoAuthorization = errors.New("The revocation handler requires authorization")
+
+// Handler is the admin http.Handler for a revocation List.  A GET returns all the revoked entries,
+// while a POST or PUT with a JSON-encoded Entries body revokes those entries locally.
+//
+// A Handler performs no authorization of its own, so it must always be mounted behind an
+// AuthorizationHandler.  Use NewHandler, which enforces this, rather than creating a Handler directly.
+type Handler struct {
+	List   *List
+	Logger log.Logger
+}
+
+// NewHandler creates the admin http.Handler for the given List, decorated with the given authorization.
+// Since anyone who can reach the returned handler can revoke tokens, an error is returned if authorization
+// has neither a Validator nor a CertificateValidator.
+func NewHandler(list *List, authorization handler.AuthorizationHandler, logger log.Logger) (http.Handler, error) {
+	if authorization.Validator == nil && authorization.CertificateValidator == nil {
+		return nil, ErrNoAuthorization
+	}
+
+	return authorization.Decorate(&Handler{List: list, Logger: logger}), nil
+}
+
+func (h *Handler) logger() log.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+
+	return logging.DefaultLogger()
+}
+
+func (h *Handler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		data, err := json.Marshal(h.List.Entries())
+		if err != nil {
+			xhttp.WriteError(response, http.StatusInternalServerError, err)
+			return
+		}
+
+		response.Header().Set("Content-Type", "application/json")
+		response.Write(data)
+
+	case http.MethodPost, http.MethodPut:
+		var entries Entries
+		if err := json.NewDecoder(request.Body).Decode(&entries); err != nil {
+			xhttp.WriteErrorf(response, http.StatusBadRequest, "Invalid revocation entries: %s", err)
+			return
+		}
+
+		if entries.Len() == 0 {
+			xhttp.WriteError(response, http.StatusBadRequest, "No revocation entries")
+			return
+		}
+
+		h.List.Add(entries)
+		logging.Info(h.logger()).Log(
+			logging.MessageKey(), "revoked locally",
+			TokenIDClaim, entries.TokenIDs,
+			SubjectClaim, entries.Subjects,
+			ClientIDClaim, entries.ClientIDs,
+		)
+
+		response.WriteHeader(http.StatusAccepted)
+
+	default:
+		response.Header().Set("Allow", "GET, POST, PUT")
+		xhttp.WriteError(response, http.StatusMethodNotAllowed, "Unsupported method")
+	}
+}
@@ -0,0 +1,131 @@
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/secure"
+	"github.com/Comcast/webpa-common/secure/handler"
+	"github.com/SermoDigital/jose/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHandlerAdd(t *testing.T, method string) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+		list    = NewList(nil, nil)
+		handler = &Handler{List: list, Logger: logging.NewTestLogger(nil, t)}
+
+		response = httptest.NewRecorder()
+		request  = httptest.NewRequest(method, "/revocations", strings.NewReader(`{"jti": ["revoked"], "client_id": ["client"]}`))
+	)
+
+	handler.ServeHTTP(response, request)
+	assert.Equal(http.StatusAccepted, response.Code)
+
+	_, revoked := list.Revoked(jws.Claims{"jti": "revoked"})
+	assert.True(revoked)
+	_, revoked = list.Revoked(jws.Claims{"client_id": "client"})
+	assert.True(revoked)
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/revocations", nil))
+	assert.Equal(http.StatusOK, response.Code)
+	assert.Equal("application/json", response.Header().Get("Content-Type"))
+
+	var entries Entries
+	require.NoError(json.Unmarshal(response.Body.Bytes(), &entries))
+	assert.Equal(Entries{TokenIDs: []string{"revoked"}, ClientIDs: []string{"client"}}, entries)
+}
+
+func testHandlerBadRequest(t *testing.T, body string) {
+	var (
+		assert  = assert.New(t)
+		list    = NewList(nil, nil)
+		handler = &Handler{List: list}
+
+		response = httptest.NewRecorder()
+		request  = httptest.NewRequest("POST", "/revocations", strings.NewReader(body))
+	)
+
+	handler.ServeHTTP(response, request)
+	assert.Equal(http.StatusBadRequest, response.Code)
+	assert.Zero(list.Entries().Len())
+}
+
+func testHandlerMethodNotAllowed(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		handler  = &Handler{List: NewList(nil, nil)}
+		response = httptest.NewRecorder()
+	)
+
+	handler.ServeHTTP(response, httptest.NewRequest("DELETE", "/revocations", nil))
+	assert.Equal(http.StatusMethodNotAllowed, response.Code)
+	assert.Equal("GET, POST, PUT", response.Header().Get("Allow"))
+}
+
+func testNewHandlerNoAuthorization(t *testing.T) {
+	assert := assert.New(t)
+	h, err := NewHandler(NewList(nil, nil), handler.AuthorizationHandler{}, nil)
+	assert.Nil(h)
+	assert.Equal(ErrNoAuthorization, err)
+}
+
+func testNewHandlerAuthorization(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+		list    = NewList(nil, nil)
+
+		authorization = handler.AuthorizationHandler{
+			Validator: secure.ValidatorFunc(func(_ context.Context, token *secure.Token) (bool, error) {
+				return token.Value() == "valid", nil
+			}),
+			Logger: logging.NewTestLogger(nil, t),
+		}
+	)
+
+	h, err := NewHandler(list, authorization, logging.NewTestLogger(nil, t))
+	require.NoError(err)
+	require.NotNil(h)
+
+	for _, authorizationValue := range []string{"", "Basic invalid"} {
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/revocations", strings.NewReader(`{"jti": ["revoked"]}`))
+		if len(authorizationValue) > 0 {
+			request.Header.Set(secure.AuthorizationHeader, authorizationValue)
+		}
+
+		h.ServeHTTP(response, request)
+		assert.Equal(http.StatusForbidden, response.Code)
+		assert.Zero(list.Entries().Len())
+	}
+
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/revocations", strings.NewReader(`{"jti": ["revoked"]}`))
+	request.Header.Set(secure.AuthorizationHeader, "Basic valid")
+	h.ServeHTTP(response, request)
+	assert.Equal(http.StatusAccepted, response.Code)
+	assert.Equal(Entries{TokenIDs: []string{"revoked"}}, list.Entries())
+}
+
+func TestNewHandler(t *testing.T) {
+	t.Run("NoAuthorization", testNewHandlerNoAuthorization)
+	t.Run("Authorization", testNewHandlerAuthorization)
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("Post", func(t *testing.T) { testHandlerAdd(t, "POST") })
+	t.Run("Put", func(t *testing.T) { testHandlerAdd(t, "PUT") })
+	t.Run("InvalidJSON", func(t *testing.T) { testHandlerBadRequest(t, "this is not JSON") })
+	t.Run("NoEntries", func(t *testing.T) { testHandlerBadRequest(t, "{}") })
+	t.Run("MethodNotAllowed", testHandlerMethodNotAllowed)
+}
@@ -0,0 +1,258 @@
+package revocation
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Comcast/webpa-common/resource"
+	"github.com/SermoDigital/jose/jws"
+)
+
+const (
+	// TokenIDClaim is the claim holding a token's unique identifier
+	TokenIDClaim = "jti"
+
+	// SubjectClaim is the claim holding a token's subject
+	SubjectClaim = "sub"
+
+	// ClientIDClaim is the claim holding the client a token was issued to
+	ClientIDClaim = "client_id"
+)
+
+// Entries is the serialized form of a revocation list, both as loaded from a resource
+// and as exchanged with the admin Handler
+type Entries struct {
+	TokenIDs  []string `json:"jti,omitempty"`
+	Subjects  []string `json:"sub,omitempty"`
+	ClientIDs []string `json:"client_id,omitempty"`
+}
+
+// Len returns the total number of entries
+func (e Entries) Len() int {
+	return len(e.TokenIDs) + len(e.Subjects) + len(e.ClientIDs)
+}
+
+type set map[string]bool
+
+// add inserts the given values, ignoring blanks, and returns the values which were not already present
+func (s set) add(values []string) (added []string) {
+	for _, v := range values {
+		if len(v) > 0 && !s[v] {
+			s[v] = true
+			added = append(added, v)
+		}
+	}
+
+	return
+}
+
+// with returns a set containing this set's values together with the given values.  If every value
+// is already present, this set is returned as is.  Otherwise, this set is copied and left unmodified.
+func (s set) with(values []string) set {
+	missing := false
+	for _, v := range values {
+		if len(v) > 0 && !s[v] {
+			missing = true
+			break
+		}
+	}
+
+	if !missing {
+		return s
+	}
+
+	c := make(set, len(s)+len(values))
+	for v := range s {
+		c[v] = true
+	}
+
+	c.add(values)
+	return c
+}
+
+func (s set) values() []string {
+	if len(s) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(s))
+	for v := range s {
+		values = append(values, v)
+	}
+
+	return values
+}
+
+// sets is an immutable, indexed form of Entries.  Once created, a sets is never modified,
+// which allows lookups without locking.
+type sets struct {
+	tokenIDs  set
+	subjects  set
+	clientIDs set
+}
+
+func newSets(entries ...Entries) *sets {
+	s := &sets{
+		tokenIDs:  make(set),
+		subjects:  make(set),
+		clientIDs: make(set),
+	}
+
+	for _, e := range entries {
+		s.tokenIDs.add(e.TokenIDs)
+		s.subjects.add(e.Subjects)
+		s.clientIDs.add(e.ClientIDs)
+	}
+
+	return s
+}
+
+// with returns a *sets that also holds the given entries.  Only the sets that change are copied,
+// the rest are shared with this *sets.
+func (s *sets) with(e Entries) *sets {
+	return &sets{
+		tokenIDs:  s.tokenIDs.with(e.TokenIDs),
+		subjects:  s.subjects.with(e.Subjects),
+		clientIDs: s.clientIDs.with(e.ClientIDs),
+	}
+}
+
+func (s *sets) len() int {
+	return len(s.tokenIDs) + len(s.subjects) + len(s.clientIDs)
+}
+
+func (s *sets) entries() Entries {
+	return Entries{
+		TokenIDs:  s.tokenIDs.values(),
+		Subjects:  s.subjects.values(),
+		ClientIDs: s.clientIDs.values(),
+	}
+}
+
+// revoked returns the claim by which the given claims are revoked
+func (s *sets) revoked(claims jws.Claims) (string, bool) {
+	if jti, ok := claims.Get(TokenIDClaim).(string); ok && s.tokenIDs[jti] {
+		return TokenIDClaim, true
+	}
+
+	if sub, ok := claims.Get(SubjectClaim).(string); ok && s.subjects[sub] {
+		return SubjectClaim, true
+	}
+
+	if clientID, ok := claims.Get(ClientIDClaim).(string); ok && s.clientIDs[clientID] {
+		return ClientIDClaim, true
+	}
+
+	return "", false
+}
+
+// List is a revocation list.  Entries come from two places:  a resource, which is periodically
+// reloaded, and local additions, which survive reloads for the life of the process.
+//
+// List implements secure.RevocationList.
+type List struct {
+	loader   resource.Loader
+	measures *Measures
+
+	// current is the merged *sets of loaded and local entries
+	current atomic.Value
+
+	updateLock sync.Mutex
+	loaded     Entries
+	local      *sets
+}
+
+// NewList creates a List which loads entries from the given resource.  If loader is nil, the
+// List only holds local additions.  The returned List is empty until Load is called.
+func NewList(loader resource.Loader, measures *Measures) *List {
+	l := &List{
+		loader:   loader,
+		measures: measures,
+		local:    newSets(),
+	}
+
+	l.current.Store(newSets())
+	return l
+}
+
+func (l *List) sets() *sets {
+	return l.current.Load().(*sets)
+}
+
+// store makes the given *sets current.  Must be called under the update lock.
+func (l *List) store(current *sets) {
+	l.current.Store(current)
+
+	if l.measures != nil {
+		l.measures.Entries.Set(float64(current.len()))
+	}
+}
+
+func (l *List) countLoad(outcome string) {
+	if l.measures != nil {
+		l.measures.Load.With(OutcomeLabel, outcome).Add(1)
+	}
+}
+
+// Load replaces the loaded entries with the current contents of this List's resource.  The resource
+// is a JSON-encoded Entries.  If the resource cannot be loaded, the previous entries are retained.
+func (l *List) Load() error {
+	if l.loader == nil {
+		return nil
+	}
+
+	data, err := resource.ReadAll(l.loader)
+	if err != nil {
+		l.countLoad(FailureOutcome)
+		return err
+	}
+
+	var loaded Entries
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		l.countLoad(FailureOutcome)
+		return err
+	}
+
+	l.updateLock.Lock()
+	l.loaded = loaded
+	l.store(newSets(l.loaded, l.local.entries()))
+	l.updateLock.Unlock()
+
+	l.countLoad(SuccessOutcome)
+	return nil
+}
+
+// Add revokes the given entries locally.  Local entries are not written back to the resource.
+// Entries which are already revoked locally are ignored, and the current entries are updated in
+// place of a full rebuild.
+func (l *List) Add(entries Entries) {
+	l.updateLock.Lock()
+	defer l.updateLock.Unlock()
+
+	added := Entries{
+		TokenIDs:  l.local.tokenIDs.add(entries.TokenIDs),
+		Subjects:  l.local.subjects.add(entries.Subjects),
+		ClientIDs: l.local.clientIDs.add(entries.ClientIDs),
+	}
+
+	if added.Len() > 0 {
+		l.store(l.sets().with(added))
+	}
+}
+
+// Entries returns a snapshot of all revoked entries, without duplicates and in no particular order
+func (l *List) Entries() Entries {
+	return l.sets().entries()
+}
+
+// Revoked tests if a token with the given claims has been revoked.  If so, the name of
+// the claim by which it was revoked is returned.
+func (l *List) Revoked(claims jws.Claims) (string, bool) {
+	claim, revoked := l.sets().revoked(claims)
+	if revoked && l.measures != nil {
+		l.measures.RevokedToken.With(ClaimLabel, claim).Add(1)
+	}
+
+	return claim, revoked
+}
@@ -0,0 +1,180 @@
+package revocation
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Comcast/webpa-common/xmetrics/xmetricstest"
+	"github.com/SermoDigital/jose/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLoader is a resource.Loader whose data or error can be changed between loads
+type testLoader struct {
+	data string
+	err  error
+}
+
+func (l *testLoader) Location() string {
+	return "test"
+}
+
+func (l *testLoader) Open() (io.ReadCloser, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	return ioutil.NopCloser(strings.NewReader(l.data)), nil
+}
+
+func sorted(values []string) []string {
+	sort.Strings(values)
+	return values
+}
+
+func TestEntriesLen(t *testing.T) {
+	assert := assert.New(t)
+	assert.Zero(Entries{}.Len())
+	assert.Equal(6, Entries{TokenIDs: []string{"1"}, Subjects: []string{"2", "3"}, ClientIDs: []string{"4", "5", "6"}}.Len())
+}
+
+func testListEmpty(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		list   = NewList(nil, nil)
+	)
+
+	assert.NoError(list.Load())
+	assert.Equal(Entries{}, list.Entries())
+
+	claim, revoked := list.Revoked(jws.Claims{"jti": "123", "sub": "test"})
+	assert.Empty(claim)
+	assert.False(revoked)
+}
+
+func testListRevoked(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+		loader   = &testLoader{data: `{"jti": ["revoked-token"], "sub": ["revoked-subject"], "client_id": ["revoked-client"]}`}
+		list     = NewList(loader, NewMeasures(provider))
+	)
+
+	require.NoError(list.Load())
+	provider.Assert(t, LoadCounter, OutcomeLabel, SuccessOutcome)(xmetricstest.Value(1.0))
+	provider.Assert(t, EntriesGauge)(xmetricstest.Value(3.0))
+
+	testData := []struct {
+		claims        jws.Claims
+		expectedClaim string
+	}{
+		{jws.Claims{}, ""},
+		{jws.Claims{"jti": "valid", "sub": "valid", "client_id": "valid"}, ""},
+		{jws.Claims{"jti": "revoked-token", "sub": "valid"}, TokenIDClaim},
+		{jws.Claims{"jti": "valid", "sub": "revoked-subject"}, SubjectClaim},
+		{jws.Claims{"jti": "valid", "sub": "valid", "client_id": "revoked-client"}, ClientIDClaim},
+		{jws.Claims{"jti": 123, "sub": []interface{}{"revoked-subject"}}, ""},
+	}
+
+	for i, record := range testData {
+		t.Logf("#%d", i)
+		claim, revoked := list.Revoked(record.claims)
+		assert.Equal(record.expectedClaim, claim)
+		assert.Equal(len(record.expectedClaim) > 0, revoked)
+	}
+
+	provider.Assert(t, RevokedTokenCounter, ClaimLabel, TokenIDClaim)(xmetricstest.Value(1.0))
+	provider.Assert(t, RevokedTokenCounter, ClaimLabel, SubjectClaim)(xmetricstest.Value(1.0))
+	provider.Assert(t, RevokedTokenCounter, ClaimLabel, ClientIDClaim)(xmetricstest.Value(1.0))
+}
+
+func testListReload(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+		loader   = &testLoader{data: `{"jti": ["first"]}`}
+		list     = NewList(loader, NewMeasures(provider))
+	)
+
+	require.NoError(list.Load())
+	list.Add(Entries{Subjects: []string{"local"}, TokenIDs: []string{"first"}})
+
+	// a failed load retains the previous entries
+	loader.err = errors.New("expected")
+	assert.Equal(loader.err, list.Load())
+	loader.err = nil
+
+	loader.data = "this is not JSON"
+	assert.Error(list.Load())
+
+	_, revoked := list.Revoked(jws.Claims{"jti": "first"})
+	assert.True(revoked)
+	provider.Assert(t, LoadCounter, OutcomeLabel, FailureOutcome)(xmetricstest.Value(2.0))
+
+	// a successful load replaces the loaded entries, but local entries survive
+	loader.data = `{"jti": ["second"]}`
+	require.NoError(list.Load())
+
+	entries := list.Entries()
+	assert.Equal([]string{"first", "second"}, sorted(entries.TokenIDs))
+	assert.Equal([]string{"local"}, entries.Subjects)
+	assert.Empty(entries.ClientIDs)
+	provider.Assert(t, EntriesGauge)(xmetricstest.Value(3.0))
+
+	_, revoked = list.Revoked(jws.Claims{"sub": "local"})
+	assert.True(revoked)
+}
+
+func testListAdd(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+		loader   = &testLoader{data: `{"sub": ["loaded"]}`}
+		list     = NewList(loader, NewMeasures(provider))
+	)
+
+	require.NoError(list.Load())
+	list.Add(Entries{TokenIDs: []string{"first", "first", ""}, ClientIDs: []string{"client"}})
+	provider.Assert(t, EntriesGauge)(xmetricstest.Value(3.0))
+
+	// duplicates of loaded or local entries do not change the list
+	before := list.sets()
+	list.Add(Entries{TokenIDs: []string{"first"}, Subjects: []string{"loaded"}})
+	assert.Equal(before.entries(), list.Entries())
+	provider.Assert(t, EntriesGauge)(xmetricstest.Value(3.0))
+
+	// only the sets with new entries are copied, and the previous sets are unmodified
+	list.Add(Entries{TokenIDs: []string{"second"}})
+	after := list.sets()
+	assert.Equal([]string{"first"}, before.tokenIDs.values())
+	assert.Equal([]string{"first", "second"}, sorted(after.tokenIDs.values()))
+	assert.Equal([]string{"loaded"}, after.subjects.values())
+	assert.Equal([]string{"client"}, after.clientIDs.values())
+	provider.Assert(t, EntriesGauge)(xmetricstest.Value(4.0))
+
+	// local entries are not duplicated across reloads
+	require.NoError(list.Load())
+	entries := list.Entries()
+	assert.Equal([]string{"first", "second"}, sorted(entries.TokenIDs))
+	assert.Equal([]string{"loaded"}, entries.Subjects)
+	assert.Equal([]string{"client"}, entries.ClientIDs)
+	provider.Assert(t, EntriesGauge)(xmetricstest.Value(4.0))
+
+	_, revoked := list.Revoked(jws.Claims{"jti": "second"})
+	assert.True(revoked)
+}
+
+func TestList(t *testing.T) {
+	t.Run("Empty", testListEmpty)
+	t.Run("Revoked", testListRevoked)
+	t.Run("Reload", testListReload)
+	t.Run("Add", testListAdd)
+}
@@ -0,0 +1,61 @@
+package revocation
+
+import (
+	"github.com/Comcast/webpa-common/xmetrics"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+)
+
+const (
+	RevokedTokenCounter = "revoked_token_count"
+	LoadCounter         = "revocation_list_load_count"
+	EntriesGauge        = "revocation_list_entries"
+
+	// ClaimLabel is the label for the claim by which a token was revoked
+	ClaimLabel = "claim"
+
+	// OutcomeLabel is the label for the outcome of loading the revocation list
+	OutcomeLabel = "outcome"
+
+	SuccessOutcome = "success"
+	FailureOutcome = "failure"
+)
+
+// Metrics is the revocation module function that adds revocation list metrics
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name:       RevokedTokenCounter,
+			Type:       "counter",
+			Help:       "The number of tokens rejected because they were revoked",
+			LabelNames: []string{ClaimLabel},
+		},
+		{
+			Name:       LoadCounter,
+			Type:       "counter",
+			Help:       "The number of attempts to load the revocation list",
+			LabelNames: []string{OutcomeLabel},
+		},
+		{
+			Name: EntriesGauge,
+			Type: "gauge",
+			Help: "The number of entries in the revocation list, including local entries",
+		},
+	}
+}
+
+// Measures is the set of metrics used by a revocation List
+type Measures struct {
+	RevokedToken metrics.Counter
+	Load         metrics.Counter
+	Entries      metrics.Gauge
+}
+
+// NewMeasures constructs a Measures given a go-kit metrics Provider
+func NewMeasures(p provider.Provider) *Measures {
+	return &Measures{
+		RevokedToken: p.NewCounter(RevokedTokenCounter),
+		Load:         p.NewCounter(LoadCounter),
+		Entries:      p.NewGauge(EntriesGauge),
+	}
+}
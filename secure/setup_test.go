@@ -1,12 +1,14 @@
 package secure
 
 import (
+	"crypto/rsa"
 	"fmt"
 	"github.com/Comcast/webpa-common/resource"
 	"github.com/Comcast/webpa-common/secure/key"
 	"github.com/SermoDigital/jose/crypto"
 	"github.com/SermoDigital/jose/jws"
 	"github.com/SermoDigital/jose/jwt"
+	"math/big"
 	"os"
 	"testing"
 )
@@ -36,6 +38,9 @@ var (
 
 	testJWT           jwt.JWT
 	testSerializedJWT []byte
+
+	// testRSAPublicKey is a placeholder RSA key for tests that mock out signature verification
+	testRSAPublicKey = &rsa.PublicKey{N: big.NewInt(123), E: 65537}
 )
 
 func TestMain(m *testing.M) {
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Comcast/webpa-common/resource"
+	"github.com/Comcast/webpa-common/types"
 	"io/ioutil"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +15,12 @@ const (
 	DefaultIssuer      = "test"
 	DefaultBits        = 4096
 	DefaultBindAddress = ":8080"
+
+	// KeyTypeRSA indicates that generated keys are RSA keys.  This is the default.
+	KeyTypeRSA = "RSA"
+
+	// KeyTypeEC indicates that generated keys are P-256 elliptic curve keys, for use with ES256
+	KeyTypeEC = "EC"
 )
 
 var (
@@ -20,8 +28,26 @@ var (
 	ErrorBlankKeyId      = errors.New("Blank key identifiers are not allowed")
 	ErrorInvalidKeyId    = errors.New("Key identifiers cannot have leading or trailing whitespace")
 	ErrorNoConfiguration = errors.New("A configuration file is required")
+	ErrorInvalidKeyType  = fmt.Errorf("The key type must be either %s or %s", KeyTypeRSA, KeyTypeEC)
+	ErrorInvalidInterval = errors.New("Rotation intervals must be positive, and overlaps cannot be negative")
 )
 
+// RotationConfiguration describes a key which is periodically replaced.  Each version of the key
+// has a key identifier of the form <name>-v<version>, e.g. rotating-v3.  When a JWT is issued using
+// the name as the kid, the current version is used.
+type RotationConfiguration struct {
+	// Name is the base key identifier for each version of this key
+	Name string `json:"name"`
+
+	// Interval is how often a new version of this key is generated
+	Interval types.Duration `json:"interval"`
+
+	// Overlap is how long a replaced version of this key continues to be published, so
+	// that JWTs signed with it can still be verified.  Replaced versions are never used
+	// to issue JWTs.
+	Overlap types.Duration `json:"overlap"`
+}
+
 // Configuration provides the basic, JSON-marshallable configuration for
 // the keyserver.
 type Configuration struct {
@@ -40,35 +66,111 @@ type Configuration struct {
 	Bits int `json:"bits"`
 
 	// Generate is a list of key identifiers which will be generated
-	// each time this server starts.
+	// each time this server starts, unless they were persisted to the KeyDirectory.
 	Generate []string `json:"generate"`
+
+	// KeyType is the type of any keys generated by the server, either KeyTypeRSA or KeyTypeEC.
+	// If unset, KeyTypeRSA is used.
+	KeyType string `json:"keyType"`
+
+	// Rotate is the set of keys which are periodically replaced with new versions
+	Rotate []RotationConfiguration `json:"rotate"`
+
+	// KeyDirectory is where generated keys are stored, as PEM files named <kid>.pem.  RSA keys are
+	// stored in PKCS#1 form and EC keys in SEC 1 form.  Stored keys are reused when the server restarts.
+	// If unset, generated keys are only held in memory.
+	KeyDirectory string `json:"keyDirectory"`
+}
+
+func validateKeyID(keyID string) error {
+	trimmedKeyId := strings.TrimSpace(keyID)
+	if len(trimmedKeyId) == 0 {
+		return ErrorBlankKeyId
+	} else if trimmedKeyId != keyID {
+		return ErrorInvalidKeyId
+	}
+
+	return nil
+}
+
+// isVersionOf tests if a key identifier has the form <name>-v<version>, i.e. it is the identifier
+// of some version of the given rotated key
+func isVersionOf(keyID, name string) bool {
+	prefix := name + "-v"
+	if !strings.HasPrefix(keyID, prefix) {
+		return false
+	}
+
+	version, err := strconv.Atoi(keyID[len(prefix):])
+	return err == nil && version > 0
 }
 
 func (c *Configuration) Validate() error {
-	if len(c.Keys) == 0 && len(c.Generate) == 0 {
+	if len(c.Keys) == 0 && len(c.Generate) == 0 && len(c.Rotate) == 0 {
 		return ErrorNoKeys
 	}
 
+	switch c.KeyType {
+	case "", KeyTypeRSA, KeyTypeEC:
+	default:
+		return ErrorInvalidKeyType
+	}
+
 	for keyID := range c.Keys {
-		trimmedKeyId := strings.TrimSpace(keyID)
-		if len(trimmedKeyId) == 0 {
-			return ErrorBlankKeyId
-		} else if trimmedKeyId != keyID {
-			return ErrorInvalidKeyId
+		if err := validateKeyID(keyID); err != nil {
+			return err
 		}
 	}
 
+	generated := make(map[string]bool, len(c.Generate))
 	for _, keyID := range c.Generate {
-		trimmedKeyId := strings.TrimSpace(keyID)
-		if len(trimmedKeyId) == 0 {
-			return ErrorBlankKeyId
-		} else if trimmedKeyId != keyID {
-			return ErrorInvalidKeyId
+		if err := validateKeyID(keyID); err != nil {
+			return err
 		}
 
 		if _, ok := c.Keys[keyID]; ok {
 			return fmt.Errorf("Key %s is ambiguous: it occurs in keys and generate", keyID)
 		}
+
+		generated[keyID] = true
+	}
+
+	rotated := make(map[string]bool, len(c.Rotate))
+	for _, r := range c.Rotate {
+		if err := validateKeyID(r.Name); err != nil {
+			return err
+		}
+
+		if _, ok := c.Keys[r.Name]; ok || generated[r.Name] || rotated[r.Name] {
+			return fmt.Errorf("Key %s is ambiguous: it occurs more than once in keys, generate, or rotate", r.Name)
+		}
+
+		if r.Interval <= 0 || r.Overlap < 0 {
+			return ErrorInvalidInterval
+		}
+
+		rotated[r.Name] = true
+	}
+
+	// the versions of a rotated key cannot collide with any other key
+	for name := range rotated {
+		for keyID := range c.Keys {
+			if isVersionOf(keyID, name) {
+				return fmt.Errorf("Key %s collides with a version of the rotated key %s", keyID, name)
+			}
+		}
+
+		for keyID := range generated {
+			if isVersionOf(keyID, name) {
+				return fmt.Errorf("Key %s collides with a version of the rotated key %s", keyID, name)
+			}
+		}
+
+		for other := range rotated {
+			if isVersionOf(other, name) {
+				return fmt.Errorf("Key %s collides with a version of the rotated key %s", other, name)
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/resource"
+	"github.com/Comcast/webpa-common/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurationValidate(t *testing.T) {
+	var (
+		hourly = func(name string) RotationConfiguration {
+			return RotationConfiguration{Name: name, Interval: types.Duration(time.Hour), Overlap: types.Duration(time.Hour)}
+		}
+
+		testData = []struct {
+			name          string
+			configuration Configuration
+			expectError   bool
+		}{
+			{"NoKeys", Configuration{}, true},
+			{"Generate", Configuration{Generate: []string{"test"}}, false},
+			{"InvalidKeyType", Configuration{Generate: []string{"test"}, KeyType: "DSA"}, true},
+			{"BlankKeyID", Configuration{Generate: []string{""}}, true},
+			{"Whitespace", Configuration{Generate: []string{" test"}}, true},
+			{"GenerateAndKeys", Configuration{Generate: []string{"test"}, Keys: map[string]*resource.Factory{"test": new(resource.Factory)}}, true},
+			{"Rotate", Configuration{Generate: []string{"test"}, Rotate: []RotationConfiguration{hourly("rotating")}}, false},
+			{"RotateTwice", Configuration{Rotate: []RotationConfiguration{hourly("rotating"), hourly("rotating")}}, true},
+			{"RotateAndGenerate", Configuration{Generate: []string{"rotating"}, Rotate: []RotationConfiguration{hourly("rotating")}}, true},
+			{"NoInterval", Configuration{Rotate: []RotationConfiguration{{Name: "rotating"}}}, true},
+			{"NegativeOverlap", Configuration{Rotate: []RotationConfiguration{{Name: "rotating", Interval: 1, Overlap: -1}}}, true},
+			{"GeneratedVersion", Configuration{Generate: []string{"rotating-v2"}, Rotate: []RotationConfiguration{hourly("rotating")}}, true},
+			{"KeyVersion", Configuration{Keys: map[string]*resource.Factory{"rotating-v1": new(resource.Factory)}, Rotate: []RotationConfiguration{hourly("rotating")}}, true},
+			{"RotatedVersion", Configuration{Rotate: []RotationConfiguration{hourly("rotating"), hourly("rotating-v3")}}, true},
+			{"SimilarNames", Configuration{Generate: []string{"rotating-v", "rotating-v0", "rotating-vx", "rotating-2"}, Rotate: []RotationConfiguration{hourly("rotating")}}, false},
+		}
+	)
+
+	for _, record := range testData {
+		t.Run(record.name, func(t *testing.T) {
+			err := record.configuration.Validate()
+			assert.Equal(t, record.expectError, err != nil, "unexpected error: %s", err)
+		})
+	}
+}
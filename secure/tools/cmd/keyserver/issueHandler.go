@@ -1,7 +1,11 @@
 package main
 
 import (
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,6 +16,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +24,11 @@ const (
 	KeyIDVariableName        = "kid"
 	DefaultExpireDuration    = time.Duration(24 * time.Hour)
 	DefaultNotBeforeDuration = time.Duration(1 * time.Hour)
+
+	// ClaimParameterPrefix is the prefix for URL parameters that are custom claims, e.g. claim.partner-id=comcast.
+	// Values that are valid JSON, such as numbers or arrays, are issued as the corresponding JSON type.  All other
+	// values are issued as strings.
+	ClaimParameterPrefix = "claim."
 )
 
 var (
@@ -31,6 +41,9 @@ var (
 		defaultSigningMethod.Alg():      defaultSigningMethod,
 		crypto.SigningMethodRS384.Alg(): crypto.SigningMethodRS384,
 		crypto.SigningMethodRS512.Alg(): crypto.SigningMethodRS512,
+		crypto.SigningMethodES256.Alg(): crypto.SigningMethodES256,
+		crypto.SigningMethodES384.Alg(): crypto.SigningMethodES384,
+		crypto.SigningMethodES512.Alg(): crypto.SigningMethodES512,
 	}
 
 	supportedNumericDateLayouts = []string{
@@ -110,7 +123,8 @@ type SigningMethod struct {
 
 func (s *SigningMethod) UnmarshalText(raw []byte) error {
 	if len(raw) == 0 {
-		*s = SigningMethod{defaultSigningMethod}
+		// the default is chosen based on the signing key
+		*s = SigningMethod{}
 		return nil
 	}
 
@@ -125,7 +139,8 @@ func (s *SigningMethod) UnmarshalText(raw []byte) error {
 }
 
 // IssueRequest contains the information necessary for issuing a JWS.
-// Any custom claims must be transmitted separately.
+// Any custom claims must be transmitted separately, either as a JSON body or
+// as URL parameters with the ClaimParameterPrefix.
 type IssueRequest struct {
 	Now time.Time `schema:"-"`
 
@@ -140,12 +155,48 @@ type IssueRequest struct {
 	Audience *[]string `schema:"aud"`
 }
 
-func (ir *IssueRequest) SigningMethod() crypto.SigningMethod {
-	if ir.Algorithm != nil {
-		return ir.Algorithm.SigningMethod
+// ecSigningMethod returns the ES algorithm for the curve of an ECDSA key, or nil if the curve is not supported
+func ecSigningMethod(ecKey *ecdsa.PrivateKey) crypto.SigningMethod {
+	switch ecKey.Curve {
+	case elliptic.P256():
+		return crypto.SigningMethodES256
+	case elliptic.P384():
+		return crypto.SigningMethodES384
+	case elliptic.P521():
+		return crypto.SigningMethodES512
+	default:
+		return nil
+	}
+}
+
+// SigningMethod returns the requested signing method, or the default for the given key.  ECDSA keys
+// default to the ES algorithm for their curve, while all other keys default to RS256.  An error is
+// returned if the requested signing method cannot be used with the key, including an ES algorithm
+// for a different curve than the key's.
+func (ir *IssueRequest) SigningMethod(signingKey stdcrypto.Signer) (crypto.SigningMethod, error) {
+	ecKey, isEC := signingKey.(*ecdsa.PrivateKey)
+	if ir.Algorithm != nil && ir.Algorithm.SigningMethod != nil {
+		signingMethod := ir.Algorithm.SigningMethod
+		if _, isRSA := signingKey.(*rsa.PrivateKey); isRSA != strings.HasPrefix(signingMethod.Alg(), "RS") || isEC != strings.HasPrefix(signingMethod.Alg(), "ES") {
+			return nil, fmt.Errorf("The algorithm %s cannot be used with key %s", signingMethod.Alg(), ir.KeyID)
+		}
+
+		if isEC {
+			if expected := ecSigningMethod(ecKey); expected == nil || expected.Alg() != signingMethod.Alg() {
+				return nil, fmt.Errorf("The algorithm %s cannot be used with the curve of key %s", signingMethod.Alg(), ir.KeyID)
+			}
+		}
+
+		return signingMethod, nil
 	}
 
-	return defaultSigningMethod
+	if isEC {
+		if signingMethod := ecSigningMethod(ecKey); signingMethod != nil {
+			return signingMethod, nil
+		}
+	}
+
+	return defaultSigningMethod, nil
 }
 
 // AddToHeader adds the appropriate header information from this issue request
@@ -206,18 +257,60 @@ func (ir *IssueRequest) AddToClaims(claims jwt.Claims) error {
 	return nil
 }
 
-func NewIssueRequest(decoder *schema.Decoder, source map[string][]string) (*IssueRequest, error) {
+// claimValue converts a custom claim URL parameter into a claim value
+func claimValue(values []string) interface{} {
+	converted := make([]interface{}, len(values))
+	for i, v := range values {
+		var value interface{}
+		if err := json.Unmarshal([]byte(v), &value); err == nil {
+			converted[i] = value
+		} else {
+			converted[i] = v
+		}
+	}
+
+	if len(converted) == 1 {
+		return converted[0]
+	}
+
+	return converted
+}
+
+// splitClaims separates custom claim URL parameters from the parameters that describe an IssueRequest
+func splitClaims(source map[string][]string) (map[string][]string, jwt.Claims) {
+	var (
+		parameters = make(map[string][]string, len(source))
+		claims     = make(jwt.Claims)
+	)
+
+	for name, values := range source {
+		if strings.HasPrefix(name, ClaimParameterPrefix) {
+			if claimName := name[len(ClaimParameterPrefix):]; len(claimName) > 0 && len(values) > 0 {
+				claims[claimName] = claimValue(values)
+			}
+		} else {
+			parameters[name] = values
+		}
+	}
+
+	return parameters, claims
+}
+
+// NewIssueRequest decodes an IssueRequest from URL parameters.  Any custom claims in the parameters
+// are returned separately.
+func NewIssueRequest(decoder *schema.Decoder, source map[string][]string) (*IssueRequest, jwt.Claims, error) {
+	parameters, claims := splitClaims(source)
 	issueRequest := &IssueRequest{}
-	if err := decoder.Decode(issueRequest, source); err != nil {
-		return nil, err
+	if err := decoder.Decode(issueRequest, parameters); err != nil {
+		return nil, nil, err
 	}
 
 	if len(issueRequest.KeyID) == 0 {
-		return nil, ErrorMissingKeyID
+		return nil, nil, ErrorMissingKeyID
 	}
 
 	issueRequest.Now = time.Now()
-	return issueRequest, nil
+	return issueRequest, claims, nil
 }
 
 // IssueHandler issues JWS tokens
@@ -229,17 +322,25 @@ type IssueHandler struct {
 
 // issue handles all the common logic for issuing a JWS token
 func (handler *IssueHandler) issue(response http.ResponseWriter, issueRequest *IssueRequest, claims jwt.Claims) {
-	issueKey, ok := handler.keyStore.PrivateKey(issueRequest.KeyID)
+	keyID, issueKey, ok := handler.keyStore.PrivateKey(issueRequest.KeyID)
 	if !ok {
 		handler.httpError(response, http.StatusBadRequest, fmt.Sprintf("No such key: %s", issueRequest.KeyID))
 		return
 	}
 
+	signingMethod, err := issueRequest.SigningMethod(issueKey)
+	if err != nil {
+		handler.httpError(response, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	if claims == nil {
 		claims = make(jwt.Claims)
 	}
 
-	issuedJWT := jws.NewJWT(jws.Claims(claims), issueRequest.SigningMethod())
+	// the kid in the JWT is always the actual key identifier, e.g. the current version of a rotated key
+	issueRequest.KeyID = keyID
+	issuedJWT := jws.NewJWT(jws.Claims(claims), signingMethod)
 	if err := issueRequest.AddToClaims(issuedJWT.Claims()); err != nil {
 		handler.httpError(response, http.StatusInternalServerError, err.Error())
 		return
@@ -269,13 +370,13 @@ func (handler *IssueHandler) SimpleIssue(response http.ResponseWriter, request *
 		return
 	}
 
-	issueRequest, err := NewIssueRequest(handler.decoder, request.Form)
+	issueRequest, claims, err := NewIssueRequest(handler.decoder, request.Form)
 	if err != nil {
 		handler.httpError(response, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	handler.issue(response, issueRequest, nil)
+	handler.issue(response, issueRequest, claims)
 }
 
 // IssueUsingBody accepts a JSON claims document, to which it then adds all the standard
@@ -287,14 +388,14 @@ func (handler *IssueHandler) IssueUsingBody(response http.ResponseWriter, reques
 		return
 	}
 
-	issueRequest, err := NewIssueRequest(handler.decoder, request.Form)
+	issueRequest, claims, err := NewIssueRequest(handler.decoder, request.Form)
 	if err != nil {
 		handler.httpError(response, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// this variant reads the claims directly from the request body
-	claims := make(jwt.Claims)
+	// this variant reads the claims directly from the request body, with any
+	// claim parameters taking precedence
 	if request.Body != nil {
 		body, err := ioutil.ReadAll(request.Body)
 		if err != nil {
@@ -304,10 +405,17 @@ func (handler *IssueHandler) IssueUsingBody(response http.ResponseWriter, reques
 
 		if len(body) > 0 {
 			// we don't want to uses the Claims unmarshalling logic, as that assumes base64
-			if err := json.Unmarshal(body, (*map[string]interface{})(&claims)); err != nil {
+			var bodyClaims map[string]interface{}
+			if err := json.Unmarshal(body, &bodyClaims); err != nil {
 				handler.httpError(response, http.StatusBadRequest, fmt.Sprintf("Unable to parse JSON in request body: %s", err))
 				return
 			}
+
+			for name, value := range bodyClaims {
+				if _, exists := claims[name]; !exists {
+					claims[name] = value
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,68 @@
+package main
+
+import (
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/SermoDigital/jose/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueRequestSigningMethod(t *testing.T) {
+	var (
+		require = require.New(t)
+
+		rsaKey, rsaErr   = rsa.GenerateKey(rand.Reader, 1024)
+		p256Key, p256Err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		p384Key, p384Err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	)
+
+	require.NoError(rsaErr)
+	require.NoError(p256Err)
+	require.NoError(p384Err)
+
+	testData := []struct {
+		name       string
+		signingKey stdcrypto.Signer
+		requested  crypto.SigningMethod
+		expected   crypto.SigningMethod
+	}{
+		{"RSADefault", rsaKey, nil, crypto.SigningMethodRS256},
+		{"RSA", rsaKey, crypto.SigningMethodRS512, crypto.SigningMethodRS512},
+		{"RSAWithES", rsaKey, crypto.SigningMethodES256, nil},
+		{"P256Default", p256Key, nil, crypto.SigningMethodES256},
+		{"P256", p256Key, crypto.SigningMethodES256, crypto.SigningMethodES256},
+		{"P256WithES384", p256Key, crypto.SigningMethodES384, nil},
+		{"P256WithES512", p256Key, crypto.SigningMethodES512, nil},
+		{"P256WithRS", p256Key, crypto.SigningMethodRS256, nil},
+		{"P384Default", p384Key, nil, crypto.SigningMethodES384},
+		{"P384WithES256", p384Key, crypto.SigningMethodES256, nil},
+	}
+
+	for _, record := range testData {
+		t.Run(record.name, func(t *testing.T) {
+			var (
+				assert       = assert.New(t)
+				issueRequest = &IssueRequest{KeyID: "test"}
+			)
+
+			if record.requested != nil {
+				issueRequest.Algorithm = &SigningMethod{record.requested}
+			}
+
+			actual, err := issueRequest.SigningMethod(record.signingKey)
+			if record.expected == nil {
+				assert.Nil(actual)
+				assert.Error(err)
+			} else {
+				assert.Equal(record.expected, actual)
+				assert.NoError(err)
+			}
+		})
+	}
+}
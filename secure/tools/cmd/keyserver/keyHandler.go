@@ -1,10 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
-	"strings"
 )
 
 // KeyHandler handles key-related requests
@@ -39,9 +39,34 @@ func (handler *KeyHandler) GetKey(response http.ResponseWriter, request *http.Re
 }
 
 func (handler *KeyHandler) ListKeys(response http.ResponseWriter, request *http.Request) {
-	keyIDs := handler.keyStore.KeyIDs()
-	response.Header().Set("Content-Type", "application/json;charset=UTF-8")
-	response.Write(
-		[]byte(fmt.Sprintf(`{"keyIds": [%s]}`, strings.Join(keyIDs, ","))),
+	keyIDs, err := json.Marshal(
+		map[string][]string{"keyIds": handler.keyStore.KeyIDs()},
 	)
+
+	if err != nil {
+		handler.httpError(response, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	response.Write(keyIDs)
+}
+
+// JWKS writes the public keys of all current keys as a JSON Web Key Set (RFC 7517), suitable
+// for clients that discover verification keys via a jwks_uri.
+func (handler *KeyHandler) JWKS(response http.ResponseWriter, request *http.Request) {
+	jwks, err := handler.keyStore.JWKS()
+	if err != nil {
+		handler.httpError(response, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		handler.httpError(response, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	response.Write(data)
 }
@@ -2,77 +2,212 @@ package main
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Comcast/webpa-common/secure/key"
 )
 
+// storedKey is a single signing key held by a KeyStore
+type storedKey struct {
+	privateKey crypto.Signer
+	publicKey  []byte
+
+	// retired is when this key was replaced by a newer version.  Retired keys are
+	// published but never used to issue JWTs.
+	retired time.Time
+}
+
+// rotation tracks the versions of a rotated key
+type rotation struct {
+	RotationConfiguration
+	version int
+}
+
+func (r *rotation) keyID(version int) string {
+	return fmt.Sprintf("%s-v%d", r.Name, version)
+}
+
 // KeyStore provides a single access point for a set of keys, keyed by their key identifiers
-// or kid values in JWTs.
+// or kid values in JWTs.  Rotated keys are also accessible via their rotation name, which
+// always refers to the current version.
 type KeyStore struct {
-	keyIDs      []string
-	privateKeys map[string]*rsa.PrivateKey
-	publicKeys  map[string][]byte
+	infoLogger *log.Logger
+	bits       int
+	keyType    string
+	directory  string
+	now        func() time.Time
+
+	lock      sync.RWMutex
+	keys      map[string]*storedKey
+	rotations map[string]*rotation
 }
 
 func (ks *KeyStore) Len() int {
-	return len(ks.keyIDs)
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+	return len(ks.keys)
 }
 
+// KeyIDs returns the sorted identifiers of all the keys in this store, including retired keys
 func (ks *KeyStore) KeyIDs() []string {
-	return ks.keyIDs
+	ks.lock.RLock()
+	keyIDs := make([]string, 0, len(ks.keys))
+	for keyID := range ks.keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+
+	ks.lock.RUnlock()
+	sort.Strings(keyIDs)
+	return keyIDs
 }
 
-func (ks *KeyStore) PrivateKey(keyID string) (privateKey *rsa.PrivateKey, ok bool) {
-	privateKey, ok = ks.privateKeys[keyID]
-	return
+// resolve maps a rotation name onto the key identifier of its current version.  Must be called under the lock.
+func (ks *KeyStore) resolve(keyID string) string {
+	if r, ok := ks.rotations[keyID]; ok {
+		return r.keyID(r.version)
+	}
+
+	return keyID
+}
+
+// PrivateKey returns the key used to issue JWTs with the given key identifier or rotation name, along
+// with the actual key identifier to place into the JWT.  Retired keys are never returned.
+func (ks *KeyStore) PrivateKey(keyID string) (actualKeyID string, privateKey crypto.Signer, ok bool) {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	actualKeyID = ks.resolve(keyID)
+	if sk, exists := ks.keys[actualKeyID]; exists && sk.retired.IsZero() {
+		return actualKeyID, sk.privateKey, true
+	}
+
+	return "", nil, false
 }
 
+// PublicKey returns the PEM-encoded public key for the given key identifier or rotation name
 func (ks *KeyStore) PublicKey(keyID string) (data []byte, ok bool) {
-	data, ok = ks.publicKeys[keyID]
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	if sk, exists := ks.keys[ks.resolve(keyID)]; exists {
+		data, ok = sk.publicKey, true
+	}
+
 	return
 }
 
+// JWKS returns the JSON Web Key Set of all the keys in this store, including retired keys
+func (ks *KeyStore) JWKS() (key.JWKS, error) {
+	keyIDs := ks.KeyIDs()
+	jwks := key.JWKS{Keys: make([]key.JWK, 0, len(keyIDs))}
+
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	for _, keyID := range keyIDs {
+		sk, ok := ks.keys[keyID]
+		if !ok {
+			continue
+		}
+
+		jwk, err := key.NewPublicJWK(keyID, sk.privateKey.Public())
+		if err != nil {
+			return key.JWKS{}, err
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks, nil
+}
+
 // NewKeyStore exchanges a Configuration for a KeyStore.
 func NewKeyStore(infoLogger *log.Logger, c *Configuration) (*KeyStore, error) {
+	return newKeyStore(infoLogger, c, time.Now)
+}
+
+// newKeyStore creates a KeyStore which uses the given clock when reloading persisted rotated keys
+func newKeyStore(infoLogger *log.Logger, c *Configuration, now func() time.Time) (*KeyStore, error) {
 	if err := c.Validate(); err != nil {
 		return nil, err
 	}
 
-	privateKeys := make(map[string]*rsa.PrivateKey, len(c.Keys)+len(c.Generate))
-	if err := resolveKeys(infoLogger, c, privateKeys); err != nil {
-		return nil, err
+	ks := &KeyStore{
+		infoLogger: infoLogger,
+		bits:       c.Bits,
+		keyType:    c.KeyType,
+		directory:  c.KeyDirectory,
+		now:        now,
+		keys:       make(map[string]*storedKey, len(c.Keys)+len(c.Generate)+len(c.Rotate)),
+		rotations:  make(map[string]*rotation, len(c.Rotate)),
 	}
 
-	if err := generateKeys(infoLogger, c, privateKeys); err != nil {
-		return nil, err
+	if ks.bits < 1 {
+		ks.bits = DefaultBits
+	}
+
+	if len(ks.keyType) == 0 {
+		ks.keyType = KeyTypeRSA
 	}
 
-	publicKeys := make(map[string][]byte, len(privateKeys))
-	if err := marshalPublicKeys(publicKeys, privateKeys); err != nil {
+	if len(ks.directory) > 0 {
+		if err := os.MkdirAll(ks.directory, 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ks.resolveKeys(c); err != nil {
 		return nil, err
 	}
 
-	keyIDs := make([]string, 0, len(privateKeys))
-	for keyID := range privateKeys {
-		keyIDs = append(keyIDs, keyID)
+	for _, keyID := range c.Generate {
+		if err := ks.loadOrGenerate(keyID); err != nil {
+			return nil, err
+		}
 	}
 
-	return &KeyStore{
-		keyIDs:      keyIDs,
-		privateKeys: privateKeys,
-		publicKeys:  publicKeys,
-	}, nil
+	for _, rc := range c.Rotate {
+		if err := ks.startRotation(rc); err != nil {
+			return nil, err
+		}
+	}
+
+	return ks, nil
 }
 
-func resolveKeys(infoLogger *log.Logger, c *Configuration, privateKeys map[string]*rsa.PrivateKey) error {
+func (ks *KeyStore) add(keyID string, privateKey crypto.Signer) error {
+	publicKey, err := marshalPublicKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	ks.keys[keyID] = &storedKey{
+		privateKey: privateKey,
+		publicKey:  publicKey,
+	}
+
+	return nil
+}
+
+func (ks *KeyStore) resolveKeys(c *Configuration) error {
 	for keyID, resourceFactory := range c.Keys {
-		infoLogger.Printf("Key [%s]: loading from resource %#v\n", keyID, resourceFactory)
+		ks.infoLogger.Printf("Key [%s]: loading from resource %#v\n", keyID, resourceFactory)
 
 		keyResolver, err := (&key.ResolverFactory{
 			Factory: *resourceFactory,
@@ -88,55 +223,251 @@ func resolveKeys(infoLogger *log.Logger, c *Configuration, privateKeys map[strin
 			return err
 		}
 
-		if resolvedPair.HasPrivate() {
-			privateKeys[keyID] = resolvedPair.Private().(*rsa.PrivateKey)
-		} else {
-			return fmt.Errorf("The key %s did not resolve to an RSA private key", keyID)
+		privateKey, ok := resolvedPair.Private().(crypto.Signer)
+		if !resolvedPair.HasPrivate() || !ok {
+			return fmt.Errorf("The key %s did not resolve to a private key", keyID)
+		}
+
+		if err := ks.add(keyID, privateKey); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func generateKeys(infoLogger *log.Logger, c *Configuration, privateKeys map[string]*rsa.PrivateKey) error {
-	bits := c.Bits
-	if bits < 1 {
-		bits = DefaultBits
+func (ks *KeyStore) keyFile(keyID string) string {
+	return filepath.Join(ks.directory, keyID+".pem")
+}
+
+// generate creates a new key of the configured type, persisting it if a key directory is configured
+func (ks *KeyStore) generate(keyID string) (privateKey crypto.Signer, err error) {
+	ks.infoLogger.Printf("Key [%s]: generating %s key ...", keyID, ks.keyType)
+
+	if ks.keyType == KeyTypeEC {
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		privateKey, err = rsa.GenerateKey(rand.Reader, ks.bits)
 	}
 
-	for _, keyID := range c.Generate {
-		infoLogger.Printf("Key [%s]: generating ...", keyID)
+	if err != nil || len(ks.directory) == 0 {
+		return
+	}
 
-		if generatedKey, err := rsa.GenerateKey(rand.Reader, bits); err == nil {
-			privateKeys[keyID] = generatedKey
-		} else {
-			return err
+	block := &pem.Block{}
+	switch k := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		block.Type = "EC PRIVATE KEY"
+		if block.Bytes, err = x509.MarshalECPrivateKey(k); err != nil {
+			return nil, err
 		}
+
+	case *rsa.PrivateKey:
+		block.Type = "RSA PRIVATE KEY"
+		block.Bytes = x509.MarshalPKCS1PrivateKey(k)
 	}
 
-	return nil
+	ks.infoLogger.Printf("Key [%s]: storing in %s", keyID, ks.keyFile(keyID))
+	err = ioutil.WriteFile(
+		ks.keyFile(keyID),
+		pem.EncodeToMemory(block),
+		0600,
+	)
+
+	return
 }
 
-func marshalPublicKeys(publicKeys map[string][]byte, privateKeys map[string]*rsa.PrivateKey) error {
-	for keyID, privateKey := range privateKeys {
-		derBytes, err := x509.MarshalPKIXPublicKey(privateKey.Public())
-		if err != nil {
-			return err
-		}
+// load reads a persisted key.  If no such key was persisted, this method returns a nil key.
+func (ks *KeyStore) load(keyID string) (crypto.Signer, error) {
+	if len(ks.directory) == 0 {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(ks.keyFile(keyID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	ks.infoLogger.Printf("Key [%s]: loading from %s", keyID, ks.keyFile(keyID))
+	pair, err := key.DefaultParser.ParseKey(key.PurposeSign, data)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, ok := pair.Private().(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("The key %s is not a signing key", keyID)
+	}
 
-		block := pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: derBytes,
+	return privateKey, nil
+}
+
+func (ks *KeyStore) loadOrGenerate(keyID string) error {
+	privateKey, err := ks.load(keyID)
+	if err == nil && privateKey == nil {
+		privateKey, err = ks.generate(keyID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ks.add(keyID, privateKey)
+}
+
+// persistedVersions returns the versions of a rotated key found in the key directory, in ascending order
+func (ks *KeyStore) persistedVersions(name string) ([]int, error) {
+	if len(ks.directory) == 0 {
+		return nil, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(ks.directory, name+"-v*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for _, file := range files {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), name+"-v"), ".pem")
+		if version, err := strconv.Atoi(suffix); err == nil && version > 0 {
+			versions = append(versions, version)
 		}
+	}
 
-		var buffer bytes.Buffer
-		err = pem.Encode(&buffer, &block)
-		if err != nil {
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// startRotation sets up a rotated key.  Persisted versions are reloaded, with all but the latest
+// considered retired as of now.  If there are no persisted versions, the first version is generated.
+func (ks *KeyStore) startRotation(rc RotationConfiguration) error {
+	r := &rotation{RotationConfiguration: rc}
+	ks.rotations[rc.Name] = r
+
+	versions, err := ks.persistedVersions(rc.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 {
+		r.version = 1
+		return ks.loadOrGenerate(r.keyID(r.version))
+	}
+
+	now := ks.now()
+	for _, version := range versions {
+		if err := ks.loadOrGenerate(r.keyID(version)); err != nil {
 			return err
 		}
 
-		publicKeys[keyID] = buffer.Bytes()
+		if version != versions[len(versions)-1] {
+			ks.keys[r.keyID(version)].retired = now
+		}
 	}
 
+	r.version = versions[len(versions)-1]
 	return nil
 }
+
+// Rotate generates the next version of the given rotated key.  The previous version is retired, and
+// any versions retired for longer than the overlap are removed.  This method returns the key identifier
+// of the new version.
+func (ks *KeyStore) Rotate(name string, now time.Time) (string, error) {
+	ks.lock.RLock()
+	r, ok := ks.rotations[name]
+	var next string
+	if ok {
+		next = r.keyID(r.version + 1)
+	}
+
+	ks.lock.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("No such rotated key: %s", name)
+	}
+
+	// generate outside the lock, as this can take a while for large RSA keys
+	privateKey, err := ks.generate(next)
+	if err != nil {
+		return "", err
+	}
+
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	if err := ks.add(next, privateKey); err != nil {
+		return "", err
+	}
+
+	if current, ok := ks.keys[r.keyID(r.version)]; ok {
+		current.retired = now
+	}
+
+	r.version++
+	ks.prune(r, now)
+	return next, nil
+}
+
+// prune removes the versions of a rotated key that have been retired for longer than the overlap.
+// Must be called under the write lock.
+func (ks *KeyStore) prune(r *rotation, now time.Time) {
+	for version := r.version - 1; version > 0; version-- {
+		keyID := r.keyID(version)
+		sk, ok := ks.keys[keyID]
+		if !ok {
+			continue
+		}
+
+		if now.Sub(sk.retired) >= time.Duration(r.Overlap) {
+			ks.infoLogger.Printf("Key [%s]: removing retired key", keyID)
+			delete(ks.keys, keyID)
+			if len(ks.directory) > 0 {
+				os.Remove(ks.keyFile(keyID))
+			}
+		}
+	}
+}
+
+// Prune removes the versions of all rotated keys that have been retired for longer than their overlap
+func (ks *KeyStore) Prune(now time.Time) {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	for _, r := range ks.rotations {
+		ks.prune(r, now)
+	}
+}
+
+// Rotations returns the configurations of all the rotated keys
+func (ks *KeyStore) Rotations() []RotationConfiguration {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	rotations := make([]RotationConfiguration, 0, len(ks.rotations))
+	for _, r := range ks.rotations {
+		rotations = append(rotations, r.RotationConfiguration)
+	}
+
+	return rotations
+}
+
+func marshalPublicKey(privateKey crypto.Signer) ([]byte, error) {
+	derBytes, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	block := pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	}
+
+	var buffer bytes.Buffer
+	err = pem.Encode(&buffer, &block)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
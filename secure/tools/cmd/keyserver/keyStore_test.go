@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyDirectory(t *testing.T) (string, func()) {
+	directory, err := ioutil.TempDir("", "keyserver")
+	require.NoError(t, err)
+	return directory, func() { os.RemoveAll(directory) }
+}
+
+// testClock is a settable clock for injecting into a KeyStore
+type testClock struct {
+	current time.Time
+}
+
+func (tc *testClock) now() time.Time {
+	return tc.current
+}
+
+func newTestKeyStore(t *testing.T, c *Configuration, clock *testClock) *KeyStore {
+	ks, err := newKeyStore(log.New(ioutil.Discard, "", 0), c, clock.now)
+	require.NoError(t, err)
+	require.NotNil(t, ks)
+	return ks
+}
+
+// persistedKeyIDs returns the sorted key identifiers stored in a key directory
+func persistedKeyIDs(t *testing.T, directory string) []string {
+	files, err := filepath.Glob(filepath.Join(directory, "*.pem"))
+	require.NoError(t, err)
+
+	keyIDs := make([]string, 0, len(files))
+	for _, file := range files {
+		keyIDs = append(keyIDs, filepath.Base(file[:len(file)-len(".pem")]))
+	}
+
+	sort.Strings(keyIDs)
+	return keyIDs
+}
+
+func TestKeyStorePersistence(t *testing.T) {
+	testData := []struct {
+		keyType       string
+		bits          int
+		expectedBlock string
+	}{
+		{KeyTypeRSA, 1024, "RSA PRIVATE KEY"},
+		{KeyTypeEC, 0, "EC PRIVATE KEY"},
+	}
+
+	for _, record := range testData {
+		t.Run(record.keyType, func(t *testing.T) {
+			var (
+				assert  = assert.New(t)
+				require = require.New(t)
+				clock   = &testClock{current: time.Now()}
+
+				directory, cleanup = newTestKeyDirectory(t)
+			)
+
+			defer cleanup()
+			c := &Configuration{
+				Bits:         record.bits,
+				KeyType:      record.keyType,
+				Generate:     []string{"test"},
+				KeyDirectory: directory,
+			}
+
+			first := newTestKeyStore(t, c, clock)
+			assert.Equal([]string{"test"}, persistedKeyIDs(t, directory))
+
+			data, err := ioutil.ReadFile(filepath.Join(directory, "test.pem"))
+			require.NoError(err)
+			block, _ := pem.Decode(data)
+			require.NotNil(block)
+			assert.Equal(record.expectedBlock, block.Type)
+
+			firstPublicKey, ok := first.PublicKey("test")
+			require.True(ok)
+
+			// a restarted key store reuses the persisted key
+			second := newTestKeyStore(t, c, clock)
+			secondPublicKey, ok := second.PublicKey("test")
+			require.True(ok)
+			assert.Equal(firstPublicKey, secondPublicKey)
+
+			// without a key directory, every key store generates its own key
+			c.KeyDirectory = ""
+			third := newTestKeyStore(t, c, clock)
+			thirdPublicKey, ok := third.PublicKey("test")
+			require.True(ok)
+			assert.NotEqual(firstPublicKey, thirdPublicKey)
+		})
+	}
+}
+
+func TestKeyStoreRotate(t *testing.T) {
+	testData := []struct {
+		name      string
+		overlap   time.Duration
+		rotations []time.Duration
+		prune     time.Duration
+
+		expectedAfterRotations []string
+		expectedAfterPrune     []string
+	}{
+		{
+			name:                   "NoRotations",
+			overlap:                time.Hour,
+			prune:                  2 * time.Hour,
+			expectedAfterRotations: []string{"rotating-v1"},
+			expectedAfterPrune:     []string{"rotating-v1"},
+		},
+		{
+			name:                   "NoOverlap",
+			rotations:              []time.Duration{0, time.Hour},
+			prune:                  2 * time.Hour,
+			expectedAfterRotations: []string{"rotating-v3"},
+			expectedAfterPrune:     []string{"rotating-v3"},
+		},
+		{
+			name:                   "Overlap",
+			overlap:                time.Hour,
+			rotations:              []time.Duration{0, 30 * time.Minute},
+			prune:                  time.Hour,
+			expectedAfterRotations: []string{"rotating-v1", "rotating-v2", "rotating-v3"},
+			expectedAfterPrune:     []string{"rotating-v2", "rotating-v3"},
+		},
+		{
+			name:                   "OverlapElapsed",
+			overlap:                time.Hour,
+			rotations:              []time.Duration{0, 2 * time.Hour},
+			prune:                  3 * time.Hour,
+			expectedAfterRotations: []string{"rotating-v2", "rotating-v3"},
+			expectedAfterPrune:     []string{"rotating-v3"},
+		},
+	}
+
+	for _, record := range testData {
+		t.Run(record.name, func(t *testing.T) {
+			var (
+				assert  = assert.New(t)
+				require = require.New(t)
+				start   = time.Now()
+				clock   = &testClock{current: start}
+
+				directory, cleanup = newTestKeyDirectory(t)
+			)
+
+			defer cleanup()
+			ks := newTestKeyStore(t, &Configuration{
+				KeyType:      KeyTypeEC,
+				KeyDirectory: directory,
+				Rotate: []RotationConfiguration{
+					{Name: "rotating", Interval: types.Duration(time.Hour), Overlap: types.Duration(record.overlap)},
+				},
+			}, clock)
+
+			for _, offset := range record.rotations {
+				keyID, err := ks.Rotate("rotating", start.Add(offset))
+				require.NoError(err)
+
+				actualKeyID, privateKey, ok := ks.PrivateKey("rotating")
+				require.True(ok)
+				assert.NotNil(privateKey)
+				assert.Equal(keyID, actualKeyID)
+			}
+
+			assert.Equal(record.expectedAfterRotations, ks.KeyIDs())
+			assert.Equal(record.expectedAfterRotations, persistedKeyIDs(t, directory))
+
+			ks.Prune(start.Add(record.prune))
+			assert.Equal(record.expectedAfterPrune, ks.KeyIDs())
+			assert.Equal(record.expectedAfterPrune, persistedKeyIDs(t, directory))
+
+			// only the current version issues JWTs, though every remaining version is published
+			current := record.expectedAfterPrune[len(record.expectedAfterPrune)-1]
+			for _, keyID := range record.expectedAfterPrune {
+				_, _, ok := ks.PrivateKey(keyID)
+				assert.Equal(keyID == current, ok)
+
+				_, ok = ks.PublicKey(keyID)
+				assert.True(ok)
+			}
+
+			_, err := ks.Rotate("nosuch", start)
+			assert.Error(err)
+		})
+	}
+}
+
+func TestKeyStoreStartRotation(t *testing.T) {
+	testData := []struct {
+		name               string
+		rotations          int
+		expectedKeyIDs     []string
+		expectedCurrent    string
+		expectedAfterPrune []string
+	}{
+		{"FirstStart", 0, []string{"rotating-v1"}, "rotating-v1", []string{"rotating-v1"}},
+		{"Restart", 2, []string{"rotating-v1", "rotating-v2", "rotating-v3"}, "rotating-v3", []string{"rotating-v3"}},
+	}
+
+	for _, record := range testData {
+		t.Run(record.name, func(t *testing.T) {
+			var (
+				assert  = assert.New(t)
+				require = require.New(t)
+				clock   = &testClock{current: time.Now()}
+
+				directory, cleanup = newTestKeyDirectory(t)
+
+				c = &Configuration{
+					KeyType:      KeyTypeEC,
+					KeyDirectory: directory,
+					Rotate: []RotationConfiguration{
+						{Name: "rotating", Interval: types.Duration(time.Hour), Overlap: types.Duration(24 * time.Hour)},
+					},
+				}
+			)
+
+			defer cleanup()
+			first := newTestKeyStore(t, c, clock)
+			for i := 0; i < record.rotations; i++ {
+				_, err := first.Rotate("rotating", clock.current)
+				require.NoError(err)
+			}
+
+			// reloaded versions other than the latest are retired as of the restart
+			clock.current = clock.current.Add(time.Hour)
+			second := newTestKeyStore(t, c, clock)
+			assert.Equal(record.expectedKeyIDs, second.KeyIDs())
+
+			actualKeyID, _, ok := second.PrivateKey("rotating")
+			require.True(ok)
+			assert.Equal(record.expectedCurrent, actualKeyID)
+
+			for _, keyID := range record.expectedKeyIDs {
+				firstPublicKey, ok := first.PublicKey(keyID)
+				require.True(ok)
+				secondPublicKey, ok := second.PublicKey(keyID)
+				require.True(ok)
+				assert.Equal(firstPublicKey, secondPublicKey)
+
+				_, _, ok = second.PrivateKey(keyID)
+				assert.Equal(keyID == record.expectedCurrent, ok)
+			}
+
+			second.Prune(clock.current.Add(24*time.Hour - time.Nanosecond))
+			assert.Equal(record.expectedKeyIDs, second.KeyIDs())
+
+			second.Prune(clock.current.Add(24 * time.Hour))
+			assert.Equal(record.expectedAfterPrune, second.KeyIDs())
+		})
+	}
+}
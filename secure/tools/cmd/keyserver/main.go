@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 )
 
 type RouteBuilder struct {
@@ -34,6 +35,9 @@ func (rb RouteBuilder) Build(router *mux.Router) {
 	keysRouter.HandleFunc(fmt.Sprintf("/keys/{%s}", KeyIDVariableName), keyHandler.GetKey)
 	rb.InfoLogger.Println("GET /keys/{kid} returns the public key associated with the given key identifier.  There is no way to look up the associated private key.")
 
+	keysRouter.HandleFunc("/.well-known/jwks.json", keyHandler.JWKS)
+	rb.InfoLogger.Println("GET /.well-known/jwks.json returns the public keys of all available keys as a JSON Web Key Set")
+
 	issueHandler := IssueHandler{
 		BasicHandler: BasicHandler{
 			keyStore:    rb.KeyStore,
@@ -51,7 +55,7 @@ func (rb RouteBuilder) Build(router *mux.Router) {
 
 	issueRouter.Methods("GET").
 		HandlerFunc(issueHandler.SimpleIssue)
-	rb.InfoLogger.Println("GET /jws?kid={kid} generates a JWT signed with the associated private key.  Additional URL parameters are interpreted as reserved claims, e.g. exp")
+	rb.InfoLogger.Println("GET /jws?kid={kid} generates a JWT signed with the associated private key.  The kid may be the name of a rotated key, in which case the current version is used.  Additional URL parameters are interpreted as reserved claims, e.g. exp, or as custom claims when prefixed with claim., e.g. claim.partner-id")
 
 	issueRouter.Methods("PUT", "POST").
 		Headers("Content-Type", "application/json").
@@ -59,6 +63,26 @@ func (rb RouteBuilder) Build(router *mux.Router) {
 	rb.InfoLogger.Println("PUT/POST /jws generates a JWT signed with the associated private key.  Additional URL parmaeters are interpreted as reserved claims, e.g. exp")
 }
 
+// rotate periodically generates new versions of a rotated key until the program exits.  Replaced
+// versions are removed once their overlap has elapsed.
+func rotate(infoLogger, errorLogger *log.Logger, keyStore *KeyStore, rc RotationConfiguration) {
+	ticker := time.NewTicker(time.Duration(rc.Interval))
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		keyID, err := keyStore.Rotate(rc.Name, now)
+		if err != nil {
+			errorLogger.Printf("Unable to rotate key [%s]: %s\n", rc.Name, err)
+			continue
+		}
+
+		infoLogger.Printf("Rotated key [%s]: the current version is %s\n", rc.Name, keyID)
+		time.AfterFunc(time.Duration(rc.Overlap), func() {
+			keyStore.Prune(time.Now())
+		})
+	}
+}
+
 func main() {
 	infoLogger := log.New(os.Stdout, "[INFO]  ", log.LstdFlags|log.LUTC)
 	errorLogger := log.New(os.Stderr, "[ERROR] ", log.LstdFlags|log.LUTC)
@@ -79,6 +103,11 @@ func main() {
 
 	infoLogger.Printf("Initialized key store with %d keys: %s\n", keyStore.Len(), keyStore.KeyIDs())
 
+	for _, rc := range keyStore.Rotations() {
+		infoLogger.Printf("Rotating key [%s] every %s with an overlap of %s\n", rc.Name, time.Duration(rc.Interval), time.Duration(rc.Overlap))
+		go rotate(infoLogger, errorLogger, keyStore, rc)
+	}
+
 	issuer := configuration.Issuer
 	if len(issuer) == 0 {
 		issuer = DefaultIssuer
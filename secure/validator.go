@@ -2,20 +2,25 @@ package secure
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"errors"
-	"fmt"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/Comcast/webpa-common/secure/key"
 	"github.com/SermoDigital/jose/jws"
 	"github.com/SermoDigital/jose/jwt"
+	"golang.org/x/crypto/ed25519"
 )
 
 var (
 	ErrorNoProtectedHeader = errors.New("Missing protected header")
 	ErrorNoSigningMethod   = errors.New("Signing method (alg) is missing or unrecognized")
+
+	ErrorIncompatibleSigningMethod = errors.New("Signing method (alg) is not compatible with the verification key")
+	ErrorTokenRevoked              = errors.New("Token has been revoked")
 )
 
 // Validator describes the behavior of a type which can validate tokens
@@ -28,6 +33,13 @@ type Validator interface {
 	Validate(context.Context, *Token) (bool, error)
 }
 
+// RevocationList describes the behavior of a type which tracks revoked tokens
+type RevocationList interface {
+	// Revoked tests if a token with the given claims has been revoked.  If so, the name
+	// of the claim by which the token was revoked, e.g. jti, is returned.
+	Revoked(jws.Claims) (string, bool)
+}
+
 // ValidatorFunc is a function type that implements Validator
 type ValidatorFunc func(context.Context, *Token) (bool, error)
 
@@ -70,25 +82,86 @@ type JWSValidator struct {
 	Resolver      key.Resolver
 	Parser        JWSParser
 	JWTValidators []*jwt.Validator
-	measures      *JWTValidationMeasures
+
+	// Capabilities authorizes requests based on the token's capabilities claim.  If unset,
+	// DefaultCapabilityChecker is used.  The request being authorized is obtained from the
+	// context via RequestInfoFromContext.
+	Capabilities CapabilityChecker
+
+	// Revocations is consulted for tokens with valid signatures.  If unset, tokens are
+	// never considered revoked.
+	Revocations RevocationList
+
+	measures *JWTValidationMeasures
 }
 
-// capabilityValidation determines if a claim's capability is valid
-func capabilityValidation(ctx context.Context, capability string) (valid_capabilities bool) {
-	pieces := strings.Split(capability, ":")
+// compatibleSigningMethod tests if a JWS alg can be verified with the given public key.  This prevents
+// tokens from choosing an algorithm that the key was never meant for, such as an HMAC alg or none.
+func compatibleSigningMethod(alg string, publicKey interface{}) bool {
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		switch alg {
+		case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+			return true
+		}
+
+	case *ecdsa.PublicKey:
+		switch alg {
+		case "ES256":
+			return k.Curve == elliptic.P256()
+		case "ES384":
+			return k.Curve == elliptic.P384()
+		case "ES512":
+			return k.Curve == elliptic.P521()
+		}
+
+	case ed25519.PublicKey:
+		return alg == SigningMethodEdDSA.Alg()
+	}
+
+	return false
+}
 
-	if len(pieces) == 5 &&
-		pieces[0] == "x1" &&
-		pieces[1] == "webpa" {
+// capabilityReason maps capability checking errors onto the reasons reported via metrics
+func capabilityReason(err error) string {
+	switch err {
+	case ErrorNoCapabilities:
+		return "no_capabilities"
+	case ErrorNoRequestInfo:
+		return "no_request_info"
+	case ErrorRouteCapabilityMissing:
+		return "route_capability_missing"
+	default:
+		return "capability_mismatch"
+	}
+}
 
-		method_value, ok := ctx.Value("method").(string)
-		if ok && (pieces[4] == "all" || strings.EqualFold(pieces[4], method_value)) {
-			claimPath := fmt.Sprintf("/%s/[^/]+/%s", pieces[2], pieces[3])
-			valid_capabilities, _ = regexp.MatchString(claimPath, ctx.Value("path").(string))
+// capabilities extracts the string capabilities from a token's claims
+func capabilities(claims jws.Claims) []string {
+	raw, _ := claims.Get("capabilities").([]interface{})
+	capabilities := make([]string, 0, len(raw))
+	for _, c := range raw {
+		if capability, ok := c.(string); ok {
+			capabilities = append(capabilities, capability)
 		}
 	}
 
-	return
+	return capabilities
+}
+
+// checkCapabilities authorizes the request in the given context against the token's capabilities
+func (v JWSValidator) checkCapabilities(ctx context.Context, claims jws.Claims) (string, error) {
+	request, ok := RequestInfoFromContext(ctx)
+	if !ok {
+		return "", ErrorNoRequestInfo
+	}
+
+	checker := v.Capabilities
+	if checker == nil {
+		checker = DefaultCapabilityChecker()
+	}
+
+	return checker.CheckCapabilities(capabilities(claims), request)
 }
 
 func (v JWSValidator) Validate(ctx context.Context, token *Token) (valid bool, err error) {
@@ -129,12 +202,22 @@ func (v JWSValidator) Validate(ctx context.Context, token *Token) (valid bool, e
 		return
 	}
 
+	publicKey := pair.Public()
+	if !compatibleSigningMethod(signingMethod.Alg(), publicKey) {
+		if v.measures != nil {
+			v.measures.ValidationReason.With("reason", "incompatible_signing_method").Add(1)
+		}
+
+		err = ErrorIncompatibleSigningMethod
+		return
+	}
+
 	// validate the signature
 	if len(v.JWTValidators) > 0 {
 		// all JWS implementations also implement jwt.JWT
-		err = jwsToken.(jwt.JWT).Validate(pair.Public(), signingMethod, v.JWTValidators...)
+		err = jwsToken.(jwt.JWT).Validate(publicKey, signingMethod, v.JWTValidators...)
 	} else {
-		err = jwsToken.Verify(pair.Public(), signingMethod)
+		err = jwsToken.Verify(publicKey, signingMethod)
 	}
 
 	if nil != err {
@@ -156,37 +239,37 @@ func (v JWSValidator) Validate(ctx context.Context, token *Token) (valid bool, e
 		return
 	}
 
+	claims, _ := jwsToken.Payload().(jws.Claims)
+	if v.Revocations != nil {
+		if _, revoked := v.Revocations.Revoked(claims); revoked {
+			if v.measures != nil {
+				v.measures.ValidationReason.With("reason", "revoked_token").Add(1)
+			}
+
+			err = ErrorTokenRevoked
+			return
+		}
+	}
+
 	// validate jwt token claims capabilities
-	if caps, capOkay := jwsToken.Payload().(jws.Claims).Get("capabilities").([]interface{}); capOkay && len(caps) > 0 {
-
-		/*  commenting out for now
-		    1. remove code in use below
-		    2. make sure to bring a back tests for this as well.
-		        - TestJWSValidatorCapabilities()
-
-				for c := 0; c < len(caps); c++ {
-					if cap_value, ok := caps[c].(string); ok {
-						if valid = capabilityValidation(ctx, cap_value); valid {
-							return
-						}
-					}
-				}
-		*/
-		// *****  REMOVE THIS CODE AFTER BRING BACK THE COMMENTED CODE ABOVE *****
-		// ***** vvvvvvvvvvvvvvv *****
-
-		// successful validation
+	capability, err := v.checkCapabilities(ctx, claims)
+	if err != nil {
 		if v.measures != nil {
-			v.measures.ValidationReason.With("reason", "ok").Add(1)
+			v.measures.ValidationReason.With("reason", capabilityReason(err)).Add(1)
 		}
 
-		return true, nil
-		// ***** ^^^^^^^^^^^^^^^ *****
+		return
+	}
 
+	// successful validation
+	if v.measures != nil {
+		v.measures.ValidationReason.With("reason", "ok").Add(1)
+		if v.measures.CapabilityMatch != nil {
+			v.measures.CapabilityMatch.With("capability", capability).Add(1)
+		}
 	}
 
-	// This fail
-	return
+	return true, nil
 }
 
 //DefineMeasures defines the metrics tool used by JWSValidator
@@ -2,17 +2,25 @@ package secure
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/Comcast/webpa-common/secure/key"
+	"github.com/Comcast/webpa-common/xmetrics/xmetricstest"
 	"github.com/SermoDigital/jose"
+	"github.com/SermoDigital/jose/crypto"
 	"github.com/SermoDigital/jose/jws"
 	"github.com/SermoDigital/jose/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
 )
 
 func ExampleSimpleJWSValidator(t *testing.T) {
@@ -31,9 +39,7 @@ func ExampleSimpleJWSValidator(t *testing.T) {
 		value:     string(testSerializedJWT),
 	}
 
-	ctx := context.Background()
-	ctx = context.WithValue(ctx, "method", "post")
-	ctx = context.WithValue(ctx, "path", "/api/foo/path")
+	ctx := WithRequestInfo(context.Background(), RequestInfo{Method: "post", Path: "/api/foo/path"})
 
 	valid, err := validator.Validate(ctx, token)
 
@@ -232,192 +238,249 @@ func TestJWSValidatorNoSigningMethod(t *testing.T) {
 	}
 }
 
-//func TestJWSValidatorCapabilities(t *testing.T) {
-//	assert := assert.New(t)
-//
-//	defaultClaims := jws.Claims{
-//		"capabilities": []interface{}{
-//			"x1:webpa:api:.*:all",
-//			"x1:webpa:api:device/.*/config/.*:all",
-//			"x1:webpa:api:device/.*/config/.*:get",
-//			"x1:webpa:api:device/.*/stat:get",
-//			"x1:webpa:api:hook:post",
-//			"x1:webpa:api:hooks:get",
-//		},
-//	}
-//
-//	ctxValid := context.Background()
-//	ctxValid = context.WithValue(ctxValid, "method", "post")
-//	ctxValid = context.WithValue(ctxValid, "path", "/api/foo/path")
-//
-//	ctxInvalidMethod := context.Background()
-//	ctxInvalidMethod = context.WithValue(ctxInvalidMethod, "method", "get")
-//	ctxInvalidMethod = context.WithValue(ctxInvalidMethod, "path", "/api/foo/path")
-//
-//	ctxInvalidPath := context.Background()
-//	ctxInvalidPath = context.WithValue(ctxInvalidPath, "method", "post")
-//	ctxInvalidPath = context.WithValue(ctxInvalidPath, "path", "/ipa/foo/path")
-//
-//	ctxInvalidApi := context.Background()
-//	ctxInvalidApi = context.WithValue(ctxInvalidApi, "method", "get")
-//	ctxInvalidApi = context.WithValue(ctxInvalidApi, "path", "/api")
-//
-//	ctxInvalidVersion := context.Background()
-//	ctxInvalidVersion = context.WithValue(ctxInvalidVersion, "method", "get")
-//	ctxInvalidVersion = context.WithValue(ctxInvalidVersion, "path", "/api/v2")
-//
-//	ctxValidConfig := context.Background()
-//	ctxValidConfig = context.WithValue(ctxValidConfig, "method", "get")
-//	ctxValidConfig = context.WithValue(ctxValidConfig, "path", "/api/v2/device/mac:112233445566/config?name=foodoo")
-//	validConfigClaims := jws.Claims{
-//		"capabilities": []interface{}{
-//			"x1:webpa:api:device/.*/config/?.*:get",
-//		},
-//	}
-//
-//	ctxValidConfig2 := context.Background()
-//	ctxValidConfig2 = context.WithValue(ctxValidConfig, "method", "get")
-//	ctxValidConfig2 = context.WithValue(ctxValidConfig, "path", "/api/v2/device/mac:112233445566/config")
-//
-//	ctxValidConfig3 := context.Background()
-//	ctxValidConfig3 = context.WithValue(ctxValidConfig, "method", "get")
-//	ctxValidConfig3 = context.WithValue(ctxValidConfig, "path", "/api/v2/device/mac:112233445566/config/")
-//
-//	ctxValidConfig4 := context.Background()
-//	ctxValidConfig4 = context.WithValue(ctxValidConfig, "method", "get")
-//	ctxValidConfig4 = context.WithValue(ctxValidConfig, "path", "/api/v2/device/mac:112233445566/config/bob")
-//
-//	validConfigClaims2 := jws.Claims{
-//		"capabilities": []interface{}{
-//			"x1:webpa:api:device/.*/config\\b:get",
-//		},
-//	}
-//
-//	ctxInvalidConfig := context.Background()
-//	ctxInvalidConfig = context.WithValue(ctxInvalidConfig, "method", "get")
-//	ctxInvalidConfig = context.WithValue(ctxInvalidConfig, "path", "/api/v2/device/mac:112233445566/config?name=foodoo")
-//	invalidConfigClaims := jws.Claims{
-//		"capabilities": []interface{}{
-//			"x1:webpa:api:device/.*/config/.*:get",
-//		},
-//	}
-//
-//	ctxInvalidConfig2 := context.Background()
-//	ctxInvalidConfig2 = context.WithValue(ctxValidConfig, "method", "get")
-//	ctxInvalidConfig2 = context.WithValue(ctxValidConfig, "path", "/api/v2/device/mac:112233445566/configure")
-//
-//	ctxInvalidConfig3 := context.Background()
-//	ctxInvalidConfig3 = context.WithValue(ctxValidConfig, "method", "get")
-//	ctxInvalidConfig3 = context.WithValue(ctxValidConfig, "path", "/api/v2/device/mac:112233445566/configure/")
-//
-//	ctxValidHook := context.Background()
-//	ctxValidHook = context.WithValue(ctxValidHook, "method", "post")
-//	ctxValidHook = context.WithValue(ctxValidHook, "path", "/api/v2/hook")
-//
-//	ctxValidHooks := context.Background()
-//	ctxValidHooks = context.WithValue(ctxValidHooks, "method", "get")
-//	ctxValidHooks = context.WithValue(ctxValidHooks, "path", "/api/v2/hooks")
-//
-//	ctxInvalidHealth := context.Background()
-//	ctxInvalidHealth = context.WithValue(ctxInvalidHealth, "method", "get")
-//	ctxInvalidHealth = context.WithValue(ctxInvalidHealth, "path", "/health")
-//
-//	ctxValidEvent := context.Background()
-//	ctxValidEvent = context.WithValue(ctxValidEvent, "method", "post")
-//	ctxValidEvent = context.WithValue(ctxValidEvent, "path", "/api/v2/notify/mac:112233445566/event/device-status")
-//
-//	ctxValidStat := context.Background()
-//	ctxValidStat = context.WithValue(ctxValidStat, "method", "get")
-//	ctxValidStat = context.WithValue(ctxValidStat, "path", "/api/v2/device/mac:112233445566/stat")
-//
-//	validStatClaims := jws.Claims{
-//		"capabilities": []interface{}{
-//			"x1:webpa:api:device/.*/stat:get",
-//		},
-//	}
-//
-//	var testData = []struct {
-//		context       context.Context
-//		claims        jws.Claims
-//		expectedValid bool
-//	}{
-//		{ctxValid, defaultClaims, true},
-//		{context.Background(), defaultClaims, false},
-//		{ctxInvalidMethod, testClaims, false},
-//		{ctxInvalidPath, defaultClaims, false},
-//		{ctxInvalidApi, defaultClaims, false},
-//		{ctxInvalidVersion, defaultClaims, false},
-//		{ctxValidConfig, validConfigClaims, true},
-//
-//		{ctxValidConfig2, validConfigClaims, true},
-//		{ctxValidConfig3, validConfigClaims, true},
-//		{ctxValidConfig4, validConfigClaims, true},
-//		{ctxValidConfig, validConfigClaims2, true},
-//		{ctxValidConfig2, validConfigClaims2, true},
-//		{ctxValidConfig3, validConfigClaims2, true},
-//		{ctxValidConfig4, validConfigClaims2, true},
-//
-//		{ctxInvalidConfig, invalidConfigClaims, false},
-//
-//		{ctxInvalidConfig2, validConfigClaims, true},
-//		{ctxInvalidConfig3, validConfigClaims, true},
-//		{ctxInvalidConfig2, validConfigClaims2, false},
-//		{ctxInvalidConfig3, validConfigClaims2, false},
-//
-//		{ctxValidHook, defaultClaims, true},
-//		{ctxValidHooks, defaultClaims, true},
-//		{ctxInvalidHealth, defaultClaims, false},
-//		{ctxValidEvent, defaultClaims, true},
-//		{ctxValidStat, validStatClaims, true},
-//	}
-//
-//	for _, record := range testData {
-//		var ok bool
-//		var method, path string
-//		if method, ok = record.context.Value("method").(string); ok {
-//			method = record.context.Value("method").(string)
-//		}
-//		if path, ok = record.context.Value("path").(string); ok {
-//			path = record.context.Value("path").(string)
-//		}
-//
-//		t.Logf("ctx method: %s, ctx path: %s, claims: %v, expectedValid: %v", method, path, record.claims, record.expectedValid)
-//		token := &Token{tokenType: Bearer, value: "does not matter"}
-//
-//		mockPair := &key.MockPair{}
-//		expectedPublicKey := interface{}(123)
-//		mockPair.On("Public").Return(expectedPublicKey).Once()
-//
-//		mockResolver := &key.MockResolver{}
-//		mockResolver.On("ResolveKey", mock.AnythingOfType("string")).Return(mockPair, nil).Once()
-//
-//		expectedSigningMethod := jws.GetSigningMethod("RS256")
-//		assert.NotNil(expectedSigningMethod)
-//
-//		mockJWS := &mockJWS{}
-//		mockJWS.On("Protected").Return(jose.Protected{"alg": "RS256"}).Once()
-//		mockJWS.On("Verify", expectedPublicKey, expectedSigningMethod).Return(nil).Once()
-//		mockJWS.On("Payload").Return(record.claims).Once()
-//
-//		mockJWSParser := &mockJWSParser{}
-//		mockJWSParser.On("ParseJWS", token).Return(mockJWS, nil).Once()
-//
-//		validator := &JWSValidator{
-//			Resolver: mockResolver,
-//			Parser:   mockJWSParser,
-//		}
-//
-//		valid, err := validator.Validate(record.context, token)
-//		assert.Equal(record.expectedValid, valid)
-//		assert.Nil(err)
-//
-//		mockPair.AssertExpectations(t)
-//		mockResolver.AssertExpectations(t)
-//		mockJWS.AssertExpectations(t)
-//		mockJWSParser.AssertExpectations(t)
-//	}
-//}
-//
+func TestJWSValidatorCapabilities(t *testing.T) {
+	var (
+		defaultClaims = jws.Claims{
+			"capabilities": []interface{}{
+				"x1:webpa:api:.*:all",
+				"x1:webpa:api:device/.*/config/.*:all",
+				"x1:webpa:api:device/.*/config/.*:get",
+				"x1:webpa:api:device/.*/stat:get",
+				"x1:webpa:api:hook:post",
+				"x1:webpa:api:hooks:get",
+			},
+		}
+
+		validConfigClaims = jws.Claims{
+			"capabilities": []interface{}{
+				"x1:webpa:api:device/.*/config/?.*:get",
+			},
+		}
+
+		validConfigClaims2 = jws.Claims{
+			"capabilities": []interface{}{
+				"x1:webpa:api:device/.*/config\\b:get",
+			},
+		}
+
+		invalidConfigClaims = jws.Claims{
+			"capabilities": []interface{}{
+				"x1:webpa:api:device/.*/config/.*:get",
+			},
+		}
+
+		validStatClaims = jws.Claims{
+			"capabilities": []interface{}{
+				"x1:webpa:api:device/.*/stat:get",
+			},
+		}
+
+		noCapabilitiesClaims = jws.Claims{
+			"capabilities": []interface{}{},
+		}
+
+		testData = []struct {
+			request       *RequestInfo
+			claims        jws.Claims
+			expectedValid bool
+			expectedError error
+		}{
+			{&RequestInfo{"post", "/api/foo/path"}, defaultClaims, true, nil},
+			{nil, defaultClaims, false, ErrorNoRequestInfo},
+			{&RequestInfo{"get", "/api/foo/path"}, testClaims, false, ErrorCapabilityMismatch},
+			{&RequestInfo{"post", "/ipa/foo/path"}, defaultClaims, false, ErrorCapabilityMismatch},
+			{&RequestInfo{"get", "/api"}, defaultClaims, false, ErrorCapabilityMismatch},
+			{&RequestInfo{"get", "/api/v2"}, defaultClaims, false, ErrorCapabilityMismatch},
+			{&RequestInfo{"post", "/api/foo/path"}, noCapabilitiesClaims, false, ErrorNoCapabilities},
+			{&RequestInfo{"post", "/api/foo/path"}, jws.Claims{}, false, ErrorNoCapabilities},
+
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config?name=foodoo"}, validConfigClaims, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config"}, validConfigClaims, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config/"}, validConfigClaims, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config/bob"}, validConfigClaims, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config?name=foodoo"}, validConfigClaims2, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config"}, validConfigClaims2, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config/"}, validConfigClaims2, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config/bob"}, validConfigClaims2, true, nil},
+
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/config?name=foodoo"}, invalidConfigClaims, false, ErrorCapabilityMismatch},
+
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/configure"}, validConfigClaims, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/configure/"}, validConfigClaims, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/configure"}, validConfigClaims2, false, ErrorCapabilityMismatch},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/configure/"}, validConfigClaims2, false, ErrorCapabilityMismatch},
+
+			{&RequestInfo{"post", "/api/v2/hook"}, defaultClaims, true, nil},
+			{&RequestInfo{"get", "/api/v2/hooks"}, defaultClaims, true, nil},
+			{&RequestInfo{"get", "/health"}, defaultClaims, false, ErrorCapabilityMismatch},
+			{&RequestInfo{"post", "/api/v2/notify/mac:112233445566/event/device-status"}, defaultClaims, true, nil},
+			{&RequestInfo{"get", "/api/v2/device/mac:112233445566/stat"}, validStatClaims, true, nil},
+		}
+	)
+
+	for _, record := range testData {
+		t.Logf("request: %v, claims: %v, expectedValid: %v", record.request, record.claims, record.expectedValid)
+
+		var (
+			assert = assert.New(t)
+			token  = &Token{tokenType: Bearer, value: "does not matter"}
+			ctx    = context.Background()
+		)
+
+		if record.request != nil {
+			ctx = WithRequestInfo(ctx, *record.request)
+		}
+
+		mockPair := &key.MockPair{}
+		expectedPublicKey := testRSAPublicKey
+		mockPair.On("Public").Return(expectedPublicKey).Once()
+
+		mockResolver := &key.MockResolver{}
+		mockResolver.On("ResolveKey", mock.AnythingOfType("string")).Return(mockPair, nil).Once()
+
+		expectedSigningMethod := jws.GetSigningMethod("RS256")
+		assert.NotNil(expectedSigningMethod)
+
+		mockJWS := &mockJWS{}
+		mockJWS.On("Protected").Return(jose.Protected{"alg": "RS256"}).Once()
+		mockJWS.On("Verify", expectedPublicKey, expectedSigningMethod).Return(nil).Once()
+		mockJWS.On("Payload").Return(record.claims).Once()
+
+		mockJWSParser := &mockJWSParser{}
+		mockJWSParser.On("ParseJWS", token).Return(mockJWS, nil).Once()
+
+		validator := &JWSValidator{
+			Resolver: mockResolver,
+			Parser:   mockJWSParser,
+		}
+
+		valid, err := validator.Validate(ctx, token)
+		assert.Equal(record.expectedValid, valid)
+		assert.Equal(record.expectedError, err)
+
+		mockPair.AssertExpectations(t)
+		mockResolver.AssertExpectations(t)
+		mockJWS.AssertExpectations(t)
+		mockJWSParser.AssertExpectations(t)
+	}
+}
+
+func TestJWSValidatorCapabilitiesMeasures(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+		measures = &JWTValidationMeasures{
+			ValidationReason: provider.NewCounter(JWTValidationReasonCounter),
+			CapabilityMatch:  provider.NewCounter(JWTCapabilityMatchCounter),
+		}
+
+		token = &Token{tokenType: Bearer, value: "does not matter"}
+	)
+
+	checker, err := NewCapabilityChecker(&CapabilityConfig{Prefix: "test:prefix"})
+	require.NoError(err)
+
+	for _, record := range []struct {
+		request       RequestInfo
+		expectedValid bool
+	}{
+		{RequestInfo{"POST", "/api/v2/hook"}, true},
+		{RequestInfo{"GET", "/api/v2/hook"}, false},
+	} {
+		mockPair := &key.MockPair{}
+		mockPair.On("Public").Return(testRSAPublicKey).Once()
+
+		mockResolver := &key.MockResolver{}
+		mockResolver.On("ResolveKey", mock.AnythingOfType("string")).Return(mockPair, nil).Once()
+
+		mockJWS := &mockJWS{}
+		mockJWS.On("Protected").Return(jose.Protected{"alg": "RS256"}).Once()
+		mockJWS.On("Verify", testRSAPublicKey, jws.GetSigningMethod("RS256")).Return(nil).Once()
+		mockJWS.On("Payload").Return(jws.Claims{"capabilities": []interface{}{"x1:webpa:api:.*:all", "test:prefix:api:hook:post"}}).Once()
+
+		mockJWSParser := &mockJWSParser{}
+		mockJWSParser.On("ParseJWS", token).Return(mockJWS, nil).Once()
+
+		validator := &JWSValidator{
+			Resolver:     mockResolver,
+			Parser:       mockJWSParser,
+			Capabilities: checker,
+		}
+
+		validator.DefineMeasures(measures)
+		valid, err := validator.Validate(WithRequestInfo(context.Background(), record.request), token)
+		assert.Equal(record.expectedValid, valid)
+		assert.Equal(record.expectedValid, err == nil)
+
+		mockPair.AssertExpectations(t)
+		mockResolver.AssertExpectations(t)
+		mockJWS.AssertExpectations(t)
+		mockJWSParser.AssertExpectations(t)
+	}
+
+	provider.Assert(t, JWTValidationReasonCounter, "reason", "ok")(xmetricstest.Value(1.0))
+	provider.Assert(t, JWTValidationReasonCounter, "reason", "capability_mismatch")(xmetricstest.Value(1.0))
+	provider.Assert(t, JWTCapabilityMatchCounter, "capability", "test:prefix:*")(xmetricstest.Value(1.0))
+}
+
+func TestJWSValidatorRevoked(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		provider = xmetricstest.NewProvider(nil, Metrics)
+		measures = &JWTValidationMeasures{
+			ValidationReason: provider.NewCounter(JWTValidationReasonCounter),
+		}
+
+		token  = &Token{tokenType: Bearer, value: "does not matter"}
+		claims = jws.Claims{"jti": "revoked", "capabilities": []interface{}{"x1:webpa:api:.*:all"}}
+		ctx    = WithRequestInfo(context.Background(), RequestInfo{"GET", "/api/v2/hooks"})
+	)
+
+	for _, revoked := range []bool{true, false} {
+		mockPair := &key.MockPair{}
+		mockPair.On("Public").Return(testRSAPublicKey).Once()
+
+		mockResolver := &key.MockResolver{}
+		mockResolver.On("ResolveKey", mock.AnythingOfType("string")).Return(mockPair, nil).Once()
+
+		mockJWS := &mockJWS{}
+		mockJWS.On("Protected").Return(jose.Protected{"alg": "RS256"}).Once()
+		mockJWS.On("Verify", testRSAPublicKey, jws.GetSigningMethod("RS256")).Return(nil).Once()
+		mockJWS.On("Payload").Return(claims).Once()
+
+		mockJWSParser := &mockJWSParser{}
+		mockJWSParser.On("ParseJWS", token).Return(mockJWS, nil).Once()
+
+		revocations := &mockRevocationList{}
+		revocations.On("Revoked", claims).Return("jti", revoked).Once()
+
+		validator := &JWSValidator{
+			Resolver:    mockResolver,
+			Parser:      mockJWSParser,
+			Revocations: revocations,
+		}
+
+		validator.DefineMeasures(measures)
+		valid, err := validator.Validate(ctx, token)
+		assert.Equal(!revoked, valid)
+		if revoked {
+			assert.Equal(ErrorTokenRevoked, err)
+		} else {
+			assert.NoError(err)
+		}
+
+		mockPair.AssertExpectations(t)
+		mockResolver.AssertExpectations(t)
+		mockJWS.AssertExpectations(t)
+		mockJWSParser.AssertExpectations(t)
+		revocations.AssertExpectations(t)
+	}
+
+	provider.Assert(t, JWTValidationReasonCounter, "reason", "revoked_token")(xmetricstest.Value(1.0))
+	provider.Assert(t, JWTValidationReasonCounter, "reason", "ok")(xmetricstest.Value(1.0))
+}
+
 // TestJWSValidatorResolverError also tests the correct key id determination
 // when the header has a "kid" field vs the JWSValidator.DefaultKeyId member being set.
 func TestJWSValidatorResolverError(t *testing.T) {
@@ -480,7 +543,7 @@ func TestJWSValidatorVerify(t *testing.T) {
 		token := &Token{tokenType: Bearer, value: "does not matter"}
 
 		mockPair := &key.MockPair{}
-		expectedPublicKey := interface{}(123)
+		expectedPublicKey := testRSAPublicKey
 		mockPair.On("Public").Return(expectedPublicKey).Once()
 
 		mockResolver := &key.MockResolver{}
@@ -504,9 +567,7 @@ func TestJWSValidatorVerify(t *testing.T) {
 			Parser:   mockJWSParser,
 		}
 
-		ctx := context.Background()
-		ctx = context.WithValue(ctx, "method", "post")
-		ctx = context.WithValue(ctx, "path", "/api/foo/path")
+		ctx := WithRequestInfo(context.Background(), RequestInfo{Method: "post", Path: "/api/foo/path"})
 
 		valid, err := validator.Validate(ctx, token)
 		assert.Equal(record.expectedValid, valid)
@@ -538,7 +599,7 @@ func TestJWSValidatorValidate(t *testing.T) {
 		token := &Token{tokenType: Bearer, value: "does not matter"}
 
 		mockPair := &key.MockPair{}
-		expectedPublicKey := interface{}(123)
+		expectedPublicKey := testRSAPublicKey
 		mockPair.On("Public").Return(expectedPublicKey).Once()
 
 		mockResolver := &key.MockResolver{}
@@ -565,9 +626,7 @@ func TestJWSValidatorValidate(t *testing.T) {
 			JWTValidators: record.expectedJWTValidators,
 		}
 
-		ctx := context.Background()
-		ctx = context.WithValue(ctx, "method", "post")
-		ctx = context.WithValue(ctx, "path", "/api/foo/path")
+		ctx := WithRequestInfo(context.Background(), RequestInfo{Method: "post", Path: "/api/foo/path"})
 
 		valid, err := validator.Validate(ctx, token)
 		assert.Equal(record.expectedValid, valid)
@@ -702,7 +761,7 @@ func TestJWTValidatorFactory(t *testing.T) {
 	}
 }
 
-//A simple verification that a pointer function signature is used
+// A simple verification that a pointer function signature is used
 func TestDefineMeasures(t *testing.T) {
 	assert := assert.New(t)
 	a, m := JWTValidatorFactory{}, &JWTValidationMeasures{}
@@ -713,3 +772,143 @@ func TestDefineMeasures(t *testing.T) {
 	b.DefineMeasures(m)
 	assert.Equal(m, b.measures)
 }
+
+func TestCompatibleSigningMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	p521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	testData := []struct {
+		alg       string
+		publicKey interface{}
+		expected  bool
+	}{
+		{"RS256", &rsaKey.PublicKey, true},
+		{"RS512", &rsaKey.PublicKey, true},
+		{"PS384", &rsaKey.PublicKey, true},
+		{"ES256", &rsaKey.PublicKey, false},
+		{"HS256", &rsaKey.PublicKey, false},
+		{"none", &rsaKey.PublicKey, false},
+		{"ES256", &p256Key.PublicKey, true},
+		{"ES384", &p256Key.PublicKey, false},
+		{"ES384", &p384Key.PublicKey, true},
+		{"ES512", &p521Key.PublicKey, true},
+		{"ES512", &p384Key.PublicKey, false},
+		{"RS256", &p256Key.PublicKey, false},
+		{"EdDSA", ed25519Key, true},
+		{"ES256", ed25519Key, false},
+		{"RS256", []byte("secret"), false},
+		{"HS256", []byte("secret"), false},
+		{"RS256", nil, false},
+	}
+
+	for _, record := range testData {
+		t.Logf("alg: %s, key: %T", record.alg, record.publicKey)
+		assert.Equal(record.expected, compatibleSigningMethod(record.alg, record.publicKey))
+	}
+}
+
+func TestJWSValidatorIncompatibleSigningMethod(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		token    = &Token{tokenType: Bearer, value: "does not matter"}
+		measures = &JWTValidationMeasures{
+			ValidationReason: xmetricstest.NewProvider(nil, Metrics).NewCounter(JWTValidationReasonCounter),
+		}
+	)
+
+	mockPair := &key.MockPair{}
+	mockPair.On("Public").Return(testRSAPublicKey).Once()
+
+	mockResolver := &key.MockResolver{}
+	mockResolver.On("ResolveKey", mock.AnythingOfType("string")).Return(mockPair, nil).Once()
+
+	mockJWS := &mockJWS{}
+	mockJWS.On("Protected").Return(jose.Protected{"alg": "HS256"}).Once()
+
+	mockJWSParser := &mockJWSParser{}
+	mockJWSParser.On("ParseJWS", token).Return(mockJWS, nil).Once()
+
+	validator := &JWSValidator{
+		Resolver: mockResolver,
+		Parser:   mockJWSParser,
+	}
+
+	validator.DefineMeasures(measures)
+	valid, err := validator.Validate(WithRequestInfo(context.Background(), RequestInfo{"post", "/api/foo/path"}), token)
+	assert.False(valid)
+	assert.Equal(ErrorIncompatibleSigningMethod, err)
+
+	mockPair.AssertExpectations(t)
+	mockResolver.AssertExpectations(t)
+	mockJWS.AssertExpectations(t)
+	mockJWSParser.AssertExpectations(t)
+}
+
+func testJWSValidatorSigned(t *testing.T, signingMethod crypto.SigningMethod, private, public interface{}) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	serialized, err := jws.NewJWT(testClaims, signingMethod).Serialize(private)
+	require.NoError(err)
+
+	pair, err := key.NewPair(key.PurposeVerify, public, nil)
+	require.NoError(err)
+
+	mockResolver := &key.MockResolver{}
+	mockResolver.On("ResolveKey", "").Return(pair, nil).Once()
+
+	validator := &JWSValidator{
+		Resolver: mockResolver,
+	}
+
+	valid, err := validator.Validate(
+		WithRequestInfo(context.Background(), RequestInfo{"post", "/api/foo/path"}),
+		&Token{tokenType: Bearer, value: string(serialized)},
+	)
+
+	assert.True(valid)
+	assert.NoError(err)
+	mockResolver.AssertExpectations(t)
+}
+
+func TestJWSValidatorSigned(t *testing.T) {
+	t.Run("ES256", func(t *testing.T) {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		testJWSValidatorSigned(t, crypto.SigningMethodES256, privateKey, &privateKey.PublicKey)
+	})
+
+	t.Run("ES384", func(t *testing.T) {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+		testJWSValidatorSigned(t, crypto.SigningMethodES384, privateKey, &privateKey.PublicKey)
+	})
+
+	t.Run("ES512", func(t *testing.T) {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+		require.NoError(t, err)
+		testJWSValidatorSigned(t, crypto.SigningMethodES512, privateKey, &privateKey.PublicKey)
+	})
+
+	t.Run("EdDSA", func(t *testing.T) {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		testJWSValidatorSigned(t, SigningMethodEdDSA, privateKey, publicKey)
+	})
+}
@@ -444,6 +444,17 @@ func (w *WebPA) flavor() string {
 	return DefaultFlavor
 }
 
+// Meta returns the facts about this server that are suitable for advertising via service discovery, such as
+// consul service metadata.  Defaults are used for any facts which were not injected.
+func (w *WebPA) Meta() map[string]string {
+	return map[string]string{
+		"build":  w.build(),
+		"server": w.server(),
+		"region": w.region(),
+		"flavor": w.flavor(),
+	}
+}
+
 // Prepare gets a WebPA server ready for execution.  This method does not return errors, but the returned
 // Runnable may return an error.  The supplied logger will usually come from the New function, but the
 // WebPA.Log object can be used to create a different logger if desired.
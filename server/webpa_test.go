@@ -315,6 +315,30 @@ func TestWebPANoPrimaryAddress(t *testing.T) {
 	handler.AssertExpectations(t)
 }
 
+func TestWebPAMeta(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(
+		map[string]string{
+			"build":  DefaultBuild,
+			"server": DefaultServer,
+			"region": DefaultRegion,
+			"flavor": DefaultFlavor,
+		},
+		(*WebPA)(nil).Meta(),
+	)
+
+	assert.Equal(
+		map[string]string{
+			"build":  "1.2.3",
+			"server": "test.example.com",
+			"region": "east",
+			"flavor": "production",
+		},
+		(&WebPA{Build: "1.2.3", Server: "test.example.com", Region: "east", Flavor: "production"}).Meta(),
+	)
+}
+
 func TestWebPA(t *testing.T) {
 	var (
 		assert  = assert.New(t)
@@ -16,12 +16,41 @@ type Accessor interface {
 	Get(key []byte) (string, error)
 }
 
+// OrderedAccessor is an Accessor which can also produce the fallback instances for a key, in
+// hash preference order.  Fallback instances are useful for failover and for routing to replicas.
+type OrderedAccessor interface {
+	Accessor
+
+	// GetN returns up to n distinct instances associated with a key, in preference order.  The first
+	// instance is the same instance returned by Get.  If n is nonpositive, a single instance is returned.
+	GetN(key []byte, n int) ([]string, error)
+}
+
+// GetN returns up to n instances for a key, in preference order, from any Accessor.  If the Accessor
+// is not an OrderedAccessor, the single instance returned by Get is used.
+func GetN(a Accessor, key []byte, n int) ([]string, error) {
+	if oa, ok := a.(OrderedAccessor); ok {
+		return oa.GetN(key, n)
+	}
+
+	instance, err := a.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{instance}, nil
+}
+
 type emptyAccessor struct{}
 
 func (ea emptyAccessor) Get([]byte) (string, error) {
 	return "", errNoInstances
 }
 
+func (ea emptyAccessor) GetN([]byte, int) ([]string, error) {
+	return nil, errNoInstances
+}
+
 // EmptyAccessor returns an Accessor that always returns an error from Get.
 func EmptyAccessor() Accessor {
 	return emptyAccessor{}
@@ -70,6 +99,26 @@ func (ua *UpdatableAccessor) Get(key []byte) (instance string, err error) {
 	return
 }
 
+// GetN returns up to n instances for the key, in preference order, from the current set of instances.
+// If the current Accessor is not an OrderedAccessor, at most one instance is returned.
+func (ua *UpdatableAccessor) GetN(key []byte, n int) (instances []string, err error) {
+	ua.lock.RLock()
+
+	switch {
+	case ua.err != nil:
+		err = ua.err
+
+	case ua.current != nil:
+		instances, err = GetN(ua.current, key, n)
+
+	default:
+		err = errNoInstances
+	}
+
+	ua.lock.RUnlock()
+	return
+}
+
 // SetError clears the instances being used by this instance and sets the error to be returned
 // by Get with every call.  This error will be returned by Get until an update with one or more instances
 // occurs.
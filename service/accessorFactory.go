@@ -8,6 +8,22 @@ const DefaultVnodeCount = 211
 // of nodes and turn them into an Accessor.
 type AccessorFactory func([]string) Accessor
 
+// consistentAccessor is the OrderedAccessor for consistent hashing.  The fallback instances for a key,
+// following the instance chosen by the hash ring, are ordered by rendezvous hashing.
+type consistentAccessor struct {
+	*consistentHash.ConsistentHash
+	fallbacks *rendezvousAccessor
+}
+
+func (ca consistentAccessor) GetN(key []byte, n int) ([]string, error) {
+	first, err := ca.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return ca.fallbacks.order(key, n, first), nil
+}
+
 func newConsistentAccessor(vnodeCount int, instances []string) Accessor {
 	if len(instances) == 0 {
 		return emptyAccessor{}
@@ -19,7 +35,10 @@ func newConsistentAccessor(vnodeCount int, instances []string) Accessor {
 		hasher.Add(i)
 	}
 
-	return hasher
+	return consistentAccessor{
+		ConsistentHash: hasher,
+		fallbacks:      newRendezvousAccessor(instances).(*rendezvousAccessor),
+	}
 }
 
 // NewConsistentAccessorFactory produces a factory which uses consistent hashing
@@ -39,9 +39,61 @@ func testNewConsistentAccessorNonEmpty(t *testing.T) {
 	}
 }
 
+func testNewConsistentAccessorGetN(t *testing.T) {
+	instances := []string{"https://one.com", "https://two.com", "https://three.com", "https://four.com"}
+	testOrderedAccessor(t, newConsistentAccessor(123, instances), instances)
+}
+
 func TestNewConsistentAccessor(t *testing.T) {
 	t.Run("Empty", testNewConsistentAccessorEmpty)
 	t.Run("Nonempty", testNewConsistentAccessorNonEmpty)
+	t.Run("GetN", testNewConsistentAccessorGetN)
+}
+
+// testOrderedAccessor verifies the general contract of an OrderedAccessor
+func testOrderedAccessor(t *testing.T, a Accessor, instances []string) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	require.Implements((*OrderedAccessor)(nil), a)
+	oa := a.(OrderedAccessor)
+
+	for _, k := range []string{"a", "alsdkjfa;lksehjuro8iwurjhf", "asdf8974", "875kjh4", "928375hjdfgkyu9832745kjshdfgoi873465"} {
+		expected, err := a.Get([]byte(k))
+		require.NoError(err)
+
+		for n := -1; n <= len(instances)+1; n++ {
+			ordered, err := oa.GetN([]byte(k), n)
+			require.NoError(err)
+			require.NotEmpty(ordered)
+			assert.Equal(expected, ordered[0])
+
+			switch {
+			case n < 1:
+				assert.Len(ordered, 1)
+			case n > len(instances):
+				assert.ElementsMatch(instances, ordered)
+			default:
+				assert.Len(ordered, n)
+			}
+
+			seen := make(map[string]bool, len(ordered))
+			for _, i := range ordered {
+				assert.False(seen[i], "duplicate instance %s", i)
+				assert.Contains(instances, i)
+				seen[i] = true
+			}
+
+			// preference order must be stable as n grows
+			if n > 1 {
+				previous, err := oa.GetN([]byte(k), n-1)
+				require.NoError(err)
+				assert.Equal(previous, ordered[:len(previous)])
+			}
+		}
+	}
 }
 
 func testNewConsistentAccessorFactory(t *testing.T, vnodeCount int) {
@@ -97,3 +97,56 @@ func TestUpdatableAccessor(t *testing.T) {
 	assert.Empty(i)
 	assert.Equal(expectedError, err)
 }
+
+func TestGetN(t *testing.T) {
+	t.Run("Accessor", func(t *testing.T) {
+		assert := assert.New(t)
+
+		instances, err := GetN(MapAccessor{"test": "a valid instance"}, []byte("test"), 3)
+		assert.Equal([]string{"a valid instance"}, instances)
+		assert.NoError(err)
+
+		instances, err = GetN(MapAccessor{"test": "a valid instance"}, []byte("nosuch"), 3)
+		assert.Empty(instances)
+		assert.Error(err)
+	})
+
+	t.Run("OrderedAccessor", func(t *testing.T) {
+		assert := assert.New(t)
+
+		instances, err := GetN(EmptyAccessor(), []byte("test"), 3)
+		assert.Empty(instances)
+		assert.Error(err)
+
+		instances, err = GetN(RendezvousAccessorFactory([]string{"https://one.com", "https://two.com"}), []byte("test"), 3)
+		assert.Len(instances, 2)
+		assert.NoError(err)
+	})
+}
+
+func TestUpdatableAccessorGetN(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ua     = new(UpdatableAccessor)
+	)
+
+	instances, err := ua.GetN([]byte("test"), 2)
+	assert.Empty(instances)
+	assert.Error(err)
+
+	ua.SetInstances(MapAccessor{"test": "a valid instance"})
+	instances, err = ua.GetN([]byte("test"), 2)
+	assert.Equal([]string{"a valid instance"}, instances)
+	assert.NoError(err)
+
+	ua.SetInstances(RendezvousAccessorFactory([]string{"https://one.com", "https://two.com", "https://three.com"}))
+	instances, err = ua.GetN([]byte("test"), 2)
+	assert.Len(instances, 2)
+	assert.NoError(err)
+
+	expectedError := errors.New("expected")
+	ua.SetError(expectedError)
+	instances, err = ua.GetN([]byte("test"), 2)
+	assert.Empty(instances)
+	assert.Equal(expectedError, err)
+}
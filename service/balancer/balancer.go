@@ -0,0 +1,306 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/Comcast/webpa-common/service/monitor"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	DefaultMaxFailures     = 5
+	DefaultEjectionTime    = 30 * time.Second
+	DefaultMaxEjectionTime = 5 * time.Minute
+)
+
+// ErrorNoInstances is returned by a Balancer when service discovery has not supplied any instances
+var ErrorNoInstances = errors.New("No instances available")
+
+// Error is returned by a Balancer when an HTTP transaction with an instance fails.  This type implements the
+// Temporary() bool method expected by xhttp.DefaultShouldRetry, so that transactions are retried on another instance.
+type Error struct {
+	// Instance is the instance that was sent the failed transaction
+	Instance string
+
+	// Err is the error returned by the underlying http.RoundTripper
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Instance, e.Err)
+}
+
+// Temporary returns true unless the transaction was canceled or timed out by its context, as another
+// instance may succeed where this one failed.
+func (e *Error) Temporary() bool {
+	return e.Err != context.Canceled && e.Err != context.DeadlineExceeded
+}
+
+// DefaultIsFailure is the default predicate for failed transactions.  A transaction fails if it returned an error
+// or any 5xx status code.
+func DefaultIsFailure(response *http.Response, err error) bool {
+	return err != nil || response.StatusCode >= 500
+}
+
+// Option configures a Balancer
+type Option func(*Balancer)
+
+// WithLogger configures the go-kit logger for a Balancer.  By default, logging.DefaultLogger() is used.
+func WithLogger(l log.Logger) Option {
+	return func(b *Balancer) {
+		if l != nil {
+			b.logger = l
+		} else {
+			b.logger = logging.DefaultLogger()
+		}
+	}
+}
+
+// WithKey restricts a Balancer to the service discovery events for a single instancer key.  By default, the events
+// for every key are used, which is appropriate when the monitor only watches (1) service.
+func WithKey(k string) Option {
+	return func(b *Balancer) {
+		b.key = k
+	}
+}
+
+// WithStrategy configures the load balancing algorithm.  By default, RoundRobin is used.
+func WithStrategy(s Strategy) Option {
+	return func(b *Balancer) {
+		if s != nil {
+			b.strategy = s
+		} else {
+			b.strategy = RoundRobin()
+		}
+	}
+}
+
+// WithTransport configures the http.RoundTripper which sends transactions to the chosen instances.  By default,
+// http.DefaultTransport is used.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(b *Balancer) {
+		if rt != nil {
+			b.transport = rt
+		} else {
+			b.transport = http.DefaultTransport
+		}
+	}
+}
+
+// WithIsFailure configures the predicate for failed transactions.  By default, DefaultIsFailure is used.
+func WithIsFailure(f func(*http.Response, error) bool) Option {
+	return func(b *Balancer) {
+		if f != nil {
+			b.isFailure = f
+		} else {
+			b.isFailure = DefaultIsFailure
+		}
+	}
+}
+
+// WithOutlierEjection configures passive outlier ejection.  An instance with maxFailures consecutive failed transactions is
+// ejected for ejectionTime multiplied by the number of times in a row it has been ejected, up to maxEjectionTime.  Nonpositive
+// values leave the corresponding defaults in place.
+func WithOutlierEjection(maxFailures int, ejectionTime, maxEjectionTime time.Duration) Option {
+	return func(b *Balancer) {
+		if maxFailures > 0 {
+			b.maxFailures = maxFailures
+		}
+
+		if ejectionTime > 0 {
+			b.ejectionTime = ejectionTime
+		}
+
+		if maxEjectionTime > 0 {
+			b.maxEjectionTime = maxEjectionTime
+		}
+	}
+}
+
+// Balancer is a client-side load balancer for HTTP transactions.  Instances are supplied by service discovery via
+// MonitorEvent, and each transaction passed to RoundTrip is sent to an instance chosen by the Strategy.
+//
+// Ejected instances are not chosen unless every instance is ejected, in which case ejection is ignored so that
+// transactions are still attempted.
+type Balancer struct {
+	logger          log.Logger
+	key             string
+	strategy        Strategy
+	transport       http.RoundTripper
+	isFailure       func(*http.Response, error) bool
+	maxFailures     int
+	ejectionTime    time.Duration
+	maxEjectionTime time.Duration
+	now             func() time.Time
+
+	lock      sync.RWMutex
+	endpoints []*Endpoint
+}
+
+// New creates a Balancer with no instances.  The returned Balancer should be passed to monitor.WithListeners.
+func New(options ...Option) *Balancer {
+	b := &Balancer{
+		logger:          logging.DefaultLogger(),
+		strategy:        RoundRobin(),
+		transport:       http.DefaultTransport,
+		isFailure:       DefaultIsFailure,
+		maxFailures:     DefaultMaxFailures,
+		ejectionTime:    DefaultEjectionTime,
+		maxEjectionTime: DefaultMaxEjectionTime,
+		now:             time.Now,
+	}
+
+	for _, o := range options {
+		o(b)
+	}
+
+	return b
+}
+
+// MonitorEvent updates the instances of this Balancer.  The state of instances that remain, such as ejection, is retained.
+// Service discovery errors leave the current instances in place.
+func (b *Balancer) MonitorEvent(e monitor.Event) {
+	if len(b.key) > 0 && e.Key != b.key {
+		return
+	}
+
+	switch {
+	case e.Stopped:
+		return
+
+	case e.Err != nil:
+		b.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "keeping current instances due to service discovery error", "key", e.Key, logging.ErrorKey(), e.Err)
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	current := make(map[string]*Endpoint, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		current[ep.instance] = ep
+	}
+
+	endpoints := make([]*Endpoint, 0, len(e.Instances))
+	for _, instance := range e.Instances {
+		if ep, ok := current[instance]; ok {
+			endpoints = append(endpoints, ep)
+			continue
+		}
+
+		normalized, err := service.NormalizeInstance("", instance)
+		if err != nil {
+			b.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "skipping invalid instance", "instance", instance, logging.ErrorKey(), err)
+			continue
+		}
+
+		u, err := url.Parse(normalized)
+		if err != nil {
+			b.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "skipping invalid instance", "instance", instance, logging.ErrorKey(), err)
+			continue
+		}
+
+		endpoints = append(endpoints, newEndpoint(instance, u))
+	}
+
+	b.endpoints = endpoints
+}
+
+// Endpoints returns the current endpoints of this Balancer
+func (b *Balancer) Endpoints() []*Endpoint {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return append([]*Endpoint(nil), b.endpoints...)
+}
+
+// pick chooses the endpoint for a transaction.  Instances which have already been tried for the transaction are
+// avoided if possible, followed by ejected instances.
+func (b *Balancer) pick(tried map[string]bool) (*Endpoint, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	if len(b.endpoints) == 0 {
+		return nil, ErrorNoInstances
+	}
+
+	var (
+		now        = b.now()
+		untried    = make([]*Endpoint, 0, len(b.endpoints))
+		candidates = make([]*Endpoint, 0, len(b.endpoints))
+	)
+
+	for _, ep := range b.endpoints {
+		if tried[ep.instance] {
+			continue
+		}
+
+		untried = append(untried, ep)
+		if !ep.ejected(now) {
+			candidates = append(candidates, ep)
+		}
+	}
+
+	switch {
+	case len(candidates) > 0:
+		return b.strategy.Pick(candidates), nil
+
+	case len(untried) > 0:
+		return b.strategy.Pick(untried), nil
+
+	default:
+		return b.strategy.Pick(b.endpoints), nil
+	}
+}
+
+// RoundTrip sends the given request to an instance chosen by this Balancer's Strategy.  The scheme and host of the
+// request's URL are replaced with those of the instance.  Errors from the underlying transport are returned as *Error.
+// The transaction counts as active for its instance until the returned response's Body is closed.
+func (b *Balancer) RoundTrip(request *http.Request) (*http.Response, error) {
+	t, _ := request.Context().Value(triedKey{}).(*tried)
+	ep, err := b.pick(t.instances())
+	if err != nil {
+		return nil, err
+	}
+
+	t.add(ep.instance)
+
+	u := *request.URL
+	u.Scheme = ep.url.Scheme
+	u.Host = ep.url.Host
+
+	outbound := request.WithContext(request.Context())
+	outbound.URL = &u
+	outbound.Host = ""
+
+	ep.begin()
+	response, err := b.transport.RoundTrip(outbound)
+	if err == nil && response != nil && response.Body != nil {
+		// the transaction remains active until the caller closes the body
+		response.Body = &activeBody{ReadCloser: response.Body, endpoint: ep}
+	} else {
+		ep.end()
+	}
+
+	if b.isFailure(response, err) {
+		if d := ep.failure(b.now(), b.maxFailures, b.ejectionTime, b.maxEjectionTime); d > 0 {
+			b.logger.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "ejecting instance", "instance", ep.instance, "duration", d.String())
+		}
+	} else {
+		ep.success(b.now())
+	}
+
+	if err != nil {
+		return nil, &Error{Instance: ep.instance, Err: err}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,219 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func instances(b *Balancer) []string {
+	var result []string
+	for _, ep := range b.Endpoints() {
+		result = append(result, ep.Instance())
+	}
+
+	return result
+}
+
+func TestError(t *testing.T) {
+	assert := assert.New(t)
+
+	err := &Error{Instance: "http://foobar.com", Err: errors.New("expected")}
+	assert.Equal("http://foobar.com: expected", err.Error())
+	assert.True(err.Temporary())
+
+	assert.False((&Error{Err: context.Canceled}).Temporary())
+	assert.False((&Error{Err: context.DeadlineExceeded}).Temporary())
+}
+
+func TestDefaultIsFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(DefaultIsFailure(nil, errors.New("expected")))
+	assert.True(DefaultIsFailure(&http.Response{StatusCode: 503}, nil))
+	assert.False(DefaultIsFailure(&http.Response{StatusCode: 404}, nil))
+	assert.False(DefaultIsFailure(&http.Response{StatusCode: 200}, nil))
+}
+
+func testBalancerDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, b := range []*Balancer{New(), New(WithLogger(nil), WithStrategy(nil), WithTransport(nil), WithIsFailure(nil), WithOutlierEjection(0, 0, 0))} {
+		assert.NotNil(b.logger)
+		assert.NotNil(b.strategy)
+		assert.Equal(http.DefaultTransport, b.transport)
+		assert.NotNil(b.isFailure)
+		assert.Equal(DefaultMaxFailures, b.maxFailures)
+		assert.Equal(DefaultEjectionTime, b.ejectionTime)
+		assert.Equal(DefaultMaxEjectionTime, b.maxEjectionTime)
+		assert.Empty(b.Endpoints())
+	}
+}
+
+func testBalancerMonitorEvent(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		b      = New(WithLogger(logging.NewTestLogger(nil, t)), WithKey("test"))
+	)
+
+	b.MonitorEvent(monitor.Event{Key: "test", Instances: []string{"http://a.com", "http://b.com:8080", " "}})
+	assert.Equal([]string{"http://a.com", "http://b.com:8080"}, instances(b))
+	original := b.Endpoints()
+
+	b.MonitorEvent(monitor.Event{Key: "other", Instances: []string{"http://other.com"}})
+	b.MonitorEvent(monitor.Event{Key: "test", Err: errors.New("expected")})
+	b.MonitorEvent(monitor.Event{Key: "test", Stopped: true})
+	assert.Equal([]string{"http://a.com", "http://b.com:8080"}, instances(b))
+
+	// existing endpoints retain their state
+	b.MonitorEvent(monitor.Event{Key: "test", Instances: []string{"http://b.com:8080", "http://c.com"}})
+	assert.Equal([]string{"http://b.com:8080", "http://c.com"}, instances(b))
+	assert.True(original[1] == b.Endpoints()[0])
+
+	b.MonitorEvent(monitor.Event{Key: "test"})
+	assert.Empty(b.Endpoints())
+}
+
+func testBalancerNoInstances(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		b      = New()
+	)
+
+	response, err := b.RoundTrip(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(response)
+	assert.Equal(ErrorNoInstances, err)
+}
+
+func testBalancerRoundTrip(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		handler = func(name string) http.Handler {
+			return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				fmt.Fprintf(response, "%s %s?%s", name, request.URL.Path, request.URL.RawQuery)
+			})
+		}
+
+		first  = httptest.NewServer(handler("first"))
+		second = httptest.NewServer(handler("second"))
+
+		b = New(WithLogger(logging.NewTestLogger(nil, t)))
+	)
+
+	defer first.Close()
+	defer second.Close()
+
+	b.MonitorEvent(monitor.Event{Instances: []string{first.URL, second.URL}})
+	client := &http.Client{Transport: b}
+
+	for _, expected := range []string{"first /test?a=1", "second /test?a=1", "first /test?a=1"} {
+		request, err := http.NewRequest("GET", "http://placeholder.net/test?a=1", nil)
+		require.NoError(err)
+
+		response, err := client.Do(request)
+		require.NoError(err)
+
+		var active int64
+		for _, ep := range b.Endpoints() {
+			active += ep.Active()
+		}
+
+		assert.Equal(int64(1), active)
+
+		var body [64]byte
+		n, _ := response.Body.Read(body[:])
+		response.Body.Close()
+		response.Body.Close()
+		assert.Equal(expected, string(body[:n]))
+	}
+
+	for _, ep := range b.Endpoints() {
+		assert.Zero(ep.Active())
+	}
+}
+
+func testBalancerOutlierEjection(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		now       = time.Now()
+		transport = new(mockRoundTripper)
+		b         = New(
+			WithLogger(logging.NewTestLogger(nil, t)),
+			WithTransport(transport),
+			WithOutlierEjection(2, time.Minute, 90*time.Second),
+		)
+
+		expectedErr = errors.New("expected")
+	)
+
+	b.now = func() time.Time { return now }
+	b.MonitorEvent(monitor.Event{Instances: []string{"http://bad.com", "http://good.com"}})
+
+	transport.On("RoundTrip", mock.MatchedBy(func(r *http.Request) bool { return r.URL.Host == "bad.com" })).Return(nil, expectedErr)
+	transport.On("RoundTrip", mock.MatchedBy(func(r *http.Request) bool { return r.URL.Host == "good.com" })).Return(&http.Response{StatusCode: 200}, nil)
+
+	roundTrip := func() (string, error) {
+		response, err := b.RoundTrip(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			require.Nil(response)
+			return err.(*Error).Instance, err
+		}
+
+		return "http://good.com", nil
+	}
+
+	// round robin alternates until bad.com reaches 2 consecutive failures
+	for _, expected := range []string{"http://bad.com", "http://good.com", "http://bad.com"} {
+		instance, _ := roundTrip()
+		assert.Equal(expected, instance)
+	}
+
+	for repeat := 0; repeat < 4; repeat++ {
+		instance, err := roundTrip()
+		assert.Equal("http://good.com", instance)
+		assert.NoError(err)
+	}
+
+	// after the ejection time, bad.com is tried again, and a single failure ejects it for longer
+	now = now.Add(time.Minute)
+	instances := make(map[string]bool)
+	for repeat := 0; repeat < 2; repeat++ {
+		instance, _ := roundTrip()
+		instances[instance] = true
+	}
+
+	assert.Equal(map[string]bool{"http://bad.com": true, "http://good.com": true}, instances)
+	b.MonitorEvent(monitor.Event{Instances: []string{"http://bad.com"}})
+
+	// when every instance is ejected, ejection is ignored
+	for repeat := 0; repeat < 2; repeat++ {
+		instance, err := roundTrip()
+		assert.Equal("http://bad.com", instance)
+		assert.Error(err)
+	}
+
+	assert.True(b.Endpoints()[0].ejected(now.Add(89 * time.Second)))
+	assert.False(b.Endpoints()[0].ejected(now.Add(90 * time.Second)))
+}
+
+func TestBalancer(t *testing.T) {
+	t.Run("Defaults", testBalancerDefaults)
+	t.Run("MonitorEvent", testBalancerMonitorEvent)
+	t.Run("NoInstances", testBalancerNoInstances)
+	t.Run("RoundTrip", testBalancerRoundTrip)
+	t.Run("OutlierEjection", testBalancerOutlierEjection)
+}
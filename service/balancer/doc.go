@@ -0,0 +1,9 @@
+/*
+Package balancer provides client-side load balancing of HTTP transactions across the instances of a discovered service.
+
+A Balancer is both a monitor.Listener, which receives the current instances from service discovery, and an
+http.RoundTripper which sends each request to an instance chosen by a pluggable Strategy.  Instances which fail
+repeatedly are passively ejected for a time.  NewRetryTransactor combines a Balancer with xhttp.RetryTransactor
+so that failed transactions are retried on a different instance.
+*/
+package balancer
@@ -0,0 +1,103 @@
+package balancer
+
+import (
+	"io"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Endpoint is a single instance which can receive HTTP transactions, together with its load and health
+type Endpoint struct {
+	instance string
+	url      *url.URL
+	active   int64
+
+	lock         sync.Mutex
+	failures     int
+	ejections    int
+	ejectedUntil time.Time
+}
+
+func newEndpoint(instance string, u *url.URL) *Endpoint {
+	return &Endpoint{
+		instance: instance,
+		url:      u,
+	}
+}
+
+// Instance returns the service discovery instance for this endpoint, e.g. "https://foobar.com:8080"
+func (e *Endpoint) Instance() string {
+	return e.instance
+}
+
+// Active returns the count of transactions currently in flight to this endpoint, including any transactions
+// whose response bodies have not yet been closed
+func (e *Endpoint) Active() int64 {
+	return atomic.LoadInt64(&e.active)
+}
+
+func (e *Endpoint) begin() {
+	atomic.AddInt64(&e.active, 1)
+}
+
+func (e *Endpoint) end() {
+	atomic.AddInt64(&e.active, -1)
+}
+
+// activeBody is a response body that keeps its transaction active until the body is closed.  This means
+// the active count includes the time spent reading the body, not just the time spent waiting for headers.
+type activeBody struct {
+	io.ReadCloser
+	endpoint *Endpoint
+	once     sync.Once
+}
+
+func (ab *activeBody) Close() error {
+	err := ab.ReadCloser.Close()
+	ab.once.Do(ab.endpoint.end)
+	return err
+}
+
+// ejected tests if this endpoint is ejected as of the given time
+func (e *Endpoint) ejected(now time.Time) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return now.Before(e.ejectedUntil)
+}
+
+// failure records a failed transaction.  If the consecutive failure count reaches maxFailures, this endpoint
+// is ejected for a time that grows with each successive ejection, up to maxEjectionTime.  The returned
+// duration is positive if and only if this failure resulted in an ejection.
+func (e *Endpoint) failure(now time.Time, maxFailures int, ejectionTime, maxEjectionTime time.Duration) time.Duration {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.failures++
+	if e.failures < maxFailures {
+		return 0
+	}
+
+	e.failures = 0
+	e.ejections++
+	d := time.Duration(e.ejections) * ejectionTime
+	if d > maxEjectionTime {
+		d = maxEjectionTime
+	}
+
+	e.ejectedUntil = now.Add(d)
+	return d
+}
+
+// success records a successful transaction, which resets the consecutive failures.  An endpoint that succeeds
+// after its ejection has ended is considered healthy again.
+func (e *Endpoint) success(now time.Time) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.failures = 0
+	if !now.Before(e.ejectedUntil) {
+		e.ejections = 0
+	}
+}
@@ -0,0 +1,17 @@
+package balancer
+
+import (
+	"net/http"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRoundTripper struct {
+	mock.Mock
+}
+
+func (m *mockRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	arguments := m.Called(request)
+	response, _ := arguments.Get(0).(*http.Response)
+	return response, arguments.Error(1)
+}
@@ -0,0 +1,54 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/Comcast/webpa-common/xhttp"
+)
+
+type triedKey struct{}
+
+// tried is the set of instances that have been sent a given transaction.  A nil *tried is valid, and tracks nothing.
+type tried struct {
+	lock sync.Mutex
+	set  map[string]bool
+}
+
+func (t *tried) instances() map[string]bool {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	result := make(map[string]bool, len(t.set))
+	for k, v := range t.set {
+		result[k] = v
+	}
+
+	return result
+}
+
+func (t *tried) add(instance string) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	t.set[instance] = true
+	t.lock.Unlock()
+}
+
+// NewRetryTransactor decorates a Balancer with xhttp.RetryTransactor, so that failed transactions are retried.  Each retry
+// is sent to an instance that has not yet been tried for the transaction, as long as there is one.  Unless o.ShouldRetry is
+// set, xhttp.DefaultShouldRetry is used, which retries any *Error that is temporary.
+func NewRetryTransactor(o xhttp.RetryOptions, b *Balancer) func(*http.Request) (*http.Response, error) {
+	retry := xhttp.RetryTransactor(o, b.RoundTrip)
+	return func(request *http.Request) (*http.Response, error) {
+		t := &tried{set: make(map[string]bool)}
+		return retry(request.WithContext(context.WithValue(request.Context(), triedKey{}, t)))
+	}
+}
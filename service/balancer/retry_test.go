@@ -0,0 +1,51 @@
+package balancer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service/monitor"
+	"github.com/Comcast/webpa-common/xhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewRetryTransactor(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		transport = new(mockRoundTripper)
+		expected  = &http.Response{StatusCode: 200}
+
+		// always picks the first candidate, so retries only move on because of the tried set
+		b = New(
+			WithLogger(logging.NewTestLogger(nil, t)),
+			WithTransport(transport),
+			WithStrategy(StrategyFunc(func(candidates []*Endpoint) *Endpoint { return candidates[0] })),
+		)
+
+		retry = NewRetryTransactor(
+			xhttp.RetryOptions{
+				Logger:  logging.NewTestLogger(nil, t),
+				Retries: 2,
+				Sleep:   func(time.Duration) {},
+			},
+			b,
+		)
+	)
+
+	b.MonitorEvent(monitor.Event{Instances: []string{"http://first.com", "http://second.com", "http://third.com"}})
+
+	transport.On("RoundTrip", mock.MatchedBy(func(r *http.Request) bool { return r.URL.Host == "first.com" })).Return(nil, errors.New("expected")).Once()
+	transport.On("RoundTrip", mock.MatchedBy(func(r *http.Request) bool { return r.URL.Host == "second.com" })).Return(nil, errors.New("expected")).Once()
+	transport.On("RoundTrip", mock.MatchedBy(func(r *http.Request) bool { return r.URL.Host == "third.com" })).Return(expected, nil).Once()
+
+	response, err := retry(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(expected, response)
+	assert.NoError(err)
+
+	transport.AssertExpectations(t)
+}
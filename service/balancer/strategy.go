@@ -0,0 +1,94 @@
+package balancer
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Strategy is a load balancing algorithm.  Pick is always passed at least (1) candidate Endpoint,
+// and must be safe for concurrent use.
+type Strategy interface {
+	Pick([]*Endpoint) *Endpoint
+}
+
+// StrategyFunc is a function type that implements Strategy
+type StrategyFunc func([]*Endpoint) *Endpoint
+
+func (sf StrategyFunc) Pick(candidates []*Endpoint) *Endpoint {
+	return sf(candidates)
+}
+
+// RoundRobin returns a Strategy which cycles through the candidates
+func RoundRobin() Strategy {
+	var next uint64
+	return StrategyFunc(func(candidates []*Endpoint) *Endpoint {
+		n := atomic.AddUint64(&next, 1) - 1
+		return candidates[n%uint64(len(candidates))]
+	})
+}
+
+// LeastRequest returns a Strategy which picks the candidate with the fewest active transactions.
+// Ties go to the earliest candidate.
+func LeastRequest() Strategy {
+	return StrategyFunc(func(candidates []*Endpoint) *Endpoint {
+		choice := candidates[0]
+		for _, c := range candidates[1:] {
+			if c.Active() < choice.Active() {
+				choice = c
+			}
+		}
+
+		return choice
+	})
+}
+
+// lockedIntn is a source of random integers that is safe for concurrent use
+type lockedIntn struct {
+	lock   sync.Mutex
+	random *rand.Rand
+}
+
+func (li *lockedIntn) Intn(n int) int {
+	li.lock.Lock()
+	defer li.lock.Unlock()
+	return li.random.Intn(n)
+}
+
+// powerOfTwoChoices picks the less loaded of two random candidates
+type powerOfTwoChoices struct {
+	intn func(int) int
+}
+
+func (p powerOfTwoChoices) Pick(candidates []*Endpoint) *Endpoint {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	var (
+		first  = p.intn(len(candidates))
+		second = p.intn(len(candidates) - 1)
+	)
+
+	// choose a distinct second candidate
+	if second >= first {
+		second++
+	}
+
+	if candidates[second].Active() < candidates[first].Active() {
+		return candidates[second]
+	}
+
+	return candidates[first]
+}
+
+// PowerOfTwoChoices returns a Strategy which picks two distinct random candidates, then uses the one with
+// fewer active transactions.  This approximates LeastRequest without every client herding onto the same instance.
+func PowerOfTwoChoices() Strategy {
+	li := &lockedIntn{
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	return powerOfTwoChoices{intn: li.Intn}
+}
@@ -0,0 +1,99 @@
+package balancer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testEndpoints(active ...int64) []*Endpoint {
+	endpoints := make([]*Endpoint, len(active))
+	for i, a := range active {
+		endpoints[i] = &Endpoint{instance: string('a' + rune(i)), active: a}
+	}
+
+	return endpoints
+}
+
+func TestRoundRobin(t *testing.T) {
+	var (
+		assert     = assert.New(t)
+		strategy   = RoundRobin()
+		candidates = testEndpoints(0, 0, 0)
+	)
+
+	for repeat := 0; repeat < 2; repeat++ {
+		for _, expected := range candidates {
+			assert.Equal(expected, strategy.Pick(candidates))
+		}
+	}
+
+	single := testEndpoints(5)
+	assert.Equal(single[0], strategy.Pick(single))
+}
+
+func TestLeastRequest(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		strategy = LeastRequest()
+	)
+
+	candidates := testEndpoints(3, 1, 2, 1)
+	assert.Equal(candidates[1], strategy.Pick(candidates))
+
+	candidates = testEndpoints(0)
+	assert.Equal(candidates[0], strategy.Pick(candidates))
+}
+
+func TestPowerOfTwoChoices(t *testing.T) {
+	t.Run("Deterministic", func(t *testing.T) {
+		testData := []struct {
+			random   []int
+			active   []int64
+			expected int
+		}{
+			{[]int{0, 0}, []int64{2, 1, 0}, 1},
+			{[]int{1, 1}, []int64{2, 1, 0}, 2},
+			{[]int{2, 0}, []int64{0, 1, 3}, 0},
+			{[]int{2, 1}, []int64{0, 3, 3}, 2},
+		}
+
+		for i, record := range testData {
+			t.Logf("%d: %#v", i, record)
+
+			var (
+				random     = record.random
+				candidates = testEndpoints(record.active...)
+				strategy   = powerOfTwoChoices{
+					intn: func(n int) int {
+						v := random[0]
+						random = random[1:]
+						assert.True(t, v < n)
+						return v
+					},
+				}
+			)
+
+			assert.Equal(t, candidates[record.expected], strategy.Pick(candidates))
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		var (
+			assert     = assert.New(t)
+			strategy   = PowerOfTwoChoices()
+			candidates = testEndpoints(5, 0, 5)
+		)
+
+		// the least loaded endpoint should never lose a comparison
+		picked := make(map[*Endpoint]int)
+		for repeat := 0; repeat < 100; repeat++ {
+			picked[strategy.Pick(candidates)]++
+		}
+
+		assert.True(picked[candidates[1]] > 0)
+
+		single := testEndpoints(1)
+		assert.Equal(single[0], strategy.Pick(single))
+	})
+}
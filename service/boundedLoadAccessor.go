@@ -0,0 +1,142 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultLoadFactor is the default bound on an instance's load relative to the average load
+const DefaultLoadFactor = 1.25
+
+// BoundedLoadAccessor implements consistent hashing with bounded loads.  Each key is assigned to the
+// first instance on the ring, starting at the key's hash, whose load is under a capacity of loadFactor times
+// the average load.  Assignments are remembered, so subsequent calls to Get for a key return the same instance
+// until that key is released.
+//
+// Every key placed by Get or GetN counts against its instance's load until Release is called for that key.
+// Callers must release each key once it is no longer in use, e.g. when a device disconnects; otherwise loads
+// only ever grow and every instance eventually sits at capacity.  An accessor is built for one set of instances,
+// so all assignments are discarded when a new accessor replaces it.
+//
+// Because assignments depend on the order in which keys are placed, this Accessor is appropriate only when a single
+// process makes all placement decisions and releases keys.  Independent processes which must agree on placement,
+// e.g. every server deciding which instance owns a device, should use the stateless Accessors instead.
+type BoundedLoadAccessor struct {
+	lock sync.Mutex
+
+	ring        *weightedRing
+	instances   int
+	loadFactor  float64
+	assignments map[string]string
+	loads       map[string]int
+}
+
+// capacity is the maximum load of any instance once another key is placed
+func (ba *BoundedLoadAccessor) capacity() int {
+	return int(math.Ceil(ba.loadFactor * float64(len(ba.assignments)+1) / float64(ba.instances)))
+}
+
+// Get returns the instance to which the given key is assigned, placing the key if necessary
+func (ba *BoundedLoadAccessor) Get(key []byte) (string, error) {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	if instance, ok := ba.assignments[string(key)]; ok {
+		return instance, nil
+	}
+
+	var (
+		capacity = ba.capacity()
+		start    = ba.ring.search(key)
+		instance = ba.ring.instances[start]
+	)
+
+	// as the load factor is at least 1, some instance is always under capacity
+	for i := 0; i < ba.ring.Len(); i++ {
+		if candidate := ba.ring.instances[(start+i)%ba.ring.Len()]; ba.loads[candidate] < capacity {
+			instance = candidate
+			break
+		}
+	}
+
+	ba.assignments[string(key)] = instance
+	ba.loads[instance]++
+	return instance, nil
+}
+
+// GetN returns the instance to which the key is assigned, placing it if necessary, followed by the
+// remaining instances in ring order.  Only the first instance counts against capacity.
+func (ba *BoundedLoadAccessor) GetN(key []byte, n int) ([]string, error) {
+	first, err := ba.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 1 {
+		n = 1
+	}
+
+	return ba.ring.walk(key, n, []string{first}), nil
+}
+
+// Release removes the assignment of the given key, freeing capacity on its instance.  Keys which are
+// not assigned are ignored.
+func (ba *BoundedLoadAccessor) Release(key []byte) {
+	ba.lock.Lock()
+	if instance, ok := ba.assignments[string(key)]; ok {
+		delete(ba.assignments, string(key))
+		ba.loads[instance]--
+	}
+
+	ba.lock.Unlock()
+}
+
+// Loads returns a copy of the number of keys assigned to each instance
+func (ba *BoundedLoadAccessor) Loads() map[string]int {
+	ba.lock.Lock()
+	loads := make(map[string]int, len(ba.loads))
+	for instance, load := range ba.loads {
+		loads[instance] = load
+	}
+
+	ba.lock.Unlock()
+	return loads
+}
+
+func newBoundedLoadAccessor(vnodeCount int, loadFactor float64, instances []string) Accessor {
+	if len(instances) == 0 {
+		return emptyAccessor{}
+	}
+
+	ba := &BoundedLoadAccessor{
+		ring:        new(weightedRing),
+		instances:   len(instances),
+		loadFactor:  loadFactor,
+		assignments: make(map[string]string),
+		loads:       make(map[string]int, len(instances)),
+	}
+
+	for _, i := range instances {
+		ba.ring.add(vnodeCount, i, Metadata{})
+	}
+
+	sort.Stable(ba.ring)
+	return ba
+}
+
+// NewBoundedLoadAccessorFactory produces a factory which creates BoundedLoadAccessor instances.  If vnodeCount
+// is nonpositive, DefaultVnodeCount is used.  If loadFactor is less than 1, DefaultLoadFactor is used.
+func NewBoundedLoadAccessorFactory(vnodeCount int, loadFactor float64) AccessorFactory {
+	if vnodeCount < 1 {
+		vnodeCount = DefaultVnodeCount
+	}
+
+	if loadFactor < 1.0 {
+		loadFactor = DefaultLoadFactor
+	}
+
+	return func(instances []string) Accessor {
+		return newBoundedLoadAccessor(vnodeCount, loadFactor, instances)
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNewBoundedLoadAccessorFactoryEmpty(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	for _, i := range [][]string{nil, []string{}} {
+		a := NewBoundedLoadAccessorFactory(0, 0.0)(i)
+		require.NotNil(a)
+		i, err := a.Get([]byte("test"))
+		assert.Empty(i)
+		assert.Error(err)
+	}
+}
+
+func testNewBoundedLoadAccessorFactoryBound(t *testing.T, loadFactor float64) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		instances = []string{"https://one.com", "https://two.com", "https://three.com"}
+		keyCount  = 3000
+		a         = NewBoundedLoadAccessorFactory(11, loadFactor)(instances)
+	)
+
+	require.IsType((*BoundedLoadAccessor)(nil), a)
+	for k := 0; k < keyCount; k++ {
+		key := []byte(fmt.Sprintf("mac:%012x", k))
+		first, err := a.Get(key)
+		require.NoError(err)
+
+		second, err := a.Get(key)
+		require.NoError(err)
+		assert.Equal(first, second)
+	}
+
+	var (
+		loads    = a.(*BoundedLoadAccessor).Loads()
+		capacity = int(math.Ceil(loadFactor * float64(keyCount) / float64(len(instances))))
+		total    = 0
+	)
+
+	require.Len(loads, len(instances))
+	for _, i := range instances {
+		assert.True(loads[i] <= capacity, "instance %s has load %d, which exceeds the capacity %d", i, loads[i], capacity)
+		total += loads[i]
+	}
+
+	assert.Equal(keyCount, total)
+}
+
+func testBoundedLoadAccessorRelease(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		a = NewBoundedLoadAccessorFactory(-1, -1.0)([]string{"https://one.com", "https://two.com"})
+	)
+
+	require.IsType((*BoundedLoadAccessor)(nil), a)
+	ba := a.(*BoundedLoadAccessor)
+
+	i, err := ba.Get([]byte("test"))
+	require.NoError(err)
+	assert.Equal(map[string]int{i: 1}, ba.Loads())
+
+	ba.Release([]byte("nosuch"))
+	assert.Equal(map[string]int{i: 1}, ba.Loads())
+
+	ba.Release([]byte("test"))
+	assert.Equal(map[string]int{i: 0}, ba.Loads())
+}
+
+func testNewBoundedLoadAccessorFactoryGetN(t *testing.T) {
+	instances := []string{"https://one.com", "https://two.com", "https://three.com", "https://four.com"}
+	testOrderedAccessor(t, NewBoundedLoadAccessorFactory(0, 0.0)(instances), instances)
+}
+
+func TestNewBoundedLoadAccessorFactory(t *testing.T) {
+	t.Run("Empty", testNewBoundedLoadAccessorFactoryEmpty)
+	t.Run("GetN", testNewBoundedLoadAccessorFactoryGetN)
+
+	for _, v := range []float64{1.0, 1.1, DefaultLoadFactor, 2.0} {
+		t.Run(fmt.Sprintf("loadFactor=%.2f", v), func(t *testing.T) {
+			testNewBoundedLoadAccessorFactoryBound(t, v)
+		})
+	}
+
+	t.Run("Release", testBoundedLoadAccessorRelease)
+}
@@ -50,20 +50,46 @@ func newInstancerKey(w Watch) string {
 	)
 }
 
-func defaultClientFactory(client *api.Client) (gokitconsul.Client, ttlUpdater) {
+// metadataClient decorates a go-kit consul Client so that the metadata in the tags of discovered
+// services is recorded under the same address:port instances that the go-kit Instancer produces
+type metadataClient struct {
+	gokitconsul.Client
+	registry *service.MetadataRegistry
+}
+
+func (mc metadataClient) Service(name, tag string, passingOnly bool, queryOpts *api.QueryOptions) ([]*api.ServiceEntry, *api.QueryMeta, error) {
+	entries, meta, err := mc.Client.Service(name, tag, passingOnly, queryOpts)
+	for _, e := range entries {
+		if e.Node == nil || e.Service == nil {
+			continue
+		}
+
+		address := e.Node.Address
+		if len(e.Service.Address) > 0 {
+			address = e.Service.Address
+		}
+
+		m, _ := service.ParseMetadataTags(e.Service.Tags)
+		mc.registry.Set(fmt.Sprintf("%s:%d", address, e.Service.Port), m)
+	}
+
+	return entries, meta, err
+}
+
+func defaultClientFactory(client *api.Client) (gokitconsul.Client, agent) {
 	return gokitconsul.NewClient(client), client.Agent()
 }
 
 var clientFactory = defaultClientFactory
 
-func newClient(co Options) (gokitconsul.Client, ttlUpdater, error) {
+func newClient(co Options) (gokitconsul.Client, agent, error) {
 	consulClient, err := api.NewClient(co.config())
 	if err != nil {
 		return nil, nil, err
 	}
 
-	gokitClient, updater := clientFactory(consulClient)
-	return gokitClient, updater, nil
+	gokitClient, a := clientFactory(consulClient)
+	return gokitClient, a, nil
 }
 
 func newInstancer(l log.Logger, c gokitconsul.Client, w Watch) sd.Instancer {
@@ -97,8 +123,37 @@ func newInstancers(l log.Logger, c gokitconsul.Client, co Options) (i service.In
 	return
 }
 
-func newRegistrars(l log.Logger, registrationScheme string, c gokitconsul.Client, u ttlUpdater, co Options) (r service.Registrars, closer func() error, err error) {
-	var consulRegistrar sd.Registrar
+// MergeMeta returns the given defaults overridden by meta.  Neither map is modified.  If there are no
+// defaults, meta itself is returned.
+func MergeMeta(defaults, meta map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return meta
+	}
+
+	merged := make(map[string]string, len(defaults)+len(meta))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+
+	for k, v := range meta {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+func newRegistrars(l log.Logger, registrationScheme string, c gokitconsul.Client, a agent, co Options) (r service.Registrars, consulRegistrars []Registrar, err error) {
+	var (
+		consulRegistrar Registrar
+
+		// all registrations share a single deregistration delay
+		drain *drain
+	)
+
+	if d := co.deregistrationDelay(); d > 0 {
+		drain = newDrain(d)
+	}
+
 	for _, registration := range co.registrations() {
 		instance := service.FormatInstance(registrationScheme, registration.Address, registration.Port)
 		if r.Has(instance) {
@@ -106,21 +161,70 @@ func newRegistrars(l log.Logger, registrationScheme string, c gokitconsul.Client
 			continue
 		}
 
+		registration.Meta = MergeMeta(co.meta(), registration.Meta)
+		if hc := co.healthCheck(); hc != nil {
+			var check *api.AgentServiceCheck
+			check, err = hc.agentServiceCheck(registrationScheme, registration.Address, registration.Port)
+			if err != nil {
+				return
+			}
+
+			// copy the checks so that the configured registration is not modified
+			registration.Checks = append(append(api.AgentServiceChecks(nil), registration.Checks...), check)
+		}
+
 		if !co.disableGenerateID() {
 			ensureIDs(&registration)
 		}
 
-		consulRegistrar, err = NewRegistrar(c, u, &registration, log.With(l, "id", registration.ID, "instance", instance))
+		consulRegistrar, err = NewRegistrar(
+			c,
+			a,
+			&registration,
+			log.With(l, "id", registration.ID, "instance", instance),
+			withDrain(drain),
+		)
+
 		if err != nil {
 			return
 		}
 
 		r.Add(instance, consulRegistrar)
+		consulRegistrars = append(consulRegistrars, consulRegistrar)
 	}
 
 	return
 }
 
+// environment is the consul service.Environment, which supports maintenance mode for all its registrations
+type environment struct {
+	service.Environment
+	registrars []Registrar
+}
+
+func (e environment) EnableMaintenance(reason string) error {
+	for _, r := range e.registrars {
+		if err := r.EnableMaintenance(reason); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e environment) DisableMaintenance() error {
+	for _, r := range e.registrars {
+		if err := r.DisableMaintenance(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewEnvironment constructs a consul service.Environment using both a consul Options (typically unmarshaled from
+// configuration) and an optional extra set of environment options.  The returned Environment also implements
+// Maintenance, which applies to every registration.
 func NewEnvironment(l log.Logger, registrationScheme string, co Options, eo ...service.Option) (service.Environment, error) {
 	if l == nil {
 		l = logging.DefaultLogger()
@@ -130,22 +234,28 @@ func NewEnvironment(l log.Logger, registrationScheme string, co Options, eo ...s
 		return nil, nil
 	}
 
-	c, u, err := newClient(co)
+	c, a, err := newClient(co)
 	if err != nil {
 		return nil, err
 	}
 
-	r, closer, err := newRegistrars(l, registrationScheme, c, u, co)
+	if registry := co.metadata(); registry != nil {
+		c = metadataClient{Client: c, registry: registry}
+	}
+
+	r, consulRegistrars, err := newRegistrars(l, registrationScheme, c, a, co)
 	if err != nil {
 		return nil, err
 	}
 
-	return service.NewEnvironment(
-		append(
-			eo,
-			service.WithRegistrars(r),
-			service.WithInstancers(newInstancers(l, c, co)),
-			service.WithCloser(closer),
-		)...,
-	), nil
+	return environment{
+		Environment: service.NewEnvironment(
+			append(
+				eo,
+				service.WithRegistrars(r),
+				service.WithInstancers(newInstancers(l, c, co)),
+			)...,
+		),
+		registrars: consulRegistrars,
+	}, nil
 }
@@ -1,9 +1,12 @@
 package consul
 
 import (
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
 	"github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -63,7 +66,7 @@ func testNewEnvironmentFull(t *testing.T) {
 		logger        = logging.NewTestLogger(nil, t)
 		clientFactory = prepareMockClientFactory()
 		client        = new(mockClient)
-		ttlUpdater    = new(mockTTLUpdater)
+		ttlUpdater    = new(mockAgent)
 
 		co = Options{
 			Client: &api.Config{
@@ -116,7 +119,7 @@ func testNewEnvironmentFull(t *testing.T) {
 		mock.MatchedBy(func(r *api.AgentServiceRegistration) bool {
 			return r.Address == "grubly.com" && r.Port == 1111
 		}),
-	).Return(error(nil)).Twice()
+	).Return(error(nil)).Once()
 
 	e, err := NewEnvironment(logger, "", co)
 	require.NoError(err)
@@ -132,8 +135,131 @@ func testNewEnvironmentFull(t *testing.T) {
 	ttlUpdater.AssertExpectations(t)
 }
 
+func testNewEnvironmentFakeAgent(t *testing.T) {
+	defer resetSleep()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger = logging.NewTestLogger(nil, t)
+		agent  = newFakeAgent()
+		server = httptest.NewServer(agent)
+		sleeps = prepareMockSleep()
+
+		co = Options{
+			Client: &api.Config{
+				Address: server.Listener.Addr().String(),
+			},
+			Registrations: []api.AgentServiceRegistration{
+				api.AgentServiceRegistration{
+					ID:      "service1",
+					Name:    "test",
+					Address: "test.com",
+					Port:    8080,
+					Meta:    map[string]string{"flavor": "canary"},
+				},
+			},
+			HealthCheck:         &HealthCheck{Port: 8081},
+			Meta:                map[string]string{"build": "1.0", "flavor": "development"},
+			DeregistrationDelay: 15 * time.Second,
+		}
+	)
+
+	defer server.Close()
+
+	e, err := NewEnvironment(logger, "https", co)
+	require.NoError(err)
+	require.NotNil(e)
+
+	e.Register()
+	r, ok := agent.registration("service1")
+	require.True(ok)
+	assert.Equal(map[string]string{"build": "1.0", "flavor": "canary"}, r.Meta)
+	require.Len(r.Checks, 1)
+	assert.Equal("https://test.com:8081/health", r.Checks[0].HTTP)
+	assert.Equal("10s", r.Checks[0].Interval)
+	assert.NotEmpty(r.Checks[0].CheckID)
+
+	// the configured registration must not be modified
+	assert.Equal(map[string]string{"flavor": "canary"}, co.Registrations[0].Meta)
+	assert.Empty(co.Registrations[0].Checks)
+
+	m, ok := e.(Maintenance)
+	require.True(ok)
+	assert.NoError(m.EnableMaintenance("testing"))
+	assert.NoError(m.DisableMaintenance())
+
+	assert.NoError(e.Close())
+	_, ok = agent.registration("service1")
+	assert.False(ok)
+
+	require.Len(sleeps, 1)
+	assert.Equal(15*time.Second, <-sleeps)
+
+	assert.Equal(
+		[]string{
+			"register service1",
+			"maintenance service1 enable=true reason=testing",
+			"maintenance service1 enable=false reason=",
+			"maintenance service1 enable=true reason=deregistering",
+			"deregister service1",
+		},
+		agent.receivedOperations(),
+	)
+}
+
 func TestNewEnvironment(t *testing.T) {
 	t.Run("Empty", testNewEnvironmentEmpty)
 	t.Run("ClientError", testNewEnvironmentClientError)
 	t.Run("Full", testNewEnvironmentFull)
+	t.Run("FakeAgent", testNewEnvironmentFakeAgent)
+}
+
+func TestMetadataClient(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		client   = new(mockClient)
+		registry = service.NewMetadataRegistry()
+
+		mc = metadataClient{Client: client, registry: registry}
+
+		expectedEntries = []*api.ServiceEntry{
+			&api.ServiceEntry{
+				Node:    &api.Node{Address: "node1.net"},
+				Service: &api.AgentService{Port: 8080, Tags: []string{"api", "weight=2", "zone=east"}},
+			},
+			&api.ServiceEntry{
+				Node:    &api.Node{Address: "node2.net"},
+				Service: &api.AgentService{Address: "service2.net", Port: 8080, Tags: []string{"zone=west"}},
+			},
+			&api.ServiceEntry{
+				Node:    &api.Node{Address: "node3.net"},
+				Service: &api.AgentService{Port: 8080, Tags: []string{"api"}},
+			},
+		}
+
+		expectedMeta = new(api.QueryMeta)
+	)
+
+	client.On("Service", "test", "api", true, (*api.QueryOptions)(nil)).Return(expectedEntries, expectedMeta, error(nil)).Once()
+
+	entries, meta, err := mc.Service("test", "api", true, nil)
+	require.NoError(err)
+	assert.Equal(expectedEntries, entries)
+	assert.Equal(expectedMeta, meta)
+
+	m, ok := registry.Metadata("https://node1.net:8080")
+	assert.True(ok)
+	assert.Equal(service.Metadata{Weight: 2, Zone: "east"}, m)
+
+	m, ok = registry.Metadata("service2.net:8080")
+	assert.True(ok)
+	assert.Equal(service.Metadata{Zone: "west"}, m)
+
+	_, ok = registry.Metadata("node3.net:8080")
+	assert.False(ok)
+
+	client.AssertExpectations(t)
 }
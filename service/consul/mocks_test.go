@@ -1,6 +1,11 @@
 package consul
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	gokitconsul "github.com/go-kit/kit/sd/consul"
@@ -26,16 +31,30 @@ type mockClientFactory struct {
 	mock.Mock
 }
 
-func (m *mockClientFactory) NewClient(c *api.Client) (gokitconsul.Client, ttlUpdater) {
+func (m *mockClientFactory) NewClient(c *api.Client) (gokitconsul.Client, agent) {
 	arguments := m.Called(c)
 	return arguments.Get(0).(gokitconsul.Client),
-		arguments.Get(1).(ttlUpdater)
+		arguments.Get(1).(agent)
 }
 
 func resetTickerFactory() {
 	tickerFactory = defaultTickerFactory
 }
 
+func resetSleep() {
+	sleep = time.Sleep
+}
+
+// prepareMockSleep replaces the package sleep function with one that records each duration
+func prepareMockSleep() <-chan time.Duration {
+	durations := make(chan time.Duration, 10)
+	sleep = func(d time.Duration) {
+		durations <- d
+	}
+
+	return durations
+}
+
 func prepareMockTickerFactory() *mockTickerFactory {
 	m := new(mockTickerFactory)
 	tickerFactory = m.NewTicker
@@ -73,10 +92,90 @@ func (m *mockClient) Service(service, tag string, passingOnly bool, queryOpts *a
 	return first, second, arguments.Error(2)
 }
 
-type mockTTLUpdater struct {
+type mockAgent struct {
 	mock.Mock
 }
 
-func (m *mockTTLUpdater) UpdateTTL(checkID, output, status string) error {
+func (m *mockAgent) UpdateTTL(checkID, output, status string) error {
 	return m.Called(checkID, output, status).Error(0)
 }
+
+func (m *mockAgent) EnableServiceMaintenance(serviceID, reason string) error {
+	return m.Called(serviceID, reason).Error(0)
+}
+
+func (m *mockAgent) DisableServiceMaintenance(serviceID string) error {
+	return m.Called(serviceID).Error(0)
+}
+
+// fakeAgent is a stub consul agent which records the service registration operations made against it
+type fakeAgent struct {
+	lock          sync.Mutex
+	operations    []string
+	registrations map[string]api.AgentServiceRegistration
+}
+
+func newFakeAgent() *fakeAgent {
+	return &fakeAgent{
+		registrations: make(map[string]api.AgentServiceRegistration),
+	}
+}
+
+func (f *fakeAgent) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPut {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path := request.URL.Path
+	switch {
+	case path == "/v1/agent/service/register":
+		var r api.AgentServiceRegistration
+		if err := json.NewDecoder(request.Body).Decode(&r); err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.registrations[r.ID] = r
+		f.operations = append(f.operations, "register "+r.ID)
+
+	case strings.HasPrefix(path, "/v1/agent/service/deregister/"):
+		id := strings.TrimPrefix(path, "/v1/agent/service/deregister/")
+		delete(f.registrations, id)
+		f.operations = append(f.operations, "deregister "+id)
+
+	case strings.HasPrefix(path, "/v1/agent/service/maintenance/"):
+		query := request.URL.Query()
+		f.operations = append(
+			f.operations,
+			fmt.Sprintf(
+				"maintenance %s enable=%s reason=%s",
+				strings.TrimPrefix(path, "/v1/agent/service/maintenance/"),
+				query.Get("enable"),
+				query.Get("reason"),
+			),
+		)
+
+	case strings.HasPrefix(path, "/v1/agent/check/update/"):
+		f.operations = append(f.operations, "ttl "+strings.TrimPrefix(path, "/v1/agent/check/update/"))
+
+	default:
+		response.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeAgent) receivedOperations() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string(nil), f.operations...)
+}
+
+func (f *fakeAgent) registration(id string) (api.AgentServiceRegistration, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	r, ok := f.registrations[id]
+	return r, ok
+}
@@ -1,20 +1,149 @@
 package consul
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Comcast/webpa-common/service"
 	"github.com/hashicorp/consul/api"
 )
 
+const (
+	HTTPHealthCheck = "http"
+	TCPHealthCheck  = "tcp"
+
+	DefaultHealthCheckPath     = "/health"
+	DefaultHealthCheckInterval = 10 * time.Second
+	DefaultHealthCheckTimeout  = 5 * time.Second
+)
+
 type Watch struct {
 	Service     string   `json:"service,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
 	PassingOnly bool     `json:"passingOnly"`
 }
 
+// HealthCheck describes a check that the consul agent performs against each registered service itself,
+// typically the service's own health endpoint.  This check is added to any checks in the registrations.
+type HealthCheck struct {
+	// Type is either HTTPHealthCheck or TCPHealthCheck.  If not supplied, HTTPHealthCheck is used.
+	Type string `json:"type,omitempty"`
+
+	// Scheme is the URI scheme of an HTTP check.  If not supplied, the registration scheme is used.
+	Scheme string `json:"scheme,omitempty"`
+
+	// Path is the request path of an HTTP check.  If not supplied, DefaultHealthCheckPath is used.
+	Path string `json:"path,omitempty"`
+
+	// Port is the port to check, e.g. when health is served on its own port.  If nonpositive, the
+	// registration's port is used.
+	Port int `json:"port,omitempty"`
+
+	// Interval is how often the agent runs the check.  If not supplied, DefaultHealthCheckInterval is used.
+	Interval time.Duration `json:"interval"`
+
+	// Timeout is the time limit for each check.  If not supplied, DefaultHealthCheckTimeout is used.
+	Timeout time.Duration `json:"timeout"`
+
+	// DeregisterCriticalServiceAfter, if positive, is how long a service may remain critical before
+	// the agent deregisters it
+	DeregisterCriticalServiceAfter time.Duration `json:"deregisterCriticalServiceAfter"`
+
+	// TLSSkipVerify disables certificate verification for HTTPS checks
+	TLSSkipVerify bool `json:"tlsSkipVerify"`
+}
+
+func (hc *HealthCheck) checkType() string {
+	if hc != nil && len(hc.Type) > 0 {
+		return strings.ToLower(hc.Type)
+	}
+
+	return HTTPHealthCheck
+}
+
+func (hc *HealthCheck) path() string {
+	if hc != nil && len(hc.Path) > 0 {
+		return hc.Path
+	}
+
+	return DefaultHealthCheckPath
+}
+
+func (hc *HealthCheck) interval() time.Duration {
+	if hc != nil && hc.Interval > 0 {
+		return hc.Interval
+	}
+
+	return DefaultHealthCheckInterval
+}
+
+func (hc *HealthCheck) timeout() time.Duration {
+	if hc != nil && hc.Timeout > 0 {
+		return hc.Timeout
+	}
+
+	return DefaultHealthCheckTimeout
+}
+
+// agentServiceCheck produces the consul check for a registration with the given scheme, address, and port
+func (hc *HealthCheck) agentServiceCheck(registrationScheme, address string, port int) (*api.AgentServiceCheck, error) {
+	if hc.Port > 0 {
+		port = hc.Port
+	}
+
+	check := &api.AgentServiceCheck{
+		Name:          "health",
+		Interval:      hc.interval().String(),
+		Timeout:       hc.timeout().String(),
+		TLSSkipVerify: hc.TLSSkipVerify,
+	}
+
+	if hc.DeregisterCriticalServiceAfter > 0 {
+		check.DeregisterCriticalServiceAfter = hc.DeregisterCriticalServiceAfter.String()
+	}
+
+	switch hc.checkType() {
+	case HTTPHealthCheck:
+		scheme := registrationScheme
+		if len(hc.Scheme) > 0 {
+			scheme = hc.Scheme
+		}
+
+		check.HTTP = service.FormatInstance(scheme, address, port) + hc.path()
+
+	case TCPHealthCheck:
+		check.TCP = fmt.Sprintf("%s:%d", address, port)
+
+	default:
+		return nil, fmt.Errorf("Unsupported health check type: %s", hc.Type)
+	}
+
+	return check, nil
+}
+
 type Options struct {
 	Client            *api.Config                    `json:"client"`
 	DisableGenerateID bool                           `json:"disableGenerateID"`
 	Registrations     []api.AgentServiceRegistration `json:"registrations,omitempty"`
 	Watches           []Watch                        `json:"watches,omitempty"`
+
+	// HealthCheck, when supplied, adds a check of each registered service's own health endpoint
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+
+	// Meta is the service metadata added to every registration, e.g. the build, region, and flavor of this
+	// server.  Any metadata in the registrations themselves takes precedence.
+	Meta map[string]string `json:"meta,omitempty"`
+
+	// DeregistrationDelay, if positive, is how long registered services stay in maintenance mode before being
+	// deregistered.  This gives load balancers and other watchers time to drain traffic away from this process.
+	// All registrations enter maintenance mode together, so this delay is incurred once regardless of how many
+	// services are registered.
+	DeregistrationDelay time.Duration `json:"deregistrationDelay"`
+
+	// Metadata receives the weight and zone advertised in the tags of each watched instance, e.g. "weight=2"
+	// and "zone=east".  This field is optional.
+	Metadata *service.MetadataRegistry `json:"-"`
 }
 
 func (o *Options) config() *api.Config {
@@ -41,6 +170,38 @@ func (o *Options) registrations() []api.AgentServiceRegistration {
 	return nil
 }
 
+func (o *Options) healthCheck() *HealthCheck {
+	if o != nil {
+		return o.HealthCheck
+	}
+
+	return nil
+}
+
+func (o *Options) meta() map[string]string {
+	if o != nil && len(o.Meta) > 0 {
+		return o.Meta
+	}
+
+	return nil
+}
+
+func (o *Options) deregistrationDelay() time.Duration {
+	if o != nil && o.DeregistrationDelay > 0 {
+		return o.DeregistrationDelay
+	}
+
+	return 0
+}
+
+func (o *Options) metadata() *service.MetadataRegistry {
+	if o != nil {
+		return o.Metadata
+	}
+
+	return nil
+}
+
 func (o *Options) watches() []Watch {
 	if o != nil && len(o.Watches) > 0 {
 		return o.Watches
@@ -2,6 +2,7 @@ package consul
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,9 @@ func testOptionsDefault(t *testing.T, o *Options) {
 	assert.False(o.disableGenerateID())
 	assert.Len(o.registrations(), 0)
 	assert.Len(o.watches(), 0)
+	assert.Nil(o.healthCheck())
+	assert.Nil(o.meta())
+	assert.Zero(o.deregistrationDelay())
 }
 
 func testOptionsCustom(t *testing.T) {
@@ -28,7 +32,10 @@ func testOptionsCustom(t *testing.T) {
 				Scheme:  "ftp",
 			},
 
-			DisableGenerateID: true,
+			DisableGenerateID:   true,
+			HealthCheck:         &HealthCheck{Path: "/status"},
+			Meta:                map[string]string{"build": "1.0"},
+			DeregistrationDelay: 30 * time.Second,
 
 			Registrations: []api.AgentServiceRegistration{
 				api.AgentServiceRegistration{
@@ -53,6 +60,9 @@ func testOptionsCustom(t *testing.T) {
 	assert.Equal("ftp", c.Scheme)
 
 	assert.True(o.disableGenerateID())
+	assert.Equal(&HealthCheck{Path: "/status"}, o.healthCheck())
+	assert.Equal(map[string]string{"build": "1.0"}, o.meta())
+	assert.Equal(30*time.Second, o.deregistrationDelay())
 
 	assert.Equal(
 		[]api.AgentServiceRegistration{
@@ -76,6 +86,68 @@ func testOptionsCustom(t *testing.T) {
 	)
 }
 
+func TestHealthCheck(t *testing.T) {
+	testData := []struct {
+		healthCheck   HealthCheck
+		scheme        string
+		expected      *api.AgentServiceCheck
+		expectedError bool
+	}{
+		{
+			healthCheck: HealthCheck{},
+			scheme:      "http",
+			expected: &api.AgentServiceCheck{
+				Name:     "health",
+				HTTP:     "http://test.com:8080/health",
+				Interval: "10s",
+				Timeout:  "5s",
+			},
+		},
+		{
+			healthCheck: HealthCheck{
+				Type:                           "HTTP",
+				Scheme:                         "https",
+				Path:                           "/status",
+				Port:                           8443,
+				Interval:                       time.Minute,
+				Timeout:                        time.Second,
+				DeregisterCriticalServiceAfter: time.Hour,
+				TLSSkipVerify:                  true,
+			},
+			scheme: "http",
+			expected: &api.AgentServiceCheck{
+				Name:                           "health",
+				HTTP:                           "https://test.com:8443/status",
+				Interval:                       "1m0s",
+				Timeout:                        "1s",
+				DeregisterCriticalServiceAfter: "1h0m0s",
+				TLSSkipVerify:                  true,
+			},
+		},
+		{
+			healthCheck: HealthCheck{Type: "tcp", Port: 9090},
+			scheme:      "https",
+			expected: &api.AgentServiceCheck{
+				Name:     "health",
+				TCP:      "test.com:9090",
+				Interval: "10s",
+				Timeout:  "5s",
+			},
+		},
+		{
+			healthCheck:   HealthCheck{Type: "grpc"},
+			expectedError: true,
+		},
+	}
+
+	for i, record := range testData {
+		t.Logf("%d: %#v", i, record)
+		actual, err := record.healthCheck.agentServiceCheck(record.scheme, "test.com", 8080)
+		assert.Equal(t, record.expected, actual)
+		assert.Equal(t, record.expectedError, err != nil)
+	}
+}
+
 func TestOptions(t *testing.T) {
 	t.Run("Default", func(t *testing.T) {
 		testOptionsDefault(t, nil)
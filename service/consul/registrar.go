@@ -32,7 +32,10 @@ func defaultTickerFactory(d time.Duration) (<-chan time.Time, func()) {
 	return t.C, t.Stop
 }
 
-var tickerFactory = defaultTickerFactory
+var (
+	tickerFactory = defaultTickerFactory
+	sleep         = time.Sleep
+)
 
 // ttlUpdater represents any object which can update the TTL status on the remote consul cluster.
 // The consul api Client implements this interface.
@@ -40,6 +43,101 @@ type ttlUpdater interface {
 	UpdateTTL(checkID, output, status string) error
 }
 
+// agent represents the consul agent operations used by registrars.  The consul api Agent implements this interface.
+type agent interface {
+	ttlUpdater
+	EnableServiceMaintenance(serviceID, reason string) error
+	DisableServiceMaintenance(serviceID string) error
+}
+
+// Maintenance describes consul's maintenance mode.  A service in maintenance mode remains registered, but
+// its health is critical so that watchers and load balancers stop routing traffic to it.
+type Maintenance interface {
+	// EnableMaintenance places services into maintenance mode, with a reason that is visible in consul
+	EnableMaintenance(reason string) error
+
+	// DisableMaintenance takes services out of maintenance mode
+	DisableMaintenance() error
+}
+
+// Registrar is a consul sd.Registrar which also supports maintenance mode for its service
+type Registrar interface {
+	sd.Registrar
+	Maintenance
+}
+
+// RegistrarOption configures a Registrar created by NewRegistrar
+type RegistrarOption func(*registrar)
+
+// WithDeregistrationDelay configures graceful deregistration.  When d is positive, Deregister first places the
+// service into maintenance mode and waits for d before actually deregistering.  This gives load balancers time
+// to drain traffic before this process exits.
+func WithDeregistrationDelay(d time.Duration) RegistrarOption {
+	return func(r *registrar) {
+		if d > 0 {
+			r.drain = newDrain(d)
+		} else {
+			r.drain = nil
+		}
+	}
+}
+
+// withDrain configures a registrar to share graceful deregistration with other registrars.  A nil drain
+// disables graceful deregistration.
+func withDrain(d *drain) RegistrarOption {
+	return func(r *registrar) {
+		r.drain = d
+	}
+}
+
+// drain implements graceful deregistration for a group of registrars, such as all the registrations of an
+// environment.  The first registrar in the group to be deregistered places every registered service of the group
+// into maintenance mode and waits once for the delay.  The remaining registrars then deregister without further
+// delay, so the total delay does not grow with the number of registrations.
+type drain struct {
+	delay time.Duration
+
+	lock       sync.Mutex
+	registrars []*registrar
+	drained    bool
+}
+
+func newDrain(delay time.Duration) *drain {
+	return &drain{delay: delay}
+}
+
+func (d *drain) add(r *registrar) {
+	d.lock.Lock()
+	d.registrars = append(d.registrars, r)
+	d.lock.Unlock()
+}
+
+// reset allows the next deregistration to delay again, as a service was registered since the last drain
+func (d *drain) reset() {
+	d.lock.Lock()
+	d.drained = false
+	d.lock.Unlock()
+}
+
+// wait places all registered services into maintenance mode and waits for the delay, unless that has already
+// happened since the last registration.  Concurrent callers all wait for the same delay.
+func (d *drain) wait(logger log.Logger) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.drained {
+		return
+	}
+
+	for _, r := range d.registrars {
+		r.enterDeregistrationMaintenance()
+	}
+
+	logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "delaying deregistration", "delay", d.delay.String())
+	sleep(d.delay)
+	d.drained = true
+}
+
 // ttlCheck holds the relevant information for managing a TTL check
 type ttlCheck struct {
 	checkID    string
@@ -121,22 +219,23 @@ func appendTTLCheck(logger log.Logger, serviceID string, agentCheck *api.AgentSe
 	return ttlChecks, nil
 }
 
-// ttlRegistrar is an sd.Registrar that binds one or more TTL updates to the Register/Deregister lifecycle.
+// registrar is the consul Registrar, which binds any TTL updates to the Register/Deregister lifecycle.
 // When Register is called, a goroutine is spawned for each TTL check that invokes UpdateTTL on an interval.
-// When Dereigster is called, any goroutines spawned are stopped and each check is set to fail (critical).
-type ttlRegistrar struct {
+// When Deregister is called, any goroutines spawned are stopped and each check is set to fail (critical).
+type registrar struct {
 	logger    log.Logger
 	serviceID string
 	registrar sd.Registrar
-	updater   ttlUpdater
+	agent     agent
 	checks    []ttlCheck
+	drain     *drain
 
 	lifecycleLock sync.Mutex
 	shutdown      chan struct{}
 }
 
-// NewRegistrar creates an sd.Registrar, binding any TTL checks to the Register/Deregister lifecycle as needed.
-func NewRegistrar(c gokitconsul.Client, u ttlUpdater, r *api.AgentServiceRegistration, logger log.Logger) (sd.Registrar, error) {
+// NewRegistrar creates a consul Registrar, binding any TTL checks to the Register/Deregister lifecycle as needed.
+func NewRegistrar(c gokitconsul.Client, a agent, r *api.AgentServiceRegistration, logger log.Logger, options ...RegistrarOption) (Registrar, error) {
 	var (
 		ttlChecks []ttlCheck
 		err       error
@@ -154,46 +253,90 @@ func NewRegistrar(c gokitconsul.Client, u ttlUpdater, r *api.AgentServiceRegistr
 		}
 	}
 
-	var registrar sd.Registrar = gokitconsul.NewRegistrar(c, r, logger)
+	cr := &registrar{
+		logger:    logger,
+		serviceID: r.ID,
+		registrar: gokitconsul.NewRegistrar(c, r, logger),
+		agent:     a,
+		checks:    ttlChecks,
+	}
+
+	for _, o := range options {
+		o(cr)
+	}
+
+	if cr.drain != nil {
+		cr.drain.add(cr)
+	}
+
+	return cr, nil
+}
 
-	// decorate the given registrar if we have any TTL checks
-	if len(ttlChecks) > 0 {
-		registrar = &ttlRegistrar{
-			logger:    logger,
-			serviceID: r.ID,
-			registrar: registrar,
-			updater:   u,
-			checks:    ttlChecks,
+func (r *registrar) Register() {
+	r.lifecycleLock.Lock()
+	registered := r.shutdown == nil
+	if registered {
+		r.registrar.Register()
+		r.shutdown = make(chan struct{})
+		for _, tc := range r.checks {
+			go tc.updatePeriodically(r.agent, r.shutdown)
 		}
 	}
 
-	return registrar, nil
+	r.lifecycleLock.Unlock()
+
+	// the drain is reset outside the lifecycle lock, as the drain itself acquires the lifecycle locks of its registrars
+	if registered && r.drain != nil {
+		r.drain.reset()
+	}
 }
 
-func (tr *ttlRegistrar) Register() {
-	defer tr.lifecycleLock.Unlock()
-	tr.lifecycleLock.Lock()
+func (r *registrar) registered() bool {
+	r.lifecycleLock.Lock()
+	defer r.lifecycleLock.Unlock()
+	return r.shutdown != nil
+}
 
-	if tr.shutdown != nil {
+// enterDeregistrationMaintenance places this service into maintenance mode ahead of a delayed deregistration.
+// Services which are not registered are ignored.
+func (r *registrar) enterDeregistrationMaintenance() {
+	if !r.registered() {
 		return
 	}
 
-	tr.registrar.Register()
-	tr.shutdown = make(chan struct{})
-	for _, tc := range tr.checks {
-		go tc.updatePeriodically(tr.updater, tr.shutdown)
+	if err := r.agent.EnableServiceMaintenance(r.serviceID, "deregistering"); err != nil {
+		r.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "unable to enter maintenance mode prior to deregistration", logging.ErrorKey(), err)
 	}
 }
 
-func (tr *ttlRegistrar) Deregister() {
-	defer tr.lifecycleLock.Unlock()
-	tr.lifecycleLock.Lock()
+func (r *registrar) Deregister() {
+	if !r.registered() {
+		return
+	}
+
+	// the delay happens outside the lifecycle lock, and at most once for all the registrars sharing the drain
+	if r.drain != nil {
+		r.drain.wait(r.logger)
+	}
+
+	defer r.lifecycleLock.Unlock()
+	r.lifecycleLock.Lock()
 
-	if tr.shutdown == nil {
+	if r.shutdown == nil {
 		return
 	}
 
-	close(tr.shutdown)
-	tr.shutdown = nil
-	tr.registrar.Deregister()
+	close(r.shutdown)
+	r.shutdown = nil
+	r.registrar.Deregister()
+}
+
+func (r *registrar) EnableMaintenance(reason string) error {
+	r.logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "enabling maintenance mode", "reason", reason)
+	return r.agent.EnableServiceMaintenance(r.serviceID, reason)
+}
+
+func (r *registrar) DisableMaintenance() error {
+	r.logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "disabling maintenance mode")
+	return r.agent.DisableServiceMaintenance(r.serviceID)
 }
@@ -36,7 +36,7 @@ func testNewRegistrarNoChecks(t *testing.T) {
 
 		logger        = logging.NewTestLogger(nil, t)
 		client        = new(mockClient)
-		ttlUpdater    = new(mockTTLUpdater)
+		ttlUpdater    = new(mockAgent)
 		tickerFactory = prepareMockTickerFactory()
 
 		registration = &api.AgentServiceRegistration{
@@ -78,7 +78,7 @@ func testNewRegistrarNoTTL(t *testing.T) {
 
 		logger        = logging.NewTestLogger(nil, t)
 		client        = new(mockClient)
-		ttlUpdater    = new(mockTTLUpdater)
+		ttlUpdater    = new(mockAgent)
 		tickerFactory = prepareMockTickerFactory()
 
 		registration = &api.AgentServiceRegistration{
@@ -130,7 +130,7 @@ func testNewRegistrarCheckMalformedTTL(t *testing.T) {
 
 		logger        = logging.NewTestLogger(nil, t)
 		client        = new(mockClient)
-		ttlUpdater    = new(mockTTLUpdater)
+		ttlUpdater    = new(mockAgent)
 		tickerFactory = prepareMockTickerFactory()
 
 		registration = &api.AgentServiceRegistration{
@@ -161,7 +161,7 @@ func testNewRegistrarCheckTTLTooSmall(t *testing.T) {
 
 		logger        = logging.NewTestLogger(nil, t)
 		client        = new(mockClient)
-		ttlUpdater    = new(mockTTLUpdater)
+		ttlUpdater    = new(mockAgent)
 		tickerFactory = prepareMockTickerFactory()
 
 		registration = &api.AgentServiceRegistration{
@@ -192,7 +192,7 @@ func testNewRegistrarChecksMalformedTTL(t *testing.T) {
 
 		logger        = logging.NewTestLogger(nil, t)
 		client        = new(mockClient)
-		ttlUpdater    = new(mockTTLUpdater)
+		ttlUpdater    = new(mockAgent)
 		tickerFactory = prepareMockTickerFactory()
 
 		registration = &api.AgentServiceRegistration{
@@ -225,7 +225,7 @@ func testNewRegistrarChecksTTLTooSmall(t *testing.T) {
 
 		logger        = logging.NewTestLogger(nil, t)
 		client        = new(mockClient)
-		ttlUpdater    = new(mockTTLUpdater)
+		ttlUpdater    = new(mockAgent)
 		tickerFactory = prepareMockTickerFactory()
 
 		registration = &api.AgentServiceRegistration{
@@ -259,7 +259,7 @@ func testNewRegistrarTTL(t *testing.T) {
 
 		logger        = logging.NewTestLogger(nil, t)
 		client        = new(mockClient)
-		ttlUpdater    = new(mockTTLUpdater)
+		ttlUpdater    = new(mockAgent)
 		tickerFactory = prepareMockTickerFactory()
 
 		timer1       = make(chan time.Time, 1)
@@ -371,6 +371,148 @@ func testNewRegistrarTTL(t *testing.T) {
 	tickerFactory.AssertExpectations(t)
 }
 
+func testNewRegistrarMaintenance(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger      = logging.NewTestLogger(nil, t)
+		client      = new(mockClient)
+		ttlUpdater  = new(mockAgent)
+		expectedErr = errors.New("expected")
+
+		registration = &api.AgentServiceRegistration{
+			ID:      "service1",
+			Address: "somehost.com",
+			Port:    1111,
+		}
+	)
+
+	ttlUpdater.On("EnableServiceMaintenance", "service1", "testing").Return(error(nil)).Once()
+	ttlUpdater.On("DisableServiceMaintenance", "service1").Return(expectedErr).Once()
+
+	r, err := NewRegistrar(client, ttlUpdater, registration, logger)
+	require.NoError(err)
+	require.NotNil(r)
+
+	assert.NoError(r.EnableMaintenance("testing"))
+	assert.Equal(expectedErr, r.DisableMaintenance())
+
+	client.AssertExpectations(t)
+	ttlUpdater.AssertExpectations(t)
+}
+
+func testNewRegistrarDeregistrationDelay(t *testing.T, maintenanceErr error) {
+	defer resetSleep()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger     = logging.NewTestLogger(nil, t)
+		client     = new(mockClient)
+		ttlUpdater = new(mockAgent)
+		sleeps     = prepareMockSleep()
+
+		registration = &api.AgentServiceRegistration{
+			ID:      "service1",
+			Address: "somehost.com",
+			Port:    1111,
+		}
+	)
+
+	client.On("Register",
+		mock.MatchedBy(func(r *api.AgentServiceRegistration) bool {
+			return r.ID == "service1"
+		}),
+	).Return(error(nil)).Once()
+
+	ttlUpdater.On("EnableServiceMaintenance", "service1", "deregistering").Return(maintenanceErr).Once()
+
+	client.On("Deregister",
+		mock.MatchedBy(func(r *api.AgentServiceRegistration) bool {
+			return r.ID == "service1"
+		}),
+	).Return(error(nil)).Once().Run(func(mock.Arguments) {
+		// the delay must have elapsed prior to deregistration
+		assert.Len(sleeps, 1)
+	})
+
+	r, err := NewRegistrar(client, ttlUpdater, registration, logger, WithDeregistrationDelay(30*time.Second))
+	require.NoError(err)
+	require.NotNil(r)
+
+	r.Deregister() // not registered, so no delay
+	assert.Empty(sleeps)
+
+	r.Register()
+	r.Deregister()
+	r.Deregister() // idempotent
+
+	require.Len(sleeps, 1)
+	assert.Equal(30*time.Second, <-sleeps)
+
+	client.AssertExpectations(t)
+	ttlUpdater.AssertExpectations(t)
+}
+
+func testNewRegistrarSharedDrain(t *testing.T) {
+	defer resetSleep()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger     = logging.NewTestLogger(nil, t)
+		client     = new(mockClient)
+		ttlUpdater = new(mockAgent)
+		sleeps     = prepareMockSleep()
+		d          = newDrain(30 * time.Second)
+
+		registrars []Registrar
+	)
+
+	for _, id := range []string{"service1", "service2", "service3"} {
+		r, err := NewRegistrar(client, ttlUpdater, &api.AgentServiceRegistration{ID: id, Address: "somehost.com", Port: 1111}, logger, withDrain(d))
+		require.NoError(err)
+		registrars = append(registrars, r)
+	}
+
+	client.On("Register", mock.AnythingOfType("*api.AgentServiceRegistration")).Return(error(nil)).Twice()
+
+	// only registered services enter maintenance mode
+	ttlUpdater.On("EnableServiceMaintenance", "service1", "deregistering").Return(error(nil)).Once()
+	ttlUpdater.On("EnableServiceMaintenance", "service2", "deregistering").Return(error(nil)).Once()
+
+	client.On("Deregister", mock.AnythingOfType("*api.AgentServiceRegistration")).Return(error(nil)).Twice().Run(func(mock.Arguments) {
+		// every service must be in maintenance mode and the delay must have elapsed prior to any deregistration
+		ttlUpdater.AssertNumberOfCalls(t, "EnableServiceMaintenance", 2)
+		assert.Len(sleeps, 1)
+	})
+
+	registrars[0].Register()
+	registrars[1].Register()
+	for _, r := range registrars {
+		r.Deregister()
+	}
+
+	require.Len(sleeps, 1)
+	assert.Equal(30*time.Second, <-sleeps)
+
+	// registering again allows another delay
+	client.On("Register", mock.AnythingOfType("*api.AgentServiceRegistration")).Return(error(nil)).Once()
+	ttlUpdater.On("EnableServiceMaintenance", "service3", "deregistering").Return(error(nil)).Once()
+	client.On("Deregister", mock.AnythingOfType("*api.AgentServiceRegistration")).Return(error(nil)).Once()
+
+	registrars[2].Register()
+	registrars[2].Deregister()
+	require.Len(sleeps, 1)
+	assert.Equal(30*time.Second, <-sleeps)
+
+	client.AssertExpectations(t)
+	ttlUpdater.AssertExpectations(t)
+}
+
 func TestNewRegistrar(t *testing.T) {
 	t.Run("NoChecks", testNewRegistrarNoChecks)
 	t.Run("NoTTL", testNewRegistrarNoTTL)
@@ -386,4 +528,17 @@ func TestNewRegistrar(t *testing.T) {
 	})
 
 	t.Run("TTL", testNewRegistrarTTL)
+	t.Run("Maintenance", testNewRegistrarMaintenance)
+
+	t.Run("DeregistrationDelay", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			testNewRegistrarDeregistrationDelay(t, nil)
+		})
+
+		t.Run("MaintenanceError", func(t *testing.T) {
+			testNewRegistrarDeregistrationDelay(t, errors.New("expected"))
+		})
+
+		t.Run("SharedDrain", testNewRegistrarSharedDrain)
+	})
 }
@@ -0,0 +1,54 @@
+package dns
+
+import (
+	"fmt"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+func newInstancerKey(w Watch) string {
+	if len(w.Service) > 0 {
+		return fmt.Sprintf("_%s._%s.%s", w.Service, w.proto(), w.Name)
+	}
+
+	return fmt.Sprintf("%s{port=%d}", w.Name, w.Port)
+}
+
+func newInstancers(l log.Logger, o Options) (i service.Instancers) {
+	r := o.resolver()
+	for _, w := range o.watches() {
+		key := newInstancerKey(w)
+		if i.Has(key) {
+			l.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "skipping duplicate watch", "name", w.Name, "service", w.Service, "proto", w.proto())
+			continue
+		}
+
+		i.Set(key, newInstancer(l, r, o.refreshInterval(), o.timeout(), w))
+	}
+
+	return
+}
+
+// NewEnvironment constructs a DNS-based service.Environment using both a DNS Options (typically unmarshaled
+// from configuration) and an optional extra set of environment options.  Each watch is resolved periodically,
+// using SRV records when a service is configured and A/AAAA records otherwise.  The returned Environment
+// has no registrations.
+func NewEnvironment(l log.Logger, o Options, eo ...service.Option) (service.Environment, error) {
+	if l == nil {
+		l = logging.DefaultLogger()
+	}
+
+	if len(o.Watches) == 0 {
+		return nil, nil
+	}
+
+	return service.NewEnvironment(
+		append(
+			eo,
+			service.WithInstancers(newInstancers(l, o)),
+		)...,
+	), nil
+}
@@ -0,0 +1,68 @@
+package dns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testNewEnvironmentEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	e, err := NewEnvironment(nil, Options{})
+	assert.Nil(e)
+	assert.NoError(err)
+}
+
+func testNewEnvironmentFull(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		resolver = new(mockResolver)
+		o        = Options{
+			Watches: []Watch{
+				{Name: "example.com", Service: "http"},
+				{Name: "example.com", Service: "http", Proto: "tcp"}, // duplicate should be ignored
+				{Name: "hosts.example.com", Port: 8080},
+			},
+			Resolver: resolver,
+		}
+	)
+
+	resolver.On("LookupSRV", mock.Anything, "http", "tcp", "example.com").
+		Return("", []*net.SRV{{Target: "host1.example.com.", Port: 8080}}, error(nil)).Once()
+	resolver.On("LookupHost", mock.Anything, "hosts.example.com").
+		Return([]string{"10.0.0.1"}, error(nil)).Once()
+
+	e, err := NewEnvironment(logging.NewTestLogger(nil, t), o)
+	require.NoError(err)
+	require.NotNil(e)
+
+	instancers := e.Instancers()
+	require.Equal(2, instancers.Len())
+	for key, expected := range map[string]string{
+		"_http._tcp.example.com":       "http://host1.example.com:8080",
+		"hosts.example.com{port=8080}": "http://10.0.0.1:8080",
+	} {
+		i, ok := instancers.Get(key)
+		require.True(ok)
+
+		events := make(chan sd.Event, 1)
+		i.Register(events)
+		assert.Equal(sd.Event{Instances: []string{expected}}, <-events)
+	}
+
+	assert.NoError(e.Close())
+	resolver.AssertExpectations(t)
+}
+
+func TestNewEnvironment(t *testing.T) {
+	t.Run("Empty", testNewEnvironmentEmpty)
+	t.Run("Full", testNewEnvironmentFull)
+}
@@ -0,0 +1,152 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/sd"
+)
+
+// formatAddress brackets IPv6 addresses so that they can be used in instance URLs
+func formatAddress(address string) string {
+	if strings.Contains(address, ":") {
+		return "[" + address + "]"
+	}
+
+	return address
+}
+
+// srvInstances converts SRV records into instances.  Only the records with the lowest priority are used,
+// as clients must not use higher priority targets while any lower priority target is available.
+func srvInstances(scheme string, records []*net.SRV) []string {
+	if len(records) == 0 {
+		return nil
+	}
+
+	priority := records[0].Priority
+	for _, r := range records[1:] {
+		if r.Priority < priority {
+			priority = r.Priority
+		}
+	}
+
+	var instances []string
+	for _, r := range records {
+		if r.Priority == priority {
+			instances = append(instances, service.FormatInstance(scheme, strings.TrimSuffix(r.Target, "."), int(r.Port)))
+		}
+	}
+
+	return instances
+}
+
+// instancer is an sd.Instancer which periodically resolves a DNS name
+type instancer struct {
+	service.UpdatableInstancer
+
+	logger          log.Logger
+	resolver        Resolver
+	watch           Watch
+	refreshInterval time.Duration
+	timeout         time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newInstancer(l log.Logger, r Resolver, refreshInterval, timeout time.Duration, w Watch) sd.Instancer {
+	ctx, cancel := context.WithCancel(context.Background())
+	i := &instancer{
+		logger:          log.With(l, "name", w.Name, "service", w.Service, "proto", w.proto()),
+		resolver:        r,
+		watch:           w,
+		refreshInterval: refreshInterval,
+		timeout:         timeout,
+		cancel:          cancel,
+		done:            make(chan struct{}),
+	}
+
+	i.update(ctx)
+	go i.run(ctx)
+
+	return service.NewContextualInstancer(
+		i,
+		map[string]interface{}{
+			"name":    w.Name,
+			"service": w.Service,
+			"proto":   w.proto(),
+		},
+	)
+}
+
+func (i *instancer) resolve(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, i.timeout)
+	defer cancel()
+
+	if len(i.watch.Service) > 0 {
+		_, records, err := i.resolver.LookupSRV(ctx, i.watch.Service, i.watch.proto(), i.watch.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		return srvInstances(i.watch.scheme(), records), nil
+	}
+
+	addresses, err := i.resolver.LookupHost(ctx, i.watch.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(addresses)
+	instances := make([]string, 0, len(addresses))
+	for _, a := range addresses {
+		instances = append(instances, service.FormatInstance(i.watch.scheme(), formatAddress(a), i.watch.Port))
+	}
+
+	return instances, nil
+}
+
+func (i *instancer) update(ctx context.Context) {
+	instances, err := i.resolve(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			i.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "failed to resolve instances", logging.ErrorKey(), err)
+			i.Update(sd.Event{Err: err})
+		}
+
+		return
+	}
+
+	i.Update(sd.Event{Instances: instances})
+}
+
+func (i *instancer) run(ctx context.Context) {
+	defer close(i.done)
+
+	ticker := time.NewTicker(i.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			i.update(ctx)
+		}
+	}
+}
+
+// Stop terminates the periodic resolution.  No further events are dispatched once this method returns.
+func (i *instancer) Stop() {
+	i.cancel()
+	<-i.done
+	i.UpdatableInstancer.Stop()
+}
@@ -0,0 +1,85 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func expectEvent(t *testing.T, events <-chan sd.Event, expected sd.Event) {
+	select {
+	case actual := <-events:
+		assert.Equal(t, expected, actual)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "No event received", "expected: %v", expected)
+	}
+}
+
+func TestSRVInstances(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Empty(srvInstances("https", nil))
+	assert.Equal(
+		[]string{"https://host1.example.com:8443", "https://host3.example.com"},
+		srvInstances("https", []*net.SRV{
+			{Target: "host1.example.com.", Port: 8443, Priority: 10, Weight: 5},
+			{Target: "host2.example.com.", Port: 8443, Priority: 20, Weight: 5},
+			{Target: "host3.example.com.", Port: 443, Priority: 10, Weight: 5},
+		}),
+	)
+}
+
+func TestInstancerSRV(t *testing.T) {
+	var (
+		require = require.New(t)
+
+		resolver    = new(mockResolver)
+		expectedErr = errors.New("expected")
+		events      = make(chan sd.Event, 10)
+	)
+
+	resolver.On("LookupSRV", mock.Anything, "http", "tcp", "example.com").
+		Return("", []*net.SRV{{Target: "host1.example.com.", Port: 8080}}, error(nil)).Once()
+	resolver.On("LookupSRV", mock.Anything, "http", "tcp", "example.com").
+		Return("", nil, expectedErr).Once()
+	resolver.On("LookupSRV", mock.Anything, "http", "tcp", "example.com").
+		Return("", []*net.SRV{{Target: "host2.example.com.", Port: 8080}, {Target: "host1.example.com.", Port: 8080}}, error(nil))
+
+	i := newInstancer(logging.NewTestLogger(nil, t), resolver, 10*time.Millisecond, time.Second, Watch{Name: "example.com", Service: "http"})
+	require.NotNil(i)
+
+	i.Register(events)
+	expectEvent(t, events, sd.Event{Instances: []string{"http://host1.example.com:8080"}})
+	expectEvent(t, events, sd.Event{Err: expectedErr})
+	expectEvent(t, events, sd.Event{Instances: []string{"http://host1.example.com:8080", "http://host2.example.com:8080"}})
+
+	i.Stop()
+	resolver.AssertExpectations(t)
+}
+
+func TestInstancerHost(t *testing.T) {
+	var (
+		require = require.New(t)
+
+		resolver = new(mockResolver)
+		events   = make(chan sd.Event, 10)
+	)
+
+	resolver.On("LookupHost", mock.Anything, "example.com").Return([]string{"10.0.0.2", "fd00::1", "10.0.0.1"}, error(nil))
+
+	i := newInstancer(logging.NewTestLogger(nil, t), resolver, time.Hour, time.Second, Watch{Name: "example.com", Port: 8443, Scheme: "https"})
+	require.NotNil(i)
+
+	i.Register(events)
+	expectEvent(t, events, sd.Event{Instances: []string{"https://10.0.0.1:8443", "https://10.0.0.2:8443", "https://[fd00::1]:8443"}})
+
+	i.Stop()
+	resolver.AssertExpectations(t)
+}
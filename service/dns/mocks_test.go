@@ -0,0 +1,24 @@
+package dns
+
+import (
+	"context"
+	"net"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockResolver struct {
+	mock.Mock
+}
+
+func (m *mockResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	arguments := m.Called(ctx, service, proto, name)
+	records, _ := arguments.Get(1).([]*net.SRV)
+	return arguments.String(0), records, arguments.Error(2)
+}
+
+func (m *mockResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	arguments := m.Called(ctx, host)
+	addresses, _ := arguments.Get(0).([]string)
+	return addresses, arguments.Error(1)
+}
@@ -0,0 +1,121 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+const (
+	DefaultScheme          = "http"
+	DefaultProto           = "tcp"
+	DefaultRefreshInterval = 30 * time.Second
+	DefaultTimeout         = 5 * time.Second
+)
+
+// Resolver is the DNS behavior required for service discovery.  *net.Resolver implements this interface.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Watch describes a DNS name to resolve into instances.  When Service is set, the SRV records for
+// _service._proto.name are used.  Otherwise, the A and AAAA records for name are used along with Port.
+type Watch struct {
+	// Name is the domain name to resolve.  This field is required.
+	Name string `json:"name"`
+
+	// Service is the SRV service, e.g. "http" or "xmpp-server".  If not supplied, Name is resolved
+	// as an A/AAAA record instead.
+	Service string `json:"service,omitempty"`
+
+	// Proto is the SRV protocol.  If not supplied, DefaultProto is used.
+	Proto string `json:"proto,omitempty"`
+
+	// Port is the port of the instances resolved from A/AAAA records.  It is ignored for SRV records,
+	// which carry their own ports.  If nonpositive, no port is included in the instances.
+	Port int `json:"port,omitempty"`
+
+	// Scheme is the scheme of the resolved instances.  If not supplied, DefaultScheme is used.
+	Scheme string `json:"scheme,omitempty"`
+}
+
+func (w Watch) proto() string {
+	if len(w.Proto) > 0 {
+		return w.Proto
+	}
+
+	return DefaultProto
+}
+
+func (w Watch) scheme() string {
+	if len(w.Scheme) > 0 {
+		return w.Scheme
+	}
+
+	return DefaultScheme
+}
+
+// Options represents the set of configurable attributes for DNS service discovery.  DNS discovery is
+// watch only, as registration is done by whatever manages the DNS records.
+type Options struct {
+	// Server is the optional address, in host:port form, of the DNS server to query.  If not supplied,
+	// the system's resolver configuration is used.
+	Server string `json:"server,omitempty"`
+
+	// RefreshInterval is how often each watch is resolved.  If not supplied, DefaultRefreshInterval is used.
+	RefreshInterval time.Duration `json:"refreshInterval"`
+
+	// Timeout is the time limit for each resolution.  If not supplied, DefaultTimeout is used.
+	Timeout time.Duration `json:"timeout"`
+
+	// Watches are the DNS names to resolve.  There is no default for this field.
+	Watches []Watch `json:"watches,omitempty"`
+
+	// Resolver is the optional DNS resolver.  When supplied, Server is ignored.
+	Resolver Resolver `json:"-"`
+}
+
+func (o *Options) refreshInterval() time.Duration {
+	if o != nil && o.RefreshInterval > 0 {
+		return o.RefreshInterval
+	}
+
+	return DefaultRefreshInterval
+}
+
+func (o *Options) timeout() time.Duration {
+	if o != nil && o.Timeout > 0 {
+		return o.Timeout
+	}
+
+	return DefaultTimeout
+}
+
+func (o *Options) watches() []Watch {
+	if o != nil && len(o.Watches) > 0 {
+		return o.Watches
+	}
+
+	return nil
+}
+
+func (o *Options) resolver() Resolver {
+	switch {
+	case o != nil && o.Resolver != nil:
+		return o.Resolver
+
+	case o != nil && len(o.Server) > 0:
+		server := o.Server
+		return &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+
+	default:
+		return net.DefaultResolver
+	}
+}
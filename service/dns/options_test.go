@@ -0,0 +1,68 @@
+package dns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultProto, Watch{}.proto())
+	assert.Equal(DefaultScheme, Watch{}.scheme())
+	assert.Equal("udp", Watch{Proto: "udp"}.proto())
+	assert.Equal("https", Watch{Scheme: "https"}.scheme())
+}
+
+func testOptionsDefault(t *testing.T, o *Options) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultRefreshInterval, o.refreshInterval())
+	assert.Equal(DefaultTimeout, o.timeout())
+	assert.Len(o.watches(), 0)
+	assert.Equal(net.DefaultResolver, o.resolver())
+}
+
+func testOptionsCustom(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		resolver = new(mockResolver)
+		o        = Options{
+			RefreshInterval: 13 * time.Minute,
+			Timeout:         17 * time.Second,
+			Watches:         []Watch{{Name: "example.com", Service: "http"}},
+			Resolver:        resolver,
+		}
+	)
+
+	assert.Equal(13*time.Minute, o.refreshInterval())
+	assert.Equal(17*time.Second, o.timeout())
+	assert.Equal([]Watch{{Name: "example.com", Service: "http"}}, o.watches())
+	assert.Equal(resolver, o.resolver())
+}
+
+func testOptionsServer(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		o      = Options{Server: "127.0.0.1:53"}
+	)
+
+	r, ok := o.resolver().(*net.Resolver)
+	if assert.True(ok) {
+		assert.True(r.PreferGo)
+		assert.NotNil(r.Dial)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		testOptionsDefault(t, nil)
+		testOptionsDefault(t, new(Options))
+	})
+
+	t.Run("Custom", testOptionsCustom)
+	t.Run("Server", testOptionsServer)
+}
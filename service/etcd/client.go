@@ -0,0 +1,269 @@
+package etcd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Comcast/webpa-common/service"
+	"github.com/coreos/etcd/clientv3"
+)
+
+// discoveryClient is the subset of etcd v3 behavior required for service discovery.  Tests
+// can supply mocked implementations of this interface.
+type discoveryClient interface {
+	// GetEntries returns the values of all keys with the given prefix
+	GetEntries(prefix string) ([]string, error)
+
+	// WatchPrefix sends to changes whenever a key with the given prefix changes.  This method
+	// blocks until the context is canceled.
+	WatchPrefix(ctx context.Context, prefix string, changes chan<- struct{})
+
+	// Register puts a key with a lease that is kept alive until the key is deregistered
+	Register(key, value string, ttl time.Duration) error
+
+	// Deregister revokes the lease for a registered key, which removes the key
+	Deregister(key string) error
+
+	// Close releases all resources.  Keys that are still registered expire along with their leases.
+	Close() error
+}
+
+// v3API is the subset of *clientv3.Client used for service discovery
+type v3API interface {
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+	Put(ctx context.Context, key, value string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error)
+	Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error)
+	Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan
+	Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error)
+	KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error)
+	Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error)
+	Close() error
+}
+
+// lease is an active keepalive for a registered key.  The id changes whenever the lease is lost
+// and the key is registered again under a new lease.
+type lease struct {
+	id     clientv3.LeaseID
+	cancel context.CancelFunc
+}
+
+// v3Client is the production discoveryClient backed by an etcd v3 client
+type v3Client struct {
+	client         v3API
+	requestTimeout time.Duration
+
+	lock   sync.Mutex
+	leases map[string]*lease
+}
+
+func newV3Client(c *Client) (discoveryClient, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   c.endpoints(),
+		DialTimeout: c.dialTimeout(),
+		Username:    c.Username,
+		Password:    c.Password,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &v3Client{
+		client:         client,
+		requestTimeout: c.requestTimeout(),
+		leases:         make(map[string]*lease),
+	}, nil
+}
+
+func (c *v3Client) GetEntries(prefix string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	response, err := c.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]string, 0, len(response.Kvs))
+	for _, kv := range response.Kvs {
+		entries = append(entries, string(kv.Value))
+	}
+
+	return entries, nil
+}
+
+func (c *v3Client) WatchPrefix(ctx context.Context, prefix string, changes chan<- struct{}) {
+	signal := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+
+	for ctx.Err() == nil {
+		for range c.client.Watch(clientv3.WithRequireLeader(ctx), prefix, clientv3.WithPrefix()) {
+			signal()
+		}
+
+		// the watch was closed, e.g. due to compaction or loss of the leader, so signal a full refresh
+		// and start a new watch after a short delay
+		signal()
+		select {
+		case <-ctx.Done():
+		case <-time.After(c.requestTimeout):
+		}
+	}
+}
+
+// grant puts a key under a new lease and starts keeping that lease alive until the given context is canceled
+func (c *v3Client) grant(ctx context.Context, key, value string, ttlSeconds int64) (clientv3.LeaseID, <-chan *clientv3.LeaseKeepAliveResponse, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	grant, err := c.client.Grant(requestCtx, ttlSeconds)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if _, err := c.client.Put(requestCtx, key, value, clientv3.WithLease(grant.ID)); err != nil {
+		return 0, nil, err
+	}
+
+	keepAlive, err := c.client.KeepAlive(ctx, grant.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return grant.ID, keepAlive, nil
+}
+
+// keepAlive consumes keepalive responses for a registered key.  The keepalive channel closes when the context is
+// canceled, but also when the lease is lost, e.g. because etcd was unreachable for longer than the TTL.  In the
+// latter case the key is put again under a new lease, retrying every request timeout until that succeeds.
+func (c *v3Client) keepAlive(ctx context.Context, l *lease, key, value string, ttlSeconds int64, responses <-chan *clientv3.LeaseKeepAliveResponse) {
+	for {
+		// the keepalive responses must be consumed, or the client will log warnings about a full channel
+		for range responses {
+		}
+
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			id, keepAlive, err := c.grant(ctx, key, value, ttlSeconds)
+			if err == nil {
+				c.lock.Lock()
+				deregistered := ctx.Err() != nil
+				if !deregistered {
+					l.id = id
+				}
+
+				c.lock.Unlock()
+				if deregistered {
+					// the key was deregistered while the new lease was being granted, so clean up the new lease
+					c.revoke(id)
+					return
+				}
+
+				responses = keepAlive
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.requestTimeout):
+			}
+		}
+	}
+}
+
+func (c *v3Client) Register(key, value string, ttl time.Duration) error {
+	ttlSeconds := int64(ttl / time.Second)
+	if ttlSeconds < 1 {
+		ttlSeconds = 1
+	}
+
+	keepAliveCtx, keepAliveCancel := context.WithCancel(context.Background())
+	id, keepAlive, err := c.grant(keepAliveCtx, key, value, ttlSeconds)
+	if err != nil {
+		keepAliveCancel()
+		return err
+	}
+
+	l := &lease{id: id, cancel: keepAliveCancel}
+	c.lock.Lock()
+	if previous, ok := c.leases[key]; ok {
+		previous.cancel()
+	}
+
+	c.leases[key] = l
+	c.lock.Unlock()
+
+	go c.keepAlive(keepAliveCtx, l, key, value, ttlSeconds, keepAlive)
+	return nil
+}
+
+func (c *v3Client) Deregister(key string) error {
+	var id clientv3.LeaseID
+	c.lock.Lock()
+	l, ok := c.leases[key]
+	if ok {
+		// canceling under the lock means the keepalive goroutine either has already recorded any new lease,
+		// or will revoke that lease itself
+		delete(c.leases, key)
+		l.cancel()
+		id = l.id
+	}
+
+	c.lock.Unlock()
+	if ok {
+		return c.revoke(id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	_, err := c.client.Delete(ctx, key)
+	return err
+}
+
+// revoke revokes a lease, which removes any keys put under it
+func (c *v3Client) revoke(id clientv3.LeaseID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	_, err := c.client.Revoke(ctx, id)
+	return err
+}
+
+func (c *v3Client) Close() error {
+	c.lock.Lock()
+	for key, l := range c.leases {
+		l.cancel()
+		delete(c.leases, key)
+	}
+
+	c.lock.Unlock()
+	return c.client.Close()
+}
+
+// metadataClient decorates a discoveryClient so that any metadata encoded in values is recorded
+// and stripped from the discovered instances
+type metadataClient struct {
+	discoveryClient
+	registry *service.MetadataRegistry
+}
+
+func (mc metadataClient) GetEntries(prefix string) ([]string, error) {
+	entries, err := mc.discoveryClient.GetEntries(prefix)
+	for i, e := range entries {
+		instance, m, _ := service.SplitInstanceMetadata(e)
+		mc.registry.Set(instance, m)
+		entries[i] = instance
+	}
+
+	return entries, err
+}
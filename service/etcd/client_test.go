@@ -0,0 +1,108 @@
+package etcd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestV3Client(api v3API) *v3Client {
+	return &v3Client{
+		client:         api,
+		requestTimeout: 10 * time.Millisecond,
+		leases:         make(map[string]*lease),
+	}
+}
+
+func (c *v3Client) leaseID(key string) clientv3.LeaseID {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if l, ok := c.leases[key]; ok {
+		return l.id
+	}
+
+	return 0
+}
+
+func testV3ClientRegisterError(t *testing.T) {
+	var (
+		assert        = assert.New(t)
+		api           = new(mockV3API)
+		client        = newTestV3Client(api)
+		expectedError = errors.New("expected")
+	)
+
+	api.On("Grant", mock.Anything, int64(1)).Return(nil, expectedError).Once()
+	assert.Equal(expectedError, client.Register("/test/key", "value", 0))
+	assert.Empty(client.leases)
+
+	api.AssertExpectations(t)
+}
+
+func testV3ClientRegisterLeaseLost(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+		api     = new(mockV3API)
+		client  = newTestV3Client(api)
+
+		firstKeepAlive  = make(chan *clientv3.LeaseKeepAliveResponse, 1)
+		secondKeepAlive = make(chan *clientv3.LeaseKeepAliveResponse, 1)
+	)
+
+	api.On("Grant", mock.Anything, int64(30)).Return(&clientv3.LeaseGrantResponse{ID: 1}, error(nil)).Once()
+	api.On("Put", mock.Anything, "/test/key", "value").Return(new(clientv3.PutResponse), error(nil)).Twice()
+	api.On("KeepAlive", mock.Anything, clientv3.LeaseID(1)).Return((<-chan *clientv3.LeaseKeepAliveResponse)(firstKeepAlive), error(nil)).Once()
+
+	require.NoError(client.Register("/test/key", "value", 30*time.Second))
+	assert.Equal(clientv3.LeaseID(1), client.leaseID("/test/key"))
+	firstKeepAlive <- &clientv3.LeaseKeepAliveResponse{ID: 1, TTL: 30}
+
+	// losing the lease puts the key again under a new lease, retrying any failures
+	api.On("Grant", mock.Anything, int64(30)).Return(nil, errors.New("expected")).Once()
+	api.On("Grant", mock.Anything, int64(30)).Return(&clientv3.LeaseGrantResponse{ID: 2}, error(nil)).Once()
+	api.On("KeepAlive", mock.Anything, clientv3.LeaseID(2)).Return((<-chan *clientv3.LeaseKeepAliveResponse)(secondKeepAlive), error(nil)).Once()
+	close(firstKeepAlive)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.leaseID("/test/key") != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	require.Equal(clientv3.LeaseID(2), client.leaseID("/test/key"))
+
+	api.On("Revoke", mock.Anything, clientv3.LeaseID(2)).Return(new(clientv3.LeaseRevokeResponse), error(nil)).Once()
+	assert.NoError(client.Deregister("/test/key"))
+	assert.Empty(client.leases)
+
+	// the etcd client closes the keepalive channel once its context is canceled
+	close(secondKeepAlive)
+	time.Sleep(50 * time.Millisecond)
+
+	api.AssertExpectations(t)
+}
+
+func testV3ClientDeregisterUnknownKey(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		api    = new(mockV3API)
+		client = newTestV3Client(api)
+	)
+
+	api.On("Delete", mock.Anything, "/test/key").Return(new(clientv3.DeleteResponse), error(nil)).Once()
+	assert.NoError(client.Deregister("/test/key"))
+
+	api.AssertExpectations(t)
+}
+
+func TestV3Client(t *testing.T) {
+	t.Run("RegisterError", testV3ClientRegisterError)
+	t.Run("RegisterLeaseLost", testV3ClientRegisterLeaseLost)
+	t.Run("DeregisterUnknownKey", testV3ClientDeregisterUnknownKey)
+}
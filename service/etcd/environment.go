@@ -0,0 +1,96 @@
+package etcd
+
+import (
+	"fmt"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// newRegistrar produces the instance and sd.Registrar for a Registration.  The key is the registration's
+// prefix followed by the address and port, while the value is the instance along with any metadata.
+func newRegistrar(base log.Logger, c discoveryClient, r Registration) (string, *registrar) {
+	instance := service.FormatInstance(
+		r.scheme(),
+		r.address(),
+		r.port(),
+	)
+
+	key := fmt.Sprintf("%s/%s:%d", r.prefix(), r.address(), r.port())
+	return instance, &registrar{
+		logger: log.With(base, "instance", instance, "key", key),
+		client: c,
+		key:    key,
+		value:  service.FormatInstanceMetadata(instance, r.metadata()),
+		ttl:    r.ttl(),
+	}
+}
+
+// clientFactory is the factory function used to create the etcd client.
+// Tests can change this for mocked behavior.
+var clientFactory = newV3Client
+
+func newClient(o Options) (discoveryClient, error) {
+	c, err := clientFactory(o.client())
+	if err != nil {
+		return nil, err
+	}
+
+	return metadataClient{discoveryClient: c, registry: o.metadata()}, nil
+}
+
+func newInstancers(l log.Logger, c discoveryClient, o Options) (i service.Instancers) {
+	for _, prefix := range o.watches() {
+		if i.Has(prefix) {
+			l.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "skipping duplicate watch", "prefix", prefix)
+			continue
+		}
+
+		i.Set(prefix, newInstancer(l, c, prefix))
+	}
+
+	return
+}
+
+func newRegistrars(l log.Logger, c discoveryClient, o Options) (r service.Registrars) {
+	for _, registration := range o.registrations() {
+		instance, registrar := newRegistrar(l, c, registration)
+		if r.Has(instance) {
+			l.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "skipping duplicate registration", "instance", instance)
+			continue
+		}
+
+		r.Add(instance, registrar)
+	}
+
+	return
+}
+
+// NewEnvironment constructs an etcd v3 service.Environment using both an etcd Options (typically unmarshaled
+// from configuration) and an optional extra set of environment options.  Registrations are put under leases which
+// are kept alive while registered, and watches track all keys under a prefix.
+func NewEnvironment(l log.Logger, o Options, eo ...service.Option) (service.Environment, error) {
+	if l == nil {
+		l = logging.DefaultLogger()
+	}
+
+	if len(o.Watches) == 0 && len(o.Registrations) == 0 {
+		return nil, nil
+	}
+
+	c, err := newClient(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewEnvironment(
+		append(
+			eo,
+			service.WithRegistrars(newRegistrars(l, c, o)),
+			service.WithInstancers(newInstancers(l, c, o)),
+			service.WithCloser(c.Close),
+		)...,
+	), nil
+}
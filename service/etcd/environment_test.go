@@ -0,0 +1,216 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForCancel is a mock Run function that simulates a watch which blocks until canceled
+func waitForCancel(arguments mock.Arguments) {
+	<-arguments.Get(0).(context.Context).Done()
+}
+
+func testNewEnvironmentEmpty(t *testing.T) {
+	defer resetClientFactory()
+
+	var (
+		assert        = assert.New(t)
+		clientFactory = prepareMockClientFactory()
+	)
+
+	e, err := NewEnvironment(nil, Options{})
+	assert.Nil(e)
+	assert.NoError(err)
+
+	clientFactory.AssertExpectations(t)
+}
+
+func testNewEnvironmentClientError(t *testing.T) {
+	defer resetClientFactory()
+
+	var (
+		assert = assert.New(t)
+
+		clientFactory       = prepareMockClientFactory()
+		expectedClientError = errors.New("expected client error")
+
+		o = Options{
+			Client: Client{
+				Endpoints: []string{"www.shinola.net:2379"},
+			},
+			Watches: []string{"/some/where"},
+		}
+	)
+
+	clientFactory.On("NewClient", &Client{Endpoints: []string{"www.shinola.net:2379"}}).Return(nil, expectedClientError).Once()
+
+	e, actualClientError := NewEnvironment(nil, o)
+	assert.Nil(e)
+	assert.Equal(expectedClientError, actualClientError)
+
+	clientFactory.AssertExpectations(t)
+}
+
+func testNewEnvironmentFull(t *testing.T) {
+	defer resetClientFactory()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger        = logging.NewTestLogger(nil, t)
+		clientFactory = prepareMockClientFactory()
+		client        = new(mockClient)
+		registry      = service.NewMetadataRegistry()
+		watching      = make(chan string, 2)
+
+		o = Options{
+			Client: Client{
+				Endpoints: []string{"someserver.net:2379"},
+			},
+			Registrations: []Registration{
+				Registration{
+					Prefix:  "/test1",
+					Address: "foobar.net",
+					Port:    1717,
+					Scheme:  "https",
+					Weight:  2,
+				},
+				Registration{
+					Prefix:  "/test1",
+					Address: "foobar.net",
+					Port:    1717,
+					Scheme:  "https",
+				}, // duplicate should be ignored
+			},
+			Watches:  []string{"/test1", "/test2", "/test2"}, // duplicate should be ignored
+			Metadata: registry,
+		}
+	)
+
+	clientFactory.On("NewClient", &Client{Endpoints: []string{"someserver.net:2379"}}).Return(client, error(nil)).Once()
+
+	client.On("GetEntries", "/test1").Return([]string{"https://foobar.net:1717#weight=2"}, error(nil)).Once()
+	client.On("WatchPrefix", mock.Anything, "/test1", mock.Anything).Once().
+		Run(func(arguments mock.Arguments) {
+			watching <- "/test1"
+			waitForCancel(arguments)
+		})
+
+	client.On("GetEntries", "/test2").Return([]string{"https://instance2.net"}, error(nil)).Once()
+	client.On("WatchPrefix", mock.Anything, "/test2", mock.Anything).Once().
+		Run(func(arguments mock.Arguments) {
+			watching <- "/test2"
+			waitForCancel(arguments)
+		})
+
+	client.On("Register", "/test1/foobar.net:1717", "https://foobar.net:1717#weight=2", DefaultTTL).Return(error(nil)).Once()
+	client.On("Deregister", "/test1/foobar.net:1717").Return(error(nil)).Twice()
+	client.On("Close").Return(error(nil)).Once()
+
+	e, err := NewEnvironment(logger, o)
+	require.NoError(err)
+	require.NotNil(e)
+
+	instancers := e.Instancers()
+	assert.Equal(2, instancers.Len())
+	for key, expected := range map[string]string{"/test1": "https://foobar.net:1717", "/test2": "https://instance2.net"} {
+		i, ok := instancers.Get(key)
+		require.True(ok)
+
+		events := make(chan sd.Event, 1)
+		i.Register(events)
+		assert.Equal(sd.Event{Instances: []string{expected}}, <-events)
+		i.Deregister(events)
+	}
+
+	m, ok := registry.Metadata("https://foobar.net:1717")
+	assert.True(ok)
+	assert.Equal(service.Metadata{Weight: 2}, m)
+
+	for j := 0; j < 2; j++ {
+		select {
+		case <-watching:
+		case <-time.After(time.Second):
+			require.Fail("The prefixes were not watched")
+		}
+	}
+
+	assert.True(e.IsRegistered("https://foobar.net:1717"))
+	e.Register()
+	e.Deregister()
+
+	assert.NoError(e.Close())
+
+	clientFactory.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestNewEnvironment(t *testing.T) {
+	t.Run("Empty", testNewEnvironmentEmpty)
+	t.Run("ClientError", testNewEnvironmentClientError)
+	t.Run("Full", testNewEnvironmentFull)
+}
+
+func TestInstancer(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger      = logging.NewTestLogger(nil, t)
+		client      = new(mockClient)
+		expectedErr = errors.New("expected")
+		watching    = make(chan chan<- struct{}, 1)
+	)
+
+	client.On("GetEntries", "/test").Return([]string{"instance1"}, error(nil)).Once()
+	client.On("GetEntries", "/test").Return(nil, expectedErr).Once()
+	client.On("GetEntries", "/test").Return([]string{"instance1", "instance2"}, error(nil)).Once()
+	client.On("WatchPrefix", mock.Anything, "/test", mock.Anything).Once().
+		Run(func(arguments mock.Arguments) {
+			watching <- arguments.Get(2).(chan<- struct{})
+			waitForCancel(arguments)
+		})
+
+	i := newInstancer(logger, client, "/test")
+	require.NotNil(i)
+
+	events := make(chan sd.Event, 3)
+	i.Register(events)
+	assert.Equal(sd.Event{Instances: []string{"instance1"}}, <-events)
+
+	var changes chan<- struct{}
+	select {
+	case changes = <-watching:
+	case <-time.After(time.Second):
+		require.Fail("The prefix was not watched")
+	}
+
+	changes <- struct{}{}
+	select {
+	case e := <-events:
+		assert.Equal(sd.Event{Err: expectedErr}, e)
+	case <-time.After(time.Second):
+		require.Fail("No event after a change")
+	}
+
+	changes <- struct{}{}
+	select {
+	case e := <-events:
+		assert.Equal(sd.Event{Instances: []string{"instance1", "instance2"}}, e)
+	case <-time.After(time.Second):
+		require.Fail("No event after a change")
+	}
+
+	i.Stop()
+	client.AssertExpectations(t)
+}
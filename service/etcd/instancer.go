@@ -0,0 +1,82 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/sd"
+)
+
+// instancer is an sd.Instancer which watches a key prefix in etcd.  The values of the keys
+// under the prefix are the instances.
+type instancer struct {
+	service.UpdatableInstancer
+
+	logger log.Logger
+	client discoveryClient
+	prefix string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newInstancer(l log.Logger, c discoveryClient, prefix string) sd.Instancer {
+	ctx, cancel := context.WithCancel(context.Background())
+	i := &instancer{
+		logger: log.With(l, "prefix", prefix),
+		client: c,
+		prefix: prefix,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	i.update()
+	go i.watch(ctx)
+
+	return service.NewContextualInstancer(i, map[string]interface{}{"prefix": prefix})
+}
+
+func (i *instancer) update() {
+	entries, err := i.client.GetEntries(i.prefix)
+	if err != nil {
+		i.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "failed to retrieve entries", logging.ErrorKey(), err)
+		i.Update(sd.Event{Err: err})
+		return
+	}
+
+	i.Update(sd.Event{Instances: entries})
+}
+
+func (i *instancer) watch(ctx context.Context) {
+	defer close(i.done)
+
+	var (
+		changes = make(chan struct{}, 1)
+		watched = make(chan struct{})
+	)
+
+	go func() {
+		defer close(watched)
+		i.client.WatchPrefix(ctx, i.prefix, changes)
+	}()
+
+	for {
+		select {
+		case <-changes:
+			i.update()
+
+		case <-ctx.Done():
+			<-watched
+			return
+		}
+	}
+}
+
+// Stop terminates the watch on the prefix.  No further events are dispatched once this method returns.
+func (i *instancer) Stop() {
+	i.cancel()
+	<-i.done
+	i.UpdatableInstancer.Stop()
+}
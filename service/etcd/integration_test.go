@@ -0,0 +1,165 @@
+// +build integration
+
+package etcd
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/embed"
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freeURL returns a local http URL with a port that is free at the time of the call
+func freeURL(t *testing.T) url.URL {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	return url.URL{Scheme: "http", Host: l.Addr().String()}
+}
+
+// startEmbeddedEtcd starts a single member etcd cluster backed by a temporary directory.  The client
+// endpoint is returned along with a closure that stops the server and removes its data.
+func startEmbeddedEtcd(t *testing.T) (string, func()) {
+	directory, err := ioutil.TempDir("", "etcd")
+	require.NoError(t, err)
+
+	var (
+		clientURL = freeURL(t)
+		peerURL   = freeURL(t)
+		c         = embed.NewConfig()
+	)
+
+	c.Dir = directory
+	c.LCUrls, c.ACUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	c.LPUrls, c.APUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	c.InitialCluster = c.InitialClusterFromName(c.Name)
+
+	e, err := embed.StartEtcd(c)
+	if err != nil {
+		os.RemoveAll(directory)
+		require.NoError(t, err)
+	}
+
+	stop := func() {
+		e.Close()
+		os.RemoveAll(directory)
+	}
+
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		stop()
+		require.Fail(t, "The embedded etcd server did not start")
+	}
+
+	return clientURL.Host, stop
+}
+
+// waitForInstances consumes events until one has exactly the expected instances
+func waitForInstances(t *testing.T, events <-chan sd.Event, expected ...string) {
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Err == nil && len(e.Instances) == len(expected) && (len(expected) == 0 || assert.ObjectsAreEqual(expected, e.Instances)) {
+				return
+			}
+
+		case <-timeout:
+			require.Fail(t, "Instances not discovered", "expected: %v", expected)
+		}
+	}
+}
+
+// leaseID returns the lease of a key, or zero if the key does not exist
+func leaseID(t *testing.T, client *clientv3.Client, key string) clientv3.LeaseID {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := client.Get(ctx, key)
+	require.NoError(t, err)
+	if len(response.Kvs) == 0 {
+		return 0
+	}
+
+	return clientv3.LeaseID(response.Kvs[0].Lease)
+}
+
+func TestIntegration(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		endpoint, stop = startEmbeddedEtcd(t)
+	)
+
+	defer stop()
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{endpoint}, DialTimeout: 5 * time.Second})
+	require.NoError(err)
+	defer client.Close()
+
+	e, err := NewEnvironment(
+		logging.NewTestLogger(nil, t),
+		Options{
+			Client: Client{
+				Endpoints:      []string{endpoint},
+				RequestTimeout: time.Second,
+			},
+			Registrations: []Registration{
+				{Prefix: "/integration", Address: "test.com", Port: 1234, TTL: 2 * time.Second},
+			},
+			Watches: []string{"/integration"},
+		},
+	)
+
+	require.NoError(err)
+	require.NotNil(e)
+	defer e.Close()
+
+	i, ok := e.Instancers().Get("/integration")
+	require.True(ok)
+	require.NotNil(i)
+
+	events := make(chan sd.Event, 10)
+	i.Register(events)
+	defer i.Deregister(events)
+	waitForInstances(t, events)
+
+	e.Register()
+	assert.True(e.IsRegistered("http://test.com:1234"))
+	waitForInstances(t, events, "http://test.com:1234")
+
+	// losing the lease removes the key, after which the key is put again under a new lease
+	const key = "/integration/test.com:1234"
+	lost := leaseID(t, client, key)
+	require.NotZero(lost)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_, err = client.Revoke(ctx, lost)
+	cancel()
+	require.NoError(err)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for current := leaseID(t, client, key); current == 0 || current == lost; current = leaseID(t, client, key) {
+		require.True(time.Now().Before(deadline), "The key was not registered again")
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	waitForInstances(t, events, "http://test.com:1234")
+
+	e.Deregister()
+	waitForInstances(t, events)
+	assert.Zero(leaseID(t, client, key))
+}
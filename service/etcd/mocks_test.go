@@ -0,0 +1,110 @@
+package etcd
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/stretchr/testify/mock"
+)
+
+// resetClientFactory resets the global singleton factory function
+// to its original value.  This function is handy as a defer for tests.
+func resetClientFactory() {
+	clientFactory = newV3Client
+}
+
+// prepareMockClientFactory creates a new mockClientFactory and sets up this package
+// to use it.
+func prepareMockClientFactory() *mockClientFactory {
+	m := new(mockClientFactory)
+	clientFactory = m.NewClient
+	return m
+}
+
+type mockClientFactory struct {
+	mock.Mock
+}
+
+func (m *mockClientFactory) NewClient(c *Client) (discoveryClient, error) {
+	arguments := m.Called(c)
+
+	first, _ := arguments.Get(0).(discoveryClient)
+	return first, arguments.Error(1)
+}
+
+type mockClient struct {
+	mock.Mock
+}
+
+func (m *mockClient) GetEntries(prefix string) ([]string, error) {
+	arguments := m.Called(prefix)
+	first, _ := arguments.Get(0).([]string)
+	return first, arguments.Error(1)
+}
+
+func (m *mockClient) WatchPrefix(ctx context.Context, prefix string, changes chan<- struct{}) {
+	m.Called(ctx, prefix, changes)
+}
+
+func (m *mockClient) Register(key, value string, ttl time.Duration) error {
+	return m.Called(key, value, ttl).Error(0)
+}
+
+func (m *mockClient) Deregister(key string) error {
+	return m.Called(key).Error(0)
+}
+
+func (m *mockClient) Close() error {
+	return m.Called().Error(0)
+}
+
+// mockV3API mocks the etcd client.  Options passed to requests are not matched.
+type mockV3API struct {
+	mock.Mock
+}
+
+func (m *mockV3API) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	arguments := m.Called(ctx, key)
+	first, _ := arguments.Get(0).(*clientv3.GetResponse)
+	return first, arguments.Error(1)
+}
+
+func (m *mockV3API) Put(ctx context.Context, key, value string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	arguments := m.Called(ctx, key, value)
+	first, _ := arguments.Get(0).(*clientv3.PutResponse)
+	return first, arguments.Error(1)
+}
+
+func (m *mockV3API) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	arguments := m.Called(ctx, key)
+	first, _ := arguments.Get(0).(*clientv3.DeleteResponse)
+	return first, arguments.Error(1)
+}
+
+func (m *mockV3API) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	first, _ := m.Called(ctx, key).Get(0).(clientv3.WatchChan)
+	return first
+}
+
+func (m *mockV3API) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	arguments := m.Called(ctx, ttl)
+	first, _ := arguments.Get(0).(*clientv3.LeaseGrantResponse)
+	return first, arguments.Error(1)
+}
+
+func (m *mockV3API) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	arguments := m.Called(ctx, id)
+	first, _ := arguments.Get(0).(<-chan *clientv3.LeaseKeepAliveResponse)
+	return first, arguments.Error(1)
+}
+
+func (m *mockV3API) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	arguments := m.Called(ctx, id)
+	first, _ := arguments.Get(0).(*clientv3.LeaseRevokeResponse)
+	return first, arguments.Error(1)
+}
+
+func (m *mockV3API) Close() error {
+	return m.Called().Error(0)
+}
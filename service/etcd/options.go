@@ -0,0 +1,180 @@
+package etcd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Comcast/webpa-common/service"
+)
+
+const (
+	DefaultEndpoint = "localhost:2379"
+	DefaultPrefix   = "/xmidt/test"
+	DefaultAddress  = "localhost"
+	DefaultPort     = 8080
+	DefaultScheme   = "http"
+
+	DefaultDialTimeout    time.Duration = 5 * time.Second
+	DefaultRequestTimeout time.Duration = 5 * time.Second
+	DefaultTTL            time.Duration = 30 * time.Second
+)
+
+type Registration struct {
+	// Prefix is the key prefix under which to register.  The registered key is the prefix followed by
+	// a slash and the address:port of the service.  If not supplied, DefaultPrefix is used.
+	Prefix string `json:"prefix,omitempty"`
+
+	// Address is the FQDN or hostname of the server which hosts the service.  If not supplied, DefaultAddress is used.
+	Address string `json:"address,omitempty"`
+
+	// Port is the TCP port on which the service listens.  If not supplied, DefaultPort is used.
+	Port int `json:"port,omitempty"`
+
+	// Scheme is the protocol used for the service.  If not supplied, DefaultScheme is used.
+	Scheme string `json:"scheme,omitempty"`
+
+	// TTL is the time to live of the lease attached to the registered key.  The lease is kept alive for as
+	// long as the service is registered, so the key disappears shortly after this process dies.  If not
+	// supplied, DefaultTTL is used.
+	TTL time.Duration `json:"ttl"`
+
+	// Weight is the relative hashing weight of the service, advertised in the key's value.  This field is optional.
+	Weight int `json:"weight,omitempty"`
+
+	// Zone is the zone in which the service runs, advertised in the key's value.  This field is optional.
+	Zone string `json:"zone,omitempty"`
+}
+
+func (r Registration) prefix() string {
+	if len(r.Prefix) > 0 {
+		return strings.TrimSuffix(r.Prefix, "/")
+	}
+
+	return DefaultPrefix
+}
+
+func (r Registration) address() string {
+	if len(r.Address) > 0 {
+		return r.Address
+	}
+
+	return DefaultAddress
+}
+
+func (r Registration) port() int {
+	if r.Port > 0 {
+		return r.Port
+	}
+
+	return DefaultPort
+}
+
+func (r Registration) scheme() string {
+	if len(r.Scheme) > 0 {
+		return r.Scheme
+	}
+
+	return DefaultScheme
+}
+
+func (r Registration) ttl() time.Duration {
+	if r.TTL > 0 {
+		return r.TTL
+	}
+
+	return DefaultTTL
+}
+
+func (r Registration) metadata() service.Metadata {
+	return service.Metadata{Weight: r.Weight, Zone: r.Zone}
+}
+
+// Client is the client portion of the options struct
+type Client struct {
+	// Endpoints are the etcd cluster members to connect to.  If not supplied, DefaultEndpoint is used.
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	// DialTimeout is the timeout for establishing a connection to etcd.
+	DialTimeout time.Duration `json:"dialTimeout"`
+
+	// RequestTimeout is the timeout for individual etcd requests, such as reads and lease grants.
+	RequestTimeout time.Duration `json:"requestTimeout"`
+
+	// Username is the optional etcd user
+	Username string `json:"username,omitempty"`
+
+	// Password is the optional password for Username
+	Password string `json:"password,omitempty"`
+}
+
+func (c *Client) endpoints() []string {
+	if c != nil && len(c.Endpoints) > 0 {
+		return c.Endpoints
+	}
+
+	return []string{DefaultEndpoint}
+}
+
+func (c *Client) dialTimeout() time.Duration {
+	if c != nil && c.DialTimeout > 0 {
+		return c.DialTimeout
+	}
+
+	return DefaultDialTimeout
+}
+
+func (c *Client) requestTimeout() time.Duration {
+	if c != nil && c.RequestTimeout > 0 {
+		return c.RequestTimeout
+	}
+
+	return DefaultRequestTimeout
+}
+
+// Options represents the set of configurable attributes for etcd v3
+type Options struct {
+	// Client holds the etcd client options
+	Client Client `json:"client"`
+
+	// Registrations are the ways in which the host process should be registered with etcd.
+	// There is no default for this field.
+	Registrations []Registration `json:"registrations,omitempty"`
+
+	// Watches are the key prefixes to watch for updates.  There is no default for this field.
+	Watches []string `json:"watches,omitempty"`
+
+	// Metadata receives the weight and zone advertised by each watched instance.  This field is optional.
+	Metadata *service.MetadataRegistry `json:"-"`
+}
+
+func (o *Options) client() *Client {
+	if o != nil {
+		return &o.Client
+	}
+
+	return nil
+}
+
+func (o *Options) registrations() []Registration {
+	if o != nil && len(o.Registrations) > 0 {
+		return o.Registrations
+	}
+
+	return nil
+}
+
+func (o *Options) metadata() *service.MetadataRegistry {
+	if o != nil {
+		return o.Metadata
+	}
+
+	return nil
+}
+
+func (o *Options) watches() []string {
+	if o != nil && len(o.Watches) > 0 {
+		return o.Watches
+	}
+
+	return nil
+}
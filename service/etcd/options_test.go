@@ -0,0 +1,141 @@
+package etcd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRegistrationDefault(t *testing.T, r Registration) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultPrefix, r.prefix())
+	assert.Equal(DefaultAddress, r.address())
+	assert.Equal(DefaultPort, r.port())
+	assert.Equal(DefaultScheme, r.scheme())
+	assert.Equal(DefaultTTL, r.ttl())
+	assert.True(r.metadata().IsEmpty())
+}
+
+func testRegistrationCustom(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		r      = Registration{
+			Prefix:  "/testy/test/test/",
+			Address: "funzo.net",
+			Port:    1234,
+			Scheme:  "ftp",
+			TTL:     17 * time.Second,
+			Weight:  3,
+			Zone:    "east",
+		}
+	)
+
+	assert.Equal("/testy/test/test", r.prefix())
+	assert.Equal("funzo.net", r.address())
+	assert.Equal(1234, r.port())
+	assert.Equal("ftp", r.scheme())
+	assert.Equal(17*time.Second, r.ttl())
+	assert.Equal(service.Metadata{Weight: 3, Zone: "east"}, r.metadata())
+}
+
+func TestRegistration(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		testRegistrationDefault(t, Registration{})
+	})
+
+	t.Run("Custom", testRegistrationCustom)
+}
+
+func testClientDefault(t *testing.T, c *Client) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{DefaultEndpoint}, c.endpoints())
+	assert.Equal(DefaultDialTimeout, c.dialTimeout())
+	assert.Equal(DefaultRequestTimeout, c.requestTimeout())
+}
+
+func testClientCustom(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		c      = Client{
+			Endpoints:      []string{"somewhere.com:2379", "else.com:2379"},
+			DialTimeout:    13 * time.Hour,
+			RequestTimeout: 1239 * time.Minute,
+		}
+	)
+
+	assert.Equal([]string{"somewhere.com:2379", "else.com:2379"}, c.endpoints())
+	assert.Equal(13*time.Hour, c.dialTimeout())
+	assert.Equal(1239*time.Minute, c.requestTimeout())
+}
+
+func TestClient(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		testClientDefault(t, nil)
+		testClientDefault(t, new(Client))
+	})
+
+	t.Run("Custom", testClientCustom)
+}
+
+func testOptionsDefault(t *testing.T, o *Options) {
+	var (
+		assert = assert.New(t)
+		c      = o.client()
+	)
+
+	assert.Equal([]string{DefaultEndpoint}, c.endpoints())
+	assert.Equal(DefaultDialTimeout, c.dialTimeout())
+	assert.Equal(DefaultRequestTimeout, c.requestTimeout())
+	assert.Len(o.registrations(), 0)
+	assert.Len(o.watches(), 0)
+	assert.Nil(o.metadata())
+}
+
+func testOptionsCustom(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		registry = service.NewMetadataRegistry()
+		o        = Options{
+			Client: Client{
+				Endpoints: []string{"somewhere.com:2379"},
+			},
+			Registrations: []Registration{
+				Registration{
+					Prefix:  "/testy/test/test",
+					Address: "funzo.net",
+					Port:    1234,
+				},
+			},
+			Watches:  []string{"/testy/test/test"},
+			Metadata: registry,
+		}
+	)
+
+	assert.Equal([]string{"somewhere.com:2379"}, o.client().endpoints())
+	assert.Equal(
+		[]Registration{
+			Registration{
+				Prefix:  "/testy/test/test",
+				Address: "funzo.net",
+				Port:    1234,
+			},
+		},
+		o.registrations(),
+	)
+
+	assert.Equal([]string{"/testy/test/test"}, o.watches())
+	assert.Equal(registry, o.metadata())
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		testOptionsDefault(t, nil)
+		testOptionsDefault(t, new(Options))
+	})
+
+	t.Run("Custom", testOptionsCustom)
+}
@@ -0,0 +1,34 @@
+package etcd
+
+import (
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// registrar is an sd.Registrar which registers a single key in etcd under a lease
+type registrar struct {
+	logger log.Logger
+	client discoveryClient
+	key    string
+	value  string
+	ttl    time.Duration
+}
+
+func (r *registrar) Register() {
+	if err := r.client.Register(r.key, r.value, r.ttl); err != nil {
+		r.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "failed to register", logging.ErrorKey(), err)
+	} else {
+		r.logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "registered")
+	}
+}
+
+func (r *registrar) Deregister() {
+	if err := r.client.Deregister(r.key); err != nil {
+		r.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "failed to deregister", logging.ErrorKey(), err)
+	} else {
+		r.logger.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "deregistered")
+	}
+}
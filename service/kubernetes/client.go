@@ -0,0 +1,110 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// apiClient is a minimal client for the list and watch operations of the kubernetes API
+type apiClient struct {
+	url          string
+	token        func() (string, error)
+	http         *http.Client
+	timeout      time.Duration
+	watchTimeout time.Duration
+}
+
+func newAPIClient(c *Client) (*apiClient, error) {
+	u, err := c.url()
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := c.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiClient{
+		url:          u,
+		token:        c.token,
+		http:         h,
+		timeout:      c.timeout(),
+		watchTimeout: c.watchTimeout(),
+	}, nil
+}
+
+// do issues a GET for the given path and query, returning the response if it has a 200 status
+func (ac *apiClient) do(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	request, err := http.NewRequest("GET", ac.url+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := ac.token()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(token) > 0 {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	request.Header.Set("Accept", "application/json")
+	response, err := ac.http.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, response.Body)
+		response.Body.Close()
+		return nil, fmt.Errorf("Kubernetes API server returned status code %d for %s", response.StatusCode, path)
+	}
+
+	return response, nil
+}
+
+// list retrieves the objects in a collection
+func (ac *apiClient) list(ctx context.Context, path string, query url.Values) (objectList, error) {
+	ctx, cancel := context.WithTimeout(ctx, ac.timeout)
+	defer cancel()
+
+	var result objectList
+	response, err := ac.do(ctx, path, query)
+	if err != nil {
+		return result, err
+	}
+
+	defer response.Body.Close()
+	err = json.NewDecoder(response.Body).Decode(&result)
+	return result, err
+}
+
+// watch opens a stream of events for a collection, starting after the given resource version.
+// The server closes the stream after the watch timeout.
+func (ac *apiClient) watch(ctx context.Context, path string, query url.Values, resourceVersion string) (io.ReadCloser, error) {
+	watchQuery := url.Values{}
+	for k, v := range query {
+		watchQuery[k] = v
+	}
+
+	watchQuery.Set("watch", "true")
+	watchQuery.Set("resourceVersion", resourceVersion)
+	watchQuery.Set("allowWatchBookmarks", "true")
+	watchQuery.Set("timeoutSeconds", strconv.Itoa(int(ac.watchTimeout/time.Second)))
+
+	response, err := ac.do(ctx, path, watchQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Body, nil
+}
@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+func newInstancerKey(w Watch) string {
+	return fmt.Sprintf(
+		"%s/%s{port=%s,endpointSlices=%t}",
+		w.namespace(),
+		w.Service,
+		w.Port,
+		w.EndpointSlices,
+	)
+}
+
+func newInstancers(l log.Logger, c *apiClient, o Options) (i service.Instancers) {
+	for _, w := range o.watches() {
+		key := newInstancerKey(w)
+		if i.Has(key) {
+			l.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "skipping duplicate watch", "namespace", w.namespace(), "service", w.Service, "port", w.Port)
+			continue
+		}
+
+		i.Set(key, newInstancer(l, c, o.metadata(), o.client().retryInterval(), w))
+	}
+
+	return
+}
+
+// NewEnvironment constructs a kubernetes service.Environment using both a kubernetes Options (typically unmarshaled
+// from configuration) and an optional extra set of environment options.  The returned Environment watches the
+// endpoints of kubernetes services.  It has no registrations, as kubernetes manages the endpoints of the service
+// to which this process belongs.
+func NewEnvironment(l log.Logger, o Options, eo ...service.Option) (service.Environment, error) {
+	if l == nil {
+		l = logging.DefaultLogger()
+	}
+
+	if len(o.Watches) == 0 {
+		return nil, nil
+	}
+
+	c, err := newAPIClient(o.client())
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewEnvironment(
+		append(
+			eo,
+			service.WithInstancers(newInstancers(l, c, o)),
+		)...,
+	), nil
+}
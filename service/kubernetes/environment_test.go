@@ -0,0 +1,82 @@
+package kubernetes
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNewEnvironmentEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	e, err := NewEnvironment(nil, Options{})
+	assert.Nil(e)
+	assert.NoError(err)
+}
+
+func testNewEnvironmentClientError(t *testing.T) {
+	assert := assert.New(t)
+	defer withEnv(map[string]string{"KUBERNETES_SERVICE_HOST": "", "KUBERNETES_SERVICE_PORT": ""})()
+
+	e, err := NewEnvironment(nil, Options{Watches: []Watch{{Service: "svc"}}})
+	assert.Nil(e)
+	assert.Equal(ErrorNoAPIServer, err)
+}
+
+func testNewEnvironmentFull(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		f = newFakeAPIServer(
+			"/api/v1/namespaces/default/endpoints",
+			"test",
+			`{"metadata": {"resourceVersion": "1"}, "items": [{
+				"metadata": {"name": "svc"},
+				"subsets": [{"addresses": [{"ip": "10.0.0.1"}], "ports": [{"port": 8080}]}]
+			}]}`,
+		)
+
+		server = httptest.NewServer(f)
+
+		o = Options{
+			Client: Client{
+				URL:   server.URL,
+				Token: "test",
+			},
+			Watches: []Watch{
+				{Service: "svc"},
+				{Service: "svc"}, // duplicate should be ignored
+			},
+		}
+	)
+
+	defer server.Close()
+
+	e, err := NewEnvironment(logging.NewTestLogger(nil, t), o)
+	require.NoError(err)
+	require.NotNil(e)
+
+	instancers := e.Instancers()
+	require.Equal(1, instancers.Len())
+	i, ok := instancers.Get("default/svc{port=,endpointSlices=false}")
+	require.True(ok)
+
+	events := make(chan sd.Event, 1)
+	i.Register(events)
+	assert.Equal(sd.Event{Instances: []string{"http://10.0.0.1:8080"}}, <-events)
+	expectWatch(t, f, "1")
+
+	assert.False(e.IsRegistered("http://10.0.0.1:8080"))
+	assert.NoError(e.Close())
+}
+
+func TestNewEnvironment(t *testing.T) {
+	t.Run("Empty", testNewEnvironmentEmpty)
+	t.Run("ClientError", testNewEnvironmentClientError)
+	t.Run("Full", testNewEnvironmentFull)
+}
@@ -0,0 +1,225 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/sd"
+)
+
+// instancer is an sd.Instancer which lists, then watches, the API objects holding the endpoints of
+// a kubernetes service.  The instances are the union of the ready endpoints across all objects.
+type instancer struct {
+	service.UpdatableInstancer
+
+	logger        log.Logger
+	client        *apiClient
+	registry      *service.MetadataRegistry
+	watch         Watch
+	resource      resource
+	path          string
+	query         url.Values
+	retryInterval time.Duration
+
+	// objects holds the instances of each API object, by object name.  Only accessed by the
+	// goroutine running the instancer.
+	objects map[string][]string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newInstancer(l log.Logger, c *apiClient, registry *service.MetadataRegistry, retryInterval time.Duration, w Watch) sd.Instancer {
+	r := endpointsResource
+	if w.EndpointSlices {
+		r = endpointSliceResource
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	i := &instancer{
+		logger:        log.With(l, "namespace", w.namespace(), "service", w.Service, "endpointSlices", w.EndpointSlices),
+		client:        c,
+		registry:      registry,
+		watch:         w,
+		resource:      r,
+		path:          r.path(w.namespace()),
+		query:         r.query(w.Service),
+		retryInterval: retryInterval,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+
+	resourceVersion, _ := i.list(ctx)
+	go i.run(ctx, resourceVersion)
+
+	return service.NewContextualInstancer(
+		i,
+		map[string]interface{}{
+			"namespace":      w.namespace(),
+			"service":        w.Service,
+			"port":           w.Port,
+			"endpointSlices": w.EndpointSlices,
+		},
+	)
+}
+
+// set records the instances of a single API object
+func (i *instancer) set(data []byte) error {
+	name, discovered, err := i.resource.decode(data, i.watch.Port)
+	if err != nil {
+		return err
+	}
+
+	instances := make([]string, 0, len(discovered))
+	for _, e := range discovered {
+		instance := service.FormatInstance(i.watch.scheme(), formatAddress(e.address), e.port)
+		i.registry.Set(instance, service.Metadata{Zone: e.zone})
+		instances = append(instances, instance)
+	}
+
+	i.objects[name] = instances
+	return nil
+}
+
+func (i *instancer) remove(data []byte) error {
+	name, _, err := i.resource.decode(data, i.watch.Port)
+	if err == nil {
+		delete(i.objects, name)
+	}
+
+	return err
+}
+
+// publish dispatches the union of the instances of all objects
+func (i *instancer) publish() {
+	var (
+		seen      = make(map[string]bool)
+		instances []string
+	)
+
+	for _, objectInstances := range i.objects {
+		for _, instance := range objectInstances {
+			if !seen[instance] {
+				seen[instance] = true
+				instances = append(instances, instance)
+			}
+		}
+	}
+
+	i.Update(sd.Event{Instances: instances})
+}
+
+// list replaces the known objects with the current state of the collection, returning
+// the resource version from which to start watching
+func (i *instancer) list(ctx context.Context) (string, error) {
+	result, err := i.client.list(ctx, i.path, i.query)
+	if err == nil {
+		i.objects = make(map[string][]string, len(result.Items))
+		for _, item := range result.Items {
+			if err = i.set(item); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		i.logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "failed to list endpoints", logging.ErrorKey(), err)
+		i.Update(sd.Event{Err: err})
+		return "", err
+	}
+
+	i.publish()
+	return result.Metadata.ResourceVersion, nil
+}
+
+// watchFrom applies the events in a single watch stream, returning the last resource version seen.
+// An error is returned if the stream fails or the watch can no longer continue from the resource version.
+func (i *instancer) watchFrom(ctx context.Context, resourceVersion string) (string, error) {
+	stream, err := i.client.watch(ctx, i.path, i.query, resourceVersion)
+	if err != nil {
+		return resourceVersion, err
+	}
+
+	defer stream.Close()
+	decoder := json.NewDecoder(stream)
+	for {
+		var e watchEvent
+		if err := decoder.Decode(&e); err == io.EOF {
+			return resourceVersion, nil
+		} else if err != nil {
+			return resourceVersion, err
+		}
+
+		var meta struct {
+			Metadata objectMeta `json:"metadata"`
+		}
+
+		switch e.Type {
+		case "ADDED", "MODIFIED":
+			err = i.set(e.Object)
+
+		case "DELETED":
+			err = i.remove(e.Object)
+
+		case "ERROR":
+			var s status
+			json.Unmarshal(e.Object, &s)
+			return resourceVersion, fmt.Errorf("Watch failed with code %d: %s %s", s.Code, s.Reason, s.Message)
+		}
+
+		if err != nil {
+			return resourceVersion, err
+		}
+
+		if err := json.Unmarshal(e.Object, &meta); err == nil && len(meta.Metadata.ResourceVersion) > 0 {
+			resourceVersion = meta.Metadata.ResourceVersion
+		}
+
+		if e.Type != "BOOKMARK" {
+			i.publish()
+		}
+	}
+}
+
+func (i *instancer) run(ctx context.Context, resourceVersion string) {
+	defer close(i.done)
+
+	for {
+		if len(resourceVersion) == 0 {
+			// the previous list or watch failed, so wait before starting over with a fresh list
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(i.retryInterval):
+			}
+
+			resourceVersion, _ = i.list(ctx)
+			continue
+		}
+
+		var err error
+		if resourceVersion, err = i.watchFrom(ctx, resourceVersion); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			i.logger.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "watch failed", logging.ErrorKey(), err)
+			resourceVersion = ""
+		}
+	}
+}
+
+// Stop terminates the watch.  No further events are dispatched once this method returns.
+func (i *instancer) Stop() {
+	i.cancel()
+	<-i.done
+	i.UpdatableInstancer.Stop()
+}
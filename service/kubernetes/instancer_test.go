@@ -0,0 +1,191 @@
+package kubernetes
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNewAPIClient(t *testing.T, url string) *apiClient {
+	c, err := newAPIClient(&Client{URL: url, Token: "test", WatchTimeout: time.Minute})
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	return c
+}
+
+func expectEvent(t *testing.T, events <-chan sd.Event, expected sd.Event) {
+	select {
+	case actual := <-events:
+		assert.Equal(t, expected, actual)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "No event received", "expected: %v", expected)
+	}
+}
+
+func expectWatch(t *testing.T, f *fakeAPIServer, expectedResourceVersion string) fakeWatch {
+	select {
+	case w := <-f.watches:
+		assert.Equal(t, expectedResourceVersion, w.resourceVersion)
+		return w
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "No watch started", "expected resource version: %s", expectedResourceVersion)
+		return fakeWatch{}
+	}
+}
+
+func TestInstancerEndpoints(t *testing.T) {
+	var (
+		require = require.New(t)
+
+		f = newFakeAPIServer(
+			"/api/v1/namespaces/test/endpoints",
+			"test",
+			`{"metadata": {"resourceVersion": "1"}, "items": [{
+				"metadata": {"name": "svc", "resourceVersion": "1"},
+				"subsets": [{
+					"addresses": [{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}],
+					"ports": [{"name": "metrics", "port": 9090}, {"name": "api", "port": 8080}]
+				}]
+			}]}`,
+			`{"metadata": {"resourceVersion": "5"}, "items": [{
+				"metadata": {"name": "svc", "resourceVersion": "5"},
+				"subsets": [{
+					"addresses": [{"ip": "10.0.0.4"}],
+					"ports": [{"name": "api", "port": 8080}]
+				}]
+			}]}`,
+		)
+
+		server = httptest.NewServer(f)
+		events = make(chan sd.Event, 10)
+	)
+
+	defer server.Close()
+
+	i := newInstancer(
+		logging.NewTestLogger(nil, t),
+		testNewAPIClient(t, server.URL),
+		nil,
+		10*time.Millisecond,
+		Watch{Namespace: "test", Service: "svc", Port: "api"},
+	)
+
+	require.NotNil(i)
+	defer i.Stop()
+
+	i.Register(events)
+	expectEvent(t, events, sd.Event{Instances: []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"}})
+	w := expectWatch(t, f, "1")
+	assert.Contains(t, f.receivedQueries()[0], "fieldSelector=metadata.name%3Dsvc")
+
+	w.events <- `{"type": "MODIFIED", "object": {
+		"metadata": {"name": "svc", "resourceVersion": "2"},
+		"subsets": [{"addresses": [{"ip": "10.0.0.3"}], "ports": [{"name": "api", "port": 8080}]}]
+	}}`
+
+	expectEvent(t, events, sd.Event{Instances: []string{"http://10.0.0.3:8080"}})
+
+	// an expired resource version forces a fresh list
+	w.events <- `{"type": "ERROR", "object": {"kind": "Status", "code": 410, "reason": "Expired", "message": "too old resource version"}}`
+	expectEvent(t, events, sd.Event{Instances: []string{"http://10.0.0.4:8080"}})
+	w = expectWatch(t, f, "5")
+
+	w.events <- `{"type": "DELETED", "object": {"metadata": {"name": "svc", "resourceVersion": "6"}}}`
+	expectEvent(t, events, sd.Event{})
+}
+
+func TestInstancerEndpointSlices(t *testing.T) {
+	var (
+		require  = require.New(t)
+		registry = service.NewMetadataRegistry()
+
+		f = newFakeAPIServer(
+			"/apis/discovery.k8s.io/v1/namespaces/default/endpointslices",
+			"test",
+			`{"metadata": {"resourceVersion": "10"}, "items": [
+				{
+					"metadata": {"name": "svc-a"},
+					"endpoints": [
+						{"addresses": ["10.0.0.1"], "conditions": {"ready": true}, "zone": "east"},
+						{"addresses": ["10.0.0.2"], "conditions": {"ready": false}, "zone": "east"}
+					],
+					"ports": [{"name": "api", "port": 8443}]
+				},
+				{
+					"metadata": {"name": "svc-b"},
+					"endpoints": [{"addresses": ["10.0.1.1"], "zone": "west"}],
+					"ports": [{"name": "api", "port": 8443}]
+				}
+			]}`,
+		)
+
+		server = httptest.NewServer(f)
+		events = make(chan sd.Event, 10)
+	)
+
+	defer server.Close()
+
+	i := newInstancer(
+		logging.NewTestLogger(nil, t),
+		testNewAPIClient(t, server.URL),
+		registry,
+		10*time.Millisecond,
+		Watch{Service: "svc", Scheme: "https", EndpointSlices: true},
+	)
+
+	require.NotNil(i)
+	defer i.Stop()
+
+	i.Register(events)
+	expectEvent(t, events, sd.Event{Instances: []string{"https://10.0.0.1:8443", "https://10.0.1.1:8443"}})
+	w := expectWatch(t, f, "10")
+	assert.Contains(t, f.receivedQueries()[0], "labelSelector=kubernetes.io%2Fservice-name%3Dsvc")
+
+	m, ok := registry.Metadata("https://10.0.0.1:8443")
+	assert.True(t, ok)
+	assert.Equal(t, service.Metadata{Zone: "east"}, m)
+
+	// bookmarks do not change the instances
+	w.events <- `{"type": "BOOKMARK", "object": {"metadata": {"resourceVersion": "11"}}}`
+	w.events <- `{"type": "DELETED", "object": {"metadata": {"name": "svc-a", "resourceVersion": "12"}}}`
+	expectEvent(t, events, sd.Event{Instances: []string{"https://10.0.1.1:8443"}})
+}
+
+func TestInstancerListError(t *testing.T) {
+	var (
+		require = require.New(t)
+
+		f      = newFakeAPIServer("/api/v1/namespaces/default/endpoints", "test")
+		server = httptest.NewServer(f)
+		events = make(chan sd.Event, 10)
+	)
+
+	defer server.Close()
+
+	i := newInstancer(
+		logging.NewTestLogger(nil, t),
+		testNewAPIClient(t, server.URL),
+		nil,
+		time.Hour,
+		Watch{Service: "svc"},
+	)
+
+	require.NotNil(i)
+	i.Register(events)
+
+	select {
+	case e := <-events:
+		assert.Error(t, e.Err)
+		assert.Empty(t, e.Instances)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "No event received")
+	}
+
+	i.Stop()
+}
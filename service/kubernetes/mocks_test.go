@@ -0,0 +1,91 @@
+package kubernetes
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// fakeWatch is a single watch request made to a fakeAPIServer
+type fakeWatch struct {
+	resourceVersion string
+	events          chan string
+}
+
+// fakeAPIServer is a stub kubernetes API server for a single collection.  List requests receive the
+// next list body, while each watch request receives whatever events are sent to its fakeWatch.
+type fakeAPIServer struct {
+	path  string
+	token string
+
+	lock    sync.Mutex
+	lists   []string
+	queries []string
+	watches chan fakeWatch
+}
+
+func newFakeAPIServer(path, token string, lists ...string) *fakeAPIServer {
+	return &fakeAPIServer{
+		path:    path,
+		token:   token,
+		lists:   lists,
+		watches: make(chan fakeWatch, 10),
+	}
+}
+
+func (f *fakeAPIServer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != f.path {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if request.Header.Get("Authorization") != "Bearer "+f.token {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.lock.Lock()
+	f.queries = append(f.queries, request.URL.RawQuery)
+	f.lock.Unlock()
+
+	if request.URL.Query().Get("watch") != "true" {
+		f.lock.Lock()
+		var list string
+		if len(f.lists) > 0 {
+			list, f.lists = f.lists[0], f.lists[1:]
+		}
+
+		f.lock.Unlock()
+		if len(list) == 0 {
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(response, list)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+	response.(http.Flusher).Flush()
+	w := fakeWatch{resourceVersion: request.URL.Query().Get("resourceVersion"), events: make(chan string, 10)}
+	f.watches <- w
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+
+		case e := <-w.events:
+			fmt.Fprintln(response, e)
+			response.(http.Flusher).Flush()
+		}
+	}
+}
+
+func (f *fakeAPIServer) receivedQueries() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string(nil), f.queries...)
+}
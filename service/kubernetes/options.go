@@ -0,0 +1,253 @@
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Comcast/webpa-common/service"
+)
+
+const (
+	// ServiceAccountDirectory is where kubernetes mounts the credentials of a pod's service account
+	ServiceAccountDirectory = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	DefaultNamespace     = "default"
+	DefaultScheme        = "http"
+	DefaultTimeout       = 10 * time.Second
+	DefaultWatchTimeout  = 5 * time.Minute
+	DefaultRetryInterval = 5 * time.Second
+)
+
+var ErrorNoAPIServer = errors.New("No kubernetes API server URL configured, and not running in a cluster")
+
+// Client describes how to connect to the kubernetes API server.  When URL is not set, the in-cluster
+// configuration is used, i.e. the KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT environment variables
+// along with the pod's service account token and CA certificate.
+type Client struct {
+	// URL is the base URL of the API server, e.g. https://kubernetes.default.svc
+	URL string `json:"url,omitempty"`
+
+	// Token is the bearer token used to authenticate with the API server.  Takes precedence over TokenFile.
+	Token string `json:"token,omitempty"`
+
+	// TokenFile is a file containing the bearer token.  It is reread for each request, so that rotated tokens
+	// are honored.  When neither this nor Token is set, the service account token is used if present.
+	TokenFile string `json:"tokenFile,omitempty"`
+
+	// CAFile is the PEM-encoded CA bundle used to verify the API server.  When not set, the service account
+	// CA certificate is used if present.
+	CAFile string `json:"caFile,omitempty"`
+
+	// InsecureSkipVerify disables verification of the API server's certificate
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+
+	// Timeout is the timeout for list requests.  If not set, DefaultTimeout is used.
+	Timeout time.Duration `json:"timeout"`
+
+	// WatchTimeout is the server-side timeout of each watch request, after which the watch is reestablished.
+	// If not set, DefaultWatchTimeout is used.
+	WatchTimeout time.Duration `json:"watchTimeout"`
+
+	// RetryInterval is how long to wait before retrying a failed list or watch.  If not set, DefaultRetryInterval is used.
+	RetryInterval time.Duration `json:"retryInterval"`
+
+	// HTTPClient is the optional HTTP client used to talk to the API server.  When set, CAFile and
+	// InsecureSkipVerify are ignored.
+	HTTPClient *http.Client `json:"-"`
+}
+
+func (c *Client) url() (string, error) {
+	if c != nil && len(c.URL) > 0 {
+		return strings.TrimSuffix(c.URL, "/"), nil
+	}
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if len(host) == 0 || len(port) == 0 {
+		return "", ErrorNoAPIServer
+	}
+
+	return "https://" + net.JoinHostPort(host, port), nil
+}
+
+func (c *Client) tokenFile() string {
+	if c != nil && len(c.TokenFile) > 0 {
+		return c.TokenFile
+	}
+
+	return filepath.Join(ServiceAccountDirectory, "token")
+}
+
+// token returns the current bearer token, which may be empty if no token is available
+func (c *Client) token() (string, error) {
+	if c != nil && len(c.Token) > 0 {
+		return c.Token, nil
+	}
+
+	data, err := ioutil.ReadFile(c.tokenFile())
+	switch {
+	case os.IsNotExist(err) && (c == nil || len(c.TokenFile) == 0):
+		return "", nil
+
+	case err != nil:
+		return "", err
+
+	default:
+		return strings.TrimSpace(string(data)), nil
+	}
+}
+
+func (c *Client) caFile() string {
+	if c != nil && len(c.CAFile) > 0 {
+		return c.CAFile
+	}
+
+	return filepath.Join(ServiceAccountDirectory, "ca.crt")
+}
+
+func (c *Client) tlsConfig() (*tls.Config, error) {
+	if c != nil && c.InsecureSkipVerify {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	data, err := ioutil.ReadFile(c.caFile())
+	switch {
+	case os.IsNotExist(err) && (c == nil || len(c.CAFile) == 0):
+		// use the system roots
+		return nil, nil
+
+	case err != nil:
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("No certificates found in %s", c.caFile())
+	}
+
+	return &tls.Config{RootCAs: roots}, nil
+}
+
+func (c *Client) httpClient() (*http.Client, error) {
+	if c != nil && c.HTTPClient != nil {
+		return c.HTTPClient, nil
+	}
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// no overall timeout is set, as watches are long-lived
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+func (c *Client) timeout() time.Duration {
+	if c != nil && c.Timeout > 0 {
+		return c.Timeout
+	}
+
+	return DefaultTimeout
+}
+
+func (c *Client) watchTimeout() time.Duration {
+	if c != nil && c.WatchTimeout > 0 {
+		return c.WatchTimeout
+	}
+
+	return DefaultWatchTimeout
+}
+
+func (c *Client) retryInterval() time.Duration {
+	if c != nil && c.RetryInterval > 0 {
+		return c.RetryInterval
+	}
+
+	return DefaultRetryInterval
+}
+
+// Watch describes a kubernetes service whose endpoints are the discovered instances
+type Watch struct {
+	// Namespace is the namespace of the service.  If not supplied, DefaultNamespace is used.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Service is the name of the kubernetes service.  This field is required.
+	Service string `json:"service"`
+
+	// Port is the name of the service port to use for instances.  If not supplied, the first port is used.
+	Port string `json:"port,omitempty"`
+
+	// Scheme is the scheme of the discovered instances.  If not supplied, DefaultScheme is used.
+	Scheme string `json:"scheme,omitempty"`
+
+	// EndpointSlices selects the discovery.k8s.io/v1 EndpointSlice API instead of the core Endpoints API.
+	// EndpointSlices scale to larger services and carry the zone of each endpoint.
+	EndpointSlices bool `json:"endpointSlices"`
+}
+
+func (w Watch) namespace() string {
+	if len(w.Namespace) > 0 {
+		return w.Namespace
+	}
+
+	return DefaultNamespace
+}
+
+func (w Watch) scheme() string {
+	if len(w.Scheme) > 0 {
+		return w.Scheme
+	}
+
+	return DefaultScheme
+}
+
+// Options represents the set of configurable attributes for kubernetes service discovery.  Kubernetes
+// discovery is watch only, as kubernetes itself maintains the endpoints of a service.
+type Options struct {
+	// Client holds the API server connection options
+	Client Client `json:"client"`
+
+	// Watches are the kubernetes services to watch for updates.  There is no default for this field.
+	Watches []Watch `json:"watches,omitempty"`
+
+	// Metadata receives the zone of each watched instance, when using EndpointSlices.  This field is optional.
+	Metadata *service.MetadataRegistry `json:"-"`
+}
+
+func (o *Options) client() *Client {
+	if o != nil {
+		return &o.Client
+	}
+
+	return nil
+}
+
+func (o *Options) metadata() *service.MetadataRegistry {
+	if o != nil {
+		return o.Metadata
+	}
+
+	return nil
+}
+
+func (o *Options) watches() []Watch {
+	if o != nil && len(o.Watches) > 0 {
+		return o.Watches
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package kubernetes
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withEnv sets environment variables for the duration of a test, returning a function that restores them
+func withEnv(values map[string]string) func() {
+	previous := make(map[string]*string, len(values))
+	for k, v := range values {
+		if old, ok := os.LookupEnv(k); ok {
+			previous[k] = &old
+		} else {
+			previous[k] = nil
+		}
+
+		if len(v) > 0 {
+			os.Setenv(k, v)
+		} else {
+			os.Unsetenv(k)
+		}
+	}
+
+	return func() {
+		for k, v := range previous {
+			if v != nil {
+				os.Setenv(k, *v)
+			} else {
+				os.Unsetenv(k)
+			}
+		}
+	}
+}
+
+func testClientDefault(t *testing.T, c *Client) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultTimeout, c.timeout())
+	assert.Equal(DefaultWatchTimeout, c.watchTimeout())
+	assert.Equal(DefaultRetryInterval, c.retryInterval())
+	assert.Equal(filepath.Join(ServiceAccountDirectory, "token"), c.tokenFile())
+	assert.Equal(filepath.Join(ServiceAccountDirectory, "ca.crt"), c.caFile())
+
+	restore := withEnv(map[string]string{"KUBERNETES_SERVICE_HOST": "", "KUBERNETES_SERVICE_PORT": ""})
+	url, err := c.url()
+	assert.Empty(url)
+	assert.Equal(ErrorNoAPIServer, err)
+	restore()
+
+	restore = withEnv(map[string]string{"KUBERNETES_SERVICE_HOST": "10.96.0.1", "KUBERNETES_SERVICE_PORT": "443"})
+	url, err = c.url()
+	assert.Equal("https://10.96.0.1:443", url)
+	assert.NoError(err)
+	restore()
+}
+
+func testClientCustom(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		httpClient = new(http.Client)
+		c          = Client{
+			URL:           "https://kubernetes.example.com/",
+			Token:         "test",
+			Timeout:       13 * time.Hour,
+			WatchTimeout:  17 * time.Minute,
+			RetryInterval: 19 * time.Second,
+			HTTPClient:    httpClient,
+		}
+	)
+
+	url, err := c.url()
+	assert.Equal("https://kubernetes.example.com", url)
+	assert.NoError(err)
+
+	token, err := c.token()
+	assert.Equal("test", token)
+	assert.NoError(err)
+
+	actual, err := c.httpClient()
+	require.NoError(err)
+	assert.True(httpClient == actual)
+
+	assert.Equal(13*time.Hour, c.timeout())
+	assert.Equal(17*time.Minute, c.watchTimeout())
+	assert.Equal(19*time.Second, c.retryInterval())
+}
+
+func testClientTokenFile(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	directory, err := ioutil.TempDir("", "kubernetes")
+	require.NoError(err)
+	defer os.RemoveAll(directory)
+
+	tokenFile := filepath.Join(directory, "token")
+	require.NoError(ioutil.WriteFile(tokenFile, []byte("rotated\n"), 0600))
+
+	token, err := (&Client{TokenFile: tokenFile}).token()
+	assert.Equal("rotated", token)
+	assert.NoError(err)
+
+	token, err = (&Client{TokenFile: filepath.Join(directory, "missing")}).token()
+	assert.Empty(token)
+	assert.Error(err)
+}
+
+func testClientTLS(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	tlsConfig, err := (&Client{InsecureSkipVerify: true}).tlsConfig()
+	require.NoError(err)
+	require.NotNil(tlsConfig)
+	assert.True(tlsConfig.InsecureSkipVerify)
+
+	directory, err := ioutil.TempDir("", "kubernetes")
+	require.NoError(err)
+	defer os.RemoveAll(directory)
+
+	tlsConfig, err = (&Client{CAFile: filepath.Join(directory, "missing")}).tlsConfig()
+	assert.Nil(tlsConfig)
+	assert.Error(err)
+
+	caFile := filepath.Join(directory, "ca.crt")
+	require.NoError(ioutil.WriteFile(caFile, []byte("not a certificate"), 0600))
+	tlsConfig, err = (&Client{CAFile: caFile}).tlsConfig()
+	assert.Nil(tlsConfig)
+	assert.Error(err)
+
+	_, err = (&Client{CAFile: caFile}).httpClient()
+	assert.Error(err)
+}
+
+func TestClient(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		testClientDefault(t, nil)
+		testClientDefault(t, new(Client))
+	})
+
+	t.Run("Custom", testClientCustom)
+	t.Run("TokenFile", testClientTokenFile)
+	t.Run("TLS", testClientTLS)
+}
+
+func TestWatch(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultNamespace, Watch{}.namespace())
+	assert.Equal(DefaultScheme, Watch{}.scheme())
+	assert.Equal("test", Watch{Namespace: "test"}.namespace())
+	assert.Equal("https", Watch{Scheme: "https"}.scheme())
+}
+
+func testOptionsDefault(t *testing.T, o *Options) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultTimeout, o.client().timeout())
+	assert.Len(o.watches(), 0)
+	assert.Nil(o.metadata())
+}
+
+func testOptionsCustom(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		registry = service.NewMetadataRegistry()
+		o        = Options{
+			Client:   Client{Timeout: 13 * time.Hour},
+			Watches:  []Watch{{Namespace: "test", Service: "svc"}},
+			Metadata: registry,
+		}
+	)
+
+	assert.Equal(13*time.Hour, o.client().timeout())
+	assert.Equal([]Watch{{Namespace: "test", Service: "svc"}}, o.watches())
+	assert.Equal(registry, o.metadata())
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		testOptionsDefault(t, nil)
+		testOptionsDefault(t, new(Options))
+	})
+
+	t.Run("Custom", testOptionsCustom)
+}
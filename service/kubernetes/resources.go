@@ -0,0 +1,172 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// The types in this file are the subsets of the kubernetes API objects needed for service discovery
+
+type objectMeta struct {
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+type objectList struct {
+	Metadata objectMeta        `json:"metadata"`
+	Items    []json.RawMessage `json:"items"`
+}
+
+// watchEvent is a single event in a watch stream.  For ERROR events, the object is a Status.
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+type port struct {
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+// selectPort returns the port with the given name, or the first port if name is empty
+func selectPort(ports []port, name string) (int, bool) {
+	for _, p := range ports {
+		if len(name) == 0 || p.Name == name {
+			return p.Port, p.Port > 0
+		}
+	}
+
+	return 0, false
+}
+
+// endpoint is a discovered network endpoint of a service
+type endpoint struct {
+	address string
+	port    int
+	zone    string
+}
+
+type endpointAddress struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname"`
+}
+
+type endpointSubset struct {
+	// Addresses only holds ready addresses.  Addresses that are not ready are ignored.
+	Addresses []endpointAddress `json:"addresses"`
+	Ports     []port            `json:"ports"`
+}
+
+// endpoints is the core v1 Endpoints object, whose name is the same as the service
+type endpoints struct {
+	Metadata objectMeta       `json:"metadata"`
+	Subsets  []endpointSubset `json:"subsets"`
+}
+
+type endpointConditions struct {
+	Ready *bool `json:"ready"`
+}
+
+type sliceEndpoint struct {
+	Addresses  []string           `json:"addresses"`
+	Conditions endpointConditions `json:"conditions"`
+	Zone       string             `json:"zone"`
+}
+
+// endpointSlice is the discovery.k8s.io/v1 EndpointSlice object.  A service can have many slices.
+type endpointSlice struct {
+	Metadata  objectMeta      `json:"metadata"`
+	Endpoints []sliceEndpoint `json:"endpoints"`
+	Ports     []port          `json:"ports"`
+}
+
+// resource describes how to find and decode the API objects which hold a service's endpoints
+type resource struct {
+	// path is the API path of the collection in a namespace
+	path func(namespace string) string
+
+	// query selects the objects for a service
+	query func(service string) url.Values
+
+	// decode extracts the name and the ready endpoints from an object
+	decode func(data []byte, portName string) (string, []endpoint, error)
+}
+
+var endpointsResource = resource{
+	path: func(namespace string) string {
+		return "/api/v1/namespaces/" + url.PathEscape(namespace) + "/endpoints"
+	},
+	query: func(service string) url.Values {
+		return url.Values{"fieldSelector": []string{"metadata.name=" + service}}
+	},
+	decode: func(data []byte, portName string) (string, []endpoint, error) {
+		var e endpoints
+		if err := json.Unmarshal(data, &e); err != nil {
+			return "", nil, err
+		}
+
+		var discovered []endpoint
+		for _, subset := range e.Subsets {
+			p, ok := selectPort(subset.Ports, portName)
+			if !ok {
+				continue
+			}
+
+			for _, a := range subset.Addresses {
+				discovered = append(discovered, endpoint{address: a.IP, port: p})
+			}
+		}
+
+		return e.Metadata.Name, discovered, nil
+	},
+}
+
+var endpointSliceResource = resource{
+	path: func(namespace string) string {
+		return "/apis/discovery.k8s.io/v1/namespaces/" + url.PathEscape(namespace) + "/endpointslices"
+	},
+	query: func(service string) url.Values {
+		return url.Values{"labelSelector": []string{"kubernetes.io/service-name=" + service}}
+	},
+	decode: func(data []byte, portName string) (string, []endpoint, error) {
+		var s endpointSlice
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", nil, err
+		}
+
+		p, ok := selectPort(s.Ports, portName)
+		if !ok {
+			return s.Metadata.Name, nil, nil
+		}
+
+		var discovered []endpoint
+		for _, e := range s.Endpoints {
+			// a missing ready condition means the endpoint is ready
+			if e.Conditions.Ready != nil && !*e.Conditions.Ready {
+				continue
+			}
+
+			for _, a := range e.Addresses {
+				discovered = append(discovered, endpoint{address: a, port: p, zone: e.Zone})
+			}
+		}
+
+		return s.Metadata.Name, discovered, nil
+	},
+}
+
+// formatAddress brackets IPv6 addresses so that they can be used in instance URLs
+func formatAddress(address string) string {
+	if strings.Contains(address, ":") && !strings.HasPrefix(address, "[") {
+		return "[" + address + "]"
+	}
+
+	return address
+}
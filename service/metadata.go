@@ -0,0 +1,231 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	// WeightKey is the metadata key, in a consul tag or zookeeper payload, holding an instance's weight
+	WeightKey = "weight"
+
+	// ZoneKey is the metadata key, in a consul tag or zookeeper payload, holding an instance's zone
+	ZoneKey = "zone"
+
+	// MaxWeight is the largest weight an instance can have.  Larger weights are treated as MaxWeight, as each
+	// unit of weight costs a full set of vnodes every time an Accessor is built.
+	MaxWeight = 100
+
+	// metadataSeparator separates an instance from its encoded metadata in a zookeeper payload
+	metadataSeparator = "#"
+)
+
+// Metadata describes the discovered attributes of a service instance that influence hashing
+type Metadata struct {
+	// Weight is the relative weight of the instance.  Nonpositive weights are treated as 1, and weights
+	// larger than MaxWeight are treated as MaxWeight.
+	Weight int `json:"weight,omitempty"`
+
+	// Zone is the zone, e.g. datacenter or availability zone, in which the instance runs
+	Zone string `json:"zone,omitempty"`
+}
+
+func (m Metadata) weight() int {
+	switch {
+	case m.Weight > MaxWeight:
+		return MaxWeight
+
+	case m.Weight > 0:
+		return m.Weight
+
+	default:
+		return 1
+	}
+}
+
+// IsEmpty tests if this Metadata carries no information
+func (m Metadata) IsEmpty() bool {
+	return m.Weight < 1 && len(m.Zone) == 0
+}
+
+// ParseMetadataTags extracts Metadata from tags of the form key=value, e.g. the tags of a consul service.
+// Tags which are not metadata are ignored.  The returned flag indicates whether any metadata was found.
+func ParseMetadataTags(tags []string) (m Metadata, found bool) {
+	for _, tag := range tags {
+		if i := strings.IndexByte(tag, '='); i > 0 {
+			key, value := tag[:i], tag[i+1:]
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case WeightKey:
+				if w, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+					m.Weight = w
+					found = true
+				}
+
+			case ZoneKey:
+				m.Zone = strings.TrimSpace(value)
+				found = true
+			}
+		}
+	}
+
+	return
+}
+
+// FormatInstanceMetadata appends encoded Metadata to an instance, producing a value suitable as a zookeeper
+// payload, e.g. "https://foobar.com:8080#weight=2&zone=east".  If the Metadata is empty, the instance is returned as is.
+func FormatInstanceMetadata(instance string, m Metadata) string {
+	if m.IsEmpty() {
+		return instance
+	}
+
+	values := make(url.Values, 2)
+	if m.Weight > 0 {
+		values.Set(WeightKey, strconv.Itoa(m.Weight))
+	}
+
+	if len(m.Zone) > 0 {
+		values.Set(ZoneKey, m.Zone)
+	}
+
+	return instance + metadataSeparator + values.Encode()
+}
+
+// SplitInstanceMetadata is the inverse of FormatInstanceMetadata.  It separates an instance from any
+// encoded Metadata.  Values without metadata are returned as is, with empty Metadata and a false flag.
+func SplitInstanceMetadata(value string) (instance string, m Metadata, found bool) {
+	i := strings.Index(value, metadataSeparator)
+	if i < 0 {
+		return value, m, false
+	}
+
+	instance = value[:i]
+	values, err := url.ParseQuery(value[i+1:])
+	if err != nil {
+		return instance, m, false
+	}
+
+	tags := make([]string, 0, len(values))
+	for key := range values {
+		tags = append(tags, key+"="+values.Get(key))
+	}
+
+	m, found = ParseMetadataTags(tags)
+	return
+}
+
+// MetadataSource provides the Metadata for service instances
+type MetadataSource interface {
+	// Metadata returns the Metadata for the given instance, with a flag indicating whether any exists
+	Metadata(instance string) (Metadata, bool)
+}
+
+// MetadataMap is a static MetadataSource, typically driven by configuration
+type MetadataMap map[string]Metadata
+
+func (mm MetadataMap) Metadata(instance string) (Metadata, bool) {
+	m, ok := mm[instance]
+	return m, ok
+}
+
+// MetadataSources is a composite MetadataSource.  The first source with Metadata for an instance wins.
+type MetadataSources []MetadataSource
+
+func (ms MetadataSources) Metadata(instance string) (Metadata, bool) {
+	for _, s := range ms {
+		if s == nil {
+			continue
+		}
+
+		if m, ok := s.Metadata(instance); ok {
+			return m, true
+		}
+	}
+
+	return Metadata{}, false
+}
+
+// metadataKey strips any scheme from an instance, as some service discovery backends
+// do not advertise the scheme of their instances
+func metadataKey(instance string) string {
+	if i := strings.Index(instance, "://"); i >= 0 {
+		return instance[i+3:]
+	}
+
+	return instance
+}
+
+// MetadataRegistry is a MetadataSource whose contents are updated by service discovery backends as instances
+// are discovered.  Instances are matched without regard to scheme, so "https://foobar.com:8080" and "foobar.com:8080"
+// have the same metadata.  The zero value of this type is ready to use.  A nil MetadataRegistry has no metadata and
+// ignores updates.
+//
+// Weights larger than MaxWeight are clamped to MaxWeight as they are set, with a warning logged to Logger.
+type MetadataRegistry struct {
+	// Logger receives warnings about clamped weights.  If unset, logging.DefaultLogger() is used.
+	Logger log.Logger
+
+	lock     sync.RWMutex
+	metadata map[string]Metadata
+}
+
+// NewMetadataRegistry creates an empty MetadataRegistry
+func NewMetadataRegistry() *MetadataRegistry {
+	return new(MetadataRegistry)
+}
+
+func (mr *MetadataRegistry) Metadata(instance string) (Metadata, bool) {
+	if mr == nil {
+		return Metadata{}, false
+	}
+
+	mr.lock.RLock()
+	m, ok := mr.metadata[metadataKey(instance)]
+	mr.lock.RUnlock()
+	return m, ok
+}
+
+func (mr *MetadataRegistry) logger() log.Logger {
+	if mr.Logger != nil {
+		return mr.Logger
+	}
+
+	return logging.DefaultLogger()
+}
+
+// Set records the Metadata for an instance.  Empty Metadata removes the instance from this registry.
+func (mr *MetadataRegistry) Set(instance string, m Metadata) {
+	if mr == nil {
+		return
+	}
+
+	if m.Weight > MaxWeight {
+		mr.logger().Log(
+			level.Key(), level.WarnValue(),
+			logging.MessageKey(), "clamping instance weight",
+			"instance", instance,
+			"weight", m.Weight,
+			"maxWeight", MaxWeight,
+		)
+
+		m.Weight = MaxWeight
+	}
+
+	mr.lock.Lock()
+	if m.IsEmpty() {
+		delete(mr.metadata, metadataKey(instance))
+	} else {
+		if mr.metadata == nil {
+			mr.metadata = make(map[string]Metadata)
+		}
+
+		mr.metadata[metadataKey(instance)] = m
+	}
+
+	mr.lock.Unlock()
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMetadataTags(t *testing.T) {
+	testData := []struct {
+		tags          []string
+		expected      Metadata
+		expectedFound bool
+	}{
+		{nil, Metadata{}, false},
+		{[]string{"api", "v1"}, Metadata{}, false},
+		{[]string{"weight=3"}, Metadata{Weight: 3}, true},
+		{[]string{"weight=abc"}, Metadata{}, false},
+		{[]string{"api", " Zone = east "}, Metadata{Zone: "east"}, true},
+		{[]string{"zone=west", "weight=2", "other=value"}, Metadata{Weight: 2, Zone: "west"}, true},
+	}
+
+	for i, record := range testData {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert := assert.New(t)
+			actual, found := ParseMetadataTags(record.tags)
+			assert.Equal(record.expected, actual)
+			assert.Equal(record.expectedFound, found)
+		})
+	}
+}
+
+func TestInstanceMetadata(t *testing.T) {
+	testData := []struct {
+		metadata       Metadata
+		expectedFormat string
+	}{
+		{Metadata{}, "https://foobar.com:8080"},
+		{Metadata{Weight: 2}, "https://foobar.com:8080#weight=2"},
+		{Metadata{Zone: "us east"}, "https://foobar.com:8080#zone=us+east"},
+		{Metadata{Weight: 5, Zone: "west"}, "https://foobar.com:8080#weight=5&zone=west"},
+	}
+
+	for i, record := range testData {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert := assert.New(t)
+			formatted := FormatInstanceMetadata("https://foobar.com:8080", record.metadata)
+			assert.Equal(record.expectedFormat, formatted)
+
+			instance, m, found := SplitInstanceMetadata(formatted)
+			assert.Equal("https://foobar.com:8080", instance)
+			assert.Equal(record.metadata, m)
+			assert.Equal(!record.metadata.IsEmpty(), found)
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		assert := assert.New(t)
+		instance, m, found := SplitInstanceMetadata("https://foobar.com:8080#%zz")
+		assert.Equal("https://foobar.com:8080", instance)
+		assert.Equal(Metadata{}, m)
+		assert.False(found)
+	})
+}
+
+func TestMetadataSources(t *testing.T) {
+	var (
+		assert = assert.New(t)
+
+		ms = MetadataSources{
+			nil,
+			MetadataMap{"https://first.com": Metadata{Weight: 1}},
+			MetadataMap{"https://first.com": Metadata{Weight: 2}, "https://second.com": Metadata{Zone: "east"}},
+		}
+	)
+
+	m, ok := ms.Metadata("https://first.com")
+	assert.True(ok)
+	assert.Equal(Metadata{Weight: 1}, m)
+
+	m, ok = ms.Metadata("https://second.com")
+	assert.True(ok)
+	assert.Equal(Metadata{Zone: "east"}, m)
+
+	m, ok = ms.Metadata("https://nosuch.com")
+	assert.False(ok)
+	assert.Equal(Metadata{}, m)
+}
+
+func testMetadataRegistryNil(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		mr     *MetadataRegistry
+	)
+
+	mr.Set("foobar.com:8080", Metadata{Weight: 2})
+	m, ok := mr.Metadata("foobar.com:8080")
+	assert.False(ok)
+	assert.Equal(Metadata{}, m)
+}
+
+func testMetadataRegistrySet(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		mr     = NewMetadataRegistry()
+	)
+
+	_, ok := mr.Metadata("foobar.com:8080")
+	assert.False(ok)
+
+	mr.Set("foobar.com:8080", Metadata{Weight: 2, Zone: "east"})
+	for _, instance := range []string{"foobar.com:8080", "https://foobar.com:8080", "http://foobar.com:8080"} {
+		m, ok := mr.Metadata(instance)
+		assert.True(ok)
+		assert.Equal(Metadata{Weight: 2, Zone: "east"}, m)
+	}
+
+	mr.Set("https://foobar.com:8080", Metadata{})
+	_, ok = mr.Metadata("foobar.com:8080")
+	assert.False(ok)
+}
+
+func testMetadataRegistryMaxWeight(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		output bytes.Buffer
+		mr     = &MetadataRegistry{Logger: log.NewLogfmtLogger(&output)}
+	)
+
+	mr.Set("foobar.com:8080", Metadata{Weight: MaxWeight})
+	m, _ := mr.Metadata("foobar.com:8080")
+	assert.Equal(MaxWeight, m.Weight)
+	assert.Zero(output.Len())
+
+	mr.Set("foobar.com:8080", Metadata{Weight: 100000, Zone: "east"})
+	m, _ = mr.Metadata("foobar.com:8080")
+	assert.Equal(Metadata{Weight: MaxWeight, Zone: "east"}, m)
+	assert.Contains(output.String(), "weight=100000")
+}
+
+func testMetadataRegistryConcurrency(t *testing.T) {
+	var (
+		mr        = new(MetadataRegistry)
+		waitGroup = new(sync.WaitGroup)
+	)
+
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			instance := "instance" + strconv.Itoa(i)
+			mr.Set(instance, Metadata{Weight: i + 1})
+			mr.Metadata(instance)
+		}(i)
+	}
+
+	waitGroup.Wait()
+	for i := 0; i < 10; i++ {
+		m, ok := mr.Metadata("instance" + strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, i+1, m.Weight)
+	}
+}
+
+func TestMetadataRegistry(t *testing.T) {
+	t.Run("Nil", testMetadataRegistryNil)
+	t.Run("Set", testMetadataRegistrySet)
+	t.Run("MaxWeight", testMetadataRegistryMaxWeight)
+	t.Run("Concurrency", testMetadataRegistryConcurrency)
+}
@@ -17,6 +17,19 @@ func (m *MockAccessor) Get(v []byte) (string, error) {
 	return arguments.String(0), arguments.Error(1)
 }
 
+// MockOrderedAccessor is a mocked OrderedAccessor
+type MockOrderedAccessor struct {
+	MockAccessor
+}
+
+var _ OrderedAccessor = (*MockOrderedAccessor)(nil)
+
+func (m *MockOrderedAccessor) GetN(v []byte, n int) ([]string, error) {
+	arguments := m.Called(v, n)
+	first, _ := arguments.Get(0).([]string)
+	return first, arguments.Error(1)
+}
+
 // MockRegistrar is a stretchr/testify mocked sd.Registrar
 type MockRegistrar struct {
 	mock.Mock
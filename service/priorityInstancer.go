@@ -0,0 +1,148 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/go-kit/kit/sd"
+)
+
+// PrioritizedInstancer associates a priority with an sd.Instancer.  Lower priorities are preferred, as with DNS SRV records.
+type PrioritizedInstancer struct {
+	// Name identifies the source of the instances, e.g. a datacenter
+	Name string
+
+	// Priority is the preference for this instancer's instances.  Lower values are preferred.
+	Priority int
+
+	// Instancer is the source of instances
+	Instancer sd.Instancer
+}
+
+type prioritySource struct {
+	PrioritizedInstancer
+	events chan sd.Event
+	state  sd.Event
+}
+
+// priorityInstancer is the sd.Instancer which merges prioritized instancers
+type priorityInstancer struct {
+	UpdatableInstancer
+
+	lock    sync.Mutex
+	sources []*prioritySource
+
+	stopOnce sync.Once
+	shutdown chan struct{}
+	wait     sync.WaitGroup
+}
+
+// NewPriorityInstancer merges several instancers into one.  The instances from all the instancers with the lowest
+// priority are combined and dispatched, as long as there is at least (1) such instance.  Otherwise, the instances
+// with the next lowest priority are used, and so on.  This allows a process to prefer instances in its own datacenter
+// while failing over to other datacenters, or to a fixed list of instances, when the preferred instances disappear.
+//
+// If no instancer has any instances, the error from the lowest priority instancer that has an error is dispatched.
+//
+// Stopping the returned Instancer does not stop the merged instancers, as they are typically owned by other Environments.
+func NewPriorityInstancer(pis ...PrioritizedInstancer) sd.Instancer {
+	pi := &priorityInstancer{
+		shutdown: make(chan struct{}),
+	}
+
+	for _, p := range pis {
+		pi.sources = append(pi.sources, &prioritySource{
+			PrioritizedInstancer: p,
+			events:               make(chan sd.Event, 10),
+		})
+	}
+
+	sort.SliceStable(pi.sources, func(i, j int) bool {
+		return pi.sources[i].Priority < pi.sources[j].Priority
+	})
+
+	// instancers typically dispatch their current state upon registration, so gather
+	// those states first in order to avoid dispatching a partial merge
+	initialized := false
+	for _, s := range pi.sources {
+		s.Instancer.Register(s.events)
+		select {
+		case s.state = <-s.events:
+			initialized = true
+		default:
+		}
+	}
+
+	if initialized {
+		pi.Update(pi.merge())
+	}
+
+	for _, s := range pi.sources {
+		pi.wait.Add(1)
+		go pi.monitor(s)
+	}
+
+	return pi
+}
+
+func (pi *priorityInstancer) monitor(s *prioritySource) {
+	defer pi.wait.Done()
+
+	for {
+		select {
+		case <-pi.shutdown:
+			return
+
+		case e := <-s.events:
+			pi.lock.Lock()
+			s.state = e
+			pi.Update(pi.merge())
+			pi.lock.Unlock()
+		}
+	}
+}
+
+// merge produces the event for the current source states.  This method must be invoked under the lock.
+func (pi *priorityInstancer) merge() sd.Event {
+	var err error
+	for i := 0; i < len(pi.sources); {
+		var (
+			priority  = pi.sources[i].Priority
+			instances []string
+			seen      = make(map[string]bool)
+		)
+
+		for ; i < len(pi.sources) && pi.sources[i].Priority == priority; i++ {
+			s := pi.sources[i]
+			if s.state.Err != nil && err == nil {
+				err = s.state.Err
+			}
+
+			for _, instance := range s.state.Instances {
+				if !seen[instance] {
+					seen[instance] = true
+					instances = append(instances, instance)
+				}
+			}
+		}
+
+		if len(instances) > 0 {
+			return sd.Event{Instances: instances}
+		}
+	}
+
+	return sd.Event{Err: err}
+}
+
+// Stop deregisters from the merged instancers and halts any further updates.  The merged instancers are not stopped.
+func (pi *priorityInstancer) Stop() {
+	pi.stopOnce.Do(func() {
+		for _, s := range pi.sources {
+			s.Instancer.Deregister(s.events)
+		}
+
+		close(pi.shutdown)
+		pi.wait.Wait()
+		pi.UpdatableInstancer.Stop()
+	})
+}
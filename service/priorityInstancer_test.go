@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectPriorityEvent(t *testing.T, events <-chan sd.Event, expected sd.Event) {
+	select {
+	case actual := <-events:
+		assert.Equal(t, expected, actual)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "No event received", "expected: %v", expected)
+	}
+}
+
+func testPriorityInstancerFailover(t *testing.T) {
+	var (
+		require = require.New(t)
+
+		local       = new(UpdatableInstancer)
+		remote      = new(UpdatableInstancer)
+		expectedErr = errors.New("expected")
+		events      = make(chan sd.Event, 10)
+	)
+
+	local.Update(sd.Event{Instances: []string{"http://local2.com", "http://local1.com"}})
+	remote.Update(sd.Event{Instances: []string{"http://remote.com"}})
+
+	pi := NewPriorityInstancer(
+		PrioritizedInstancer{Name: "fixed", Priority: 2, Instancer: sd.FixedInstancer{"http://fixed.com"}},
+		PrioritizedInstancer{Name: "remote", Priority: 1, Instancer: remote},
+		PrioritizedInstancer{Name: "local", Instancer: local},
+	)
+
+	require.NotNil(pi)
+	defer pi.Stop()
+
+	pi.Register(events)
+	expectPriorityEvent(t, events, sd.Event{Instances: []string{"http://local1.com", "http://local2.com"}})
+
+	local.Update(sd.Event{})
+	expectPriorityEvent(t, events, sd.Event{Instances: []string{"http://remote.com"}})
+
+	remote.Update(sd.Event{Err: expectedErr})
+	expectPriorityEvent(t, events, sd.Event{Instances: []string{"http://fixed.com"}})
+
+	local.Update(sd.Event{Instances: []string{"http://local1.com"}})
+	expectPriorityEvent(t, events, sd.Event{Instances: []string{"http://local1.com"}})
+
+	// changes to less preferred instances do not result in events
+	remote.Update(sd.Event{Instances: []string{"http://remote.com"}})
+	local.Update(sd.Event{Instances: []string{"http://local3.com"}})
+	expectPriorityEvent(t, events, sd.Event{Instances: []string{"http://local3.com"}})
+}
+
+func testPriorityInstancerSamePriority(t *testing.T) {
+	var (
+		require = require.New(t)
+
+		first  = new(UpdatableInstancer)
+		second = new(UpdatableInstancer)
+		events = make(chan sd.Event, 10)
+	)
+
+	pi := NewPriorityInstancer(
+		PrioritizedInstancer{Name: "first", Instancer: first},
+		PrioritizedInstancer{Name: "second", Instancer: second},
+	)
+
+	require.NotNil(pi)
+	defer pi.Stop()
+
+	pi.Register(events)
+	assert.Len(t, events, 0)
+
+	first.Update(sd.Event{Instances: []string{"http://a.com", "http://b.com"}})
+	expectPriorityEvent(t, events, sd.Event{Instances: []string{"http://a.com", "http://b.com"}})
+
+	second.Update(sd.Event{Instances: []string{"http://b.com", "http://c.com"}})
+	expectPriorityEvent(t, events, sd.Event{Instances: []string{"http://a.com", "http://b.com", "http://c.com"}})
+}
+
+func testPriorityInstancerError(t *testing.T) {
+	var (
+		require = require.New(t)
+
+		local     = new(UpdatableInstancer)
+		remote    = new(UpdatableInstancer)
+		localErr  = errors.New("local")
+		remoteErr = errors.New("remote")
+		events    = make(chan sd.Event, 10)
+	)
+
+	local.Update(sd.Event{Err: localErr})
+	remote.Update(sd.Event{Err: remoteErr})
+
+	pi := NewPriorityInstancer(
+		PrioritizedInstancer{Name: "remote", Priority: 1, Instancer: remote},
+		PrioritizedInstancer{Name: "local", Instancer: local},
+	)
+
+	require.NotNil(pi)
+	defer pi.Stop()
+
+	pi.Register(events)
+	expectPriorityEvent(t, events, sd.Event{Err: localErr})
+
+	local.Update(sd.Event{})
+	expectPriorityEvent(t, events, sd.Event{Err: remoteErr})
+
+	remote.Update(sd.Event{})
+	expectPriorityEvent(t, events, sd.Event{})
+}
+
+func testPriorityInstancerStop(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		source = new(UpdatableInstancer)
+		events = make(chan sd.Event, 10)
+	)
+
+	source.Update(sd.Event{Instances: []string{"http://a.com"}})
+	pi := NewPriorityInstancer(PrioritizedInstancer{Instancer: source})
+	require.NotNil(pi)
+
+	pi.Register(events)
+	expectPriorityEvent(t, events, sd.Event{Instances: []string{"http://a.com"}})
+
+	pi.Stop()
+	pi.Stop() // idempotent
+
+	source.lock.Lock()
+	assert.Empty(source.listeners)
+	source.lock.Unlock()
+
+	source.Update(sd.Event{Instances: []string{"http://b.com"}})
+	assert.Len(events, 0)
+
+	// the merged instancer is not stopped
+	state, _ := source.State()
+	assert.Equal(sd.Event{Instances: []string{"http://b.com"}}, state)
+}
+
+func TestPriorityInstancer(t *testing.T) {
+	t.Run("Failover", testPriorityInstancerFailover)
+	t.Run("SamePriority", testPriorityInstancerSamePriority)
+	t.Run("Error", testPriorityInstancerError)
+	t.Run("Stop", testPriorityInstancerStop)
+}
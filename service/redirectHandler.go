@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -9,6 +10,8 @@ import (
 	"github.com/go-kit/kit/log/level"
 )
 
+var errNoAvailableInstances = errors.New("None of the instances for the key are available")
+
 // KeyFunc examines an HTTP request and produces the service key to use when finding
 // an instance to use.
 //
@@ -29,6 +32,37 @@ type RedirectHandler struct {
 
 	// RedirectCode is the HTTP status code sent as part of the redirect.  If not set, http.StatusTemporaryRedirect is used.
 	RedirectCode int
+
+	// Available is an optional strategy, e.g. driven by health checks, for determining whether an instance can receive
+	// redirects.  If not set, requests are always redirected to the instance returned by the Accessor's Get method.
+	Available func(instance string) bool
+
+	// Fallbacks is the number of additional instances, in hash preference order, that are tried when the preferred
+	// instance is not available.  Fallbacks require an Accessor which implements OrderedAccessor.  This field is only
+	// used when Available is set.
+	Fallbacks int
+}
+
+// instance selects the instance to which a request with the given key is redirected
+func (rh *RedirectHandler) instance(key []byte) (string, error) {
+	if rh.Available == nil {
+		return rh.Accessor.Get(key)
+	}
+
+	instances, err := GetN(rh.Accessor, key, 1+rh.Fallbacks)
+	if err != nil {
+		return "", err
+	}
+
+	for _, i := range instances {
+		if rh.Available(i) {
+			return i, nil
+		}
+
+		rh.Logger.Log(level.Key(), level.DebugValue(), logging.MessageKey(), "skipping unavailable instance", "instance", i)
+	}
+
+	return "", errNoAvailableInstances
 }
 
 func (rh *RedirectHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
@@ -39,8 +73,12 @@ func (rh *RedirectHandler) ServeHTTP(response http.ResponseWriter, request *http
 		return
 	}
 
-	instance, err := rh.Accessor.Get(key)
-	if err != nil {
+	instance, err := rh.instance(key)
+	if err == errNoAvailableInstances {
+		rh.Logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "no instance is available", logging.ErrorKey(), err)
+		http.Error(response, err.Error(), http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
 		rh.Logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "accessor failed to return an instance", logging.ErrorKey(), err)
 		http.Error(response, err.Error(), http.StatusInternalServerError)
 		return
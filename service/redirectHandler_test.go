@@ -123,9 +123,95 @@ func testRedirectHandlerSuccessWithPath(t *testing.T) {
 	accessor.AssertExpectations(t)
 }
 
+func testRedirectHandlerFallback(t *testing.T) {
+	var (
+		assert = assert.New(t)
+
+		expectedKey = []byte("asdfqwer")
+		keyFunc     = func(*http.Request) ([]byte, error) { return expectedKey, nil }
+		accessor    = new(MockOrderedAccessor)
+
+		response = httptest.NewRecorder()
+		request  = httptest.NewRequest("GET", "/", nil)
+
+		handler = RedirectHandler{
+			Logger:    logging.NewTestLogger(nil, t),
+			KeyFunc:   keyFunc,
+			Accessor:  accessor,
+			Available: func(i string) bool { return i != "https://down.com" },
+			Fallbacks: 2,
+		}
+	)
+
+	accessor.On("GetN", expectedKey, 3).Return([]string{"https://down.com", "https://up.com", "https://other.com"}, error(nil)).Once()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(http.StatusTemporaryRedirect, response.Code)
+	assert.Equal("https://up.com", response.HeaderMap.Get("Location"))
+	accessor.AssertExpectations(t)
+}
+
+func testRedirectHandlerNoAvailableInstances(t *testing.T) {
+	var (
+		assert = assert.New(t)
+
+		expectedKey = []byte("asdfqwer")
+		keyFunc     = func(*http.Request) ([]byte, error) { return expectedKey, nil }
+		accessor    = new(MockOrderedAccessor)
+
+		response = httptest.NewRecorder()
+		request  = httptest.NewRequest("GET", "/", nil)
+
+		handler = RedirectHandler{
+			Logger:    logging.NewTestLogger(nil, t),
+			KeyFunc:   keyFunc,
+			Accessor:  accessor,
+			Available: func(string) bool { return false },
+			Fallbacks: 1,
+		}
+	)
+
+	accessor.On("GetN", expectedKey, 2).Return([]string{"https://down1.com", "https://down2.com"}, error(nil)).Once()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(http.StatusServiceUnavailable, response.Code)
+	accessor.AssertExpectations(t)
+}
+
+func testRedirectHandlerFallbackAccessorError(t *testing.T) {
+	var (
+		assert = assert.New(t)
+
+		expectedKey   = []byte("asdfqwer")
+		keyFunc       = func(*http.Request) ([]byte, error) { return expectedKey, nil }
+		expectedError = errors.New("expected")
+		accessor      = new(MockAccessor)
+
+		response = httptest.NewRecorder()
+		request  = httptest.NewRequest("GET", "/", nil)
+
+		handler = RedirectHandler{
+			Logger:    logging.NewTestLogger(nil, t),
+			KeyFunc:   keyFunc,
+			Accessor:  accessor,
+			Available: func(string) bool { return true },
+			Fallbacks: 1,
+		}
+	)
+
+	accessor.On("Get", expectedKey).Return("", expectedError).Once()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(http.StatusInternalServerError, response.Code)
+	accessor.AssertExpectations(t)
+}
+
 func TestRedirectHandler(t *testing.T) {
 	t.Run("KeyFuncError", testRedirectHandlerKeyFuncError)
 	t.Run("AccessorError", testRedirectHandlerAccessorError)
 	t.Run("Success", testRedirectHandlerSuccess)
 	t.Run("SuccessPath", testRedirectHandlerSuccessWithPath)
+	t.Run("Fallback", testRedirectHandlerFallback)
+	t.Run("NoAvailableInstances", testRedirectHandlerNoAvailableInstances)
+	t.Run("FallbackAccessorError", testRedirectHandlerFallbackAccessorError)
 }
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// hash64 computes the 64-bit FNV-1a hash of a value
+func hash64(v []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(v)
+	return h.Sum64()
+}
+
+// mix64 is the murmur3 finalizer, used to spread the bits of combined hashes
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// rendezvousAccessor implements highest random weight hashing.  Each key is assigned to the instance
+// with the highest score for that key, so that changing the set of instances only moves the keys
+// assigned to instances which were removed or the keys now scoring highest on instances which were added.
+type rendezvousAccessor struct {
+	instances []string
+	seeds     []uint64
+}
+
+func (ra *rendezvousAccessor) Get(key []byte) (string, error) {
+	var (
+		k         = hash64(key)
+		best      = 0
+		bestScore = mix64(ra.seeds[0] ^ k)
+	)
+
+	for i := 1; i < len(ra.seeds); i++ {
+		if score := mix64(ra.seeds[i] ^ k); score > bestScore || (score == bestScore && ra.instances[i] < ra.instances[best]) {
+			best, bestScore = i, score
+		}
+	}
+
+	return ra.instances[best], nil
+}
+
+// order returns up to n instances in descending order of score for the key.  If first is nonempty, it is
+// placed first regardless of its score.
+func (ra *rendezvousAccessor) order(key []byte, n int, first string) []string {
+	if n < 1 {
+		n = 1
+	}
+
+	if n > len(ra.instances) {
+		n = len(ra.instances)
+	}
+
+	var (
+		k       = hash64(key)
+		indices = make([]int, len(ra.instances))
+		scores  = make([]uint64, len(ra.instances))
+	)
+
+	for i := range ra.instances {
+		indices[i] = i
+		scores[i] = mix64(ra.seeds[i] ^ k)
+	}
+
+	sort.Slice(indices, func(a, b int) bool {
+		if scores[indices[a]] == scores[indices[b]] {
+			return ra.instances[indices[a]] < ra.instances[indices[b]]
+		}
+
+		return scores[indices[a]] > scores[indices[b]]
+	})
+
+	ordered := make([]string, 0, n)
+	if len(first) > 0 {
+		ordered = append(ordered, first)
+	}
+
+	for _, i := range indices {
+		if len(ordered) == n {
+			break
+		}
+
+		if ra.instances[i] != first {
+			ordered = append(ordered, ra.instances[i])
+		}
+	}
+
+	return ordered
+}
+
+func (ra *rendezvousAccessor) GetN(key []byte, n int) ([]string, error) {
+	return ra.order(key, n, ""), nil
+}
+
+func newRendezvousAccessor(instances []string) Accessor {
+	if len(instances) == 0 {
+		return emptyAccessor{}
+	}
+
+	ra := &rendezvousAccessor{
+		instances: make([]string, len(instances)),
+		seeds:     make([]uint64, len(instances)),
+	}
+
+	for i, instance := range instances {
+		ra.instances[i] = instance
+		ra.seeds[i] = hash64([]byte(instance))
+	}
+
+	return ra
+}
+
+// RendezvousAccessorFactory creates Accessors which use rendezvous, or highest random weight, hashing.
+// Compared to consistent hashing, rendezvous hashing moves the minimum number of keys when instances change
+// and needs no vnodes, at the cost of a Get which is linear in the number of instances.
+func RendezvousAccessorFactory(instances []string) Accessor {
+	return newRendezvousAccessor(instances)
+}
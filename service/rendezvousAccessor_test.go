@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRendezvousAccessorFactoryEmpty(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	for _, i := range [][]string{nil, []string{}} {
+		a := RendezvousAccessorFactory(i)
+		require.NotNil(a)
+		i, err := a.Get([]byte("test"))
+		assert.Empty(i)
+		assert.Error(err)
+	}
+}
+
+func testRendezvousAccessorFactoryBalance(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		instances = []string{"https://one.com", "https://two.com", "https://three.com", "https://four.com"}
+		a         = RendezvousAccessorFactory(instances)
+		counts    = make(map[string]int)
+	)
+
+	require.NotNil(a)
+	for k := 0; k < 10000; k++ {
+		i, err := a.Get([]byte(fmt.Sprintf("mac:%012x", k)))
+		require.NoError(err)
+		counts[i]++
+	}
+
+	require.Len(counts, len(instances))
+	for _, i := range instances {
+		assert.InDelta(2500, counts[i], 250, "instance %s has an unbalanced load", i)
+	}
+}
+
+func testRendezvousAccessorFactoryMovement(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		before = RendezvousAccessorFactory([]string{"https://one.com", "https://two.com", "https://three.com"})
+		after  = RendezvousAccessorFactory([]string{"https://three.com", "https://one.com", "https://two.com", "https://four.com"})
+	)
+
+	for k := 0; k < 1000; k++ {
+		key := []byte(fmt.Sprintf("mac:%012x", k))
+		b, err := before.Get(key)
+		require.NoError(err)
+
+		a, err := after.Get(key)
+		require.NoError(err)
+
+		if a != "https://four.com" {
+			assert.Equal(b, a, "key %s was moved unnecessarily", key)
+		}
+	}
+}
+
+func testRendezvousAccessorFactoryGetN(t *testing.T) {
+	instances := []string{"https://one.com", "https://two.com", "https://three.com", "https://four.com"}
+	testOrderedAccessor(t, RendezvousAccessorFactory(instances), instances)
+}
+
+func TestRendezvousAccessorFactory(t *testing.T) {
+	t.Run("Empty", testRendezvousAccessorFactoryEmpty)
+	t.Run("GetN", testRendezvousAccessorFactoryGetN)
+	t.Run("Balance", testRendezvousAccessorFactoryBalance)
+	t.Run("Movement", testRendezvousAccessorFactoryMovement)
+}
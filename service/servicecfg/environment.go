@@ -1,9 +1,14 @@
 package servicecfg
 
 import (
+	"strconv"
+
 	"github.com/Comcast/webpa-common/logging"
 	"github.com/Comcast/webpa-common/service"
 	"github.com/Comcast/webpa-common/service/consul"
+	"github.com/Comcast/webpa-common/service/dns"
+	"github.com/Comcast/webpa-common/service/etcd"
+	"github.com/Comcast/webpa-common/service/kubernetes"
 	"github.com/Comcast/webpa-common/service/zk"
 	"github.com/Comcast/webpa-common/xviper"
 	"github.com/go-kit/kit/log"
@@ -12,11 +17,22 @@ import (
 )
 
 var (
-	zookeeperEnvironmentFactory = zk.NewEnvironment
-	consulEnvironmentFactory    = consul.NewEnvironment
+	zookeeperEnvironmentFactory  = zk.NewEnvironment
+	consulEnvironmentFactory     = consul.NewEnvironment
+	etcdEnvironmentFactory       = etcd.NewEnvironment
+	kubernetesEnvironmentFactory = kubernetes.NewEnvironment
+	dnsEnvironmentFactory        = dns.NewEnvironment
 )
 
+// NewEnvironment creates the service discovery Environment described by the configuration in u.
 func NewEnvironment(l log.Logger, u xviper.Unmarshaler) (service.Environment, error) {
+	return NewEnvironmentWithMeta(l, u, nil)
+}
+
+// NewEnvironmentWithMeta is like NewEnvironment, but also advertises the given service metadata through backends
+// which support it, e.g. consul.  The meta is typically server.WebPA.Meta().  Any metadata in the configuration
+// takes precedence.
+func NewEnvironmentWithMeta(l log.Logger, u xviper.Unmarshaler, meta map[string]string) (service.Environment, error) {
 	if l == nil {
 		l = logging.DefaultLogger()
 	}
@@ -26,22 +42,39 @@ func NewEnvironment(l log.Logger, u xviper.Unmarshaler) (service.Environment, er
 		return nil, err
 	}
 
+	af, registry, err := o.accessorFactory()
+	if err != nil {
+		return nil, err
+	}
+
+	if registry != nil {
+		registry.Logger = l
+	}
+
 	eo := []service.Option{
-		service.WithAccessorFactory(
-			service.NewConsistentAccessorFactory(o.vnodeCount()),
-		),
+		service.WithAccessorFactory(af),
 		service.WithDefaultScheme(o.defaultScheme()),
 	}
 
-	if len(o.Fixed) > 0 {
-		l.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "using a fixed set of instances for service discovery", "instances", o.Fixed)
+	if len(o.Sources) > 0 {
+		return newMergedEnvironment(l, o, registry, meta, eo)
+	}
+
+	return newSourceEnvironment(l, o.source(), o.DefaultScheme, registry, meta, eo)
+}
+
+// newSourceEnvironment creates the Environment for a single service discovery backend.  If no backend is configured,
+// this function returns a nil Environment and a nil error.
+func newSourceEnvironment(l log.Logger, s Source, registrationScheme string, registry *service.MetadataRegistry, meta map[string]string, eo []service.Option) (service.Environment, error) {
+	if len(s.Fixed) > 0 {
+		l.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "using a fixed set of instances for service discovery", "instances", s.Fixed)
 		return service.NewEnvironment(
 			append(eo,
 				service.WithInstancers(
 					service.Instancers{
 						"fixed": service.NewContextualInstancer(
-							sd.FixedInstancer(o.Fixed),
-							map[string]interface{}{"fixed": o.Fixed},
+							sd.FixedInstancer(s.Fixed),
+							map[string]interface{}{"fixed": s.Fixed},
 						),
 					},
 				),
@@ -49,15 +82,121 @@ func NewEnvironment(l log.Logger, u xviper.Unmarshaler) (service.Environment, er
 		), nil
 	}
 
-	if o.Zookeeper != nil {
+	if s.Zookeeper != nil {
 		l.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "using zookeeper for service discovery")
-		return zookeeperEnvironmentFactory(l, *o.Zookeeper, eo...)
+		s.Zookeeper.Metadata = registry
+		return zookeeperEnvironmentFactory(l, *s.Zookeeper, eo...)
 	}
 
-	if o.Consul != nil {
+	if s.Consul != nil {
 		l.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "using consul for service discovery")
-		return consulEnvironmentFactory(l, o.DefaultScheme, *o.Consul, eo...)
+		s.Consul.Metadata = registry
+		s.Consul.Meta = consul.MergeMeta(meta, s.Consul.Meta)
+
+		return consulEnvironmentFactory(l, registrationScheme, *s.Consul, eo...)
+	}
+
+	if s.Etcd != nil {
+		l.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "using etcd for service discovery")
+		s.Etcd.Metadata = registry
+		return etcdEnvironmentFactory(l, *s.Etcd, eo...)
+	}
+
+	if s.Kubernetes != nil {
+		l.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "using kubernetes for service discovery")
+		s.Kubernetes.Metadata = registry
+		return kubernetesEnvironmentFactory(l, *s.Kubernetes, eo...)
+	}
+
+	if s.DNS != nil {
+		l.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "using DNS for service discovery")
+		return dnsEnvironmentFactory(l, *s.DNS, eo...)
 	}
 
 	return nil, nil
 }
+
+// newMergedEnvironment creates an Environment whose instancers merge the instancers of each source by priority
+func newMergedEnvironment(l log.Logger, o *Options, registry *service.MetadataRegistry, meta map[string]string, eo []service.Option) (service.Environment, error) {
+	var (
+		sources []service.Environment
+		keyed   = make(map[string][]service.PrioritizedInstancer)
+		fixed   []service.PrioritizedInstancer
+	)
+
+	closeSources := func() (err error) {
+		for _, e := range sources {
+			if closeErr := e.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+
+		return
+	}
+
+	for position, s := range o.Sources {
+		name := s.Name
+		if len(name) == 0 {
+			name = strconv.Itoa(position)
+		}
+
+		sourceLogger := log.With(l, "source", name, "priority", s.Priority)
+		e, err := newSourceEnvironment(sourceLogger, s, o.DefaultScheme, registry, meta, eo)
+		if err != nil {
+			closeSources()
+			return nil, err
+		}
+
+		if e == nil {
+			sourceLogger.Log(level.Key(), level.WarnValue(), logging.MessageKey(), "skipping service discovery source with no backend")
+			continue
+		}
+
+		sources = append(sources, e)
+		for key, i := range e.Instancers() {
+			pi := service.PrioritizedInstancer{Name: name, Priority: s.Priority, Instancer: i}
+			if len(s.Fixed) > 0 {
+				fixed = append(fixed, pi)
+			} else {
+				keyed[key] = append(keyed[key], pi)
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	if len(keyed) == 0 {
+		keyed["fixed"] = nil
+	}
+
+	var merged service.Instancers
+	for key, pis := range keyed {
+		pis = append(pis, fixed...)
+		priorities := make(map[string]interface{}, len(pis))
+		for _, pi := range pis {
+			priorities[pi.Name] = pi.Priority
+		}
+
+		merged.Set(
+			key,
+			service.NewContextualInstancer(
+				service.NewPriorityInstancer(pis...),
+				map[string]interface{}{"sources": priorities},
+			),
+		)
+	}
+
+	l.Log(level.Key(), level.InfoValue(), logging.MessageKey(), "merging service discovery sources", "sources", len(sources))
+	return mergedEnvironment{
+		Environment: service.NewEnvironment(
+			append(
+				eo,
+				service.WithInstancers(merged),
+				service.WithCloser(closeSources),
+			)...,
+		),
+		sources: sources,
+	}, nil
+}
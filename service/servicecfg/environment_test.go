@@ -9,9 +9,13 @@ import (
 	"github.com/Comcast/webpa-common/logging"
 	"github.com/Comcast/webpa-common/service"
 	"github.com/Comcast/webpa-common/service/consul"
+	"github.com/Comcast/webpa-common/service/dns"
+	"github.com/Comcast/webpa-common/service/etcd"
+	"github.com/Comcast/webpa-common/service/kubernetes"
 	"github.com/Comcast/webpa-common/service/zk"
 	"github.com/Comcast/webpa-common/xviper"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/sd"
 	"github.com/hashicorp/consul/api"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -222,10 +226,522 @@ func testNewEnvironmentConsul(t *testing.T) {
 	assert.NoError(actualEnvironment.Close())
 }
 
+func testNewEnvironmentEtcd(t *testing.T) {
+	defer resetEnvironmentFactories()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger = logging.NewTestLogger(nil, t)
+		v      = viper.New()
+
+		expectedEnvironment = service.NewEnvironment()
+
+		configuration = strings.NewReader(`
+			{
+				"etcd": {
+					"client": {
+						"endpoints": ["host1.com:2379", "host2.com:2379"],
+						"dialTimeout": "10s"
+					},
+					"registrations": [
+						{
+							"prefix": "/some/where",
+							"address": "foobar.com",
+							"port": 2121,
+							"ttl": "15s"
+						}
+					],
+					"watches": ["/some/where"]
+				}
+			}
+		`)
+	)
+
+	v.SetConfigType("json")
+	require.NoError(v.ReadConfig(configuration))
+
+	etcdEnvironmentFactory = func(l log.Logger, eo etcd.Options, o ...service.Option) (service.Environment, error) {
+		assert.Equal(logger, l)
+		assert.Equal(
+			etcd.Options{
+				Client: etcd.Client{
+					Endpoints:   []string{"host1.com:2379", "host2.com:2379"},
+					DialTimeout: 10 * time.Second,
+				},
+				Registrations: []etcd.Registration{
+					etcd.Registration{
+						Prefix:  "/some/where",
+						Address: "foobar.com",
+						Port:    2121,
+						TTL:     15 * time.Second,
+					},
+				},
+				Watches: []string{"/some/where"},
+			},
+			eo,
+		)
+
+		return expectedEnvironment, nil
+	}
+
+	actualEnvironment, err := NewEnvironment(logger, v)
+	require.NoError(err)
+	require.NotNil(actualEnvironment)
+	assert.Equal(expectedEnvironment, actualEnvironment)
+
+	assert.NoError(actualEnvironment.Close())
+}
+
+func testNewEnvironmentKubernetes(t *testing.T) {
+	defer resetEnvironmentFactories()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger = logging.NewTestLogger(nil, t)
+		v      = viper.New()
+
+		expectedEnvironment = service.NewEnvironment()
+
+		configuration = strings.NewReader(`
+			{
+				"kubernetes": {
+					"client": {
+						"url": "https://kubernetes.default.svc"
+					},
+					"watches": [
+						{
+							"namespace": "xmidt",
+							"service": "talaria",
+							"port": "api",
+							"endpointSlices": true
+						}
+					]
+				}
+			}
+		`)
+	)
+
+	v.SetConfigType("json")
+	require.NoError(v.ReadConfig(configuration))
+
+	kubernetesEnvironmentFactory = func(l log.Logger, ko kubernetes.Options, eo ...service.Option) (service.Environment, error) {
+		assert.Equal(logger, l)
+		assert.Equal(
+			kubernetes.Options{
+				Client: kubernetes.Client{
+					URL: "https://kubernetes.default.svc",
+				},
+				Watches: []kubernetes.Watch{
+					kubernetes.Watch{
+						Namespace:      "xmidt",
+						Service:        "talaria",
+						Port:           "api",
+						EndpointSlices: true,
+					},
+				},
+			},
+			ko,
+		)
+
+		return expectedEnvironment, nil
+	}
+
+	actualEnvironment, err := NewEnvironment(logger, v)
+	require.NoError(err)
+	require.NotNil(actualEnvironment)
+	assert.Equal(expectedEnvironment, actualEnvironment)
+
+	assert.NoError(actualEnvironment.Close())
+}
+
+func testNewEnvironmentDNS(t *testing.T) {
+	defer resetEnvironmentFactories()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger = logging.NewTestLogger(nil, t)
+		v      = viper.New()
+
+		expectedEnvironment = service.NewEnvironment()
+
+		configuration = strings.NewReader(`
+			{
+				"dns": {
+					"refreshInterval": "1m",
+					"watches": [
+						{
+							"name": "example.com",
+							"service": "talaria",
+							"scheme": "https"
+						}
+					]
+				}
+			}
+		`)
+	)
+
+	v.SetConfigType("json")
+	require.NoError(v.ReadConfig(configuration))
+
+	dnsEnvironmentFactory = func(l log.Logger, do dns.Options, eo ...service.Option) (service.Environment, error) {
+		assert.Equal(logger, l)
+		assert.Equal(
+			dns.Options{
+				RefreshInterval: time.Minute,
+				Watches: []dns.Watch{
+					dns.Watch{
+						Name:    "example.com",
+						Service: "talaria",
+						Scheme:  "https",
+					},
+				},
+			},
+			do,
+		)
+
+		return expectedEnvironment, nil
+	}
+
+	actualEnvironment, err := NewEnvironment(logger, v)
+	require.NoError(err)
+	require.NotNil(actualEnvironment)
+	assert.Equal(expectedEnvironment, actualEnvironment)
+
+	assert.NoError(actualEnvironment.Close())
+}
+
+func testNewEnvironmentWeighted(t *testing.T) {
+	defer resetEnvironmentFactories()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger = logging.NewTestLogger(nil, t)
+		v      = viper.New()
+
+		configuration = strings.NewReader(`
+			{
+				"weighted": {
+					"zone": "east",
+					"instances": {
+						"https://static.net:8080": {"weight": 2, "zone": "west"}
+					}
+				},
+				"consul": {
+					"watches": [
+						{
+							"service": "test"
+						}
+					]
+				}
+			}
+		`)
+	)
+
+	v.SetConfigType("json")
+	require.NoError(v.ReadConfig(configuration))
+
+	consulEnvironmentFactory = func(l log.Logger, registrationScheme string, co consul.Options, eo ...service.Option) (service.Environment, error) {
+		require.NotNil(co.Metadata)
+		co.Metadata.Set("east.net:8080", service.Metadata{Zone: "east"})
+		return service.NewEnvironment(eo...), nil
+	}
+
+	e, err := NewEnvironment(logger, v)
+	require.NoError(err)
+	require.NotNil(e)
+
+	a := e.AccessorFactory()([]string{"https://static.net:8080", "https://east.net:8080", "https://other.net:8080"})
+	require.NotNil(a)
+	for _, k := range []string{"a", "alsdkjfa;lksehjuro8iwurjhf", "asdf8974", "875kjh4", "928375hjdfgkyu9832745kjshdfgoi873465"} {
+		i, err := a.Get([]byte(k))
+		assert.Equal("https://east.net:8080", i)
+		assert.NoError(err)
+	}
+
+	assert.NoError(e.Close())
+}
+
+func testNewEnvironmentWithMetaConsul(t *testing.T) {
+	defer resetEnvironmentFactories()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger = logging.NewTestLogger(nil, t)
+		v      = viper.New()
+
+		expectedEnvironment = service.NewEnvironment()
+
+		configuration = strings.NewReader(`
+			{
+				"consul": {
+					"meta": {
+						"flavor": "canary"
+					},
+					"healthCheck": {
+						"type": "http",
+						"port": 8081,
+						"interval": "15s"
+					},
+					"deregistrationDelay": "30s",
+					"registrations": [
+						{
+							"name": "test",
+							"address": "foobar.com",
+							"port": 2121
+						}
+					]
+				}
+			}
+		`)
+	)
+
+	v.SetConfigType("json")
+	require.NoError(v.ReadConfig(configuration))
+
+	consulEnvironmentFactory = func(l log.Logger, registrationScheme string, co consul.Options, eo ...service.Option) (service.Environment, error) {
+		assert.Equal(logger, l)
+		assert.Equal(
+			map[string]string{
+				"build":  "1.0",
+				"region": "east",
+				"flavor": "canary",
+			},
+			co.Meta,
+		)
+
+		assert.Equal(&consul.HealthCheck{Type: "http", Port: 8081, Interval: 15 * time.Second}, co.HealthCheck)
+		assert.Equal(30*time.Second, co.DeregistrationDelay)
+		return expectedEnvironment, nil
+	}
+
+	actualEnvironment, err := NewEnvironmentWithMeta(
+		logger,
+		v,
+		map[string]string{"build": "1.0", "region": "east", "flavor": "development"},
+	)
+
+	require.NoError(err)
+	assert.Equal(expectedEnvironment, actualEnvironment)
+}
+
+func testNewEnvironmentSources(t *testing.T) {
+	defer resetEnvironmentFactories()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger = logging.NewTestLogger(nil, t)
+		v      = viper.New()
+
+		local     = new(service.UpdatableInstancer)
+		remote    = new(service.UpdatableInstancer)
+		registrar = new(service.MockRegistrar)
+
+		configuration = strings.NewReader(`
+			{
+				"sources": [
+					{
+						"name": "fallback",
+						"priority": 2,
+						"fixed": ["https://fixed.net:8080"]
+					},
+					{
+						"name": "west",
+						"priority": 1,
+						"consul": {
+							"client": {
+								"datacenter": "west"
+							},
+							"watches": [
+								{
+									"service": "test"
+								}
+							]
+						}
+					},
+					{
+						"name": "east",
+						"consul": {
+							"client": {
+								"datacenter": "east"
+							},
+							"watches": [
+								{
+									"service": "test"
+								}
+							]
+						}
+					},
+					{
+						"name": "empty"
+					}
+				]
+			}
+		`)
+	)
+
+	v.SetConfigType("json")
+	require.NoError(v.ReadConfig(configuration))
+
+	local.Update(sd.Event{Instances: []string{"https://east.net:8080"}})
+	remote.Update(sd.Event{Instances: []string{"https://west.net:8080"}})
+	registrar.On("Register").Once()
+	registrar.On("Deregister").Twice()
+
+	consulEnvironmentFactory = func(l log.Logger, registrationScheme string, co consul.Options, eo ...service.Option) (service.Environment, error) {
+		require.NotNil(co.Client)
+		if co.Client.Datacenter == "east" {
+			return service.NewEnvironment(
+				append(
+					eo,
+					service.WithInstancers(service.Instancers{"test": local}),
+					service.WithRegistrars(service.Registrars{"https://east.net:8080": registrar}),
+				)...,
+			), nil
+		}
+
+		return service.NewEnvironment(
+			append(eo, service.WithInstancers(service.Instancers{"test": remote}))...,
+		), nil
+	}
+
+	e, err := NewEnvironment(logger, v)
+	require.NoError(err)
+	require.NotNil(e)
+
+	i := e.Instancers()
+	require.Len(i, 1)
+	require.NotNil(i["test"])
+
+	events := make(chan sd.Event, 10)
+	i["test"].Register(events)
+	assert.Equal(sd.Event{Instances: []string{"https://east.net:8080"}}, <-events)
+
+	local.Update(sd.Event{})
+	assert.Equal(sd.Event{Instances: []string{"https://west.net:8080"}}, <-events)
+
+	remote.Update(sd.Event{Err: errors.New("expected")})
+	assert.Equal(sd.Event{Instances: []string{"https://fixed.net:8080"}}, <-events)
+
+	local.Update(sd.Event{Instances: []string{"https://east.net:8080"}})
+	assert.Equal(sd.Event{Instances: []string{"https://east.net:8080"}}, <-events)
+
+	assert.True(e.IsRegistered("https://east.net:8080"))
+	assert.False(e.IsRegistered("https://west.net:8080"))
+	e.Register()
+	e.Deregister()
+
+	assert.NoError(e.Close())
+	registrar.AssertExpectations(t)
+}
+
+func testNewEnvironmentSourcesFixed(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger = logging.NewTestLogger(nil, t)
+		v      = viper.New()
+
+		configuration = strings.NewReader(`
+			{
+				"sources": [
+					{
+						"priority": 1,
+						"fixed": ["https://fallback.net:8080"]
+					},
+					{
+						"fixed": ["https://primary.net:8080"]
+					}
+				]
+			}
+		`)
+	)
+
+	v.SetConfigType("json")
+	require.NoError(v.ReadConfig(configuration))
+
+	e, err := NewEnvironment(logger, v)
+	require.NoError(err)
+	require.NotNil(e)
+
+	i := e.Instancers()
+	require.Len(i, 1)
+	require.NotNil(i["fixed"])
+
+	events := make(chan sd.Event, 10)
+	i["fixed"].Register(events)
+	assert.Equal(sd.Event{Instances: []string{"https://primary.net:8080"}}, <-events)
+
+	assert.NoError(e.Close())
+}
+
+func testNewEnvironmentSourcesError(t *testing.T) {
+	defer resetEnvironmentFactories()
+
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		logger        = logging.NewTestLogger(nil, t)
+		v             = viper.New()
+		expectedError = errors.New("expected")
+
+		configuration = strings.NewReader(`
+			{
+				"sources": [
+					{
+						"fixed": ["https://fixed.net:8080"]
+					},
+					{
+						"consul": {
+							"watches": [
+								{
+									"service": "test"
+								}
+							]
+						}
+					}
+				]
+			}
+		`)
+	)
+
+	v.SetConfigType("json")
+	require.NoError(v.ReadConfig(configuration))
+
+	consulEnvironmentFactory = func(log.Logger, string, consul.Options, ...service.Option) (service.Environment, error) {
+		return nil, expectedError
+	}
+
+	e, err := NewEnvironment(logger, v)
+	assert.Nil(e)
+	assert.Equal(expectedError, err)
+}
+
 func TestNewEnvironment(t *testing.T) {
 	t.Run("Empty", testNewEnvironmentEmpty)
 	t.Run("UnmarshalError", testNewEnvironmentUnmarshalError)
 	t.Run("Fixed", testNewEnvironmentFixed)
 	t.Run("Zookeeper", testNewEnvironmentZookeeper)
 	t.Run("Consul", testNewEnvironmentConsul)
+	t.Run("Etcd", testNewEnvironmentEtcd)
+	t.Run("Kubernetes", testNewEnvironmentKubernetes)
+	t.Run("DNS", testNewEnvironmentDNS)
+	t.Run("Weighted", testNewEnvironmentWeighted)
+	t.Run("WithMetaConsul", testNewEnvironmentWithMetaConsul)
+	t.Run("Sources", testNewEnvironmentSources)
+	t.Run("SourcesFixed", testNewEnvironmentSourcesFixed)
+	t.Run("SourcesError", testNewEnvironmentSourcesError)
 }
@@ -0,0 +1,62 @@
+package servicecfg
+
+import (
+	"github.com/Comcast/webpa-common/service"
+	"github.com/Comcast/webpa-common/service/consul"
+)
+
+// mergedEnvironment is the service.Environment for several sources.  Its instancers are the merged instancers,
+// while registration is delegated to each source.  Closing this environment closes each source.
+type mergedEnvironment struct {
+	service.Environment
+	sources []service.Environment
+}
+
+func (me mergedEnvironment) Register() {
+	for _, e := range me.sources {
+		e.Register()
+	}
+}
+
+func (me mergedEnvironment) Deregister() {
+	for _, e := range me.sources {
+		e.Deregister()
+	}
+}
+
+func (me mergedEnvironment) IsRegistered(instance string) bool {
+	for _, e := range me.sources {
+		if e.IsRegistered(instance) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnableMaintenance places the registrations of any sources that support maintenance mode, such as consul,
+// into maintenance mode
+func (me mergedEnvironment) EnableMaintenance(reason string) error {
+	for _, e := range me.sources {
+		if m, ok := e.(consul.Maintenance); ok {
+			if err := m.EnableMaintenance(reason); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// DisableMaintenance takes the registrations of any sources that support maintenance mode out of maintenance mode
+func (me mergedEnvironment) DisableMaintenance() error {
+	for _, e := range me.sources {
+		if m, ok := e.(consul.Maintenance); ok {
+			if err := m.DisableMaintenance(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
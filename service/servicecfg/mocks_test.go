@@ -2,6 +2,9 @@ package servicecfg
 
 import (
 	"github.com/Comcast/webpa-common/service/consul"
+	"github.com/Comcast/webpa-common/service/dns"
+	"github.com/Comcast/webpa-common/service/etcd"
+	"github.com/Comcast/webpa-common/service/kubernetes"
 	"github.com/Comcast/webpa-common/service/zk"
 )
 
@@ -9,4 +12,7 @@ import (
 func resetEnvironmentFactories() {
 	zookeeperEnvironmentFactory = zk.NewEnvironment
 	consulEnvironmentFactory = consul.NewEnvironment
+	etcdEnvironmentFactory = etcd.NewEnvironment
+	kubernetesEnvironmentFactory = kubernetes.NewEnvironment
+	dnsEnvironmentFactory = dns.NewEnvironment
 }
@@ -1,20 +1,103 @@
 package servicecfg
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/Comcast/webpa-common/service"
 	"github.com/Comcast/webpa-common/service/consul"
+	"github.com/Comcast/webpa-common/service/dns"
+	"github.com/Comcast/webpa-common/service/etcd"
+	"github.com/Comcast/webpa-common/service/kubernetes"
 	"github.com/Comcast/webpa-common/service/zk"
 )
 
+const (
+	// ConsistentAlgorithm selects vnode consistent hashing.  This is the default.
+	ConsistentAlgorithm = "consistent"
+
+	// RendezvousAlgorithm selects rendezvous, or highest random weight, hashing
+	RendezvousAlgorithm = "rendezvous"
+
+	// BoundedLoadAlgorithm selects consistent hashing with bounded loads.  The Accessors it creates are
+	// service.BoundedLoadAccessor instances, and code that places keys must call Release for each key that is
+	// no longer in use, e.g. when a device disconnects.  Placement depends on the keys this process has placed,
+	// so different processes may assign the same key to different instances.  This algorithm is therefore only
+	// suitable when a single process both places and releases keys, such as a server choosing where to send
+	// the devices that connect to it.
+	BoundedLoadAlgorithm = "boundedLoad"
+)
+
+var ErrorWeightedAlgorithm = errors.New("Weighted hashing is only supported by the consistent algorithm")
+
+// Weighted configures weighted, zone-aware consistent hashing.  Instance weights and zones are taken
+// from service discovery, e.g. consul tags or zookeeper payloads, and from the static Instances.
+type Weighted struct {
+	// Zone is the zone of this process.  Keys are hashed to instances in this zone when there are any.
+	Zone string `json:"zone,omitempty"`
+
+	// Instances is the static metadata for instances, which takes precedence over discovered metadata
+	Instances service.MetadataMap `json:"instances,omitempty"`
+}
+
+// Source is one of several service discovery backends whose instances are merged, e.g. consul in the local and
+// remote datacenters, or a fixed list of last resort instances.  Exactly one backend should be configured.
+//
+// The instancers of each source are merged by key, with a fixed list being merged into every key.  Instances from the
+// sources with the lowest Priority are used, failing over to higher priorities only when there are no instances.
+type Source struct {
+	// Name identifies this source, e.g. a datacenter.  If not supplied, the position of this source is used.
+	Name string `json:"name,omitempty"`
+
+	// Priority is the preference for this source's instances.  Lower values are preferred, as with DNS SRV records.
+	Priority int `json:"priority"`
+
+	Fixed      []string            `json:"fixed,omitempty"`
+	Zookeeper  *zk.Options         `json:"zookeeper,omitempty"`
+	Consul     *consul.Options     `json:"consul,omitempty"`
+	Etcd       *etcd.Options       `json:"etcd,omitempty"`
+	Kubernetes *kubernetes.Options `json:"kubernetes,omitempty"`
+	DNS        *dns.Options        `json:"dns,omitempty"`
+}
+
 // Options contains the superset of all necessary options for initializing service discovery.
 type Options struct {
 	VnodeCount    int    `json:"vnodeCount,omitempty"`
 	DisableFilter bool   `json:"disableFilter"`
 	DefaultScheme string `json:"defaultScheme"`
 
-	Fixed     []string        `json:"fixed,omitempty"`
-	Zookeeper *zk.Options     `json:"zookeeper,omitempty"`
-	Consul    *consul.Options `json:"consul,omitempty"`
+	// Algorithm is the hashing algorithm used to assign keys to instances.  If unset, ConsistentAlgorithm is used.
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// LoadFactor is the bound on an instance's load relative to the average, used by BoundedLoadAlgorithm.
+	// If less than 1, service.DefaultLoadFactor is used.
+	LoadFactor float64 `json:"loadFactor,omitempty"`
+
+	// Weighted, when supplied, enables weighted and zone-aware hashing in place of plain consistent hashing
+	Weighted *Weighted `json:"weighted,omitempty"`
+
+	Fixed      []string            `json:"fixed,omitempty"`
+	Zookeeper  *zk.Options         `json:"zookeeper,omitempty"`
+	Consul     *consul.Options     `json:"consul,omitempty"`
+	Etcd       *etcd.Options       `json:"etcd,omitempty"`
+	Kubernetes *kubernetes.Options `json:"kubernetes,omitempty"`
+	DNS        *dns.Options        `json:"dns,omitempty"`
+
+	// Sources, when supplied, configures several backends whose instances are merged by priority.  The backends
+	// configured directly in these Options are ignored in that case.
+	Sources []Source `json:"sources,omitempty"`
+}
+
+// source returns the single backend configured directly in these options
+func (o *Options) source() Source {
+	return Source{
+		Fixed:      o.Fixed,
+		Zookeeper:  o.Zookeeper,
+		Consul:     o.Consul,
+		Etcd:       o.Etcd,
+		Kubernetes: o.Kubernetes,
+		DNS:        o.DNS,
+	}
 }
 
 func (o *Options) vnodeCount() int {
@@ -25,6 +108,54 @@ func (o *Options) vnodeCount() int {
 	return service.DefaultVnodeCount
 }
 
+func (o *Options) algorithm() string {
+	if o != nil && len(o.Algorithm) > 0 {
+		return o.Algorithm
+	}
+
+	return ConsistentAlgorithm
+}
+
+func (o *Options) loadFactor() float64 {
+	if o != nil && o.LoadFactor >= 1.0 {
+		return o.LoadFactor
+	}
+
+	return service.DefaultLoadFactor
+}
+
+// accessorFactory returns the AccessorFactory described by these options, along with the registry that service
+// discovery backends should populate with instance metadata.  The registry is nil if weighted hashing is not configured.
+func (o *Options) accessorFactory() (service.AccessorFactory, *service.MetadataRegistry, error) {
+	algorithm := o.algorithm()
+	if o != nil && o.Weighted != nil {
+		if algorithm != ConsistentAlgorithm {
+			return nil, nil, ErrorWeightedAlgorithm
+		}
+
+		registry := service.NewMetadataRegistry()
+		return service.NewWeightedAccessorFactory(
+			o.vnodeCount(),
+			o.Weighted.Zone,
+			service.MetadataSources{o.Weighted.Instances, registry},
+		), registry, nil
+	}
+
+	switch algorithm {
+	case ConsistentAlgorithm:
+		return service.NewConsistentAccessorFactory(o.vnodeCount()), nil, nil
+
+	case RendezvousAlgorithm:
+		return service.RendezvousAccessorFactory, nil, nil
+
+	case BoundedLoadAlgorithm:
+		return service.NewBoundedLoadAccessorFactory(o.vnodeCount(), o.loadFactor()), nil, nil
+
+	default:
+		return nil, nil, fmt.Errorf("Unsupported hashing algorithm: %s", algorithm)
+	}
+}
+
 func (o *Options) disableFilter() bool {
 	if o != nil {
 		return o.DisableFilter
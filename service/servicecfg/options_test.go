@@ -1,6 +1,7 @@
 package servicecfg
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/Comcast/webpa-common/service"
@@ -12,6 +13,14 @@ func testOptionsDefault(t *testing.T, o *Options) {
 	assert.Equal(service.DefaultVnodeCount, o.vnodeCount())
 	assert.False(o.disableFilter())
 	assert.Equal(service.DefaultScheme, o.defaultScheme())
+
+	assert.Equal(ConsistentAlgorithm, o.algorithm())
+	assert.Equal(service.DefaultLoadFactor, o.loadFactor())
+
+	af, registry, err := o.accessorFactory()
+	assert.NotNil(af)
+	assert.Nil(registry)
+	assert.NoError(err)
 }
 
 func testOptionsCustom(t *testing.T) {
@@ -22,12 +31,55 @@ func testOptionsCustom(t *testing.T) {
 			VnodeCount:    345234,
 			DisableFilter: true,
 			DefaultScheme: "ftp",
+			Algorithm:     BoundedLoadAlgorithm,
+			LoadFactor:    1.5,
 		}
 	)
 
 	assert.Equal(345234, o.vnodeCount())
 	assert.True(o.disableFilter())
 	assert.Equal("ftp", o.defaultScheme())
+	assert.Equal(BoundedLoadAlgorithm, o.algorithm())
+	assert.Equal(1.5, o.loadFactor())
+}
+
+func testOptionsAccessorFactory(t *testing.T) {
+	testData := []struct {
+		options          Options
+		expectedRegistry bool
+		expectedError    bool
+	}{
+		{Options{}, false, false},
+		{Options{Algorithm: ConsistentAlgorithm}, false, false},
+		{Options{Algorithm: RendezvousAlgorithm}, false, false},
+		{Options{Algorithm: BoundedLoadAlgorithm}, false, false},
+		{Options{Algorithm: "nosuch"}, false, true},
+		{Options{Weighted: &Weighted{Zone: "east"}}, true, false},
+		{Options{Algorithm: RendezvousAlgorithm, Weighted: new(Weighted)}, false, true},
+	}
+
+	for i, record := range testData {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert := assert.New(t)
+			af, registry, err := record.options.accessorFactory()
+			if record.expectedError {
+				assert.Nil(af)
+				assert.Nil(registry)
+				assert.Error(err)
+				return
+			}
+
+			assert.NoError(err)
+			if assert.NotNil(af) {
+				a := af([]string{"https://an.instance.com"})
+				i, err := a.Get([]byte("test"))
+				assert.Equal("https://an.instance.com", i)
+				assert.NoError(err)
+			}
+
+			assert.Equal(record.expectedRegistry, registry != nil)
+		})
+	}
 }
 
 func TestOptions(t *testing.T) {
@@ -37,4 +89,5 @@ func TestOptions(t *testing.T) {
 	})
 
 	t.Run("Custom", testOptionsCustom)
+	t.Run("AccessorFactory", testOptionsAccessorFactory)
 }
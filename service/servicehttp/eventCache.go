@@ -0,0 +1,163 @@
+package servicehttp
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Comcast/webpa-common/service"
+	"github.com/Comcast/webpa-common/service/monitor"
+)
+
+// InstancerState is a snapshot of the most recent service discovery activity for a single instancer key.
+type InstancerState struct {
+	// Key is the in-process identifier of the instancer, as used by service.Instancers
+	Key string `json:"key"`
+
+	// Metadata is any contextual information supplied by the instancer, such as the service name or path
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// EventCount is the count of events received for this key, including errors
+	EventCount int `json:"eventCount"`
+
+	// ErrorCount is the count of error events received for this key
+	ErrorCount int `json:"errorCount"`
+
+	// Instances are the instances from the most recent event that did not carry an error
+	Instances []string `json:"instances"`
+
+	// LastUpdate is the time of the most recent event that did not carry an error.  This field is nil if no
+	// such event has occurred.
+	LastUpdate *time.Time `json:"lastUpdate,omitempty"`
+
+	// LastError is the text of the most recent error, if any
+	LastError string `json:"lastError,omitempty"`
+
+	// LastErrorTime is the time of the most recent error.  This field is nil if no error has occurred.
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+
+	// Stopped indicates whether monitoring of this key has stopped
+	Stopped bool `json:"stopped"`
+}
+
+func (is InstancerState) copy() InstancerState {
+	is.Instances = append([]string(nil), is.Instances...)
+	if is.LastUpdate != nil {
+		t := *is.LastUpdate
+		is.LastUpdate = &t
+	}
+
+	if is.LastErrorTime != nil {
+		t := *is.LastErrorTime
+		is.LastErrorTime = &t
+	}
+
+	return is
+}
+
+// EventCache is a monitor.Listener which retains the latest service discovery state for each instancer key.
+// This type is safe for concurrent use.
+type EventCache struct {
+	now func() time.Time
+
+	lock   sync.RWMutex
+	states map[string]*InstancerState
+
+	// accessors holds the accessor for each key's current instances, discarded whenever the instances change
+	accessors map[string]service.Accessor
+}
+
+// NewEventCache creates an empty EventCache, suitable for passing to monitor.WithListeners.
+func NewEventCache() *EventCache {
+	return &EventCache{
+		now:       time.Now,
+		states:    make(map[string]*InstancerState),
+		accessors: make(map[string]service.Accessor),
+	}
+}
+
+// MonitorEvent records the given event, replacing any previous state for the event's key.
+func (ec *EventCache) MonitorEvent(e monitor.Event) {
+	now := ec.now()
+
+	ec.lock.Lock()
+	defer ec.lock.Unlock()
+
+	s, ok := ec.states[e.Key]
+	if !ok {
+		s = &InstancerState{Key: e.Key}
+		ec.states[e.Key] = s
+	}
+
+	if c, ok := e.Instancer.(interface {
+		Metadata() map[string]interface{}
+	}); ok {
+		s.Metadata = c.Metadata()
+	}
+
+	switch {
+	case e.Stopped:
+		s.Stopped = true
+
+	case e.Err != nil:
+		s.EventCount++
+		s.ErrorCount++
+		s.LastError = e.Err.Error()
+		s.LastErrorTime = &now
+
+	default:
+		s.EventCount++
+		s.Instances = append([]string(nil), e.Instances...)
+		s.LastUpdate = &now
+		delete(ec.accessors, e.Key)
+	}
+}
+
+// Get returns a copy of the state for the given key, if any events have been received for that key.
+func (ec *EventCache) Get(key string) (InstancerState, bool) {
+	ec.lock.RLock()
+	defer ec.lock.RUnlock()
+
+	if s, ok := ec.states[key]; ok {
+		return s.copy(), true
+	}
+
+	return InstancerState{}, false
+}
+
+// States returns copies of all the cached states, sorted by key.
+func (ec *EventCache) States() []InstancerState {
+	ec.lock.RLock()
+	states := make([]InstancerState, 0, len(ec.states))
+	for _, s := range ec.states {
+		states = append(states, s.copy())
+	}
+
+	ec.lock.RUnlock()
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Key < states[j].Key
+	})
+
+	return states
+}
+
+// accessor returns the Accessor for the current instances of the given key.  The Accessor is created with the given
+// factory on first use after each update and reused until the next update, so callers must always pass the same factory.
+// A key with no events yields an Accessor over no instances.
+func (ec *EventCache) accessor(key string, af service.AccessorFactory) service.Accessor {
+	ec.lock.Lock()
+	defer ec.lock.Unlock()
+
+	if a, ok := ec.accessors[key]; ok {
+		return a
+	}
+
+	var instances []string
+	if s, ok := ec.states[key]; ok {
+		instances = s.Instances
+	}
+
+	a := af(instances)
+	ec.accessors[key] = a
+	return a
+}
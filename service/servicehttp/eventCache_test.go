@@ -0,0 +1,100 @@
+package servicehttp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/service"
+	"github.com/Comcast/webpa-common/service/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventCache(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		expectedErr = errors.New("expected")
+		now         = time.Now()
+		instancer   = service.NewContextualInstancer(new(service.MockInstancer), map[string]interface{}{"service": "test"})
+
+		ec = NewEventCache()
+	)
+
+	require.NotNil(ec)
+	ec.now = func() time.Time { return now }
+	assert.Empty(ec.States())
+
+	_, ok := ec.Get("test")
+	assert.False(ok)
+
+	instances := []string{"http://first.com:8080", "http://second.com:8080"}
+	ec.MonitorEvent(monitor.Event{Key: "test", Instancer: instancer, EventCount: 1, Instances: instances})
+	instances[0] = "http://changed.com:8080"
+
+	s, ok := ec.Get("test")
+	assert.True(ok)
+	assert.Equal(
+		InstancerState{
+			Key:        "test",
+			Metadata:   map[string]interface{}{"service": "test"},
+			EventCount: 1,
+			Instances:  []string{"http://first.com:8080", "http://second.com:8080"},
+			LastUpdate: &now,
+		},
+		s,
+	)
+
+	ec.MonitorEvent(monitor.Event{Key: "another", EventCount: 1, Err: expectedErr})
+	ec.MonitorEvent(monitor.Event{Key: "test", Instancer: instancer, EventCount: 2, Err: expectedErr})
+	ec.MonitorEvent(monitor.Event{Key: "test", Instancer: instancer, EventCount: 3, Stopped: true})
+
+	states := ec.States()
+	require.Len(states, 2)
+	assert.Equal("another", states[0].Key)
+	assert.Empty(states[0].Instances)
+	assert.Equal(1, states[0].ErrorCount)
+
+	assert.Equal("test", states[1].Key)
+	assert.Equal(2, states[1].EventCount)
+	assert.Equal(1, states[1].ErrorCount)
+	assert.Equal([]string{"http://first.com:8080", "http://second.com:8080"}, states[1].Instances)
+	assert.Equal(expectedErr.Error(), states[1].LastError)
+	require.NotNil(states[1].LastErrorTime)
+	assert.Equal(now, *states[1].LastErrorTime)
+	assert.True(states[1].Stopped)
+}
+
+func TestEventCacheAccessor(t *testing.T) {
+	var (
+		assert = assert.New(t)
+
+		ec      = NewEventCache()
+		created [][]string
+		af      = func(instances []string) service.Accessor {
+			created = append(created, instances)
+			return service.DefaultAccessorFactory(instances)
+		}
+	)
+
+	ec.MonitorEvent(monitor.Event{Key: "test", EventCount: 1, Instances: []string{"http://first.com:8080"}})
+	first := ec.accessor("test", af)
+	assert.True(first == ec.accessor("test", af))
+	assert.Equal([][]string{{"http://first.com:8080"}}, created)
+
+	// errors do not change the instances, so the accessor is kept
+	ec.MonitorEvent(monitor.Event{Key: "test", EventCount: 2, Err: errors.New("expected")})
+	assert.True(first == ec.accessor("test", af))
+	assert.Len(created, 1)
+
+	ec.MonitorEvent(monitor.Event{Key: "test", EventCount: 3, Instances: []string{"http://second.com:8080"}})
+	instance, err := ec.accessor("test", af).Get([]byte("key"))
+	assert.Equal("http://second.com:8080", instance)
+	assert.NoError(err)
+	assert.Equal([][]string{{"http://first.com:8080"}, {"http://second.com:8080"}}, created)
+
+	_, err = ec.accessor("nosuch", af).Get([]byte("key"))
+	assert.Error(err)
+}
@@ -0,0 +1,220 @@
+package servicehttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Comcast/webpa-common/service"
+	"github.com/Comcast/webpa-common/xhttp"
+)
+
+const (
+	// HashKeyParameter is the query parameter holding the key to hash for a hash lookup
+	HashKeyParameter = "key"
+
+	// HashCountParameter is the optional query parameter holding the number of instances, in preference order,
+	// to return for a hash lookup
+	HashCountParameter = "count"
+)
+
+// InstanceView describes a single discovered instance
+type InstanceView struct {
+	Instance   string `json:"instance"`
+	Registered bool   `json:"registered"`
+}
+
+// InstancerView describes the current state of a single instancer
+type InstancerView struct {
+	InstancerState
+	Instances []InstanceView `json:"instances"`
+}
+
+// EnvironmentView is the JSON representation of a service discovery environment
+type EnvironmentView struct {
+	DefaultScheme string          `json:"defaultScheme"`
+	Instancers    []InstancerView `json:"instancers"`
+}
+
+// HashResult is the outcome of hashing a key against a single instancer's instances
+type HashResult struct {
+	Key        string   `json:"key"`
+	Instance   string   `json:"instance,omitempty"`
+	Instances  []string `json:"instances,omitempty"`
+	Registered bool     `json:"registered"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// HashView is the JSON representation of a hash lookup
+type HashView struct {
+	Key     string       `json:"key"`
+	Results []HashResult `json:"results"`
+}
+
+// Introspection is an http.Handler that exposes what service discovery currently looks like in this process.
+// A request whose path ends with "/hash" performs a hash lookup for the key in the HashKeyParameter.  Any other
+// request returns an EnvironmentView.  Both responses are JSON.
+//
+// The Cache must be registered as a listener with the monitor for the Environment.  Instancers which have
+// not yet produced an event are still reported, but with no instances.
+//
+// Hash lookups use an Accessor created by the Environment's AccessorFactory from the cached instances.  The Accessor
+// is reused until the instances next change.  The lookup reflects the hashing done elsewhere in this process only to
+// the extent that the factory is deterministic, which is true of all the stateless Accessors.
+type Introspection struct {
+	// Environment is the service discovery environment being introspected.  This field is required.
+	Environment service.Environment
+
+	// Cache supplies the latest event for each instancer.  This field is required.
+	Cache *EventCache
+
+	// KeyParser is the optional strategy for turning the HashKeyParameter into a service.Key.  If not
+	// supplied, the parameter is hashed as is.
+	KeyParser service.KeyParser
+}
+
+func (i *Introspection) parseKey(v string) (service.Key, error) {
+	if i.KeyParser != nil {
+		return i.KeyParser(v)
+	}
+
+	return service.StringKey(v), nil
+}
+
+// states returns the cached state of each instancer known either to the environment or the cache, sorted by key
+func (i *Introspection) states() []InstancerState {
+	var (
+		states = i.Cache.States()
+		seen   = make(map[string]bool, len(states))
+	)
+
+	for _, s := range states {
+		seen[s.Key] = true
+	}
+
+	added := false
+	for k, v := range i.Environment.Instancers() {
+		if !seen[k] {
+			s := InstancerState{Key: k}
+			if c, ok := v.(interface {
+				Metadata() map[string]interface{}
+			}); ok {
+				s.Metadata = c.Metadata()
+			}
+
+			states = append(states, s)
+			added = true
+		}
+	}
+
+	if added {
+		sort.Slice(states, func(i, j int) bool {
+			return states[i].Key < states[j].Key
+		})
+	}
+
+	return states
+}
+
+func (i *Introspection) writeJSON(response http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		xhttp.WriteError(response, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Write(data)
+}
+
+func (i *Introspection) serveEnvironment(response http.ResponseWriter) {
+	view := EnvironmentView{
+		DefaultScheme: i.Environment.DefaultScheme(),
+		Instancers:    []InstancerView{},
+	}
+
+	for _, s := range i.states() {
+		iv := InstancerView{
+			InstancerState: s,
+			Instances:      make([]InstanceView, 0, len(s.Instances)),
+		}
+
+		for _, instance := range s.Instances {
+			iv.Instances = append(iv.Instances, InstanceView{
+				Instance:   instance,
+				Registered: i.Environment.IsRegistered(instance),
+			})
+		}
+
+		view.Instancers = append(view.Instancers, iv)
+	}
+
+	i.writeJSON(response, view)
+}
+
+func (i *Introspection) serveHash(response http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	v := query.Get(HashKeyParameter)
+	if len(v) == 0 {
+		xhttp.WriteErrorf(response, http.StatusBadRequest, "missing %s parameter", HashKeyParameter)
+		return
+	}
+
+	key, err := i.parseKey(v)
+	if err != nil {
+		xhttp.WriteErrorf(response, http.StatusBadRequest, "invalid key: %s", err)
+		return
+	}
+
+	count := 1
+	if c := query.Get(HashCountParameter); len(c) > 0 {
+		count, err = strconv.Atoi(c)
+		if err != nil || count < 1 {
+			xhttp.WriteErrorf(response, http.StatusBadRequest, "invalid %s parameter: %s", HashCountParameter, c)
+			return
+		}
+	}
+
+	var (
+		view = HashView{
+			Key:     string(key.Bytes()),
+			Results: []HashResult{},
+		}
+
+		accessorFactory = i.Environment.AccessorFactory()
+	)
+
+	for _, s := range i.states() {
+		result := HashResult{Key: s.Key}
+		instances, err := service.GetN(i.Cache.accessor(s.Key, accessorFactory), key.Bytes(), count)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Instance = instances[0]
+			result.Registered = i.Environment.IsRegistered(result.Instance)
+			if count > 1 {
+				result.Instances = instances
+			}
+		}
+
+		view.Results = append(view.Results, result)
+	}
+
+	i.writeJSON(response, view)
+}
+
+func (i *Introspection) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		response.Header().Set("Allow", "GET")
+		xhttp.WriteError(response, http.StatusMethodNotAllowed, "Unsupported method")
+		return
+	}
+
+	if strings.HasSuffix(request.URL.Path, "/hash") {
+		i.serveHash(response, request)
+	} else {
+		i.serveEnvironment(response)
+	}
+}
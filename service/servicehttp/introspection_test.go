@@ -0,0 +1,156 @@
+package servicehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Comcast/webpa-common/service"
+	"github.com/Comcast/webpa-common/service/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIntrospection() *Introspection {
+	var (
+		instancers service.Instancers
+		registrars service.Registrars
+		cache      = NewEventCache()
+	)
+
+	instancers.Set("first", service.NewContextualInstancer(new(service.MockInstancer), map[string]interface{}{"service": "first"}))
+	instancers.Set("second", new(service.MockInstancer))
+	registrars.Add("http://local.com:8080", new(service.MockRegistrar))
+
+	cache.MonitorEvent(monitor.Event{
+		Key:        "first",
+		EventCount: 1,
+		Instances:  []string{"http://local.com:8080", "http://remote.com:8080"},
+	})
+
+	return &Introspection{
+		Environment: service.NewEnvironment(
+			service.WithInstancers(instancers),
+			service.WithRegistrars(registrars),
+			service.WithAccessorFactory(func(instances []string) service.Accessor {
+				if len(instances) == 0 {
+					return service.EmptyAccessor()
+				}
+
+				return service.MapAccessor{"mac:112233445566": instances[0]}
+			}),
+		),
+		Cache: cache,
+	}
+}
+
+func testIntrospectionEnvironment(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		handler  = newTestIntrospection()
+		response = httptest.NewRecorder()
+		view     EnvironmentView
+	)
+
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(http.StatusOK, response.Code)
+	assert.Equal("application/json", response.HeaderMap.Get("Content-Type"))
+	require.NoError(json.Unmarshal(response.Body.Bytes(), &view))
+
+	assert.Equal(service.DefaultScheme, view.DefaultScheme)
+	require.Len(view.Instancers, 2)
+
+	assert.Equal("first", view.Instancers[0].Key)
+	assert.Equal(1, view.Instancers[0].EventCount)
+	assert.Equal(
+		[]InstanceView{
+			{Instance: "http://local.com:8080", Registered: true},
+			{Instance: "http://remote.com:8080", Registered: false},
+		},
+		view.Instancers[0].Instances,
+	)
+
+	assert.Equal("second", view.Instancers[1].Key)
+	assert.Zero(view.Instancers[1].EventCount)
+	assert.Empty(view.Instancers[1].Instances)
+}
+
+func testIntrospectionHash(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		handler  = newTestIntrospection()
+		response = httptest.NewRecorder()
+		view     HashView
+	)
+
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/introspect/hash?key=mac:112233445566", nil))
+	assert.Equal(http.StatusOK, response.Code)
+	require.NoError(json.Unmarshal(response.Body.Bytes(), &view))
+
+	assert.Equal(
+		HashView{
+			Key: "mac:112233445566",
+			Results: []HashResult{
+				{Key: "first", Instance: "http://local.com:8080", Registered: true},
+				{Key: "second", Error: "There are no instances available"},
+			},
+		},
+		view,
+	)
+}
+
+func testIntrospectionHashKeyParser(t *testing.T) {
+	var (
+		assert = assert.New(t)
+
+		handler  = newTestIntrospection()
+		response = httptest.NewRecorder()
+	)
+
+	handler.KeyParser = func(string) (service.Key, error) {
+		return nil, errors.New("expected")
+	}
+
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/hash?key=invalid", nil))
+	assert.Equal(http.StatusBadRequest, response.Code)
+}
+
+func testIntrospectionBadRequest(t *testing.T) {
+	testData := []struct {
+		method       string
+		target       string
+		expectedCode int
+	}{
+		{"GET", "/hash", http.StatusBadRequest},
+		{"GET", "/hash?key=test&count=0", http.StatusBadRequest},
+		{"GET", "/hash?key=test&count=abc", http.StatusBadRequest},
+		{"POST", "/", http.StatusMethodNotAllowed},
+	}
+
+	for _, record := range testData {
+		t.Run(record.method+record.target, func(t *testing.T) {
+			var (
+				assert = assert.New(t)
+
+				handler  = newTestIntrospection()
+				response = httptest.NewRecorder()
+			)
+
+			handler.ServeHTTP(response, httptest.NewRequest(record.method, record.target, nil))
+			assert.Equal(record.expectedCode, response.Code)
+		})
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	t.Run("Environment", testIntrospectionEnvironment)
+	t.Run("Hash", testIntrospectionHash)
+	t.Run("HashKeyParser", testIntrospectionHashKeyParser)
+	t.Run("BadRequest", testIntrospectionBadRequest)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Comcast/webpa-common/service"
+	"github.com/Comcast/webpa-common/service/servicecfg"
+)
+
+var (
+	ErrorNoSteps   = errors.New("At least one set of instances is required")
+	ErrorEmptyStep = errors.New("Each set of instances must have at least one instance")
+)
+
+type Arguments struct {
+	File       string
+	Instances  int
+	Keys       int
+	Algorithms string
+	VnodeCount int
+	LoadFactor float64
+}
+
+// Result holds the key movement and load balance of one algorithm for one set of instances
+type Result struct {
+	Moved     int
+	MaxLoad   float64
+	Deviation float64
+}
+
+func newAccessorFactory(algorithm string, arguments Arguments) (service.AccessorFactory, error) {
+	switch algorithm {
+	case servicecfg.ConsistentAlgorithm:
+		return service.NewConsistentAccessorFactory(arguments.VnodeCount), nil
+
+	case servicecfg.RendezvousAlgorithm:
+		return service.RendezvousAccessorFactory, nil
+
+	case servicecfg.BoundedLoadAlgorithm:
+		return service.NewBoundedLoadAccessorFactory(arguments.VnodeCount, arguments.LoadFactor), nil
+
+	default:
+		return nil, fmt.Errorf("Unsupported hashing algorithm: %s", algorithm)
+	}
+}
+
+// readSteps reads a JSON array of instance sets from a file.  Each set is a step in the simulation.
+func readSteps(file string) ([][]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps [][]string
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// generateSteps produces a default sequence of instance changes:  the loss of an instance,
+// its return, and then the addition of a new instance
+func generateSteps(count int) [][]string {
+	instances := make([]string, count+1)
+	for i := 0; i < len(instances); i++ {
+		instances[i] = fmt.Sprintf("https://instance%d.example.com:8080", i)
+	}
+
+	return [][]string{
+		instances[:count],
+		instances[:count-1],
+		instances[:count],
+		instances,
+	}
+}
+
+// simulate assigns each key for each step, measuring the keys that moved since the previous step
+// along with the load on each instance
+func simulate(af service.AccessorFactory, keys [][]byte, steps [][]string) ([]Result, error) {
+	var (
+		results  = make([]Result, len(steps))
+		previous = make([]string, len(keys))
+		current  = make([]string, len(keys))
+	)
+
+	for s, instances := range steps {
+		var (
+			a     = af(instances)
+			loads = make(map[string]int, len(instances))
+		)
+
+		for k, key := range keys {
+			instance, err := a.Get(key)
+			if err != nil {
+				return nil, err
+			}
+
+			current[k] = instance
+			loads[instance]++
+			if s > 0 && previous[k] != instance {
+				results[s].Moved++
+			}
+		}
+
+		var (
+			mean     = float64(len(keys)) / float64(len(instances))
+			max      = 0
+			variance = 0.0
+		)
+
+		for _, i := range instances {
+			load := loads[i]
+			if load > max {
+				max = load
+			}
+
+			variance += (float64(load) - mean) * (float64(load) - mean)
+		}
+
+		results[s].MaxLoad = float64(max) / mean
+		results[s].Deviation = math.Sqrt(variance/float64(len(instances))) / mean
+		previous, current = current, previous
+	}
+
+	return results, nil
+}
+
+func run(arguments Arguments) error {
+	var (
+		steps [][]string
+		err   error
+	)
+
+	if len(arguments.File) > 0 {
+		steps, err = readSteps(arguments.File)
+		if err != nil {
+			return err
+		}
+	} else if arguments.Instances > 1 {
+		steps = generateSteps(arguments.Instances)
+	}
+
+	for _, instances := range steps {
+		if len(instances) == 0 {
+			return ErrorEmptyStep
+		}
+	}
+
+	if len(steps) == 0 {
+		return ErrorNoSteps
+	}
+
+	keys := make([][]byte, arguments.Keys)
+	for k := 0; k < len(keys); k++ {
+		keys[k] = []byte(fmt.Sprintf("mac:%012x", k))
+	}
+
+	output := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(output, "algorithm\tstep\tinstances\tmoved\tmoved %\tmax/mean load\tstddev %")
+	for _, algorithm := range strings.Split(arguments.Algorithms, ",") {
+		algorithm = strings.TrimSpace(algorithm)
+		af, err := newAccessorFactory(algorithm, arguments)
+		if err != nil {
+			return err
+		}
+
+		results, err := simulate(af, keys, steps)
+		if err != nil {
+			return err
+		}
+
+		for s, r := range results {
+			fmt.Fprintf(
+				output,
+				"%s\t%d\t%d\t%d\t%.2f\t%.3f\t%.2f\n",
+				algorithm,
+				s,
+				len(steps[s]),
+				r.Moved,
+				100.0*float64(r.Moved)/float64(len(keys)),
+				r.MaxLoad,
+				100.0*r.Deviation,
+			)
+		}
+	}
+
+	return output.Flush()
+}
+
+func main() {
+	var arguments Arguments
+	flag.StringVar(&arguments.File, "f", "", "a JSON file containing an array of instance sets, simulated in order")
+	flag.IntVar(&arguments.Instances, "n", 10, "the number of instances used to generate a sequence of changes when no file is supplied")
+	flag.IntVar(&arguments.Keys, "k", 100000, "the number of keys to hash")
+	flag.StringVar(
+		&arguments.Algorithms,
+		"a",
+		strings.Join([]string{servicecfg.ConsistentAlgorithm, servicecfg.RendezvousAlgorithm, servicecfg.BoundedLoadAlgorithm}, ","),
+		"the comma-delimited hashing algorithms to simulate",
+	)
+
+	flag.IntVar(&arguments.VnodeCount, "v", service.DefaultVnodeCount, "the vnode count for the consistent and bounded load algorithms")
+	flag.Float64Var(&arguments.LoadFactor, "l", service.DefaultLoadFactor, "the load factor for the bounded load algorithm")
+	flag.Parse()
+
+	if err := run(arguments); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
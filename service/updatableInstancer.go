@@ -0,0 +1,97 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/go-kit/kit/sd"
+)
+
+// UpdatableInstancer is an sd.Instancer whose state is pushed to it by a service discovery backend.  It is
+// the building block for backends, such as etcd or kubernetes, that go-kit does not provide an Instancer for.
+//
+// The zero value of this type is a usable Instancer with no state.  Channels registered before the first
+// update receive nothing until that update.  Stop has no effect other than to prevent further updates, as
+// backends are expected to wrap this type with their own shutdown logic.
+type UpdatableInstancer struct {
+	lock      sync.Mutex
+	updated   bool
+	stopped   bool
+	state     sd.Event
+	listeners map[chan<- sd.Event]bool
+}
+
+// Update sets the current state of this Instancer and dispatches it to all registered channels.  Updates with
+// the same instances and error as the current state, regardless of instance order, are ignored.
+func (ui *UpdatableInstancer) Update(e sd.Event) {
+	if len(e.Instances) > 0 {
+		e.Instances = append([]string(nil), e.Instances...)
+		sort.Strings(e.Instances)
+	}
+
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	if ui.stopped || (ui.updated && sameEvent(ui.state, e)) {
+		return
+	}
+
+	ui.updated = true
+	ui.state = e
+	for l := range ui.listeners {
+		l <- e
+	}
+}
+
+// State returns the most recent event passed to Update.  The returned flag is false if there have been no updates.
+func (ui *UpdatableInstancer) State() (sd.Event, bool) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+	return ui.state, ui.updated
+}
+
+func (ui *UpdatableInstancer) Register(events chan<- sd.Event) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	if ui.listeners == nil {
+		ui.listeners = make(map[chan<- sd.Event]bool)
+	}
+
+	ui.listeners[events] = true
+	if ui.updated {
+		events <- ui.state
+	}
+}
+
+func (ui *UpdatableInstancer) Deregister(events chan<- sd.Event) {
+	ui.lock.Lock()
+	delete(ui.listeners, events)
+	ui.lock.Unlock()
+}
+
+// Stop prevents any further updates from being dispatched
+func (ui *UpdatableInstancer) Stop() {
+	ui.lock.Lock()
+	ui.stopped = true
+	ui.lock.Unlock()
+}
+
+func sameEvent(left, right sd.Event) bool {
+	switch {
+	case left.Err != right.Err:
+		return false
+
+	case len(left.Instances) != len(right.Instances):
+		return false
+
+	default:
+		for i := range left.Instances {
+			if left.Instances[i] != right.Instances[i] {
+				return false
+			}
+		}
+
+		return true
+	}
+}
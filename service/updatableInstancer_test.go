@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-kit/kit/sd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUpdatableInstancerInitial(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ui     = new(UpdatableInstancer)
+		events = make(chan sd.Event, 1)
+	)
+
+	state, updated := ui.State()
+	assert.False(updated)
+	assert.Equal(sd.Event{}, state)
+
+	ui.Register(events)
+	assert.Len(events, 0)
+
+	ui.Update(sd.Event{Instances: []string{"b", "a"}})
+	assert.Equal(sd.Event{Instances: []string{"a", "b"}}, <-events)
+
+	ui.Deregister(events)
+	ui.Update(sd.Event{Instances: []string{"c"}})
+	assert.Len(events, 0)
+}
+
+func testUpdatableInstancerUpdate(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		ui          = new(UpdatableInstancer)
+		events      = make(chan sd.Event, 10)
+		expectedErr = errors.New("expected")
+	)
+
+	ui.Update(sd.Event{Instances: []string{"a", "b"}})
+	ui.Register(events)
+	require.Len(events, 1)
+	assert.Equal(sd.Event{Instances: []string{"a", "b"}}, <-events)
+
+	// duplicates are ignored, regardless of order
+	ui.Update(sd.Event{Instances: []string{"b", "a"}})
+	assert.Len(events, 0)
+
+	ui.Update(sd.Event{Err: expectedErr})
+	require.Len(events, 1)
+	assert.Equal(sd.Event{Err: expectedErr}, <-events)
+
+	ui.Update(sd.Event{})
+	require.Len(events, 1)
+	assert.Equal(sd.Event{}, <-events)
+
+	state, updated := ui.State()
+	assert.True(updated)
+	assert.Equal(sd.Event{}, state)
+
+	ui.Stop()
+	ui.Update(sd.Event{Instances: []string{"c"}})
+	assert.Len(events, 0)
+}
+
+func TestUpdatableInstancer(t *testing.T) {
+	t.Run("Initial", testUpdatableInstancerInitial)
+	t.Run("Update", testUpdatableInstancerUpdate)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// weightedRing is a consistent hash ring in which each instance has a number of vnodes proportional to its weight
+type weightedRing struct {
+	hashes    []uint32
+	instances []string
+}
+
+func (r *weightedRing) Len() int {
+	return len(r.hashes)
+}
+
+func (r *weightedRing) Less(i, j int) bool {
+	return r.hashes[i] < r.hashes[j]
+}
+
+func (r *weightedRing) Swap(i, j int) {
+	r.hashes[i], r.hashes[j] = r.hashes[j], r.hashes[i]
+	r.instances[i], r.instances[j] = r.instances[j], r.instances[i]
+}
+
+func (r *weightedRing) add(vnodeCount int, instance string, m Metadata) {
+	for v := 0; v < vnodeCount*m.weight(); v++ {
+		r.hashes = append(r.hashes, crc32.ChecksumIEEE([]byte(instance+"-"+strconv.Itoa(v))))
+		r.instances = append(r.instances, instance)
+	}
+}
+
+// search returns the index of the first vnode at or after the hash of the given key, wrapping around the ring
+func (r *weightedRing) search(key []byte) int {
+	h := crc32.ChecksumIEEE(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return i
+}
+
+func (r *weightedRing) get(key []byte) string {
+	return r.instances[r.search(key)]
+}
+
+// walk appends distinct instances to ordered, visiting the ring clockwise from the key's hash, until
+// ordered has n instances or the ring is exhausted.  Instances already in ordered are skipped.
+func (r *weightedRing) walk(key []byte, n int, ordered []string) []string {
+	if len(r.hashes) == 0 {
+		return ordered
+	}
+
+	seen := make(map[string]bool, n)
+	for _, i := range ordered {
+		seen[i] = true
+	}
+
+	start := r.search(key)
+	for v := 0; v < len(r.hashes) && len(ordered) < n; v++ {
+		if instance := r.instances[(start+v)%len(r.hashes)]; !seen[instance] {
+			seen[instance] = true
+			ordered = append(ordered, instance)
+		}
+	}
+
+	return ordered
+}
+
+// weightedAccessor hashes keys to the instances in the local zone, falling back to all instances
+// when the local zone has no instances
+type weightedAccessor struct {
+	local  *weightedRing
+	global *weightedRing
+}
+
+func (wa *weightedAccessor) Get(key []byte) (string, error) {
+	if wa.local.Len() > 0 {
+		return wa.local.get(key), nil
+	}
+
+	return wa.global.get(key), nil
+}
+
+// GetN returns the instances in the local zone in ring order, followed by the remaining instances in ring order
+func (wa *weightedAccessor) GetN(key []byte, n int) ([]string, error) {
+	if n < 1 {
+		n = 1
+	}
+
+	return wa.global.walk(key, n, wa.local.walk(key, n, nil)), nil
+}
+
+func newWeightedAccessor(vnodeCount int, zone string, source MetadataSource, instances []string) Accessor {
+	if len(instances) == 0 {
+		return emptyAccessor{}
+	}
+
+	wa := &weightedAccessor{
+		local:  new(weightedRing),
+		global: new(weightedRing),
+	}
+
+	for _, i := range instances {
+		var m Metadata
+		if source != nil {
+			m, _ = source.Metadata(i)
+		}
+
+		wa.global.add(vnodeCount, i, m)
+		if len(zone) > 0 && m.Zone == zone {
+			wa.local.add(vnodeCount, i, m)
+		}
+	}
+
+	sort.Stable(wa.local)
+	sort.Stable(wa.global)
+	return wa
+}
+
+// NewWeightedAccessorFactory produces a factory which uses consistent hashing of server nodes, where
+// each instance is given vnodeCount vnodes for each unit of weight.  Instance weights and zones are obtained
+// from the given MetadataSource, which may be nil.  Instances without metadata have a weight of 1 and no zone.
+//
+// If zone is nonempty, keys are hashed only to instances in that zone.  When there are no instances in the zone,
+// keys are hashed to all instances.  If vnodeCount is nonpositive, DefaultVnodeCount is used.
+func NewWeightedAccessorFactory(vnodeCount int, zone string, source MetadataSource) AccessorFactory {
+	if vnodeCount < 1 {
+		vnodeCount = DefaultVnodeCount
+	}
+
+	return func(instances []string) Accessor {
+		return newWeightedAccessor(vnodeCount, zone, source, instances)
+	}
+}
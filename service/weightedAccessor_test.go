@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNewWeightedAccessorEmpty(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	for _, i := range [][]string{nil, []string{}} {
+		a := newWeightedAccessor(111, "east", nil, i)
+		require.NotNil(a)
+		i, err := a.Get([]byte("test"))
+		assert.Empty(i)
+		assert.Error(err)
+	}
+}
+
+func testNewWeightedAccessorWeights(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		source = MetadataMap{
+			"https://heavy.com": Metadata{Weight: 3},
+		}
+
+		a      = newWeightedAccessor(DefaultVnodeCount, "", source, []string{"https://heavy.com", "https://light.com"})
+		counts = make(map[string]int)
+	)
+
+	require.NotNil(a)
+	for k := 0; k < 10000; k++ {
+		i, err := a.Get([]byte(fmt.Sprintf("mac:%012x", k)))
+		require.NoError(err)
+		counts[i]++
+	}
+
+	require.Len(counts, 2)
+	ratio := float64(counts["https://heavy.com"]) / float64(counts["https://light.com"])
+	assert.True(math.Abs(ratio-3.0) < 0.75, "expected a ratio near 3, got %f", ratio)
+}
+
+func testNewWeightedAccessorMaxWeight(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		source = MetadataMap{
+			"https://huge.com": Metadata{Weight: 100000},
+		}
+	)
+
+	a, ok := newWeightedAccessor(10, "", source, []string{"https://huge.com", "https://light.com"}).(*weightedAccessor)
+	require.True(ok)
+	assert.Equal(10*MaxWeight+10, a.global.Len())
+}
+
+func testNewWeightedAccessorZone(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		source = MetadataMap{
+			"https://east1.com": Metadata{Zone: "east"},
+			"https://east2.com": Metadata{Zone: "east"},
+			"https://west.com":  Metadata{Zone: "west"},
+		}
+
+		all      = []string{"https://east1.com", "https://east2.com", "https://west.com", "https://nozone.com"}
+		outage   = []string{"https://west.com", "https://nozone.com"}
+		keys     = []string{"a", "alsdkjfa;lksehjuro8iwurjhf", "asdf8974", "875kjh4", "928375hjdfgkyu9832745kjshdfgoi873465"}
+		af       = NewWeightedAccessorFactory(-1, "east", source)
+		global   = NewWeightedAccessorFactory(0, "", source)(outage)
+		fallback = af(outage)
+		local    = af(all)
+	)
+
+	require.NotNil(local)
+	require.NotNil(fallback)
+	for _, k := range keys {
+		i, err := local.Get([]byte(k))
+		assert.NoError(err)
+		assert.Contains([]string{"https://east1.com", "https://east2.com"}, i)
+
+		// when the zone has no instances, hashing must match a zone-agnostic ring
+		expected, err := global.Get([]byte(k))
+		require.NoError(err)
+		i, err = fallback.Get([]byte(k))
+		assert.NoError(err)
+		assert.Equal(expected, i)
+	}
+}
+
+func testNewWeightedAccessorConsistency(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		af     = NewWeightedAccessorFactory(DefaultVnodeCount, "", nil)
+		before = af([]string{"https://one.com", "https://two.com", "https://three.com"})
+		after  = af([]string{"https://one.com", "https://two.com"})
+	)
+
+	for k := 0; k < 1000; k++ {
+		key := []byte(fmt.Sprintf("mac:%012x", k))
+		b, err := before.Get(key)
+		require.NoError(err)
+
+		a, err := after.Get(key)
+		require.NoError(err)
+
+		if b != "https://three.com" {
+			assert.Equal(b, a, "key %s was moved unnecessarily", key)
+		}
+	}
+}
+
+func testNewWeightedAccessorGetN(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		source = MetadataMap{
+			"https://east1.com": Metadata{Zone: "east", Weight: 2},
+			"https://east2.com": Metadata{Zone: "east"},
+			"https://west.com":  Metadata{Zone: "west"},
+		}
+
+		instances = []string{"https://east1.com", "https://east2.com", "https://west.com", "https://nozone.com"}
+		a         = NewWeightedAccessorFactory(0, "east", source)(instances)
+	)
+
+	testOrderedAccessor(t, a, instances)
+
+	ordered, err := a.(OrderedAccessor).GetN([]byte("test"), len(instances))
+	require.NoError(err)
+	assert.ElementsMatch([]string{"https://east1.com", "https://east2.com"}, ordered[:2])
+}
+
+func TestNewWeightedAccessor(t *testing.T) {
+	t.Run("Empty", testNewWeightedAccessorEmpty)
+	t.Run("Weights", testNewWeightedAccessorWeights)
+	t.Run("MaxWeight", testNewWeightedAccessorMaxWeight)
+	t.Run("Zone", testNewWeightedAccessorZone)
+	t.Run("Consistency", testNewWeightedAccessorConsistency)
+	t.Run("GetN", testNewWeightedAccessorGetN)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/go-kit/kit/log/level"
 	"github.com/go-kit/kit/sd"
 	gokitzk "github.com/go-kit/kit/sd/zk"
+	"github.com/samuel/go-zookeeper/zk"
 )
 
 func newService(r Registration) (string, gokitzk.Service) {
@@ -19,22 +20,46 @@ func newService(r Registration) (string, gokitzk.Service) {
 	return url, gokitzk.Service{
 		Path: r.path(),
 		Name: r.name(),
-		Data: []byte(url),
+		Data: []byte(service.FormatInstanceMetadata(url, r.metadata())),
 	}
 }
 
+// metadataClient decorates a go-kit zookeeper Client so that any metadata encoded in znode payloads
+// is recorded and stripped from the discovered instances
+type metadataClient struct {
+	gokitzk.Client
+	registry *service.MetadataRegistry
+}
+
+func (mc metadataClient) GetEntries(path string) ([]string, <-chan zk.Event, error) {
+	entries, events, err := mc.Client.GetEntries(path)
+	for i, e := range entries {
+		instance, m, _ := service.SplitInstanceMetadata(e)
+		mc.registry.Set(instance, m)
+		entries[i] = instance
+	}
+
+	return entries, events, err
+}
+
 // clientFactory is the factory function used to create a go-kit zookeeper Client.
 // Tests can change this for mocked behavior.
 var clientFactory = gokitzk.NewClient
 
 func newClient(l log.Logger, zo Options) (gokitzk.Client, error) {
 	client := zo.client()
-	return clientFactory(
+	c, err := clientFactory(
 		client.servers(),
 		l,
 		gokitzk.ConnectTimeout(client.connectTimeout()),
 		gokitzk.SessionTimeout(client.sessionTimeout()),
 	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return metadataClient{Client: c, registry: zo.metadata()}, nil
 }
 
 func newInstancer(l log.Logger, c gokitzk.Client, path string) (i sd.Instancer, err error) {
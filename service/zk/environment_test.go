@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/service"
 	"github.com/go-kit/kit/log"
 	gokitzk "github.com/go-kit/kit/sd/zk"
 	"github.com/samuel/go-zookeeper/zk"
@@ -177,3 +178,53 @@ func TestNewEnvironment(t *testing.T) {
 	t.Run("InstancerError", testNewEnvironmentInstancerError)
 	t.Run("Full", testNewEnvironmentFull)
 }
+
+func TestNewServiceMetadata(t *testing.T) {
+	var (
+		assert = assert.New(t)
+
+		instance, s = newService(Registration{
+			Name:    "foobar",
+			Address: "foobar.net",
+			Port:    1717,
+			Scheme:  "https",
+			Weight:  3,
+			Zone:    "east",
+		})
+	)
+
+	assert.Equal("https://foobar.net:1717", instance)
+	assert.Equal("https://foobar.net:1717#weight=3&zone=east", string(s.Data))
+}
+
+func TestMetadataClient(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		require  = require.New(t)
+		client   = new(mockClient)
+		zkEvents = make(chan zk.Event, 5)
+		registry = service.NewMetadataRegistry()
+
+		mc = metadataClient{Client: client, registry: registry}
+	)
+
+	client.On("GetEntries", "/test").Return(
+		[]string{"https://instance1.net:1717#weight=2&zone=east", "https://instance2.net:1717"},
+		(<-chan zk.Event)(zkEvents),
+		error(nil),
+	).Once()
+
+	entries, events, err := mc.GetEntries("/test")
+	require.NoError(err)
+	assert.Equal([]string{"https://instance1.net:1717", "https://instance2.net:1717"}, entries)
+	assert.Equal((<-chan zk.Event)(zkEvents), events)
+
+	m, ok := registry.Metadata("https://instance1.net:1717")
+	assert.True(ok)
+	assert.Equal(service.Metadata{Weight: 2, Zone: "east"}, m)
+
+	_, ok = registry.Metadata("https://instance2.net:1717")
+	assert.False(ok)
+
+	client.AssertExpectations(t)
+}
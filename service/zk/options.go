@@ -3,6 +3,8 @@ package zk
 import (
 	"strings"
 	"time"
+
+	"github.com/Comcast/webpa-common/service"
 )
 
 const (
@@ -32,6 +34,12 @@ type Registration struct {
 
 	// Scheme specific the protocl used for the service.  If not supplied, DefaultScheme is used.
 	Scheme string `json:"scheme,omitempty"`
+
+	// Weight is the relative hashing weight of the service, advertised in the znode payload.  This field is optional.
+	Weight int `json:"weight,omitempty"`
+
+	// Zone is the zone in which the service runs, advertised in the znode payload.  This field is optional.
+	Zone string `json:"zone,omitempty"`
 }
 
 func (r Registration) name() string {
@@ -74,6 +82,10 @@ func (r Registration) scheme() string {
 	return DefaultScheme
 }
 
+func (r Registration) metadata() service.Metadata {
+	return service.Metadata{Weight: r.Weight, Zone: r.Zone}
+}
+
 // Client is the client portion of the options struct
 type Client struct {
 	// Connection is the comma-delimited Zookeeper connection string.  Both this and
@@ -140,6 +152,9 @@ type Options struct {
 
 	// Watches are the zookeeper paths to watch for updates.  There is no default for this field.
 	Watches []string `json:"watches,omitempty"`
+
+	// Metadata receives the weight and zone advertised by each watched instance.  This field is optional.
+	Metadata *service.MetadataRegistry `json:"-"`
 }
 
 func (o *Options) client() *Client {
@@ -158,6 +173,14 @@ func (o *Options) registrations() []Registration {
 	return nil
 }
 
+func (o *Options) metadata() *service.MetadataRegistry {
+	if o != nil {
+		return o.Metadata
+	}
+
+	return nil
+}
+
 func (o *Options) watches() []string {
 	if o != nil && len(o.Watches) > 0 {
 		return o.Watches
@@ -0,0 +1,15 @@
+package tracing
+
+// Exporter ships finished spans to a tracing backend, such as a Zipkin or OpenTelemetry collector.
+// Spans are exported as they finish, so implementations must be safe for concurrent use and should
+// not block.  Typically, an Exporter queues spans and sends them in batches.
+type Exporter interface {
+	Export(...Span)
+}
+
+// ExporterFunc is a function type that implements Exporter
+type ExporterFunc func(...Span)
+
+func (ef ExporterFunc) Export(spans ...Span) {
+	ef(spans...)
+}
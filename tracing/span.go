@@ -23,6 +23,19 @@ type Span interface {
 	Error() error
 }
 
+// TracedSpan is a Span which participates in a distributed trace.  Spans produced by a Spanner
+// created with NewSpanner implement this interface.
+type TracedSpan interface {
+	Span
+
+	// SpanContext identifies this span and its trace
+	SpanContext() SpanContext
+
+	// ParentSpanID is the identifier of this span's parent.  This will be invalid, i.e. all zeroes,
+	// for the root span of a trace.
+	ParentSpanID() SpanID
+}
+
 // span is the internal Span implementation
 type span struct {
 	name     string
@@ -30,6 +43,9 @@ type span struct {
 	duration time.Duration
 	err      error
 
+	context SpanContext
+	parent  SpanID
+
 	state uint32
 }
 
@@ -49,6 +65,14 @@ func (s *span) Error() error {
 	return s.err
 }
 
+func (s *span) SpanContext() SpanContext {
+	return s.context
+}
+
+func (s *span) ParentSpanID() SpanID {
+	return s.parent
+}
+
 func (s *span) finish(duration time.Duration, err error) bool {
 	if atomic.CompareAndSwapUint32(&s.state, 0, 1) {
 		s.duration = duration
@@ -1,6 +1,7 @@
 package tracing
 
 import (
+	"context"
 	"time"
 )
 
@@ -14,6 +15,32 @@ type Spanner interface {
 	Start(string) func(error) Span
 }
 
+// ChildSpanner is a Spanner which can start spans within an existing distributed trace.  Spanners
+// created with NewSpanner implement this interface.
+type ChildSpanner interface {
+	Spanner
+
+	// StartChild begins a new, unfinished span as a child of the given parent.  If the parent is not
+	// valid, the new span is the root of a new trace.  The returned SpanContext identifies the new span,
+	// and can be propagated to other services before the span is finished.
+	StartChild(parent SpanContext, name string) (SpanContext, func(error) Span)
+}
+
+// StartSpan begins a new span as a child of the SpanContext carried by ctx, if any.  The returned context
+// carries the new span's SpanContext, so that spans started from it are children of the new span.
+//
+// If the Spanner does not implement ChildSpanner, the span is started with Start and ctx is returned as is.
+func StartSpan(ctx context.Context, s Spanner, name string) (context.Context, func(error) Span) {
+	cs, ok := s.(ChildSpanner)
+	if !ok {
+		return ctx, s.Start(name)
+	}
+
+	parent, _ := SpanContextFromContext(ctx)
+	child, finisher := cs.StartChild(parent, name)
+	return WithSpanContext(ctx, child), finisher
+}
+
 // SpannerOption supplies a configuration option to a Spanner.
 type SpannerOption func(*spanner)
 
@@ -38,8 +65,20 @@ func Since(since func(time.Time) time.Duration) SpannerOption {
 	}
 }
 
+// ExportTo sets an Exporter on a spanner.  Each sampled span is exported when it is finished.
+// If e is nil, this option does nothing.
+func ExportTo(e Exporter) SpannerOption {
+	return func(sp *spanner) {
+		if e != nil {
+			sp.exporter = e
+		}
+	}
+}
+
 // NewSpanner constructs a new Spanner with the given options.  By default, a Spanner
 // will use time.Now() to get the current time and time.Since() to compute durations.
+//
+// The returned Spanner also implements ChildSpanner.
 func NewSpanner(o ...SpannerOption) Spanner {
 	sp := &spanner{
 		now:   time.Now,
@@ -55,18 +94,50 @@ func NewSpanner(o ...SpannerOption) Spanner {
 
 // spanner is the internal spanner implementation.
 type spanner struct {
-	now   func() time.Time
-	since func(time.Time) time.Duration
+	now      func() time.Time
+	since    func(time.Time) time.Duration
+	exporter Exporter
 }
 
+// Start begins a span outside of any distributed trace.  Identifiers are only generated when an exporter
+// is configured, since otherwise the span can be neither exported nor propagated.  Without an exporter, the
+// returned span's SpanContext is therefore invalid.
 func (sp *spanner) Start(name string) func(error) Span {
+	if sp.exporter == nil {
+		return sp.finisher(&span{name: name, start: sp.now()})
+	}
+
+	_, finisher := sp.StartChild(SpanContext{}, name)
+	return finisher
+}
+
+func (sp *spanner) StartChild(parent SpanContext, name string) (SpanContext, func(error) Span) {
 	s := &span{
 		name:  name,
 		start: sp.now(),
 	}
 
+	if parent.IsValid() {
+		s.parent = parent.SpanID
+		s.context = SpanContext{
+			TraceID: parent.TraceID,
+			SpanID:  NewSpanID(),
+			Sampled: parent.Sampled,
+		}
+	} else {
+		s.context = newRootSpanContext()
+	}
+
+	return s.context, sp.finisher(s)
+}
+
+// finisher returns the closure which finishes, and possibly exports, the given span
+func (sp *spanner) finisher(s *span) func(error) Span {
 	return func(err error) Span {
-		s.finish(sp.since(s.start), err)
+		if s.finish(sp.since(s.start), err) && sp.exporter != nil && s.context.Sampled {
+			sp.exporter.Export(s)
+		}
+
 		return s
 	}
 }
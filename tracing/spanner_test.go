@@ -1,6 +1,7 @@
 package tracing
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
@@ -92,3 +93,125 @@ func TestSpanner(t *testing.T) {
 	assert.Equal(expectedDuration, span.Duration())
 	assert.Equal(expectedError, span.Error())
 }
+
+func TestSpannerStartIdentifiers(t *testing.T) {
+	assert := assert.New(t)
+
+	// without an exporter, spans outside of a trace are never identified
+	untraced := NewSpanner().Start("untraced")(nil).(TracedSpan)
+	assert.False(untraced.SpanContext().IsValid())
+	assert.False(untraced.ParentSpanID().IsValid())
+
+	traced := NewSpanner(ExportTo(ExporterFunc(func(...Span) {}))).Start("traced")(nil).(TracedSpan)
+	assert.True(traced.SpanContext().IsValid())
+	assert.True(traced.SpanContext().Sampled)
+}
+
+func TestSpannerStartChild(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		sp     = NewSpanner()
+		parent = SpanContext{TraceID: NewTraceID(), SpanID: NewSpanID(), Sampled: false}
+	)
+
+	cs, ok := sp.(ChildSpanner)
+	require.True(ok)
+
+	root, rootFinisher := cs.StartChild(SpanContext{}, "root")
+	assert.True(root.IsValid())
+	assert.True(root.Sampled)
+
+	rootSpan, ok := rootFinisher(nil).(TracedSpan)
+	require.True(ok)
+	assert.Equal(root, rootSpan.SpanContext())
+	assert.False(rootSpan.ParentSpanID().IsValid())
+
+	child, childFinisher := cs.StartChild(parent, "child")
+	assert.Equal(parent.TraceID, child.TraceID)
+	assert.NotEqual(parent.SpanID, child.SpanID)
+	assert.False(child.Sampled)
+
+	childSpan, ok := childFinisher(nil).(TracedSpan)
+	require.True(ok)
+	assert.Equal("child", childSpan.Name())
+	assert.Equal(child, childSpan.SpanContext())
+	assert.Equal(parent.SpanID, childSpan.ParentSpanID())
+}
+
+func TestStartSpan(t *testing.T) {
+	t.Run("ChildSpanner", func(t *testing.T) {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+
+			sp = NewSpanner()
+		)
+
+		parentCtx, parentFinisher := StartSpan(context.Background(), sp, "parent")
+		parent, ok := SpanContextFromContext(parentCtx)
+		require.True(ok)
+
+		childCtx, childFinisher := StartSpan(parentCtx, sp, "child")
+		child, ok := SpanContextFromContext(childCtx)
+		require.True(ok)
+
+		assert.Equal(parent.TraceID, child.TraceID)
+		assert.Equal(parent.SpanID, childFinisher(nil).(TracedSpan).ParentSpanID())
+		assert.Equal(parent, parentFinisher(nil).(TracedSpan).SpanContext())
+	})
+
+	t.Run("Spanner", func(t *testing.T) {
+		var (
+			assert = assert.New(t)
+
+			expected = &span{name: "test"}
+			sp       = spannerFunc(func(name string) func(error) Span {
+				assert.Equal("test", name)
+				return func(error) Span { return expected }
+			})
+
+			ctx = context.WithValue(context.Background(), testContextKey{}, "value")
+		)
+
+		actualCtx, finisher := StartSpan(ctx, sp, "test")
+		assert.Equal(ctx, actualCtx)
+		assert.Equal(expected, finisher(nil))
+	})
+}
+
+type testContextKey struct{}
+
+// spannerFunc is a Spanner which does not implement ChildSpanner
+type spannerFunc func(string) func(error) Span
+
+func (sf spannerFunc) Start(name string) func(error) Span {
+	return sf(name)
+}
+
+func TestExportTo(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		exported []Span
+		sp       = NewSpanner(ExportTo(ExporterFunc(func(spans ...Span) {
+			exported = append(exported, spans...)
+		})))
+	)
+
+	finisher := sp.Start("sampled")
+	s := finisher(nil)
+	finisher(errors.New("this should not export again"))
+	require.Len(exported, 1)
+	assert.Equal(s, exported[0])
+
+	_, finisher = sp.(ChildSpanner).StartChild(SpanContext{TraceID: NewTraceID(), SpanID: NewSpanID()}, "unsampled")
+	finisher(nil)
+	assert.Len(exported, 1)
+
+	assert.NotPanics(func() {
+		NewSpanner(ExportTo(nil)).Start("nil")(nil)
+	})
+}
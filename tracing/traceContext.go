@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	// traceParentVersion is the only version of the W3C traceparent format this package emits
+	traceParentVersion = "00"
+
+	// sampledFlag is the trace-flags bit indicating that the caller may have recorded the trace
+	sampledFlag = "01"
+	notSampled  = "00"
+)
+
+var ErrorInvalidTraceParent = errors.New("Invalid traceparent value")
+
+// TraceID identifies a distributed trace.  It is the 16-byte trace-id of the W3C Trace Context specification.
+type TraceID [16]byte
+
+// NewTraceID generates a random TraceID
+func NewTraceID() TraceID {
+	var t TraceID
+	rand.Read(t[:])
+	return t
+}
+
+// IsValid tests if this TraceID has at least one nonzero byte
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String returns the lowercase hex representation of this TraceID
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span within a trace.  It is the 8-byte parent-id of the W3C Trace Context specification.
+type SpanID [8]byte
+
+// NewSpanID generates a random SpanID
+func NewSpanID() SpanID {
+	var s SpanID
+	rand.Read(s[:])
+	return s
+}
+
+// IsValid tests if this SpanID has at least one nonzero byte
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String returns the lowercase hex representation of this SpanID
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is the portion of a span that is propagated across process boundaries
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+
+	// Sampled indicates that the span should be exported
+	Sampled bool
+}
+
+// newRootSpanContext generates the identifiers of a new, sampled trace using a single read of random data
+func newRootSpanContext() SpanContext {
+	var (
+		sc  = SpanContext{Sampled: true}
+		ids [len(sc.TraceID) + len(sc.SpanID)]byte
+	)
+
+	rand.Read(ids[:])
+	copy(sc.TraceID[:], ids[:len(sc.TraceID)])
+	copy(sc.SpanID[:], ids[len(sc.TraceID):])
+	return sc
+}
+
+// IsValid tests if both the TraceID and SpanID are valid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// TraceParent returns the W3C traceparent header value for this SpanContext, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (sc SpanContext) TraceParent() string {
+	flags := notSampled
+	if sc.Sampled {
+		flags = sampledFlag
+	}
+
+	return traceParentVersion + "-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+func decodeHex(dst []byte, value string) bool {
+	if len(value) != 2*len(dst) || strings.ToLower(value) != value {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(value))
+	return err == nil
+}
+
+// ParseTraceParent parses a W3C traceparent header value.  Values with versions later than 00 are
+// parsed as version 00, ignoring any additional fields, as the specification requires.
+func ParseTraceParent(value string) (SpanContext, error) {
+	var (
+		sc      SpanContext
+		version [1]byte
+		flags   [1]byte
+		fields  = strings.Split(strings.TrimSpace(value), "-")
+	)
+
+	if len(fields) < 4 ||
+		!decodeHex(version[:], fields[0]) ||
+		version[0] == 0xff ||
+		(version[0] == 0 && len(fields) != 4) ||
+		!decodeHex(sc.TraceID[:], fields[1]) ||
+		!decodeHex(sc.SpanID[:], fields[2]) ||
+		!decodeHex(flags[:], fields[3]) ||
+		!sc.IsValid() {
+		return SpanContext{}, ErrorInvalidTraceParent
+	}
+
+	sc.Sampled = flags[0]&0x01 != 0
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// WithSpanContext returns a context carrying the given SpanContext.  Spans started from the
+// returned context via StartSpan are children of the given SpanContext.
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the SpanContext previously stored with WithSpanContext, if any
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTraceID(t *testing.T) {
+	assert := assert.New(t)
+	first, second := NewTraceID(), NewTraceID()
+	assert.True(first.IsValid())
+	assert.True(second.IsValid())
+	assert.NotEqual(first, second)
+	assert.Len(first.String(), 32)
+	assert.False(TraceID{}.IsValid())
+}
+
+func TestNewSpanID(t *testing.T) {
+	assert := assert.New(t)
+	first, second := NewSpanID(), NewSpanID()
+	assert.True(first.IsValid())
+	assert.True(second.IsValid())
+	assert.NotEqual(first, second)
+	assert.Len(first.String(), 16)
+	assert.False(SpanID{}.IsValid())
+}
+
+func testParseTraceParentValid(t *testing.T) {
+	testData := []struct {
+		value           string
+		expectedSampled bool
+		expectedValue   string
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", false, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{" 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03 ", true, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", true, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}
+
+	for _, record := range testData {
+		t.Run(record.value, func(t *testing.T) {
+			assert := assert.New(t)
+			sc, err := ParseTraceParent(record.value)
+			assert.NoError(err)
+			assert.True(sc.IsValid())
+			assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+			assert.Equal("00f067aa0ba902b7", sc.SpanID.String())
+			assert.Equal(record.expectedSampled, sc.Sampled)
+			assert.Equal(record.expectedValue, sc.TraceParent())
+		})
+	}
+}
+
+func testParseTraceParentInvalid(t *testing.T) {
+	testData := []string{
+		"",
+		"garbage",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902zz-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+	}
+
+	for _, value := range testData {
+		t.Run(value, func(t *testing.T) {
+			assert := assert.New(t)
+			sc, err := ParseTraceParent(value)
+			assert.Equal(ErrorInvalidTraceParent, err)
+			assert.Equal(SpanContext{}, sc)
+		})
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	t.Run("Valid", testParseTraceParentValid)
+	t.Run("Invalid", testParseTraceParentInvalid)
+}
+
+func TestSpanContextFromContext(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	sc, ok := SpanContextFromContext(nil)
+	assert.False(ok)
+	assert.Equal(SpanContext{}, sc)
+
+	sc, ok = SpanContextFromContext(context.Background())
+	assert.False(ok)
+
+	sc, ok = SpanContextFromContext(WithSpanContext(context.Background(), SpanContext{}))
+	assert.False(ok)
+
+	expected := SpanContext{TraceID: NewTraceID(), SpanID: NewSpanID(), Sampled: true}
+	sc, ok = SpanContextFromContext(WithSpanContext(context.Background(), expected))
+	require.True(ok)
+	assert.Equal(expected, sc)
+}
@@ -2,6 +2,7 @@ package tracinghttp
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"time"
@@ -13,8 +14,50 @@ import (
 const (
 	SpanHeader  = "X-Xmidt-Span"
 	ErrorHeader = "X-Xmidt-Error"
+
+	// TraceParentHeader is the W3C Trace Context header carrying the caller's span
+	TraceParentHeader = "traceparent"
 )
 
+// ExtractTraceParent is a go-kit server RequestFunc that adds the SpanContext from the request's traceparent
+// header to the context.  Spans started from the returned context via tracing.StartSpan are children of the
+// caller's span.  If the header is missing or invalid, ctx is returned as is.
+func ExtractTraceParent(ctx context.Context, request *http.Request) context.Context {
+	value := request.Header.Get(TraceParentHeader)
+	if len(value) == 0 {
+		return ctx
+	}
+
+	sc, err := tracing.ParseTraceParent(value)
+	if err != nil {
+		return ctx
+	}
+
+	return tracing.WithSpanContext(ctx, sc)
+}
+
+// InjectTraceParent is a go-kit client RequestFunc that sets the traceparent header of an outbound request
+// from the SpanContext in the context, if any.
+func InjectTraceParent(ctx context.Context, request *http.Request) context.Context {
+	if sc, ok := tracing.SpanContextFromContext(ctx); ok {
+		request.Header.Set(TraceParentHeader, sc.TraceParent())
+	}
+
+	return ctx
+}
+
+// TraceParent is an Alice-style constructor that decorates an http.Handler so that each request's
+// context carries the SpanContext from its traceparent header, as with ExtractTraceParent.
+func TraceParent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if ctx := ExtractTraceParent(request.Context(), request); ctx != request.Context() {
+			request = request.WithContext(ctx)
+		}
+
+		next.ServeHTTP(response, request)
+	})
+}
+
 // HeadersForSpans emits header information for each Span.  The timeLayout may be empty, in which case time.RFC3339 is used.
 // All times are converted to UTC prior to formatting.
 func HeadersForSpans(timeLayout string, h http.Header, spans ...tracing.Span) {
@@ -1,15 +1,18 @@
 package tracinghttp
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/Comcast/webpa-common/tracing"
 	"github.com/Comcast/webpa-common/xhttp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHeadersForSpans(t *testing.T) {
@@ -58,3 +61,69 @@ func TestHeadersForSpans(t *testing.T) {
 		assert.Equal(record.expectedHeader, actualHeader)
 	}
 }
+
+func TestExtractTraceParent(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	t.Run("Valid", func(t *testing.T) {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+			request = httptest.NewRequest("GET", "/", nil)
+		)
+
+		request.Header.Set(TraceParentHeader, traceParent)
+		sc, ok := tracing.SpanContextFromContext(ExtractTraceParent(context.Background(), request))
+		require.True(ok)
+		assert.Equal(traceParent, sc.TraceParent())
+	})
+
+	for _, value := range []string{"", "invalid"} {
+		t.Run("Missing or invalid: "+value, func(t *testing.T) {
+			var (
+				assert  = assert.New(t)
+				request = httptest.NewRequest("GET", "/", nil)
+				ctx     = context.Background()
+			)
+
+			request.Header.Set(TraceParentHeader, value)
+			assert.Equal(ctx, ExtractTraceParent(ctx, request))
+		})
+	}
+}
+
+func TestInjectTraceParent(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		request = httptest.NewRequest("GET", "/", nil)
+		sc      = tracing.SpanContext{TraceID: tracing.NewTraceID(), SpanID: tracing.NewSpanID(), Sampled: true}
+	)
+
+	InjectTraceParent(context.Background(), request)
+	assert.Empty(request.Header.Get(TraceParentHeader))
+
+	InjectTraceParent(tracing.WithSpanContext(context.Background(), sc), request)
+	assert.Equal(sc.TraceParent(), request.Header.Get(TraceParentHeader))
+}
+
+func TestTraceParent(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		expected = tracing.SpanContext{TraceID: tracing.NewTraceID(), SpanID: tracing.NewSpanID(), Sampled: true}
+		actual   tracing.SpanContext
+		ok       bool
+
+		decorated = TraceParent(http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
+			actual, ok = tracing.SpanContextFromContext(request.Context())
+		}))
+
+		request = httptest.NewRequest("GET", "/", nil)
+	)
+
+	request.Header.Set(TraceParentHeader, expected.TraceParent())
+	decorated.ServeHTTP(httptest.NewRecorder(), request)
+	require.True(ok)
+	assert.Equal(expected, actual)
+}
@@ -0,0 +1,297 @@
+/*
+Package zipkin provides a tracing.Exporter which sends spans to a Zipkin-compatible collector using the
+Zipkin v2 JSON format.  Most OpenTelemetry collectors accept this format via their zipkin receiver.
+*/
+package zipkin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/tracing"
+	"github.com/Comcast/webpa-common/types"
+	"github.com/go-kit/kit/log"
+	gokithttp "github.com/go-kit/kit/transport/http"
+)
+
+const (
+	DefaultURL           = "http://localhost:9411/api/v2/spans"
+	DefaultServiceName   = "webpa"
+	DefaultQueueSize     = 1000
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+	DefaultTimeout       = 10 * time.Second
+)
+
+// Options describes the configuration of an Exporter.  All fields are optional.
+type Options struct {
+	// URL is the collector's span endpoint.  If unset, DefaultURL is used.
+	URL string `json:"url"`
+
+	// ServiceName is the local service name reported with each span.  If unset, DefaultServiceName is used.
+	ServiceName string `json:"serviceName"`
+
+	// QueueSize is the maximum number of spans waiting to be sent.  Spans exported while the queue is
+	// full are dropped.  If nonpositive, DefaultQueueSize is used.
+	QueueSize int `json:"queueSize"`
+
+	// BatchSize is the maximum number of spans sent in a single request.  If nonpositive, DefaultBatchSize is used.
+	BatchSize int `json:"batchSize"`
+
+	// FlushInterval is how often queued spans are sent.  If nonpositive, DefaultFlushInterval is used.
+	FlushInterval types.Duration `json:"flushInterval"`
+
+	// Timeout is the HTTP client timeout used when Client is unset.  If nonpositive, DefaultTimeout is used.
+	Timeout types.Duration `json:"timeout"`
+
+	// Client is the HTTP client used to send spans.  If unset, a client with the Timeout is used.
+	Client *http.Client `json:"-"`
+
+	// Logger is used to report failures to send spans.  If unset, logging.DefaultLogger() is used.
+	Logger log.Logger `json:"-"`
+}
+
+func (o *Options) url() string {
+	if o != nil && len(o.URL) > 0 {
+		return o.URL
+	}
+
+	return DefaultURL
+}
+
+func (o *Options) serviceName() string {
+	if o != nil && len(o.ServiceName) > 0 {
+		return o.ServiceName
+	}
+
+	return DefaultServiceName
+}
+
+func (o *Options) queueSize() int {
+	if o != nil && o.QueueSize > 0 {
+		return o.QueueSize
+	}
+
+	return DefaultQueueSize
+}
+
+func (o *Options) batchSize() int {
+	if o != nil && o.BatchSize > 0 {
+		return o.BatchSize
+	}
+
+	return DefaultBatchSize
+}
+
+func (o *Options) flushInterval() time.Duration {
+	if o != nil && o.FlushInterval > 0 {
+		return time.Duration(o.FlushInterval)
+	}
+
+	return DefaultFlushInterval
+}
+
+func (o *Options) client() *http.Client {
+	if o != nil && o.Client != nil {
+		return o.Client
+	}
+
+	timeout := DefaultTimeout
+	if o != nil && o.Timeout > 0 {
+		timeout = time.Duration(o.Timeout)
+	}
+
+	return &http.Client{Timeout: timeout}
+}
+
+func (o *Options) logger() log.Logger {
+	if o != nil && o.Logger != nil {
+		return o.Logger
+	}
+
+	return logging.DefaultLogger()
+}
+
+// Endpoint is the Zipkin v2 model of a network endpoint
+type Endpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// Span is the Zipkin v2 model of a span.  Timestamps and durations are in microseconds.
+type Span struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint *Endpoint         `json:"localEndpoint,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+// NewSpan converts a tracing.Span into the Zipkin model.  Spans which do not implement tracing.TracedSpan
+// are given random identifiers.  A span's error, including any HTTP status code, is reported in tags.
+func NewSpan(serviceName string, s tracing.Span) Span {
+	var (
+		sc     tracing.SpanContext
+		parent tracing.SpanID
+	)
+
+	if ts, ok := s.(tracing.TracedSpan); ok {
+		sc, parent = ts.SpanContext(), ts.ParentSpanID()
+	} else {
+		sc = tracing.SpanContext{TraceID: tracing.NewTraceID(), SpanID: tracing.NewSpanID()}
+	}
+
+	zs := Span{
+		TraceID:       sc.TraceID.String(),
+		ID:            sc.SpanID.String(),
+		Name:          s.Name(),
+		Timestamp:     s.Start().UnixNano() / int64(time.Microsecond),
+		Duration:      int64(s.Duration() / time.Microsecond),
+		LocalEndpoint: &Endpoint{ServiceName: serviceName},
+	}
+
+	if parent.IsValid() {
+		zs.ParentID = parent.String()
+	}
+
+	if err := s.Error(); err != nil {
+		zs.Tags = map[string]string{"error": err.Error()}
+		if coder, ok := err.(gokithttp.StatusCoder); ok {
+			zs.Tags["http.status_code"] = fmt.Sprintf("%d", coder.StatusCode())
+		}
+	}
+
+	return zs
+}
+
+// Exporter is a tracing.Exporter which queues spans and sends them to a Zipkin collector in batches.
+// Export never blocks.  Queued spans are sent periodically while Run is active, and on demand via Flush.
+type Exporter struct {
+	url           string
+	serviceName   string
+	batchSize     int
+	flushInterval time.Duration
+	client        *http.Client
+	logger        log.Logger
+
+	queue     chan tracing.Span
+	flushLock sync.Mutex
+}
+
+// NewExporter creates an Exporter from the given options, which may be nil
+func NewExporter(o *Options) *Exporter {
+	return &Exporter{
+		url:           o.url(),
+		serviceName:   o.serviceName(),
+		batchSize:     o.batchSize(),
+		flushInterval: o.flushInterval(),
+		client:        o.client(),
+		logger:        o.logger(),
+		queue:         make(chan tracing.Span, o.queueSize()),
+	}
+}
+
+// Export queues spans to be sent.  Spans that do not fit in the queue are dropped.
+func (e *Exporter) Export(spans ...tracing.Span) {
+	for _, s := range spans {
+		select {
+		case e.queue <- s:
+		default:
+			logging.Error(e.logger).Log(logging.MessageKey(), "span queue full, dropping span", "name", s.Name())
+		}
+	}
+}
+
+// Flush sends all queued spans, in batches.  The first error encountered is returned.  The spans
+// in the failed batch are dropped, while any spans remaining in the queue are left for the next flush.
+func (e *Exporter) Flush() error {
+	e.flushLock.Lock()
+	defer e.flushLock.Unlock()
+
+	for {
+		batch := make([]Span, 0, e.batchSize)
+	Batch:
+		for len(batch) < e.batchSize {
+			select {
+			case s := <-e.queue:
+				batch = append(batch, NewSpan(e.serviceName, s))
+			default:
+				break Batch
+			}
+		}
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := e.send(batch); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *Exporter) send(batch []Span) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	response, err := e.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("Zipkin collector returned status code %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// Run implements concurrent.Runnable.  Queued spans are flushed every flush interval until shutdown
+// is closed, at which point a final flush is made.
+func (e *Exporter) Run(waitGroup *sync.WaitGroup, shutdown <-chan struct{}) error {
+	waitGroup.Add(1)
+	go func() {
+		defer waitGroup.Done()
+
+		ticker := time.NewTicker(e.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdown:
+				e.flush()
+				return
+			case <-ticker.C:
+				e.flush()
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (e *Exporter) flush() {
+	if err := e.Flush(); err != nil {
+		logging.Error(e.logger).Log(logging.MessageKey(), "unable to send spans", "url", e.url, logging.ErrorKey(), err)
+	}
+}
@@ -0,0 +1,213 @@
+package zipkin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/tracing"
+	"github.com/Comcast/webpa-common/types"
+	"github.com/Comcast/webpa-common/xhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector is a stub Zipkin collector which records the batches it receives
+type collector struct {
+	lock       sync.Mutex
+	batches    [][]Span
+	statusCode int
+}
+
+func (c *collector) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if request.Method != "POST" || request.Header.Get("Content-Type") != "application/json" {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var batch []Span
+	if err := json.NewDecoder(request.Body).Decode(&batch); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.batches = append(c.batches, batch)
+	if c.statusCode > 0 {
+		response.WriteHeader(c.statusCode)
+	} else {
+		response.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (c *collector) received() [][]Span {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([][]Span(nil), c.batches...)
+}
+
+func TestNewSpan(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		start  = time.Unix(1500000000, 123456789)
+
+		sp = tracing.NewSpanner(
+			tracing.Now(func() time.Time { return start }),
+			tracing.Since(func(time.Time) time.Duration { return 1500 * time.Microsecond }),
+		)
+
+		parent, parentFinisher = sp.(tracing.ChildSpanner).StartChild(tracing.SpanContext{}, "parent")
+		child, childFinisher   = sp.(tracing.ChildSpanner).StartChild(parent, "child")
+	)
+
+	assert.Equal(
+		Span{
+			TraceID:       parent.TraceID.String(),
+			ID:            parent.SpanID.String(),
+			Name:          "parent",
+			Timestamp:     1500000000123456,
+			Duration:      1500,
+			LocalEndpoint: &Endpoint{ServiceName: "test"},
+		},
+		NewSpan("test", parentFinisher(nil)),
+	)
+
+	assert.Equal(
+		Span{
+			TraceID:       child.TraceID.String(),
+			ID:            child.SpanID.String(),
+			ParentID:      parent.SpanID.String(),
+			Name:          "child",
+			Timestamp:     1500000000123456,
+			Duration:      1500,
+			LocalEndpoint: &Endpoint{ServiceName: "test"},
+			Tags:          map[string]string{"error": "expected", "http.status_code": "503"},
+		},
+		NewSpan("test", childFinisher(&xhttp.Error{Code: 503, Text: "expected"})),
+	)
+}
+
+func TestExporterFlush(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		c      = new(collector)
+		server = httptest.NewServer(c)
+
+		exporter = NewExporter(&Options{
+			URL:         server.URL,
+			ServiceName: "test",
+			BatchSize:   2,
+			QueueSize:   4,
+			Logger:      logging.NewTestLogger(nil, t),
+		})
+
+		sp = tracing.NewSpanner(tracing.ExportTo(exporter))
+	)
+
+	defer server.Close()
+
+	require.NoError(exporter.Flush())
+	assert.Empty(c.received())
+
+	for _, name := range []string{"one", "two", "three", "four", "dropped"} {
+		sp.Start(name)(nil)
+	}
+
+	require.NoError(exporter.Flush())
+
+	batches := c.received()
+	require.Len(batches, 2)
+	require.Len(batches[0], 2)
+	require.Len(batches[1], 2)
+
+	var names []string
+	for _, batch := range batches {
+		for _, s := range batch {
+			assert.Equal("test", s.LocalEndpoint.ServiceName)
+			assert.Len(s.TraceID, 32)
+			assert.Len(s.ID, 16)
+			names = append(names, s.Name)
+		}
+	}
+
+	assert.Equal([]string{"one", "two", "three", "four"}, names)
+}
+
+func TestExporterFlushError(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		c      = &collector{statusCode: http.StatusInternalServerError}
+		server = httptest.NewServer(c)
+
+		exporter = NewExporter(&Options{
+			URL:    server.URL,
+			Logger: logging.NewTestLogger(nil, t),
+		})
+	)
+
+	defer server.Close()
+
+	exporter.Export(tracing.NewSpanner().Start("test")(errors.New("expected")))
+	assert.Error(exporter.Flush())
+
+	batches := c.received()
+	require.Len(batches, 1)
+	require.Len(batches[0], 1)
+	assert.Equal("expected", batches[0][0].Tags["error"])
+}
+
+func TestExporterRun(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		c      = new(collector)
+		server = httptest.NewServer(c)
+
+		exporter = NewExporter(&Options{
+			URL:           server.URL,
+			FlushInterval: types.Duration(time.Hour),
+			Logger:        logging.NewTestLogger(nil, t),
+		})
+
+		waitGroup = new(sync.WaitGroup)
+		shutdown  = make(chan struct{})
+	)
+
+	defer server.Close()
+
+	require.NoError(exporter.Run(waitGroup, shutdown))
+	exporter.Export(tracing.NewSpanner().Start("test")(nil))
+	close(shutdown)
+	waitGroup.Wait()
+
+	batches := c.received()
+	require.Len(batches, 1)
+	require.Len(batches[0], 1)
+	assert.Equal("test", batches[0][0].Name)
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, o := range []*Options{nil, new(Options)} {
+		assert.Equal(DefaultURL, o.url())
+		assert.Equal(DefaultServiceName, o.serviceName())
+		assert.Equal(DefaultQueueSize, o.queueSize())
+		assert.Equal(DefaultBatchSize, o.batchSize())
+		assert.Equal(DefaultFlushInterval, o.flushInterval())
+		assert.Equal(DefaultTimeout, o.client().Timeout)
+		assert.NotNil(o.logger())
+	}
+}
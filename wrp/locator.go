@@ -0,0 +1,70 @@
+package wrp
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidLocator = errors.New("Invalid WRP locator")
+
+// Locator is the parsed form of a WRP source or destination, which has the general
+// form scheme:authority[/service[/ignored]].  For example, mac:112233445566/config/foo
+// has a scheme of mac, an authority of 112233445566, a service of config, and an
+// ignored part of /foo.
+type Locator struct {
+	// Scheme is the part of the locator before the first colon, e.g. mac, uuid, dns, serial, or event
+	Scheme string
+
+	// Authority identifies the entity within the scheme, e.g. a MAC address or DNS name
+	Authority string
+
+	// Service is the optional service name following the authority, without any slashes
+	Service string
+
+	// Ignored is whatever follows the service, including its leading slash
+	Ignored string
+}
+
+// ParseLocator parses a WRP locator.  The scheme is lowercased, but the remaining parts are
+// returned as is.  A locator must have a nonempty scheme and authority.
+func ParseLocator(value string) (Locator, error) {
+	i := strings.IndexByte(value, ':')
+	if i < 1 {
+		return Locator{}, ErrInvalidLocator
+	}
+
+	l := Locator{Scheme: strings.ToLower(value[:i])}
+	rest := value[i+1:]
+	if j := strings.IndexByte(rest, '/'); j >= 0 {
+		l.Authority = rest[:j]
+		rest = rest[j+1:]
+		if k := strings.IndexByte(rest, '/'); k >= 0 {
+			l.Service = rest[:k]
+			l.Ignored = rest[k:]
+		} else {
+			l.Service = rest
+		}
+	} else {
+		l.Authority = rest
+	}
+
+	if len(l.Authority) == 0 {
+		return Locator{}, ErrInvalidLocator
+	}
+
+	return l, nil
+}
+
+// ID returns the scheme:authority portion of this locator, which identifies a device or other entity
+func (l Locator) ID() string {
+	return l.Scheme + ":" + l.Authority
+}
+
+// String returns the full string form of this locator
+func (l Locator) String() string {
+	if len(l.Service) == 0 {
+		return l.ID()
+	}
+
+	return l.ID() + "/" + l.Service + l.Ignored
+}
@@ -0,0 +1,45 @@
+package wrp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLocator(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		testData = []struct {
+			value    string
+			expected Locator
+			id       string
+		}{
+			{"mac:112233445566", Locator{Scheme: "mac", Authority: "112233445566"}, "mac:112233445566"},
+			{"MAC:112233445566/config", Locator{Scheme: "mac", Authority: "112233445566", Service: "config"}, "mac:112233445566"},
+			{"dns:talaria.com/config/foo/bar", Locator{Scheme: "dns", Authority: "talaria.com", Service: "config", Ignored: "/foo/bar"}, "dns:talaria.com"},
+			{"event:device-status/mac:112233445566/online", Locator{Scheme: "event", Authority: "device-status", Service: "mac:112233445566", Ignored: "/online"}, "event:device-status"},
+			{"uuid:1234/", Locator{Scheme: "uuid", Authority: "1234"}, "uuid:1234"},
+		}
+	)
+
+	for _, record := range testData {
+		actual, err := ParseLocator(record.value)
+		assert.NoError(err)
+		assert.Equal(record.expected, actual)
+		assert.Equal(record.id, actual.ID())
+	}
+
+	for _, invalid := range []string{"", "mac", ":112233445566", "mac:", "mac:/config"} {
+		actual, err := ParseLocator(invalid)
+		assert.Equal(ErrInvalidLocator, err)
+		assert.Equal(Locator{}, actual)
+	}
+}
+
+func TestLocatorString(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("mac:112233445566", Locator{Scheme: "mac", Authority: "112233445566"}.String())
+	assert.Equal("mac:112233445566/config", Locator{Scheme: "mac", Authority: "112233445566", Service: "config"}.String())
+	assert.Equal("dns:foo.com/config/bar", Locator{Scheme: "dns", Authority: "foo.com", Service: "config", Ignored: "/bar"}.String())
+}
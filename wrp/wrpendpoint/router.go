@@ -0,0 +1,279 @@
+package wrpendpoint
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+// NotDeliveredRDR is the request delivery response used in the responses produced by a Router for
+// requests that match no route
+const NotDeliveredRDR int64 = 1
+
+type varsKey struct{}
+
+// WithVars returns a new context containing the given route variables
+func WithVars(parent context.Context, vars map[string]string) context.Context {
+	return context.WithValue(parent, varsKey{}, vars)
+}
+
+// Vars returns the variables extracted from a request's destination by a Router.  If the
+// matching route had no variables, this function returns nil.
+func Vars(ctx context.Context) map[string]string {
+	vars, _ := ctx.Value(varsKey{}).(map[string]string)
+	return vars
+}
+
+// Router is a Service that dispatches requests to other Services based on the message type and the
+// destination of each request.  Routes are evaluated in the order they are added, and the first
+// matching route handles the request.
+//
+// The destination path used for matching is formed from the service and ignored parts of the
+// request's destination locator.  For example, a destination of mac:112233445566/config/foo has
+// a service of config and a destination path of /config/foo.
+//
+// Requests that match no route produce a response with a 404 status, or a 405 status if a route
+// matched the destination but not the message type.  The response is addressed back to the sender,
+// with NotDeliveredRDR as its request delivery response.
+type Router struct {
+	routes   []*Route
+	notFound Service
+}
+
+// NewRouter creates an empty Router
+func NewRouter() *Router {
+	return new(Router)
+}
+
+// Handle adds a route for the given Service.  The returned Route matches all requests until it
+// is further constrained.
+func (r *Router) Handle(s Service) *Route {
+	route := &Route{service: s}
+	r.routes = append(r.routes, route)
+	return route
+}
+
+// HandleFunc adds a route for the given function
+func (r *Router) HandleFunc(f func(context.Context, Request) (Response, error)) *Route {
+	return r.Handle(ServiceFunc(f))
+}
+
+// NotFound sets a custom Service used for requests that match no route.  If not set, a WRP
+// response with a 404 or 405 status is produced.
+func (r *Router) NotFound(s Service) {
+	r.notFound = s
+}
+
+func (r *Router) ServeWRP(ctx context.Context, request Request) (Response, error) {
+	var (
+		destination = request.Destination()
+		locator, _  = wrp.ParseLocator(destination)
+		path        = destinationPath(locator)
+		messageType wrp.MessageType
+		hasType     = false
+		mismatch    = false
+	)
+
+	if m := request.Message(); m != nil {
+		messageType, hasType = m.Type, true
+	}
+
+	for _, route := range r.routes {
+		vars, ok := route.matchDestination(locator, path)
+		if !ok {
+			continue
+		}
+
+		if hasType && !route.matchType(messageType) {
+			mismatch = true
+			continue
+		}
+
+		if len(vars) > 0 {
+			ctx = WithVars(ctx, vars)
+		}
+
+		return route.service.ServeWRP(ctx, request)
+	}
+
+	if r.notFound != nil {
+		return r.notFound.ServeWRP(ctx, request)
+	}
+
+	if mismatch {
+		return unroutable(request, http.StatusMethodNotAllowed)
+	}
+
+	return unroutable(request, http.StatusNotFound)
+}
+
+// unroutable produces the response for a request that matched no route
+func unroutable(request Request, status int64) (Response, error) {
+	m := request.Message()
+	if m == nil {
+		return nil, fmt.Errorf("No route for destination %s", request.Destination())
+	}
+
+	response := m.Response(m.Destination, NotDeliveredRDR).(*wrp.Message)
+	response.SetStatus(status)
+	response.ContentType = ""
+	return WrapAsResponse(response), nil
+}
+
+// destinationPath produces the path used to match path patterns
+func destinationPath(l wrp.Locator) string {
+	if len(l.Service) == 0 {
+		return "/"
+	}
+
+	return "/" + l.Service + l.Ignored
+}
+
+// Route describes which requests are dispatched to a Service.  All configured constraints must match.
+type Route struct {
+	service Service
+	types   map[wrp.MessageType]bool
+	name    string
+	path    *regexp.Regexp
+	vars    []string
+}
+
+// Types constrains this route to the given message types
+func (rt *Route) Types(types ...wrp.MessageType) *Route {
+	if rt.types == nil {
+		rt.types = make(map[wrp.MessageType]bool, len(types))
+	}
+
+	for _, t := range types {
+		rt.types[t] = true
+	}
+
+	return rt
+}
+
+// Service constrains this route to destinations with the given locator service, e.g. config
+func (rt *Route) Service(name string) *Route {
+	rt.name = name
+	return rt
+}
+
+// Path constrains this route to destination paths matching the given pattern.  The pattern must match
+// the entire destination path.  Variables are declared with braces, in the same manner as gorilla/mux,
+// e.g. /config/{name} or /iot/{id:[0-9]+}.  A variable with no regular expression matches a single path
+// segment.  Matched variables are available to the Service via Vars.
+//
+// This method panics if the pattern is invalid.
+func (rt *Route) Path(pattern string) *Route {
+	expression, vars, err := compilePathPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	rt.path = expression
+	rt.vars = vars
+	return rt
+}
+
+func (rt *Route) matchType(t wrp.MessageType) bool {
+	return len(rt.types) == 0 || rt.types[t]
+}
+
+func (rt *Route) matchDestination(l wrp.Locator, path string) (map[string]string, bool) {
+	if len(rt.name) > 0 && rt.name != l.Service {
+		return nil, false
+	}
+
+	if rt.path == nil {
+		return nil, true
+	}
+
+	match := rt.path.FindStringSubmatch(path)
+	if match == nil {
+		return nil, false
+	}
+
+	var vars map[string]string
+	if len(rt.vars) > 0 {
+		vars = make(map[string]string, len(rt.vars))
+		for i, name := range rt.vars {
+			vars[name] = match[i+1]
+		}
+	}
+
+	return vars, true
+}
+
+// compilePathPattern turns a path pattern into an anchored regular expression
+func compilePathPattern(pattern string) (*regexp.Regexp, []string, error) {
+	var (
+		expression bytes.Buffer
+		vars       []string
+		remaining  = pattern
+	)
+
+	expression.WriteString("^")
+	for len(remaining) > 0 {
+		start := strings.IndexByte(remaining, '{')
+		if start < 0 {
+			expression.WriteString(regexp.QuoteMeta(remaining))
+			break
+		}
+
+		end := matchingBrace(remaining, start)
+		if end < 0 {
+			return nil, nil, fmt.Errorf("Unbalanced braces in path pattern: %s", pattern)
+		}
+
+		expression.WriteString(regexp.QuoteMeta(remaining[:start]))
+
+		name, varPattern := remaining[start+1:end], "[^/]+"
+		if colon := strings.IndexByte(name, ':'); colon >= 0 {
+			name, varPattern = name[:colon], name[colon+1:]
+		}
+
+		if len(name) == 0 || len(varPattern) == 0 {
+			return nil, nil, fmt.Errorf("Invalid variable in path pattern: %s", pattern)
+		}
+
+		// each variable must be exactly one submatch, so capturing groups are not allowed
+		varExpression, err := regexp.Compile(varPattern)
+		if err != nil {
+			return nil, nil, err
+		} else if varExpression.NumSubexp() > 0 {
+			return nil, nil, fmt.Errorf("Variable patterns cannot contain capturing groups: %s", pattern)
+		}
+
+		expression.WriteString("(")
+		expression.WriteString(varPattern)
+		expression.WriteString(")")
+		vars = append(vars, name)
+		remaining = remaining[end+1:]
+	}
+
+	expression.WriteString("$")
+	compiled, err := regexp.Compile(expression.String())
+	return compiled, vars, err
+}
+
+// matchingBrace returns the index of the brace that closes the one at start, or -1 if there is none
+func matchingBrace(value string, start int) int {
+	depth := 0
+	for i := start; i < len(value); i++ {
+		switch value[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
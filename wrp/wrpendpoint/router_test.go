@@ -0,0 +1,142 @@
+package wrpendpoint
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRouterRequest(t *testing.T, m *wrp.Message) Request {
+	return WrapAsRequest(logging.NewTestLogger(nil, t), m)
+}
+
+func TestRouter(t *testing.T) {
+	var (
+		router = NewRouter()
+		served []string
+		vars   []map[string]string
+
+		service = func(name string) func(context.Context, Request) (Response, error) {
+			return func(ctx context.Context, r Request) (Response, error) {
+				served = append(served, name)
+				vars = append(vars, Vars(ctx))
+				return WrapAsResponse(new(wrp.Message)), nil
+			}
+		}
+	)
+
+	router.HandleFunc(service("config")).Service("config").Types(wrp.SimpleRequestResponseMessageType)
+	router.HandleFunc(service("iot")).Path("/iot/{thing}/{id:[0-9]+}").Types(wrp.CreateMessageType, wrp.RetrieveMessageType)
+	router.HandleFunc(service("iot-any")).Path("/iot/{rest:.*}")
+	router.HandleFunc(service("event")).Service("event").Types(wrp.SimpleEventMessageType)
+
+	testData := []struct {
+		message      wrp.Message
+		expectedName string
+		expectedVars map[string]string
+	}{
+		{wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Destination: "mac:112233445566/config"}, "config", nil},
+		{wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Destination: "mac:112233445566/config/foo/bar"}, "config", nil},
+		{wrp.Message{Type: wrp.CreateMessageType, Destination: "mac:112233445566/iot/light/12"}, "iot", map[string]string{"thing": "light", "id": "12"}},
+		{wrp.Message{Type: wrp.UpdateMessageType, Destination: "mac:112233445566/iot/light/12"}, "iot-any", map[string]string{"rest": "light/12"}},
+		{wrp.Message{Type: wrp.RetrieveMessageType, Destination: "mac:112233445566/iot/light/abc"}, "iot-any", map[string]string{"rest": "light/abc"}},
+		{wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "dns:foo.com/event"}, "event", nil},
+	}
+
+	for _, record := range testData {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+		)
+
+		served, vars = nil, nil
+		response, err := router.ServeWRP(context.Background(), testRouterRequest(t, &record.message))
+		require.NoError(err)
+		require.NotNil(response)
+		assert.Equal([]string{record.expectedName}, served)
+		assert.Equal([]map[string]string{record.expectedVars}, vars)
+	}
+}
+
+func TestRouterUnroutable(t *testing.T) {
+	var (
+		router = NewRouter()
+		called = false
+	)
+
+	router.HandleFunc(func(context.Context, Request) (Response, error) {
+		called = true
+		return nil, nil
+	}).Service("config").Types(wrp.SimpleRequestResponseMessageType)
+
+	testData := []struct {
+		message        wrp.Message
+		expectedStatus int64
+	}{
+		{wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Source: "dns:foo.com", Destination: "mac:112233445566/iot", TransactionUUID: "1", ContentType: "text/plain", Payload: []byte("foo")}, http.StatusNotFound},
+		{wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Source: "dns:foo.com", Destination: "mac:112233445566", TransactionUUID: "2"}, http.StatusNotFound},
+		{wrp.Message{Type: wrp.CreateMessageType, Source: "dns:foo.com", Destination: "mac:112233445566/config", TransactionUUID: "3"}, http.StatusMethodNotAllowed},
+	}
+
+	for _, record := range testData {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+		)
+
+		response, err := router.ServeWRP(context.Background(), testRouterRequest(t, &record.message))
+		require.NoError(err)
+		require.NotNil(response)
+
+		m := response.Message()
+		require.NotNil(m)
+		assert.Equal(record.message.Type, m.Type)
+		assert.Equal(record.message.TransactionUUID, m.TransactionUUID)
+		assert.Equal(record.message.Destination, m.Source)
+		assert.Equal("dns:foo.com", m.Destination)
+		require.NotNil(m.Status)
+		assert.Equal(record.expectedStatus, *m.Status)
+		require.NotNil(m.RequestDeliveryResponse)
+		assert.Equal(NotDeliveredRDR, *m.RequestDeliveryResponse)
+		assert.Empty(m.ContentType)
+		assert.Empty(m.Payload)
+	}
+
+	assert.False(t, called)
+}
+
+func TestRouterNotFound(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		router   = NewRouter()
+		expected = WrapAsResponse(new(wrp.Message))
+		service  = new(mockService)
+		request  = testRouterRequest(t, &wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:foo"})
+	)
+
+	service.On("ServeWRP", context.Background(), request).Return(expected, nil).Once()
+	router.NotFound(service)
+
+	actual, err := router.ServeWRP(context.Background(), request)
+	assert.Equal(expected, actual)
+	assert.NoError(err)
+	service.AssertExpectations(t)
+}
+
+func TestRoutePathInvalid(t *testing.T) {
+	for _, pattern := range []string{"/iot/{", "/iot/{}", "/iot/{id:}", "/iot/{id:(}", "/iot/{id:([0-9]+)}"} {
+		assert.Panics(t, func() { NewRouter().Handle(new(mockService)).Path(pattern) }, pattern)
+	}
+}
+
+func TestVars(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(Vars(context.Background()))
+	assert.Equal(map[string]string{"foo": "bar"}, Vars(WithVars(context.Background(), map[string]string{"foo": "bar"})))
+}
@@ -93,8 +93,16 @@ func ClientEncodeRequestHeaders(custom http.Header) gokithttp.EncodeRequestFunc
 	}
 }
 
+// writeStatusCode writes the status code for a WRP response that implements go-kit's StatusCoder.  Any other
+// response is left with the default 200 status.
+func writeStatusCode(httpResponse http.ResponseWriter, wrpResponse wrpendpoint.Response) {
+	if sc, ok := wrpResponse.(gokithttp.StatusCoder); ok {
+		httpResponse.WriteHeader(sc.StatusCode())
+	}
+}
+
 // ServerEncodeResponseBody produces a go-kit transport/http.EncodeResponseFunc that transforms a wrphttp.Response into
-// an HTTP response.
+// an HTTP response.  If the response implements go-kit's StatusCoder, that status code is used.
 func ServerEncodeResponseBody(timeLayout string, format wrp.Format) gokithttp.EncodeResponseFunc {
 	return func(ctx context.Context, httpResponse http.ResponseWriter, value interface{}) error {
 		var (
@@ -109,18 +117,28 @@ func ServerEncodeResponseBody(timeLayout string, format wrp.Format) gokithttp.En
 		}
 
 		httpResponse.Header().Set("Content-Type", format.ContentType())
+		writeStatusCode(httpResponse, wrpResponse)
 		_, err := output.WriteTo(httpResponse)
 		return err
 	}
 }
 
 // ServerEncodeResponseHeaders encodes a WRP response's fields into the HTTP response's headers.  The payload
-// is written as the HTTP response body.
+// is written as the HTTP response body.  If the response implements go-kit's StatusCoder, that status code is used.
 func ServerEncodeResponseHeaders(timeLayout string) gokithttp.EncodeResponseFunc {
 	return func(ctx context.Context, httpResponse http.ResponseWriter, value interface{}) error {
 		wrpResponse := value.(wrpendpoint.Response)
 		tracinghttp.HeadersForSpans(timeLayout, httpResponse.Header(), wrpResponse.Spans()...)
 		AddMessageHeaders(httpResponse.Header(), wrpResponse.Message())
-		return WriteMessagePayload(httpResponse.Header(), httpResponse, wrpResponse.Message())
+
+		// the payload headers must be set before any status code is written
+		var payload bytes.Buffer
+		if err := WriteMessagePayload(httpResponse.Header(), &payload, wrpResponse.Message()); err != nil {
+			return err
+		}
+
+		writeStatusCode(httpResponse, wrpResponse)
+		_, err := payload.WriteTo(httpResponse)
+		return err
 	}
 }
@@ -246,6 +246,31 @@ func testServerEncodeResponseBodyEncodeError(t *testing.T, format wrp.Format) {
 	wrpResponse.AssertExpectations(t)
 }
 
+func testServerEncodeResponseBodyStatusCode(t *testing.T, format wrp.Format) {
+	var (
+		assert          = assert.New(t)
+		expectedPayload = []byte("expected payload")
+		httpResponse    = httptest.NewRecorder()
+		wrpResponse     = new(mockStatusCodeResponse)
+	)
+
+	wrpResponse.On("Spans").Return([]tracing.Span{})
+	wrpResponse.On("StatusCode").Return(http.StatusAccepted).Once()
+	wrpResponse.On("Encode", mock.MatchedBy(func(io.Writer) bool { return true }), format).
+		Run(func(arguments mock.Arguments) {
+			output := arguments.Get(0).(io.Writer)
+			output.Write(expectedPayload)
+		}).
+		Return(error(nil)).Once()
+
+	assert.NoError(ServerEncodeResponseBody("", format)(context.Background(), httpResponse, wrpResponse))
+	assert.Equal(http.StatusAccepted, httpResponse.Code)
+	assert.Equal(format.ContentType(), httpResponse.HeaderMap.Get("Content-Type"))
+	assert.Equal(expectedPayload, httpResponse.Body.Bytes())
+
+	wrpResponse.AssertExpectations(t)
+}
+
 func TestServerEncodeResponseBody(t *testing.T) {
 	for _, format := range wrp.AllFormats() {
 		t.Run(format.String(), func(t *testing.T) {
@@ -253,6 +278,10 @@ func TestServerEncodeResponseBody(t *testing.T) {
 				testServerEncodeResponseBodySuccess(t, format)
 			})
 
+			t.Run("StatusCode", func(t *testing.T) {
+				testServerEncodeResponseBodyStatusCode(t, format)
+			})
+
 			t.Run("EncodeError", func(t *testing.T) {
 				testServerEncodeResponseBodyEncodeError(t, format)
 			})
@@ -316,7 +345,37 @@ func testServerEncodeResponseHeadersWithPayload(t *testing.T) {
 	wrpResponse.AssertExpectations(t)
 }
 
+func testServerEncodeResponseHeadersStatusCode(t *testing.T) {
+	var (
+		assert = assert.New(t)
+
+		message = wrp.Message{
+			Type:        wrp.SimpleEventMessageType,
+			Source:      "test",
+			Destination: "mac:121212121212",
+			Payload:     []byte("expected payload"),
+			ContentType: "text/plain",
+		}
+
+		wrpResponse  = new(mockStatusCodeResponse)
+		httpResponse = httptest.NewRecorder()
+	)
+
+	wrpResponse.On("Spans").Return([]tracing.Span{})
+	wrpResponse.On("Message").Return(&message).Twice()
+	wrpResponse.On("StatusCode").Return(http.StatusAccepted).Once()
+
+	assert.NoError(ServerEncodeResponseHeaders("")(context.Background(), httpResponse, wrpResponse))
+	assert.Equal(http.StatusAccepted, httpResponse.Code)
+	assert.Equal(wrp.SimpleEventMessageType.FriendlyName(), httpResponse.HeaderMap.Get(MessageTypeHeader))
+	assert.Equal("text/plain", httpResponse.HeaderMap.Get("Content-Type"))
+	assert.Equal("expected payload", httpResponse.Body.String())
+
+	wrpResponse.AssertExpectations(t)
+}
+
 func TestServerEncodeResponseHeaders(t *testing.T) {
 	t.Run("NoPayload", testServerEncodeResponseHeadersNoPayload)
 	t.Run("WithPayload", testServerEncodeResponseHeadersWithPayload)
+	t.Run("StatusCode", testServerEncodeResponseHeadersStatusCode)
 }
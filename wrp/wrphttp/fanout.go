@@ -9,6 +9,7 @@ import (
 	"github.com/Comcast/webpa-common/middleware"
 	"github.com/Comcast/webpa-common/middleware/fanout"
 	"github.com/Comcast/webpa-common/tracing"
+	"github.com/Comcast/webpa-common/tracing/tracinghttp"
 	"github.com/Comcast/webpa-common/transport/transporthttp"
 	"github.com/Comcast/webpa-common/wrp"
 	"github.com/Comcast/webpa-common/xhttp"
@@ -202,7 +203,7 @@ func NewFanoutEndpoint(o *FanoutOptions) (endpoint.Endpoint, error) {
 				url,
 				ClientEncodeRequestBody(wrp.Msgpack, customHeader),
 				ClientDecodeResponseBody(wrp.Msgpack),
-				gokithttp.SetClient(httpClient), gokithttp.ClientBefore(transporthttp.GetBody(logger)),
+				gokithttp.SetClient(httpClient), gokithttp.ClientBefore(transporthttp.GetBody(logger), tracinghttp.InjectTraceParent),
 			).Endpoint()
 	}
 
@@ -5,9 +5,11 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
 	"github.com/Comcast/webpa-common/wrp"
 )
 
@@ -22,17 +24,150 @@ const (
 	SourceHeader                  = "X-Xmidt-Source"
 	DestinationHeader             = "X-Webpa-Device-Name"
 	AcceptHeader                  = "X-Xmidt-Accept"
+	HeadersHeader                 = "X-Xmidt-Headers"
+	MetadataHeader                = "X-Xmidt-Metadata"
+	PartnerIdHeader               = "X-Xmidt-Partner-Id"
+	ServiceNameHeader             = "X-Xmidt-Service-Name"
+	URLHeader                     = "X-Xmidt-Url"
 )
 
 var (
 	errMissingMessageTypeHeader = fmt.Errorf("Missing %s header", MessageTypeHeader)
 )
 
-// getMessageType extracts the wrp.MessageType from header.  This is a required field.
+// headerField describes how a single WRP message field maps onto HTTP headers.  The name is the
+// header emitted by AddMessageHeaders.  The legacy name, if set, is the older X-Midt-* header which
+// is still accepted by SetMessageFromHeaders when the primary header is absent.
+//
+// A get function may panic to indicate that a header was present but malformed.
+type headerField struct {
+	name   string
+	legacy string
+	get    func(values []string, m *wrp.Message)
+	set    func(h http.Header, name string, m *wrp.Message)
+}
+
+// values returns the header values for this field, falling back to the legacy header if necessary
+func (hf headerField) values(h http.Header) []string {
+	if v := h[hf.name]; len(v) > 0 {
+		return v
+	}
+
+	if len(hf.legacy) > 0 {
+		return h[hf.legacy]
+	}
+
+	return nil
+}
+
+// messageFields is the complete mapping between wrp.Message fields and HTTP headers.  The payload and
+// content type are not part of this table, as they are carried by the HTTP entity and its Content-Type.
+var messageFields = []headerField{
+	{
+		name:   MessageTypeHeader,
+		legacy: wrp.MsgTypeHeader,
+		get:    func(v []string, m *wrp.Message) { m.Type = getMessageType(v) },
+		set: func(h http.Header, n string, m *wrp.Message) {
+			h.Set(n, m.Type.FriendlyName())
+		},
+	},
+	{
+		name:   SourceHeader,
+		legacy: wrp.SourceHeader,
+		get:    func(v []string, m *wrp.Message) { m.Source = getStringHeader(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setStringHeader(h, n, m.Source) },
+	},
+	{
+		name: DestinationHeader,
+		get:  func(v []string, m *wrp.Message) { m.Destination = getStringHeader(v) },
+		set:  func(h http.Header, n string, m *wrp.Message) { setStringHeader(h, n, m.Destination) },
+	},
+	{
+		name:   TransactionUuidHeader,
+		legacy: wrp.TransactionUuidHeader,
+		get:    func(v []string, m *wrp.Message) { m.TransactionUUID = getStringHeader(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setStringHeader(h, n, m.TransactionUUID) },
+	},
+	{
+		name:   StatusHeader,
+		legacy: wrp.StatusHeader,
+		get:    func(v []string, m *wrp.Message) { m.Status = getIntHeader(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setIntHeader(h, n, m.Status) },
+	},
+	{
+		name:   RequestDeliveryResponseHeader,
+		legacy: wrp.RDRHeader,
+		get:    func(v []string, m *wrp.Message) { m.RequestDeliveryResponse = getIntHeader(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setIntHeader(h, n, m.RequestDeliveryResponse) },
+	},
+	{
+		name:   IncludeSpansHeader,
+		legacy: wrp.IncludeSpansHeader,
+		get:    func(v []string, m *wrp.Message) { m.IncludeSpans = getBoolHeader(v) },
+		set: func(h http.Header, n string, m *wrp.Message) {
+			if m.IncludeSpans != nil {
+				h.Set(n, strconv.FormatBool(*m.IncludeSpans))
+			}
+		},
+	},
+	{
+		name:   SpanHeader,
+		legacy: wrp.SpansHeader,
+		get:    func(v []string, m *wrp.Message) { m.Spans = getSpans(v) },
+		set: func(h http.Header, n string, m *wrp.Message) {
+			for _, s := range m.Spans {
+				h.Add(n, s.Name+","+strconv.FormatInt(s.Start.Unix(), 10)+","+s.Duration.String())
+			}
+		},
+	},
+	{
+		name: AcceptHeader,
+		get:  func(v []string, m *wrp.Message) { m.Accept = getStringHeader(v) },
+		set:  func(h http.Header, n string, m *wrp.Message) { setStringHeader(h, n, m.Accept) },
+	},
+	{
+		name:   PathHeader,
+		legacy: wrp.PathHeader,
+		get:    func(v []string, m *wrp.Message) { m.Path = getStringHeader(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setStringHeader(h, n, m.Path) },
+	},
+	{
+		name:   HeadersHeader,
+		legacy: wrp.HeadersArrHeader,
+		get:    func(v []string, m *wrp.Message) { m.Headers = getListHeader(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setListHeader(h, n, m.Headers) },
+	},
+	{
+		name:   MetadataHeader,
+		legacy: wrp.MetadataHeader,
+		get:    func(v []string, m *wrp.Message) { m.Metadata = getMetadata(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setMetadata(h, n, m.Metadata) },
+	},
+	{
+		name:   PartnerIdHeader,
+		legacy: wrp.PartnerIdHeader,
+		get:    func(v []string, m *wrp.Message) { m.PartnerIDs = getPartnerIDs(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setListHeader(h, n, m.PartnerIDs) },
+	},
+	{
+		name:   ServiceNameHeader,
+		legacy: wrp.ServiceNameHeader,
+		get:    func(v []string, m *wrp.Message) { m.ServiceName = getStringHeader(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setStringHeader(h, n, m.ServiceName) },
+	},
+	{
+		name:   URLHeader,
+		legacy: wrp.URLHeader,
+		get:    func(v []string, m *wrp.Message) { m.URL = getStringHeader(v) },
+		set:    func(h http.Header, n string, m *wrp.Message) { setStringHeader(h, n, m.URL) },
+	},
+}
+
+// getMessageType extracts the wrp.MessageType from header values.  This is a required field.
 //
 // This function panics if the message type header is missing or invalid.
-func getMessageType(h http.Header) wrp.MessageType {
-	value := h.Get(MessageTypeHeader)
+func getMessageType(v []string) wrp.MessageType {
+	value := getStringHeader(v)
 	if len(value) == 0 {
 		panic(errMissingMessageTypeHeader)
 	}
@@ -45,10 +180,25 @@ func getMessageType(h http.Header) wrp.MessageType {
 	return messageType
 }
 
+// getStringHeader returns the first header value, or the empty string if there are no values
+func getStringHeader(v []string) string {
+	if len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+func setStringHeader(h http.Header, n, value string) {
+	if len(value) > 0 {
+		h.Set(n, value)
+	}
+}
+
 // getIntHeader returns the header as a int64, or returns nil if the header is absent.
 // This function panics if the header is present but not a valid integer.
-func getIntHeader(h http.Header, n string) *int64 {
-	value := h.Get(n)
+func getIntHeader(v []string) *int64 {
+	value := getStringHeader(v)
 	if len(value) == 0 {
 		return nil
 	}
@@ -61,8 +211,14 @@ func getIntHeader(h http.Header, n string) *int64 {
 	return &i
 }
 
-func getBoolHeader(h http.Header, n string) *bool {
-	value := h.Get(n)
+func setIntHeader(h http.Header, n string, value *int64) {
+	if value != nil {
+		h.Set(n, strconv.FormatInt(*value, 10))
+	}
+}
+
+func getBoolHeader(v []string) *bool {
+	value := getStringHeader(v)
 	if len(value) == 0 {
 		return nil
 	}
@@ -75,41 +231,111 @@ func getBoolHeader(h http.Header, n string) *bool {
 	return &b
 }
 
-func getSpans(h http.Header) []wrp.Money_Span {
-    var spans []wrp.Money_Span
-    for _, value := range h[SpanHeader]{
-        fields := strings.Split(value, ",")
-        var Name string
-        var Start time.Time
-        var Duration time.Duration
-        if len(fields) != 3 {
-            panic(fmt.Errorf("Invalid %s header: %s", SpanHeader, value))
-        }
-
-        for i := 0; i < len(fields); i++ {
-            fields[i] = strings.TrimSpace(fields[i])
-            switch i {
-                case 0:
-                    name := fields[i]
-                    Name = name
-                case 1:
-                    start, err := strconv.ParseInt(fields[i], 10, 64)
-                    if err != nil {
-                        panic(err)
-                    }
-                    Start = time.Unix(start, 0).UTC()
-                case 2:
-                    fields[i] = strings.Trim(fields[i], "ns")
-                    duration, err := strconv.ParseInt(fields[i], 10, 64)
-                    if err != nil {
-                        panic(err)
-                    }
-                    Duration = time.Duration(duration)
-            }
-        }
-        spans = append(spans, wrp.Money_Span{Name, Start, Duration})
-    }
-    return spans
+// getListHeader returns a copy of the header values, or nil if there are none.  Each header
+// value is a single list element, which allows elements to contain commas.
+func getListHeader(v []string) []string {
+	if len(v) == 0 {
+		return nil
+	}
+
+	return append([]string(nil), v...)
+}
+
+func setListHeader(h http.Header, n string, values []string) {
+	for _, value := range values {
+		h.Add(n, value)
+	}
+}
+
+// getMetadata parses metadata header values, each of which must be of the form key=value.  Whitespace
+// is preserved in both keys and values, so that metadata survives a round trip through HTTP headers.
+// This function panics if any value is malformed.
+func getMetadata(v []string) map[string]string {
+	if len(v) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]string, len(v))
+	for _, value := range v {
+		i := strings.IndexByte(value, '=')
+		if i < 1 {
+			panic(fmt.Errorf("Invalid %s header: %s", MetadataHeader, value))
+		}
+
+		metadata[value[:i]] = value[i+1:]
+	}
+
+	return metadata
+}
+
+// setMetadata adds a key=value header for each metadata entry.  Entries are emitted in key order, so that
+// the headers for a given message are always the same.
+func setMetadata(h http.Header, n string, metadata map[string]string) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		h.Add(n, key+"="+metadata[key])
+	}
+}
+
+// getPartnerIDs parses partner id header values.  Partner ids never contain commas, so a single
+// header value may hold a comma-separated list as well.
+func getPartnerIDs(v []string) []string {
+	var partnerIDs []string
+	for _, value := range v {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); len(id) > 0 {
+				partnerIDs = append(partnerIDs, id)
+			}
+		}
+	}
+
+	return partnerIDs
+}
+
+// getSpans parses span header values, each of which must be of the form name,start,duration.  The start
+// is expressed in seconds since the epoch, while the duration is in time.Duration string format.
+// This function panics if any value is malformed.
+func getSpans(v []string) []wrp.Money_Span {
+	var spans []wrp.Money_Span
+	for _, value := range v {
+		fields := strings.Split(value, ",")
+		if len(fields) != 3 {
+			panic(fmt.Errorf("Invalid %s header: %s", SpanHeader, value))
+		}
+
+		start, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+		if err != nil {
+			panic(err)
+		}
+
+		spans = append(spans, wrp.Money_Span{
+			Name:     strings.TrimSpace(fields[0]),
+			Start:    time.Unix(start, 0).UTC(),
+			Duration: getDuration(strings.TrimSpace(fields[2])),
+		})
+	}
+
+	return spans
+}
+
+// getDuration parses a span duration.  For backward compatibility, a bare integer is interpreted
+// as nanoseconds.
+func getDuration(value string) time.Duration {
+	if ns, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ns)
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
 }
 
 func readPayload(h http.Header, p io.Reader) ([]byte, string) {
@@ -154,6 +380,7 @@ func NewMessageFromHeaders(h http.Header, p io.Reader) (message *wrp.Message, er
 	err = SetMessageFromHeaders(h, message)
 	if err != nil {
 		message = nil
+		return
 	}
 
 	message.Payload = payload
@@ -162,7 +389,11 @@ func NewMessageFromHeaders(h http.Header, p io.Reader) (message *wrp.Message, er
 }
 
 // SetMessageFromHeaders transfers header fields onto the given WRP message.  The payload is not
-// handled by this method.
+// handled by this method, though the message's ContentType is set from the Content-Type header.
+//
+// Every field of a wrp.Message other than the payload has a corresponding header.  Where a field
+// used to be carried by one of the X-Midt-* headers defined in the wrp package, that older header
+// is accepted when the current header is absent.
 func SetMessageFromHeaders(h http.Header, m *wrp.Message) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -175,61 +406,20 @@ func SetMessageFromHeaders(h http.Header, m *wrp.Message) (err error) {
 		}
 	}()
 
-	m.Type = getMessageType(h)
-	m.Source = h.Get(SourceHeader)
-	m.Destination = h.Get(DestinationHeader)
-	m.TransactionUUID = h.Get(TransactionUuidHeader)
-	m.Status = getIntHeader(h, StatusHeader)
-	m.RequestDeliveryResponse = getIntHeader(h, RequestDeliveryResponseHeader)
-	m.IncludeSpans = getBoolHeader(h, IncludeSpansHeader)
-	m.Spans = getSpans(h)
-	m.ContentType = h.Get("Content-Type")
-	m.Accept = h.Get(AcceptHeader)
-	m.Path = h.Get(PathHeader)
+	for _, field := range messageFields {
+		field.get(field.values(h), m)
+	}
 
+	m.ContentType = h.Get("Content-Type")
 	return
 }
 
 // AddMessageHeaders adds the HTTP header representation of a given WRP message.
 // This function does not handle the payload, to allow further headers to be written by
-// calling code.
+// calling code.  Use WriteMessagePayload to write the payload along with its Content-Type.
 func AddMessageHeaders(h http.Header, m *wrp.Message) {
-	h.Set(MessageTypeHeader, m.Type.FriendlyName())
-
-	if len(m.Source) > 0 {
-		h.Set(SourceHeader, m.Source)
-	}
-
-	if len(m.Destination) > 0 {
-		h.Set(DestinationHeader, m.Destination)
-	}
-
-	if len(m.TransactionUUID) > 0 {
-		h.Set(TransactionUuidHeader, m.TransactionUUID)
-	}
-
-	if m.Status != nil {
-		h.Set(StatusHeader, strconv.FormatInt(*m.Status, 10))
-	}
-
-	if m.RequestDeliveryResponse != nil {
-		h.Set(RequestDeliveryResponseHeader, strconv.FormatInt(*m.RequestDeliveryResponse, 10))
-	}
-
-	if m.IncludeSpans != nil {
-		h.Set(IncludeSpansHeader, strconv.FormatBool(*m.IncludeSpans))
-	}
-
-	for _, s := range m.Spans {
-		h.Add(SpanHeader, (s.Name+","+strconv.FormatInt(s.Start.Unix(),10)+","+s.Duration.String()))
-	}
-
-	if len(m.Accept) > 0 {
-		h.Set(AcceptHeader, m.Accept)
-	}
-
-	if len(m.Path) > 0 {
-		h.Set(PathHeader, m.Path)
+	for _, field := range messageFields {
+		field.set(h, field.name, m)
 	}
 }
 
@@ -3,16 +3,16 @@ package wrphttp
 import (
 	"bytes"
 	"errors"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
-	"github.com/Comcast/webpa-common/wrp"
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
-	"github.com/stretchr/testify/require"
 )
 
 func testNewMessageFromHeadersSuccess(t *testing.T) {
@@ -20,10 +20,10 @@ func testNewMessageFromHeadersSuccess(t *testing.T) {
 		assert  = assert.New(t)
 		require = require.New(t)
 
-		expectedStatus                  int64 = 928
-		expectedRequestDeliveryResponse int64 = 1
-		expectedIncludeSpans            bool  = true
-		expectedSpans []wrp.Money_Span = []wrp.Money_Span{{"foo", time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC), time.Duration(223)},{"bar", time.Date(2018, 1, 2, 12, 0, 0, 0, time.UTC), time.Duration(211)},}
+		expectedStatus                  int64            = 928
+		expectedRequestDeliveryResponse int64            = 1
+		expectedIncludeSpans            bool             = true
+		expectedSpans                   []wrp.Money_Span = []wrp.Money_Span{{"foo", time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC), time.Duration(223)}, {"bar", time.Date(2018, 1, 2, 12, 0, 0, 0, time.UTC), time.Duration(211)}}
 
 		testData = []struct {
 			header   http.Header
@@ -57,9 +57,9 @@ func testNewMessageFromHeadersSuccess(t *testing.T) {
 					StatusHeader:                  []string{strconv.FormatInt(expectedStatus, 10)},
 					RequestDeliveryResponseHeader: []string{strconv.FormatInt(expectedRequestDeliveryResponse, 10)},
 					IncludeSpansHeader:            []string{strconv.FormatBool(expectedIncludeSpans)},
-					SpanHeader:                    []string{expectedSpans[0].Name+","+strconv.FormatInt(expectedSpans[0].Start.Unix(),10)+","+expectedSpans[0].Duration.String(),expectedSpans[1].Name+","+strconv.FormatInt(expectedSpans[1].Start.Unix(),10)+","+expectedSpans[1].Duration.String(),},
-					AcceptHeader: []string{"application/json"},
-					PathHeader:   []string{"/foo/bar"},
+					SpanHeader:                    []string{expectedSpans[0].Name + "," + strconv.FormatInt(expectedSpans[0].Start.Unix(), 10) + "," + expectedSpans[0].Duration.String(), expectedSpans[1].Name + "," + strconv.FormatInt(expectedSpans[1].Start.Unix(), 10) + "," + expectedSpans[1].Duration.String()},
+					AcceptHeader:                  []string{"application/json"},
+					PathHeader:                    []string{"/foo/bar"},
 				},
 				payload: nil,
 				expected: wrp.Message{
@@ -70,9 +70,9 @@ func testNewMessageFromHeadersSuccess(t *testing.T) {
 					Status:                  &expectedStatus,
 					RequestDeliveryResponse: &expectedRequestDeliveryResponse,
 					IncludeSpans:            &expectedIncludeSpans,
-					Spans: 			 expectedSpans,
-					Accept: "application/json",
-					Path:   "/foo/bar",
+					Spans:                   expectedSpans,
+					Accept:                  "application/json",
+					Path:                    "/foo/bar",
 				},
 			},
 			{
@@ -210,17 +210,149 @@ func TestNewMessageFromHeaders(t *testing.T) {
 	})
 
 	t.Run("BadSpanHeader", testNewMessageFromHeadersBadSpanHeader)
+	t.Run("BadMetadataHeader", testNewMessageFromHeadersBadMetadataHeader)
 	t.Run("BadPayload", testNewMessageFromHeadersBadPayload)
+	t.Run("AllFields", testNewMessageFromHeadersAllFields)
+	t.Run("LegacyHeaders", testNewMessageFromHeadersLegacyHeaders)
+}
+
+func testNewMessageFromHeadersBadMetadataHeader(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, value := range []string{"no equals sign", "=value"} {
+		message, err := NewMessageFromHeaders(
+			http.Header{
+				MessageTypeHeader: []string{wrp.SimpleEventMessageType.FriendlyName()},
+				MetadataHeader:    []string{value},
+			},
+			nil,
+		)
+
+		assert.Nil(message)
+		assert.Error(err)
+	}
+}
+
+func testNewMessageFromHeadersAllFields(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		expectedStatus int64 = 200
+		expectedRDR    int64 = 0
+		expectedSpans        = []wrp.Money_Span{{"foo", time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC), 1500 * time.Millisecond}}
+	)
+
+	actual, err := NewMessageFromHeaders(
+		http.Header{
+			MessageTypeHeader:             []string{"SimpleRequestResponse"},
+			TransactionUuidHeader:         []string{"1234"},
+			SourceHeader:                  []string{"dns:test.com"},
+			DestinationHeader:             []string{"mac:111122223333/config"},
+			StatusHeader:                  []string{"200"},
+			RequestDeliveryResponseHeader: []string{"0"},
+			SpanHeader:                    []string{"foo," + strconv.FormatInt(expectedSpans[0].Start.Unix(), 10) + ",1.5s"},
+			AcceptHeader:                  []string{"application/json"},
+			PathHeader:                    []string{"/foo/bar"},
+			HeadersHeader:                 []string{"X-Foo: bar, baz", "X-Moo: cow"},
+			MetadataHeader:                []string{"/boot-time=1234", "trust=1000", "/url=http://foo.com?a=b", "/banner=  hello, world "},
+			PartnerIdHeader:               []string{"comcast, cox", "sky"},
+			ServiceNameHeader:             []string{"config"},
+			URLHeader:                     []string{"https://foo.com/api"},
+			"Content-Type":                []string{"application/json"},
+		},
+		strings.NewReader(`{"foo": "bar"}`),
+	)
+
+	require.NoError(err)
+	require.NotNil(actual)
+	assert.Equal(
+		wrp.Message{
+			Type:                    wrp.SimpleRequestResponseMessageType,
+			TransactionUUID:         "1234",
+			Source:                  "dns:test.com",
+			Destination:             "mac:111122223333/config",
+			Status:                  &expectedStatus,
+			RequestDeliveryResponse: &expectedRDR,
+			Spans:                   expectedSpans,
+			Accept:                  "application/json",
+			Path:                    "/foo/bar",
+			Headers:                 []string{"X-Foo: bar, baz", "X-Moo: cow"},
+			Metadata:                map[string]string{"/boot-time": "1234", "trust": "1000", "/url": "http://foo.com?a=b", "/banner": "  hello, world "},
+			PartnerIDs:              []string{"comcast", "cox", "sky"},
+			ServiceName:             "config",
+			URL:                     "https://foo.com/api",
+			ContentType:             "application/json",
+			Payload:                 []byte(`{"foo": "bar"}`),
+		},
+		*actual,
+	)
+}
+
+func testNewMessageFromHeadersLegacyHeaders(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		expectedStatus       int64 = 404
+		expectedRDR          int64 = 1
+		expectedIncludeSpans       = true
+		expectedSpans              = []wrp.Money_Span{{"foo", time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC), 2 * time.Second}}
+	)
+
+	actual, err := NewMessageFromHeaders(
+		http.Header{
+			wrp.MsgTypeHeader:         []string{"SimpleEvent"},
+			wrp.TransactionUuidHeader: []string{"1234"},
+			wrp.SourceHeader:          []string{"dns:test.com"},
+			wrp.StatusHeader:          []string{"404"},
+			wrp.RDRHeader:             []string{"1"},
+			wrp.IncludeSpansHeader:    []string{"true"},
+			wrp.SpansHeader:           []string{"foo," + strconv.FormatInt(expectedSpans[0].Start.Unix(), 10) + ",2s"},
+			wrp.PathHeader:            []string{"/foo"},
+			wrp.HeadersArrHeader:      []string{"X-Foo: bar"},
+			wrp.MetadataHeader:        []string{"key=value"},
+			wrp.PartnerIdHeader:       []string{"comcast"},
+			wrp.ServiceNameHeader:     []string{"iot"},
+			wrp.URLHeader:             []string{"https://foo.com"},
+
+			// the current header takes precedence over the legacy header
+			PathHeader: []string{"/bar"},
+		},
+		nil,
+	)
+
+	require.NoError(err)
+	require.NotNil(actual)
+	assert.Equal(
+		wrp.Message{
+			Type:                    wrp.SimpleEventMessageType,
+			TransactionUUID:         "1234",
+			Source:                  "dns:test.com",
+			Status:                  &expectedStatus,
+			RequestDeliveryResponse: &expectedRDR,
+			IncludeSpans:            &expectedIncludeSpans,
+			Spans:                   expectedSpans,
+			Path:                    "/bar",
+			Headers:                 []string{"X-Foo: bar"},
+			Metadata:                map[string]string{"key": "value"},
+			PartnerIDs:              []string{"comcast"},
+			ServiceName:             "iot",
+			URL:                     "https://foo.com",
+		},
+		*actual,
+	)
 }
 
 func TestAddMessageHeaders(t *testing.T) {
 	var (
 		assert = assert.New(t)
 
-		expectedStatus                  int64 = 123
-		expectedRequestDeliveryResponse int64 = 2
-		expectedIncludeSpans            bool  = true
-		expectedSpans []wrp.Money_Span = []wrp.Money_Span{{"foo", time.Date(2018, 2, 1, 12, 0, 0, 0, time.UTC), time.Duration(211)}}
+		expectedStatus                  int64            = 123
+		expectedRequestDeliveryResponse int64            = 2
+		expectedIncludeSpans            bool             = true
+		expectedSpans                   []wrp.Money_Span = []wrp.Money_Span{{"foo", time.Date(2018, 2, 1, 12, 0, 0, 0, time.UTC), time.Duration(211)}}
+		expectedMetadata                                 = map[string]string{"/boot-time": "1234", "/fw-name": "fw1", "/hw-model": "hw1", "trust": "1000"}
 
 		testData = []struct {
 			message  wrp.Message
@@ -246,6 +378,11 @@ func TestAddMessageHeaders(t *testing.T) {
 					Spans:                   expectedSpans,
 					Accept:                  "application/json",
 					Path:                    "/foo/bar",
+					Headers:                 []string{"X-Foo: bar", "X-Moo: cow"},
+					Metadata:                expectedMetadata,
+					PartnerIDs:              []string{"comcast", "cox"},
+					ServiceName:             "config",
+					URL:                     "https://foo.com",
 				},
 				expected: http.Header{
 					MessageTypeHeader:             []string{wrp.SimpleRequestResponseMessageType.FriendlyName()},
@@ -255,9 +392,14 @@ func TestAddMessageHeaders(t *testing.T) {
 					StatusHeader:                  []string{strconv.FormatInt(expectedStatus, 10)},
 					RequestDeliveryResponseHeader: []string{strconv.FormatInt(expectedRequestDeliveryResponse, 10)},
 					IncludeSpansHeader:            []string{strconv.FormatBool(expectedIncludeSpans)},
-					SpanHeader:                    []string{expectedSpans[0].Name+","+strconv.FormatInt(expectedSpans[0].Start.Unix(),10)+","+expectedSpans[0].Duration.String()},
+					SpanHeader:                    []string{expectedSpans[0].Name + "," + strconv.FormatInt(expectedSpans[0].Start.Unix(), 10) + "," + expectedSpans[0].Duration.String()},
 					AcceptHeader:                  []string{"application/json"},
 					PathHeader:                    []string{"/foo/bar"},
+					HeadersHeader:                 []string{"X-Foo: bar", "X-Moo: cow"},
+					MetadataHeader:                []string{"/boot-time=1234", "/fw-name=fw1", "/hw-model=hw1", "trust=1000"},
+					PartnerIdHeader:               []string{"comcast", "cox"},
+					ServiceNameHeader:             []string{"config"},
+					URLHeader:                     []string{"https://foo.com"},
 				},
 			},
 		}
@@ -271,6 +413,79 @@ func TestAddMessageHeaders(t *testing.T) {
 	}
 }
 
+func TestMessageHeadersRoundTrip(t *testing.T) {
+	var (
+		expectedStatus       int64 = 500
+		expectedRDR          int64 = 2
+		expectedIncludeSpans       = false
+
+		testData = []wrp.Message{
+			{
+				Type: wrp.AuthorizationStatusMessageType,
+			},
+			{
+				Type:        wrp.SimpleEventMessageType,
+				Source:      "mac:112233445566/event",
+				Destination: "event:device-status",
+				ContentType: "application/json",
+				Payload:     []byte(`{"online": true}`),
+				Metadata:    map[string]string{"/fw-name": "fw1", "/hw-model": "hw1"},
+				PartnerIDs:  []string{"comcast"},
+			},
+			{
+				Type:                    wrp.SimpleRequestResponseMessageType,
+				Source:                  "dns:talaria.com",
+				Destination:             "mac:112233445566/config",
+				TransactionUUID:         "a-b-c-d",
+				Accept:                  "application/msgpack",
+				Status:                  &expectedStatus,
+				RequestDeliveryResponse: &expectedRDR,
+				IncludeSpans:            &expectedIncludeSpans,
+				Spans:                   []wrp.Money_Span{{"foo", time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC), 3 * time.Millisecond}, {"bar", time.Date(2018, 3, 2, 0, 0, 0, 0, time.UTC), 2 * time.Minute}},
+				Headers:                 []string{"X-Foo: 1, 2", "X-Bar: 3"},
+				Path:                    "/config/foo",
+				ContentType:             "application/octet-stream",
+				Payload:                 []byte{1, 2, 3},
+				PartnerIDs:              []string{"comcast", "cox"},
+			},
+			{
+				Type:        wrp.ServiceRegistrationMessageType,
+				ServiceName: "iot",
+				URL:         "tcp://127.0.0.1:1234",
+			},
+			{
+				Type:            wrp.CreateMessageType,
+				Source:          "dns:test.com",
+				Destination:     "mac:112233445566/iot",
+				TransactionUUID: "1234",
+				Path:            "/iot/thing",
+				ContentType:     "text/plain",
+				Payload:         []byte("thing"),
+			},
+		}
+	)
+
+	for i, expected := range testData {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var (
+				assert  = assert.New(t)
+				require = require.New(t)
+
+				header = make(http.Header)
+				body   bytes.Buffer
+			)
+
+			AddMessageHeaders(header, &expected)
+			require.NoError(WriteMessagePayload(header, &body, &expected))
+
+			actual, err := NewMessageFromHeaders(header, &body)
+			require.NoError(err)
+			require.NotNil(actual)
+			assert.Equal(expected, *actual)
+		})
+	}
+}
+
 func testWriteMessagePayloadEmptyPayload(t *testing.T) {
 	assert := assert.New(t)
 
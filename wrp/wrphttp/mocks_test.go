@@ -63,3 +63,12 @@ func (m *mockRequestResponse) Spans() []tracing.Span {
 func (m *mockRequestResponse) WithSpans(spans ...tracing.Span) interface{} {
 	return m.Called(spans).Get(0)
 }
+
+// mockStatusCodeResponse is a mockRequestResponse that also implements go-kit's StatusCoder
+type mockStatusCodeResponse struct {
+	mockRequestResponse
+}
+
+func (m *mockStatusCodeResponse) StatusCode() int {
+	return m.Called().Int(0)
+}
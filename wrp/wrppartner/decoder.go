@@ -0,0 +1,58 @@
+package wrppartner
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/Comcast/webpa-common/wrp/wrphttp"
+	"github.com/Comcast/webpa-common/xhttp"
+	gokithttp "github.com/go-kit/kit/transport/http"
+)
+
+// DecodeRequest decorates a go-kit DecodeRequestFunc from the wrphttp package, such as wrphttp.DecodeRequest,
+// so that the partner ids of each decoded Entity are enforced.  Requests that fail enforcement produce an
+// error with a 403 status code.
+//
+// When an Entity's message is modified, the Entity's Contents are re-encoded in its Format.
+func DecodeRequest(e *Enforcer, next gokithttp.DecodeRequestFunc) gokithttp.DecodeRequestFunc {
+	return func(ctx context.Context, original *http.Request) (interface{}, error) {
+		decoded, err := next(ctx, original)
+		if err != nil {
+			return decoded, err
+		}
+
+		switch entity := decoded.(type) {
+		case *wrphttp.Entity:
+			return entity, enforceEntity(ctx, e, entity)
+
+		case wrphttp.Entity:
+			err := enforceEntity(ctx, e, &entity)
+			return entity, err
+
+		default:
+			return decoded, nil
+		}
+	}
+}
+
+func enforceEntity(ctx context.Context, e *Enforcer, entity *wrphttp.Entity) error {
+	enforced, err := e.Enforce(tokenPartnerIDs(ctx), &entity.Message)
+	if err != nil {
+		return &xhttp.Error{Code: http.StatusForbidden, Text: err.Error()}
+	}
+
+	if enforced != &entity.Message {
+		entity.Message = *enforced
+		if len(entity.Contents) > 0 {
+			var contents []byte
+			if err := wrp.NewEncoderBytes(&contents, entity.Format).Encode(&entity.Message); err != nil {
+				return err
+			}
+
+			entity.Contents = contents
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package wrppartner
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/Comcast/webpa-common/wrp/wrphttp"
+	"github.com/Comcast/webpa-common/xhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDecodeRequestEntity(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		original = wrp.Message{Type: wrp.SimpleEventMessageType, Source: "test", Destination: "event:foo", PartnerIDs: []string{"comcast", "cox"}}
+		contents []byte
+	)
+
+	require.NoError(wrp.NewEncoderBytes(&contents, wrp.JSON).Encode(&original))
+
+	e, err := NewEnforcer(Config{Mode: ModeIntersect}, nil)
+	require.NoError(err)
+
+	var (
+		decoder = DecodeRequest(e, wrphttp.DecodeRequest)
+		request = httptest.NewRequest("POST", "/", bytes.NewReader(contents))
+	)
+
+	request.Header.Set("Content-Type", wrp.JSON.ContentType())
+	decoded, err := decoder(contextWithPartnerIDs("comcast"), request)
+	require.NoError(err)
+	require.IsType(&wrphttp.Entity{}, decoded)
+
+	entity := decoded.(*wrphttp.Entity)
+	assert.Equal([]string{"comcast"}, entity.Message.PartnerIDs)
+
+	var reencoded wrp.Message
+	require.NoError(wrp.NewDecoderBytes(entity.Contents, wrp.JSON).Decode(&reencoded))
+	assert.Equal("test", reencoded.Source)
+	assert.Equal([]string{"comcast"}, reencoded.PartnerIDs)
+}
+
+func testDecodeRequestHeaders(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+		request = httptest.NewRequest("POST", "/", nil)
+	)
+
+	e, err := NewEnforcer(Config{}, nil)
+	require.NoError(err)
+
+	request.Header.Set(wrphttp.MessageTypeHeader, wrp.SimpleEventMessageType.FriendlyName())
+	request.Header.Set(wrphttp.DestinationHeader, "event:foo")
+
+	decoded, err := DecodeRequest(e, wrphttp.DecodeRequestHeaders)(contextWithPartnerIDs("comcast"), request)
+	require.NoError(err)
+	require.IsType(wrphttp.Entity{}, decoded)
+	assert.Equal([]string{"comcast"}, decoded.(wrphttp.Entity).Message.PartnerIDs)
+}
+
+func testDecodeRequestRejected(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	e, err := NewEnforcer(Config{}, nil)
+	require.NoError(err)
+
+	decoder := DecodeRequest(e, func(context.Context, *http.Request) (interface{}, error) {
+		return &wrphttp.Entity{Message: wrp.Message{PartnerIDs: []string{"cox"}}}, nil
+	})
+
+	_, err = decoder(contextWithPartnerIDs("comcast"), httptest.NewRequest("POST", "/", nil))
+	require.IsType(&xhttp.Error{}, err)
+	assert.Equal(http.StatusForbidden, err.(*xhttp.Error).Code)
+}
+
+func testDecodeRequestError(t *testing.T) {
+	var (
+		assert        = assert.New(t)
+		require       = require.New(t)
+		expectedError = &xhttp.Error{Code: http.StatusBadRequest}
+	)
+
+	e, err := NewEnforcer(Config{}, nil)
+	require.NoError(err)
+
+	decoder := DecodeRequest(e, func(context.Context, *http.Request) (interface{}, error) {
+		return nil, expectedError
+	})
+
+	decoded, err := decoder(contextWithPartnerIDs("comcast"), httptest.NewRequest("POST", "/", nil))
+	assert.Nil(decoded)
+	assert.Equal(expectedError, err)
+}
+
+func TestDecodeRequest(t *testing.T) {
+	t.Run("Entity", testDecodeRequestEntity)
+	t.Run("Headers", testDecodeRequestHeaders)
+	t.Run("Rejected", testDecodeRequestRejected)
+	t.Run("Error", testDecodeRequestError)
+}
@@ -0,0 +1,16 @@
+/*
+Package wrppartner ties the partner ids of WRP messages to the partner ids of the token that authorized
+the request carrying them.  Without this, any authorized caller could send a message on behalf of any partner.
+
+Token partner ids are obtained from secure/handler.FromContext, so requests must pass through a
+secure/handler.AuthorizationHandler first.  An Enforcer can decorate a wrpendpoint.Service or a go-kit
+DecodeRequestFunc from the wrphttp package.  Configuration determines whether a message's partner ids are
+rejected, overwritten, or intersected with the token's:
+
+	{
+	  "mode": "intersect",
+	  "wildcard": "*",
+	  "checkDevice": true
+	}
+*/
+package wrppartner
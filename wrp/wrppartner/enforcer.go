@@ -0,0 +1,197 @@
+package wrppartner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+const (
+	// ModeReject rejects messages that carry any partner id the token does not allow
+	ModeReject = "reject"
+
+	// ModeOverwrite replaces the partner ids of messages with the token's partner ids
+	ModeOverwrite = "overwrite"
+
+	// ModeIntersect removes the partner ids the token does not allow from messages.  Messages left
+	// with no partner ids are rejected.
+	ModeIntersect = "intersect"
+)
+
+var (
+	ErrorInvalidMode           = fmt.Errorf("The partner id enforcement mode must be one of %s, %s, or %s", ModeReject, ModeOverwrite, ModeIntersect)
+	ErrorNoTokenPartners       = errors.New("The token does not allow any partner ids")
+	ErrorPartnerNotAllowed     = errors.New("The WRP message carries a partner id the token does not allow")
+	ErrorDevicePartnerMismatch = errors.New("The WRP message partner ids do not match the partner ids of the target device")
+)
+
+// DevicePartners provides the partner ids associated with devices, typically from metadata the
+// devices reported when they connected
+type DevicePartners interface {
+	// PartnerIDs returns the partner ids of the given device.  If the device's partner ids are not
+	// known, this method returns false and no device check is made.
+	PartnerIDs(device.ID) ([]string, bool)
+}
+
+// DevicePartnersFunc is a function type that implements DevicePartners
+type DevicePartnersFunc func(device.ID) ([]string, bool)
+
+func (dpf DevicePartnersFunc) PartnerIDs(id device.ID) ([]string, bool) {
+	return dpf(id)
+}
+
+// Config is the configurable description of partner id enforcement
+type Config struct {
+	// Mode is one of ModeReject, ModeOverwrite, or ModeIntersect.  If unset, ModeReject is used.
+	// In every mode, messages with no partner ids are given the token's partner ids.
+	Mode string `json:"mode"`
+
+	// Wildcard is a token partner id which allows any partner id, e.g. "*".  Messages authorized by a token
+	// with the wildcard are not modified.  If unset, no token partner id is treated as a wildcard.
+	Wildcard string `json:"wildcard"`
+
+	// AllowMissing allows requests whose token has no partner ids, leaving their messages unmodified.
+	// By default, such requests are rejected.
+	AllowMissing bool `json:"allowMissing"`
+
+	// CheckDevice requires that the partner ids of messages destined for a device include at least one
+	// of that device's partner ids.  This has no effect unless the Enforcer has a DevicePartners.
+	CheckDevice bool `json:"checkDevice"`
+}
+
+// Enforcer applies the partner ids of a token to WRP messages.  An Enforcer is immutable and safe
+// for concurrent use.
+type Enforcer struct {
+	mode           string
+	wildcard       string
+	allowMissing   bool
+	devicePartners DevicePartners
+}
+
+// NewEnforcer produces an Enforcer from configuration.  The DevicePartners may be nil, in which
+// case no device checks are made.
+func NewEnforcer(c Config, dp DevicePartners) (*Enforcer, error) {
+	e := &Enforcer{
+		mode:         c.Mode,
+		wildcard:     c.Wildcard,
+		allowMissing: c.AllowMissing,
+	}
+
+	switch e.mode {
+	case "":
+		e.mode = ModeReject
+	case ModeReject, ModeOverwrite, ModeIntersect:
+	default:
+		return nil, ErrorInvalidMode
+	}
+
+	if c.CheckDevice {
+		e.devicePartners = dp
+	}
+
+	return e, nil
+}
+
+// Enforce applies the given token partner ids to a message.  If the message is modified, a copy is
+// returned and the original is left untouched.  Otherwise, the original message is returned.
+func (e *Enforcer) Enforce(tokenPartnerIDs []string, m *wrp.Message) (*wrp.Message, error) {
+	if len(tokenPartnerIDs) == 0 {
+		if e.allowMissing {
+			return m, nil
+		}
+
+		return nil, ErrorNoTokenPartners
+	}
+
+	allowed := make(map[string]bool, len(tokenPartnerIDs))
+	for _, v := range tokenPartnerIDs {
+		if len(e.wildcard) > 0 && v == e.wildcard {
+			return m, nil
+		}
+
+		allowed[v] = true
+	}
+
+	var partnerIDs []string
+	switch {
+	case len(m.PartnerIDs) == 0 || e.mode == ModeOverwrite:
+		partnerIDs = tokenPartnerIDs
+
+	case e.mode == ModeIntersect:
+		for _, v := range m.PartnerIDs {
+			if allowed[v] {
+				partnerIDs = append(partnerIDs, v)
+			}
+		}
+
+		if len(partnerIDs) == 0 {
+			return nil, ErrorPartnerNotAllowed
+		}
+
+	default:
+		for _, v := range m.PartnerIDs {
+			if !allowed[v] {
+				return nil, ErrorPartnerNotAllowed
+			}
+		}
+
+		partnerIDs = m.PartnerIDs
+	}
+
+	if err := e.checkDevice(m.Destination, partnerIDs); err != nil {
+		return nil, err
+	}
+
+	if equal(partnerIDs, m.PartnerIDs) {
+		return m, nil
+	}
+
+	copyOf := *m
+	copyOf.PartnerIDs = append([]string(nil), partnerIDs...)
+	return &copyOf, nil
+}
+
+// checkDevice verifies that the given partner ids include at least one of the partner ids of the
+// destination device.  Destinations which are not devices, and devices whose partner ids are unknown,
+// always pass.
+func (e *Enforcer) checkDevice(destination string, partnerIDs []string) error {
+	if e.devicePartners == nil {
+		return nil
+	}
+
+	id, err := device.ParseID(destination)
+	if err != nil {
+		return nil
+	}
+
+	devicePartnerIDs, ok := e.devicePartners.PartnerIDs(id)
+	if !ok {
+		return nil
+	}
+
+	for _, d := range devicePartnerIDs {
+		for _, p := range partnerIDs {
+			if d == p {
+				return nil
+			}
+		}
+	}
+
+	return ErrorDevicePartnerMismatch
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
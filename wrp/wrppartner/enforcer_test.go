@@ -0,0 +1,99 @@
+package wrppartner
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEnforcer(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		assert := assert.New(t)
+		e, err := NewEnforcer(Config{}, nil)
+		assert.NoError(err)
+		assert.Equal(ModeReject, e.mode)
+	})
+
+	t.Run("InvalidMode", func(t *testing.T) {
+		assert := assert.New(t)
+		e, err := NewEnforcer(Config{Mode: "ignore"}, nil)
+		assert.Nil(e)
+		assert.Equal(ErrorInvalidMode, err)
+	})
+}
+
+func TestEnforcerEnforce(t *testing.T) {
+	devicePartners := DevicePartnersFunc(func(id device.ID) ([]string, bool) {
+		if id == "mac:112233445566" {
+			return []string{"comcast"}, true
+		}
+
+		return nil, false
+	})
+
+	testData := []struct {
+		config             Config
+		tokenPartnerIDs    []string
+		messagePartnerIDs  []string
+		destination        string
+		expectedPartnerIDs []string
+		expectedChanged    bool
+		expectedError      error
+	}{
+		{Config{}, nil, []string{"comcast"}, "event:test", nil, false, ErrorNoTokenPartners},
+		{Config{AllowMissing: true}, nil, []string{"comcast"}, "event:test", []string{"comcast"}, false, nil},
+		{Config{Wildcard: "*"}, []string{"*"}, []string{"cox"}, "event:test", []string{"cox"}, false, nil},
+		{Config{}, []string{"*"}, []string{"cox"}, "event:test", nil, false, ErrorPartnerNotAllowed},
+
+		{Config{}, []string{"comcast", "cox"}, []string{"comcast"}, "event:test", []string{"comcast"}, false, nil},
+		{Config{}, []string{"comcast"}, []string{"comcast", "cox"}, "event:test", nil, false, ErrorPartnerNotAllowed},
+		{Config{}, []string{"comcast"}, nil, "event:test", []string{"comcast"}, true, nil},
+
+		{Config{Mode: ModeOverwrite}, []string{"comcast"}, []string{"cox"}, "event:test", []string{"comcast"}, true, nil},
+		{Config{Mode: ModeOverwrite}, []string{"comcast"}, []string{"comcast"}, "event:test", []string{"comcast"}, false, nil},
+
+		{Config{Mode: ModeIntersect}, []string{"comcast", "sky"}, []string{"comcast", "cox"}, "event:test", []string{"comcast"}, true, nil},
+		{Config{Mode: ModeIntersect}, []string{"comcast"}, []string{"comcast"}, "event:test", []string{"comcast"}, false, nil},
+		{Config{Mode: ModeIntersect}, []string{"sky"}, []string{"comcast", "cox"}, "event:test", nil, false, ErrorPartnerNotAllowed},
+
+		{Config{CheckDevice: true}, []string{"comcast"}, []string{"comcast"}, "mac:112233445566/config", []string{"comcast"}, false, nil},
+		{Config{CheckDevice: true}, []string{"cox"}, []string{"cox"}, "mac:112233445566/config", nil, false, ErrorDevicePartnerMismatch},
+		{Config{CheckDevice: true}, []string{"cox"}, []string{"cox"}, "mac:665544332211/config", []string{"cox"}, false, nil},
+		{Config{}, []string{"cox"}, []string{"cox"}, "mac:112233445566/config", []string{"cox"}, false, nil},
+	}
+
+	for i, record := range testData {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var (
+				assert  = assert.New(t)
+				require = require.New(t)
+			)
+
+			e, err := NewEnforcer(record.config, devicePartners)
+			require.NoError(err)
+
+			original := &wrp.Message{
+				Type:        wrp.SimpleEventMessageType,
+				Destination: record.destination,
+				PartnerIDs:  record.messagePartnerIDs,
+			}
+
+			enforced, err := e.Enforce(record.tokenPartnerIDs, original)
+			assert.Equal(record.expectedError, err)
+			assert.Equal(record.messagePartnerIDs, original.PartnerIDs)
+			if record.expectedError != nil {
+				assert.Nil(enforced)
+				return
+			}
+
+			require.NotNil(enforced)
+			assert.Equal(record.expectedPartnerIDs, enforced.PartnerIDs)
+			assert.Equal(record.expectedChanged, enforced != original)
+			assert.Equal(record.destination, enforced.Destination)
+		})
+	}
+}
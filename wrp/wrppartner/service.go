@@ -0,0 +1,45 @@
+package wrppartner
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/secure/handler"
+	"github.com/Comcast/webpa-common/wrp/wrpendpoint"
+	"github.com/Comcast/webpa-common/xhttp"
+)
+
+// tokenPartnerIDs returns the partner ids of the token that authorized a request, if any
+func tokenPartnerIDs(ctx context.Context) []string {
+	if values, ok := handler.FromContext(ctx); ok && values != nil {
+		return values.PartnerIDs
+	}
+
+	return nil
+}
+
+// NewService decorates a wrpendpoint.Service so that the partner ids of each request's message are
+// enforced before the request reaches next.  Requests that fail enforcement produce an error with a
+// 403 status code, which implements go-kit's StatusCoder.
+func NewService(e *Enforcer, next wrpendpoint.Service) wrpendpoint.Service {
+	return wrpendpoint.ServiceFunc(func(ctx context.Context, request wrpendpoint.Request) (wrpendpoint.Response, error) {
+		original := request.Message()
+		if original == nil {
+			return nil, &xhttp.Error{Code: http.StatusBadRequest, Text: "The WRP request has no message"}
+		}
+
+		enforced, err := e.Enforce(tokenPartnerIDs(ctx), original)
+		if err != nil {
+			logging.Error(request.Logger()).Log(logging.MessageKey(), "WRP partner id enforcement failed", "partnerIDs", original.PartnerIDs, logging.ErrorKey(), err)
+			return nil, &xhttp.Error{Code: http.StatusForbidden, Text: err.Error()}
+		}
+
+		if enforced != original {
+			logging.Debug(request.Logger()).Log(logging.MessageKey(), "WRP partner ids changed", "partnerIDs", enforced.PartnerIDs)
+			request = wrpendpoint.WrapAsRequest(request.Logger(), enforced)
+		}
+
+		return next.ServeWRP(ctx, request)
+	})
+}
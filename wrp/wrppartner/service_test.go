@@ -0,0 +1,76 @@
+package wrppartner
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/secure/handler"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/Comcast/webpa-common/wrp/wrpendpoint"
+	"github.com/Comcast/webpa-common/xhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func contextWithPartnerIDs(partnerIDs ...string) context.Context {
+	return handler.NewContextWithValue(context.Background(), &handler.ContextValues{PartnerIDs: partnerIDs})
+}
+
+func TestNewService(t *testing.T) {
+	e, err := NewEnforcer(Config{Mode: ModeOverwrite}, nil)
+	require.NoError(t, err)
+
+	var (
+		expected = wrpendpoint.WrapAsResponse(new(wrp.Message))
+		received []*wrp.Message
+		service  = NewService(e, wrpendpoint.ServiceFunc(func(ctx context.Context, request wrpendpoint.Request) (wrpendpoint.Response, error) {
+			received = append(received, request.Message())
+			return expected, nil
+		}))
+	)
+
+	t.Run("Unchanged", func(t *testing.T) {
+		var (
+			assert   = assert.New(t)
+			original = &wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:foo", PartnerIDs: []string{"comcast"}}
+		)
+
+		received = nil
+		actual, err := service.ServeWRP(contextWithPartnerIDs("comcast"), wrpendpoint.WrapAsRequest(logging.NewTestLogger(nil, t), original))
+		assert.Equal(expected, actual)
+		assert.NoError(err)
+		assert.Equal([]*wrp.Message{original}, received)
+		assert.True(original == received[0])
+	})
+
+	t.Run("Overwritten", func(t *testing.T) {
+		assert := assert.New(t)
+
+		received = nil
+		actual, err := service.ServeWRP(
+			contextWithPartnerIDs("comcast"),
+			wrpendpoint.WrapAsRequest(logging.NewTestLogger(nil, t), &wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:foo", PartnerIDs: []string{"cox"}}),
+		)
+
+		assert.Equal(expected, actual)
+		assert.NoError(err)
+		assert.Equal([]*wrp.Message{{Type: wrp.SimpleEventMessageType, Destination: "event:foo", PartnerIDs: []string{"comcast"}}}, received)
+	})
+
+	t.Run("NoToken", func(t *testing.T) {
+		assert := assert.New(t)
+
+		received = nil
+		actual, err := service.ServeWRP(
+			context.Background(),
+			wrpendpoint.WrapAsRequest(logging.NewTestLogger(nil, t), &wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:foo", PartnerIDs: []string{"cox"}}),
+		)
+
+		assert.Nil(actual)
+		assert.Empty(received)
+		require.IsType(t, &xhttp.Error{}, err)
+		assert.Equal(http.StatusForbidden, err.(*xhttp.Error).Code)
+	})
+}
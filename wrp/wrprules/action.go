@@ -0,0 +1,166 @@
+package wrprules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+// actionOutcome tells the engine how to proceed after an action
+type actionOutcome int
+
+const (
+	actionContinue actionOutcome = iota
+	actionStop
+	actionDrop
+)
+
+// action is a compiled ActionConfig.  Actions are always applied to a copy of the original message.
+type action func(*wrp.Message) actionOutcome
+
+// field provides access to a single message field as a string
+type field struct {
+	get func(*wrp.Message) string
+	set func(*wrp.Message, string)
+}
+
+var fields = map[string]field{
+	"source": {
+		get: func(m *wrp.Message) string { return m.Source },
+		set: func(m *wrp.Message, v string) { m.Source = v },
+	},
+	"dest": {
+		get: func(m *wrp.Message) string { return m.Destination },
+		set: func(m *wrp.Message, v string) { m.Destination = v },
+	},
+	"transaction_uuid": {
+		get: func(m *wrp.Message) string { return m.TransactionUUID },
+		set: func(m *wrp.Message, v string) { m.TransactionUUID = v },
+	},
+	"content_type": {
+		get: func(m *wrp.Message) string { return m.ContentType },
+		set: func(m *wrp.Message, v string) { m.ContentType = v },
+	},
+	"accept": {
+		get: func(m *wrp.Message) string { return m.Accept },
+		set: func(m *wrp.Message, v string) { m.Accept = v },
+	},
+	"path": {
+		get: func(m *wrp.Message) string { return m.Path },
+		set: func(m *wrp.Message, v string) { m.Path = v },
+	},
+	"service_name": {
+		get: func(m *wrp.Message) string { return m.ServiceName },
+		set: func(m *wrp.Message, v string) { m.ServiceName = v },
+	},
+	"url": {
+		get: func(m *wrp.Message) string { return m.URL },
+		set: func(m *wrp.Message, v string) { m.URL = v },
+	},
+	"partner_ids": {
+		get: func(m *wrp.Message) string { return strings.Join(m.PartnerIDs, ",") },
+		set: func(m *wrp.Message, v string) { m.PartnerIDs = splitList(v) },
+	},
+}
+
+var (
+	errMissingMetadataKey = errors.New("A key is required for metadata actions")
+	errMissingDestination = errors.New("A value is required for route actions")
+)
+
+// metadataField produces a field for a single metadata entry.  Setting the empty string removes the entry.
+func metadataField(key string) field {
+	return field{
+		get: func(m *wrp.Message) string { return m.Metadata[key] },
+		set: func(m *wrp.Message, v string) {
+			if len(v) > 0 {
+				if m.Metadata == nil {
+					m.Metadata = make(map[string]string)
+				}
+
+				m.Metadata[key] = v
+			} else if m.Metadata != nil {
+				delete(m.Metadata, key)
+				if len(m.Metadata) == 0 {
+					m.Metadata = nil
+				}
+			}
+		},
+	}
+}
+
+func newField(ac ActionConfig) (field, error) {
+	if ac.Field == "metadata" {
+		if len(ac.Key) == 0 {
+			return field{}, errMissingMetadataKey
+		}
+
+		return metadataField(ac.Key), nil
+	}
+
+	if f, ok := fields[ac.Field]; ok {
+		return f, nil
+	}
+
+	return field{}, fmt.Errorf("Unsupported field: %s", ac.Field)
+}
+
+func newAction(ac ActionConfig) (action, error) {
+	switch ac.Type {
+	case ActionDrop:
+		return func(*wrp.Message) actionOutcome { return actionDrop }, nil
+
+	case ActionRoute:
+		if len(ac.Value) == 0 {
+			return nil, errMissingDestination
+		}
+
+		destination := ac.Value
+		return func(m *wrp.Message) actionOutcome {
+			m.Destination = destination
+			return actionStop
+		}, nil
+
+	case ActionSet, ActionRemove:
+		f, err := newField(ac)
+		if err != nil {
+			return nil, err
+		}
+
+		value := ac.Value
+		if ac.Type == ActionRemove {
+			value = ""
+		}
+
+		return func(m *wrp.Message) actionOutcome {
+			f.set(m, value)
+			return actionContinue
+		}, nil
+
+	case ActionRewrite:
+		f, err := newField(ac)
+		if err != nil {
+			return nil, err
+		}
+
+		pattern, err := regexp.Compile(ac.Pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		replacement := ac.Replacement
+		return func(m *wrp.Message) actionOutcome {
+			if current := f.get(m); len(current) > 0 {
+				f.set(m, pattern.ReplaceAllString(current, replacement))
+			}
+
+			return actionContinue
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("Unsupported action type: %s", ac.Type)
+	}
+}
@@ -0,0 +1,116 @@
+package wrprules
+
+import (
+	"github.com/spf13/viper"
+)
+
+const (
+	// ActionSet sets a field, or a metadata entry, to a value
+	ActionSet = "set"
+
+	// ActionRemove clears a field or removes a metadata entry
+	ActionRemove = "remove"
+
+	// ActionRewrite performs a regular expression replacement on a field or metadata entry
+	ActionRewrite = "rewrite"
+
+	// ActionDrop drops the message.  No further rules or actions are applied.
+	ActionDrop = "drop"
+
+	// ActionRoute sets the destination of the message.  No further rules or actions are applied.
+	ActionRoute = "route"
+)
+
+// Config is the configuration for an Engine
+type Config struct {
+	// Rules is the ordered list of rules to apply
+	Rules []RuleConfig
+}
+
+// RuleConfig describes a single rule
+type RuleConfig struct {
+	// Name identifies this rule in logs and errors.  If unset, the index of the rule is used.
+	Name string
+
+	// Match holds the criteria a message must meet for this rule's actions to apply
+	Match MatchConfig
+
+	// Actions are applied in order to matching messages
+	Actions []ActionConfig
+
+	// Final indicates that no further rules are evaluated once this rule matches
+	Final bool
+}
+
+// MatchConfig describes the criteria for matching a message.  All configured criteria must match.
+// An empty MatchConfig matches every message.  All patterns are regular expressions, which are
+// unanchored unless the pattern itself uses ^ or $.
+type MatchConfig struct {
+	// Types is the set of message types, by friendly name or integer value, that match.  If empty,
+	// all message types match.
+	Types []string
+
+	// Source holds patterns for the parts of the message's source locator
+	Source LocatorConfig
+
+	// Destination holds patterns for the parts of the message's destination locator
+	Destination LocatorConfig
+
+	// Path is a pattern for the message's path field
+	Path string
+
+	// Metadata holds patterns for metadata values.  Each entry's key must be present in the message.
+	Metadata []MetadataMatchConfig
+
+	// PartnerIDs is a set of partner ids.  A message matches if it carries at least one of them.
+	PartnerIDs []string
+}
+
+// MetadataMatchConfig is a pattern for a single metadata value.  This is a list entry rather than a map,
+// as configuration keys are not case sensitive while metadata keys are.
+type MetadataMatchConfig struct {
+	// Key is the metadata key, which must be present in the message
+	Key string
+
+	// Pattern is matched against the metadata value.  An empty pattern matches any value.
+	Pattern string
+}
+
+// LocatorConfig holds patterns for each part of a WRP locator.  See wrp.Locator.
+type LocatorConfig struct {
+	Scheme    string
+	Authority string
+	Service   string
+	Ignored   string
+}
+
+// ActionConfig describes a single action taken against a matching message
+type ActionConfig struct {
+	// Type is one of the Action constants, e.g. ActionSet
+	Type string
+
+	// Field is the message field the action applies to.  Supported fields are source, dest, transaction_uuid,
+	// content_type, accept, path, service_name, url, partner_ids, and metadata.  Not used by drop and route actions.
+	Field string
+
+	// Key is the metadata key, required when Field is metadata
+	Key string
+
+	// Value is the value for set and route actions.  For the partner_ids field, the value is a comma-separated list.
+	Value string
+
+	// Pattern is the regular expression used by rewrite actions
+	Pattern string
+
+	// Replacement is the replacement text used by rewrite actions, which may refer to pattern submatches with $1, $name, etc.
+	Replacement string
+}
+
+// NewConfig unmarshals a Config from a Viper environment.  A nil Viper results in an empty Config.
+func NewConfig(v *viper.Viper) (c Config, err error) {
+	if v != nil {
+		err = v.Unmarshal(&c)
+	}
+
+	return
+}
@@ -0,0 +1,74 @@
+package wrprules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig(t *testing.T) {
+	t.Run("Nil", func(t *testing.T) {
+		c, err := NewConfig(nil)
+		assert.Equal(t, Config{}, c)
+		assert.NoError(t, err)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+			v       = viper.New()
+		)
+
+		v.SetConfigType("json")
+		require.NoError(v.ReadConfig(strings.NewReader(`
+			{
+				"rules": [
+					{
+						"name": "config2",
+						"final": true,
+						"match": {
+							"types": ["SimpleRequestResponse"],
+							"destination": {"service": "^config$"},
+							"metadata": [{"key": "/Fw-Name", "pattern": "^fw"}],
+							"partnerIDs": ["comcast"]
+						},
+						"actions": [
+							{"type": "rewrite", "field": "dest", "pattern": "/config$", "replacement": "/config2"}
+						]
+					}
+				]
+			}
+		`)))
+
+		c, err := NewConfig(v)
+		require.NoError(err)
+		assert.Equal(
+			Config{
+				Rules: []RuleConfig{
+					{
+						Name:  "config2",
+						Final: true,
+						Match: MatchConfig{
+							Types:       []string{"SimpleRequestResponse"},
+							Destination: LocatorConfig{Service: "^config$"},
+							Metadata:    []MetadataMatchConfig{{Key: "/Fw-Name", Pattern: "^fw"}},
+							PartnerIDs:  []string{"comcast"},
+						},
+						Actions: []ActionConfig{
+							{Type: ActionRewrite, Field: "dest", Pattern: "/config$", Replacement: "/config2"},
+						},
+					},
+				},
+			},
+			c,
+		)
+
+		e, err := NewEngine(c)
+		assert.NotNil(e)
+		assert.NoError(err)
+	})
+}
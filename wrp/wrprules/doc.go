@@ -0,0 +1,32 @@
+/*
+Package wrprules implements a configurable rules engine that transforms, reroutes, or drops WRP messages.
+
+Rules are evaluated in order.  Each rule has match criteria and a list of actions.  When a message matches
+a rule, that rule's actions are applied to a copy of the message before the next rule is evaluated.  A rule
+marked as final, or any rule that drops or routes a message, ends evaluation.
+
+Rules are typically unmarshalled from configuration:
+
+	{
+	  "rules": [
+	    {
+	      "name": "tag-partner",
+	      "match": {"partnerIDs": ["comcast"]},
+	      "actions": [{"type": "set", "field": "metadata", "key": "/tenant", "value": "comcast"}]
+	    },
+	    {
+	      "name": "config2",
+	      "match": {"destination": {"service": "^config$"}},
+	      "actions": [{"type": "rewrite", "field": "dest", "pattern": "/config(/|$)", "replacement": "/config2$1"}]
+	    },
+	    {
+	      "name": "drop-noise",
+	      "match": {"types": ["SimpleEvent"], "destination": {"authority": "^noisy-.*"}},
+	      "actions": [{"type": "drop"}]
+	    }
+	  ]
+	}
+
+An Engine can decorate a wrpendpoint.Service or a device.Listener.
+*/
+package wrprules
@@ -0,0 +1,285 @@
+package wrprules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+// Result describes the outcome of applying rules to a message
+type Result struct {
+	// Matched holds the names of the rules that matched, in order
+	Matched []string
+
+	// Dropped indicates that a rule dropped the message
+	Dropped bool
+}
+
+// Engine applies an ordered set of rules to WRP messages
+type Engine interface {
+	// Apply evaluates each rule against the given message.  The original message is never modified.
+	// If any actions were applied, the returned message is a copy.  Otherwise, the original message
+	// is returned.
+	Apply(*wrp.Message) (*wrp.Message, Result)
+}
+
+// NewEngine compiles the given configuration into an Engine.  An error is returned if any rule is
+// invalid, e.g. a pattern doesn't compile or an action refers to an unknown field.
+func NewEngine(c Config) (Engine, error) {
+	e := &engine{rules: make([]rule, 0, len(c.Rules))}
+	for i, rc := range c.Rules {
+		name := rc.Name
+		if len(name) == 0 {
+			name = strconv.Itoa(i)
+		}
+
+		r, err := newRule(name, rc)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid rule %s: %s", name, err)
+		}
+
+		e.rules = append(e.rules, r)
+	}
+
+	return e, nil
+}
+
+type engine struct {
+	rules []rule
+}
+
+func (e *engine) Apply(m *wrp.Message) (*wrp.Message, Result) {
+	var (
+		result  Result
+		current = m
+		copied  = false
+	)
+
+	for _, r := range e.rules {
+		if !r.matches(current) {
+			continue
+		}
+
+		result.Matched = append(result.Matched, r.name)
+		for _, a := range r.actions {
+			if !copied {
+				current = copyMessage(current)
+				copied = true
+			}
+
+			switch a(current) {
+			case actionDrop:
+				result.Dropped = true
+				return current, result
+
+			case actionStop:
+				return current, result
+			}
+		}
+
+		if r.final {
+			break
+		}
+	}
+
+	return current, result
+}
+
+// rule is a compiled RuleConfig
+type rule struct {
+	name    string
+	final   bool
+	match   []predicate
+	actions []action
+}
+
+func newRule(name string, rc RuleConfig) (rule, error) {
+	r := rule{name: name, final: rc.Final}
+
+	var err error
+	if r.match, err = newPredicates(rc.Match); err != nil {
+		return r, err
+	}
+
+	for _, ac := range rc.Actions {
+		a, err := newAction(ac)
+		if err != nil {
+			return r, err
+		}
+
+		r.actions = append(r.actions, a)
+	}
+
+	return r, nil
+}
+
+func (r rule) matches(m *wrp.Message) bool {
+	for _, p := range r.match {
+		if !p(m) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// predicate is a single, compiled match criterion
+type predicate func(*wrp.Message) bool
+
+func newPredicates(mc MatchConfig) ([]predicate, error) {
+	var predicates []predicate
+	if len(mc.Types) > 0 {
+		types := make(map[wrp.MessageType]bool, len(mc.Types))
+		for _, v := range mc.Types {
+			t, err := wrp.StringToMessageType(v)
+			if err != nil {
+				return nil, err
+			}
+
+			types[t] = true
+		}
+
+		predicates = append(predicates, func(m *wrp.Message) bool { return types[m.Type] })
+	}
+
+	for _, l := range []struct {
+		config LocatorConfig
+		value  func(*wrp.Message) string
+	}{
+		{mc.Source, func(m *wrp.Message) string { return m.Source }},
+		{mc.Destination, func(m *wrp.Message) string { return m.Destination }},
+	} {
+		p, err := newLocatorPredicate(l.config, l.value)
+		if err != nil {
+			return nil, err
+		} else if p != nil {
+			predicates = append(predicates, p)
+		}
+	}
+
+	if len(mc.Path) > 0 {
+		pattern, err := regexp.Compile(mc.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		predicates = append(predicates, func(m *wrp.Message) bool { return pattern.MatchString(m.Path) })
+	}
+
+	for _, mm := range mc.Metadata {
+		var (
+			key     = mm.Key
+			pattern *regexp.Regexp
+			err     error
+		)
+
+		if len(mm.Pattern) > 0 {
+			if pattern, err = regexp.Compile(mm.Pattern); err != nil {
+				return nil, err
+			}
+		}
+
+		predicates = append(predicates, func(m *wrp.Message) bool {
+			value, ok := m.Metadata[key]
+			return ok && (pattern == nil || pattern.MatchString(value))
+		})
+	}
+
+	if len(mc.PartnerIDs) > 0 {
+		partnerIDs := make(map[string]bool, len(mc.PartnerIDs))
+		for _, v := range mc.PartnerIDs {
+			partnerIDs[v] = true
+		}
+
+		predicates = append(predicates, func(m *wrp.Message) bool {
+			for _, v := range m.PartnerIDs {
+				if partnerIDs[v] {
+					return true
+				}
+			}
+
+			return false
+		})
+	}
+
+	return predicates, nil
+}
+
+// newLocatorPredicate compiles the patterns for a locator.  If no patterns are configured,
+// this function returns a nil predicate.
+func newLocatorPredicate(lc LocatorConfig, value func(*wrp.Message) string) (predicate, error) {
+	var parts []func(wrp.Locator) bool
+	for _, p := range []struct {
+		pattern string
+		part    func(wrp.Locator) string
+	}{
+		{lc.Scheme, func(l wrp.Locator) string { return l.Scheme }},
+		{lc.Authority, func(l wrp.Locator) string { return l.Authority }},
+		{lc.Service, func(l wrp.Locator) string { return l.Service }},
+		{lc.Ignored, func(l wrp.Locator) string { return l.Ignored }},
+	} {
+		if len(p.pattern) == 0 {
+			continue
+		}
+
+		pattern, err := regexp.Compile(p.pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		part := p.part
+		parts = append(parts, func(l wrp.Locator) bool { return pattern.MatchString(part(l)) })
+	}
+
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	return func(m *wrp.Message) bool {
+		l, err := wrp.ParseLocator(value(m))
+		if err != nil {
+			return false
+		}
+
+		for _, p := range parts {
+			if !p(l) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// copyMessage produces a shallow copy of a message with its own Metadata and PartnerIDs, so that actions
+// can modify the copy without affecting the original
+func copyMessage(m *wrp.Message) *wrp.Message {
+	copyOf := *m
+	if m.Metadata != nil {
+		copyOf.Metadata = make(map[string]string, len(m.Metadata))
+		for k, v := range m.Metadata {
+			copyOf.Metadata[k] = v
+		}
+	}
+
+	if m.PartnerIDs != nil {
+		copyOf.PartnerIDs = append([]string(nil), m.PartnerIDs...)
+	}
+
+	return &copyOf
+}
+
+// splitList parses a comma-separated list, ignoring blank elements
+func splitList(v string) []string {
+	var values []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			values = append(values, s)
+		}
+	}
+
+	return values
+}
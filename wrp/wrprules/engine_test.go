@@ -0,0 +1,203 @@
+package wrprules
+
+import (
+	"testing"
+
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage() *wrp.Message {
+	return &wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      "mac:112233445566/event",
+		Destination: "event:device-status/mac:112233445566/online",
+		Path:        "/online",
+		Metadata:    map[string]string{"/fw-name": "fw1"},
+		PartnerIDs:  []string{"comcast"},
+	}
+}
+
+func testEngineApply(t *testing.T, c Config, original *wrp.Message, expected *wrp.Message, expectedResult Result) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		originalCopy = copyMessage(original)
+	)
+
+	e, err := NewEngine(c)
+	require.NoError(err)
+	require.NotNil(e)
+
+	actual, result := e.Apply(original)
+	assert.Equal(expected, actual)
+	assert.Equal(expectedResult, result)
+	assert.Equal(originalCopy, original, "the original message should never be modified")
+}
+
+func TestEngine(t *testing.T) {
+	t.Run("NoRules", func(t *testing.T) {
+		testEngineApply(t, Config{}, testMessage(), testMessage(), Result{})
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		testEngineApply(
+			t,
+			Config{Rules: []RuleConfig{
+				{Match: MatchConfig{Types: []string{"SimpleRequestResponse"}}, Actions: []ActionConfig{{Type: ActionDrop}}},
+				{Match: MatchConfig{Source: LocatorConfig{Scheme: "^dns$"}}, Actions: []ActionConfig{{Type: ActionDrop}}},
+				{Match: MatchConfig{Destination: LocatorConfig{Service: "^config$"}}, Actions: []ActionConfig{{Type: ActionDrop}}},
+				{Match: MatchConfig{Path: "^/offline"}, Actions: []ActionConfig{{Type: ActionDrop}}},
+				{Match: MatchConfig{Metadata: []MetadataMatchConfig{{Key: "/hw-model"}}}, Actions: []ActionConfig{{Type: ActionDrop}}},
+				{Match: MatchConfig{Metadata: []MetadataMatchConfig{{Key: "/fw-name", Pattern: "^fw2$"}}}, Actions: []ActionConfig{{Type: ActionDrop}}},
+				{Match: MatchConfig{PartnerIDs: []string{"cox", "sky"}}, Actions: []ActionConfig{{Type: ActionDrop}}},
+			}},
+			testMessage(),
+			testMessage(),
+			Result{},
+		)
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		expected := testMessage()
+		expected.Metadata["/tenant"] = "comcast"
+		expected.ContentType = "application/json"
+		expected.PartnerIDs = []string{"comcast", "cox"}
+
+		testEngineApply(
+			t,
+			Config{Rules: []RuleConfig{
+				{
+					Name: "tenant",
+					Match: MatchConfig{
+						Types:      []string{"SimpleEvent", "4"},
+						Source:     LocatorConfig{Scheme: "^mac$", Service: "^event$"},
+						PartnerIDs: []string{"comcast"},
+						Metadata:   []MetadataMatchConfig{{Key: "/fw-name", Pattern: "^fw"}},
+					},
+					Actions: []ActionConfig{
+						{Type: ActionSet, Field: "metadata", Key: "/tenant", Value: "comcast"},
+						{Type: ActionSet, Field: "content_type", Value: "application/json"},
+						{Type: ActionSet, Field: "partner_ids", Value: "comcast, cox"},
+					},
+				},
+			}},
+			testMessage(),
+			expected,
+			Result{Matched: []string{"tenant"}},
+		)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		expected := testMessage()
+		expected.Metadata = nil
+		expected.Path = ""
+
+		testEngineApply(
+			t,
+			Config{Rules: []RuleConfig{
+				{
+					Actions: []ActionConfig{
+						{Type: ActionRemove, Field: "metadata", Key: "/fw-name"},
+						{Type: ActionRemove, Field: "path"},
+					},
+				},
+			}},
+			testMessage(),
+			expected,
+			Result{Matched: []string{"0"}},
+		)
+	})
+
+	t.Run("Rewrite", func(t *testing.T) {
+		original := testMessage()
+		original.Destination = "mac:112233445566/config/foo"
+
+		expected := copyMessage(original)
+		expected.Destination = "mac:112233445566/config2/foo"
+
+		testEngineApply(
+			t,
+			Config{Rules: []RuleConfig{
+				{
+					Name:    "config2",
+					Match:   MatchConfig{Destination: LocatorConfig{Service: "^config$"}},
+					Actions: []ActionConfig{{Type: ActionRewrite, Field: "dest", Pattern: "/config(/|$)", Replacement: "/config2$1"}},
+				},
+				{
+					Name:    "second pass",
+					Match:   MatchConfig{Destination: LocatorConfig{Service: "^config$"}},
+					Actions: []ActionConfig{{Type: ActionDrop}},
+				},
+			}},
+			original,
+			expected,
+			Result{Matched: []string{"config2"}},
+		)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		testEngineApply(
+			t,
+			Config{Rules: []RuleConfig{
+				{Name: "drop", Match: MatchConfig{Destination: LocatorConfig{Authority: "^device-status$"}}, Actions: []ActionConfig{{Type: ActionDrop}}},
+				{Name: "never", Actions: []ActionConfig{{Type: ActionSet, Field: "path", Value: "/never"}}},
+			}},
+			testMessage(),
+			testMessage(),
+			Result{Matched: []string{"drop"}, Dropped: true},
+		)
+	})
+
+	t.Run("Route", func(t *testing.T) {
+		expected := testMessage()
+		expected.Destination = "event:other"
+
+		testEngineApply(
+			t,
+			Config{Rules: []RuleConfig{
+				{Name: "route", Actions: []ActionConfig{{Type: ActionRoute, Value: "event:other"}, {Type: ActionDrop}}},
+				{Name: "never", Actions: []ActionConfig{{Type: ActionDrop}}},
+			}},
+			testMessage(),
+			expected,
+			Result{Matched: []string{"route"}},
+		)
+	})
+
+	t.Run("Final", func(t *testing.T) {
+		expected := testMessage()
+		expected.URL = "http://foo.com"
+
+		testEngineApply(
+			t,
+			Config{Rules: []RuleConfig{
+				{Name: "final", Final: true, Actions: []ActionConfig{{Type: ActionSet, Field: "url", Value: "http://foo.com"}}},
+				{Name: "never", Actions: []ActionConfig{{Type: ActionDrop}}},
+			}},
+			testMessage(),
+			expected,
+			Result{Matched: []string{"final"}},
+		)
+	})
+}
+
+func TestNewEngineInvalid(t *testing.T) {
+	for _, rc := range []RuleConfig{
+		{Match: MatchConfig{Types: []string{"NotAMessageType"}}},
+		{Match: MatchConfig{Source: LocatorConfig{Authority: "("}}},
+		{Match: MatchConfig{Path: "("}},
+		{Match: MatchConfig{Metadata: []MetadataMatchConfig{{Key: "key", Pattern: "("}}}},
+		{Actions: []ActionConfig{{Type: "nosuchaction"}}},
+		{Actions: []ActionConfig{{Type: ActionSet, Field: "nosuchfield"}}},
+		{Actions: []ActionConfig{{Type: ActionSet, Field: "metadata"}}},
+		{Actions: []ActionConfig{{Type: ActionRewrite, Field: "path", Pattern: "("}}},
+		{Actions: []ActionConfig{{Type: ActionRoute}}},
+	} {
+		e, err := NewEngine(Config{Rules: []RuleConfig{rc}})
+		assert.Nil(t, e)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,42 @@
+package wrprules
+
+import (
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+// NewListener decorates a device.Listener so that the WRP message of each event passes through the given
+// Engine before next sees it.  Events whose message is dropped by a rule are not passed to next.  Events
+// without a *wrp.Message are passed to next unchanged.
+//
+// When a message is transformed, next receives a copy of the event whose Message is the transformed message
+// and whose Contents is that message encoded in the event's Format.  If the transformed message cannot be
+// encoded, the event is not passed to next.
+func NewListener(e Engine, next device.Listener) device.Listener {
+	return func(event *device.Event) {
+		original, ok := event.Message.(*wrp.Message)
+		if !ok {
+			next(event)
+			return
+		}
+
+		transformed, result := e.Apply(original)
+		if result.Dropped {
+			return
+		}
+
+		if transformed != original {
+			var contents []byte
+			if err := wrp.NewEncoderBytes(&contents, event.Format).Encode(transformed); err != nil {
+				return
+			}
+
+			copyOf := *event
+			copyOf.Message = transformed
+			copyOf.Contents = contents
+			event = &copyOf
+		}
+
+		next(event)
+	}
+}
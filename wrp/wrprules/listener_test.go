@@ -0,0 +1,49 @@
+package wrprules
+
+import (
+	"testing"
+
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewListener(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+	)
+
+	e, err := NewEngine(Config{Rules: []RuleConfig{
+		{Match: MatchConfig{Types: []string{"SimpleEvent"}, Path: "^/noise"}, Actions: []ActionConfig{{Type: ActionDrop}}},
+		{Match: MatchConfig{Types: []string{"SimpleEvent"}}, Actions: []ActionConfig{{Type: ActionSet, Field: "metadata", Key: "/seen", Value: "true"}}},
+	}})
+
+	require.NoError(err)
+
+	var (
+		received []*device.Event
+		listener = NewListener(e, func(e *device.Event) { received = append(received, e) })
+
+		connect  = &device.Event{Type: device.Connect}
+		response = &device.Event{Type: device.TransactionComplete, Message: &wrp.Message{Type: wrp.SimpleRequestResponseMessageType}, Format: wrp.Msgpack}
+		noise    = &wrp.Message{Type: wrp.SimpleEventMessageType, Path: "/noise"}
+		event    = &wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:foo"}
+	)
+
+	listener(connect)
+	listener(response)
+	listener(&device.Event{Type: device.MessageReceived, Message: noise, Format: wrp.Msgpack, Contents: wrp.MustEncode(noise, wrp.Msgpack)})
+	listener(&device.Event{Type: device.MessageReceived, Message: event, Format: wrp.Msgpack, Contents: wrp.MustEncode(event, wrp.Msgpack)})
+
+	require.Len(received, 3)
+	assert.True(connect == received[0])
+	assert.True(response == received[1])
+
+	expected := &wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:foo", Metadata: map[string]string{"/seen": "true"}}
+	assert.Equal(device.MessageReceived, received[2].Type)
+	assert.Equal(expected, received[2].Message)
+	assert.Equal(wrp.MustEncode(expected, wrp.Msgpack), received[2].Contents)
+	assert.Nil(event.Metadata)
+}
@@ -0,0 +1,65 @@
+package wrprules
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/tracing"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/Comcast/webpa-common/wrp/wrpendpoint"
+)
+
+// droppedResponse is the wrpendpoint.Response for a request dropped by a rule.  It implements go-kit's
+// StatusCoder, so that HTTP transports respond with a 202 (Accepted) status.
+type droppedResponse struct {
+	wrpendpoint.Response
+}
+
+// newDroppedResponse creates the response to a dropped request.  The response is addressed back to the
+// request's source and carries a 202 (Accepted) WRP status.
+func newDroppedResponse(request *wrp.Message) wrpendpoint.Response {
+	status := int64(http.StatusAccepted)
+	return droppedResponse{
+		wrpendpoint.WrapAsResponse(&wrp.Message{
+			Type:            request.Type,
+			Source:          request.Destination,
+			Destination:     request.Source,
+			TransactionUUID: request.TransactionUUID,
+			Status:          &status,
+		}),
+	}
+}
+
+func (dr droppedResponse) StatusCode() int {
+	return http.StatusAccepted
+}
+
+func (dr droppedResponse) WithSpans(spans ...tracing.Span) interface{} {
+	return droppedResponse{dr.Response.WithSpans(spans...).(wrpendpoint.Response)}
+}
+
+// NewService decorates a wrpendpoint.Service so that requests pass through the given Engine before
+// reaching next.  Requests dropped by a rule are not passed to next.  Instead, a response with a 202 (Accepted)
+// status is returned, as the request was successfully handled by being discarded.
+func NewService(e Engine, next wrpendpoint.Service) wrpendpoint.Service {
+	return wrpendpoint.ServiceFunc(func(ctx context.Context, request wrpendpoint.Request) (wrpendpoint.Response, error) {
+		original := request.Message()
+		if original == nil {
+			return next.ServeWRP(ctx, request)
+		}
+
+		transformed, result := e.Apply(original)
+		if result.Dropped {
+			logging.Debug(request.Logger()).Log(logging.MessageKey(), "WRP request dropped", "rules", result.Matched)
+			return newDroppedResponse(original), nil
+		}
+
+		if transformed != original {
+			logging.Debug(request.Logger()).Log(logging.MessageKey(), "WRP request transformed", "rules", result.Matched, "destination", transformed.Destination)
+			request = wrpendpoint.WrapAsRequest(request.Logger(), transformed)
+		}
+
+		return next.ServeWRP(ctx, request)
+	})
+}
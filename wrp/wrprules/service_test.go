@@ -0,0 +1,94 @@
+package wrprules
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/tracing"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/Comcast/webpa-common/wrp/wrpendpoint"
+	gokithttp "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewService(t *testing.T) {
+	e, err := NewEngine(Config{Rules: []RuleConfig{
+		{Match: MatchConfig{Path: "^/drop"}, Actions: []ActionConfig{{Type: ActionDrop}}},
+		{Match: MatchConfig{Destination: LocatorConfig{Service: "^config$"}}, Actions: []ActionConfig{{Type: ActionRoute, Value: "mac:112233445566/config2"}}},
+	}})
+
+	require.NoError(t, err)
+
+	var (
+		expected = wrpendpoint.WrapAsResponse(new(wrp.Message))
+		received []*wrp.Message
+		service  = NewService(e, wrpendpoint.ServiceFunc(func(ctx context.Context, request wrpendpoint.Request) (wrpendpoint.Response, error) {
+			received = append(received, request.Message())
+			return expected, nil
+		}))
+	)
+
+	t.Run("Unchanged", func(t *testing.T) {
+		var (
+			assert   = assert.New(t)
+			original = &wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:foo"}
+		)
+
+		received = nil
+		actual, err := service.ServeWRP(context.Background(), wrpendpoint.WrapAsRequest(logging.NewTestLogger(nil, t), original))
+		assert.Equal(expected, actual)
+		assert.NoError(err)
+		assert.Equal([]*wrp.Message{original}, received)
+		assert.True(original == received[0])
+	})
+
+	t.Run("Transformed", func(t *testing.T) {
+		assert := assert.New(t)
+
+		received = nil
+		actual, err := service.ServeWRP(
+			context.Background(),
+			wrpendpoint.WrapAsRequest(logging.NewTestLogger(nil, t), &wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Destination: "mac:112233445566/config"}),
+		)
+
+		assert.Equal(expected, actual)
+		assert.NoError(err)
+		assert.Equal([]*wrp.Message{{Type: wrp.SimpleRequestResponseMessageType, Destination: "mac:112233445566/config2"}}, received)
+	})
+
+	t.Run("Dropped", func(t *testing.T) {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+		)
+
+		received = nil
+		actual, err := service.ServeWRP(
+			context.Background(),
+			wrpendpoint.WrapAsRequest(
+				logging.NewTestLogger(nil, t),
+				&wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Source: "dns:foo.com", Destination: "mac:112233445566/drop", TransactionUUID: "1234", Path: "/drop/me"},
+			),
+		)
+
+		require.NotNil(actual)
+		assert.NoError(err)
+		assert.Empty(received)
+
+		expectedStatus := int64(http.StatusAccepted)
+		assert.Equal(
+			&wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Source: "mac:112233445566/drop", Destination: "dns:foo.com", TransactionUUID: "1234", Status: &expectedStatus},
+			actual.Message(),
+		)
+
+		require.Implements((*gokithttp.StatusCoder)(nil), actual)
+		assert.Equal(http.StatusAccepted, actual.(gokithttp.StatusCoder).StatusCode())
+
+		withSpans := actual.WithSpans(tracing.NewSpanner().Start("test")(nil))
+		require.Implements((*gokithttp.StatusCoder)(nil), withSpans)
+		assert.Len(withSpans.(wrpendpoint.Response).Spans(), 1)
+	})
+}
@@ -0,0 +1,14 @@
+/*
+Package wrpsecure provides an optional, end-to-end security envelope for WRP messages.
+
+A message is signed over its canonical encoding, and its payload may optionally be encrypted
+for a particular recipient.  All envelope information travels in the message's Metadata, so
+signed and encrypted messages pass unchanged through routing software that knows nothing about
+this package.  Fields that routing software changes from hop to hop, such as spans and the
+TransportMetadataKeys, are not signed.  Keys are resolved through the secure/key package, using
+the key id carried in the envelope.
+
+When both are used, messages are encrypted first and then signed.  Receivers verify first and
+then decrypt.
+*/
+package wrpsecure
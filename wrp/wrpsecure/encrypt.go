@@ -0,0 +1,200 @@
+package wrpsecure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+
+	"github.com/Comcast/webpa-common/secure/key"
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+const (
+	// EncryptionAlgorithm is the only supported payload encryption scheme.  A random AES-256 content key
+	// encrypts the payload with GCM, and that content key is wrapped with the recipient's RSA public key
+	// using OAEP with SHA-256.
+	EncryptionAlgorithm = "RSA-OAEP-256+A256GCM"
+
+	// EncryptedContentType is the content type of a message whose payload has been encrypted
+	EncryptedContentType = "application/octet-stream"
+
+	contentKeySize = 32
+)
+
+// Encrypter produces WRP messages with encrypted payloads
+type Encrypter interface {
+	// Encrypt returns a copy of the given message with its payload encrypted.  The original message
+	// is not modified.  Messages without a payload are copied with an encryption envelope regardless,
+	// so that receivers can tell that the message was processed.
+	Encrypt(*wrp.Message) (*wrp.Message, error)
+}
+
+// Decrypter recovers the payloads of encrypted WRP messages
+type Decrypter interface {
+	// Decrypt returns a copy of the given message with its plaintext payload and original content type.
+	// The encryption envelope is removed, along with any signature, since the signature no longer
+	// applies to the decrypted message.  If the given message is not encrypted, it is returned as is.
+	Decrypt(*wrp.Message) (*wrp.Message, error)
+}
+
+// NewEncrypter creates an Encrypter for the recipient identified by keyID.  Only the public key of the
+// resolved Pair is used.
+func NewEncrypter(keyID string, resolver key.Resolver) Encrypter {
+	return &encrypter{keyID: keyID, resolver: resolver, random: rand.Reader}
+}
+
+type encrypter struct {
+	keyID    string
+	resolver key.Resolver
+	random   io.Reader
+}
+
+func (e *encrypter) Encrypt(m *wrp.Message) (*wrp.Message, error) {
+	if IsEncrypted(m) {
+		return nil, ErrorAlreadyEncrypted
+	}
+
+	pair, err := e.resolver.ResolveKey(e.keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := pair.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrorAlgorithmKeyMismatch
+	}
+
+	contentKey := make([]byte, contentKeySize)
+	if _, err := io.ReadFull(e.random, contentKey); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(e.random, nonce); err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), e.random, publicKey, contentKey, []byte(EncryptionAlgorithm))
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := copyMessage(m)
+	encrypted.Metadata[EncryptionKeyIDKey] = e.keyID
+	encrypted.Metadata[EncryptionAlgorithmKey] = EncryptionAlgorithm
+	encrypted.Metadata[EncryptedKeyKey] = base64.RawURLEncoding.EncodeToString(wrappedKey)
+	encrypted.Metadata[EncryptionNonceKey] = base64.RawURLEncoding.EncodeToString(nonce)
+	if len(m.ContentType) > 0 {
+		encrypted.Metadata[EncryptionContentTypeKey] = m.ContentType
+	}
+
+	encrypted.ContentType = EncryptedContentType
+	encrypted.Payload = aead.Seal(nil, nonce, m.Payload, nil)
+	return encrypted, nil
+}
+
+// NewDecrypter creates a Decrypter which resolves keys using the key id in each message's envelope.
+// The resolved Pair must have a private key, so the resolver will usually be configured with a
+// private key Purpose such as key.PurposeSign.
+func NewDecrypter(resolver key.Resolver) Decrypter {
+	return &decrypter{resolver: resolver}
+}
+
+type decrypter struct {
+	resolver key.Resolver
+}
+
+func (d *decrypter) Decrypt(m *wrp.Message) (*wrp.Message, error) {
+	if !IsEncrypted(m) {
+		return m, nil
+	}
+
+	if m.Metadata[EncryptionAlgorithmKey] != EncryptionAlgorithm {
+		return nil, ErrorUnsupportedAlgorithm
+	}
+
+	keyID := m.Metadata[EncryptionKeyIDKey]
+	if len(keyID) == 0 {
+		return nil, ErrorMissingKeyID
+	}
+
+	wrappedKey, err := base64.RawURLEncoding.DecodeString(m.Metadata[EncryptedKeyKey])
+	if err != nil || len(wrappedKey) == 0 {
+		return nil, ErrorMalformedEnvelope
+	}
+
+	nonce, err := base64.RawURLEncoding.DecodeString(m.Metadata[EncryptionNonceKey])
+	if err != nil {
+		return nil, ErrorMalformedEnvelope
+	}
+
+	pair, err := d.resolver.ResolveKey(keyID)
+	if err != nil {
+		return nil, err
+	} else if !pair.HasPrivate() {
+		return nil, ErrorPrivateKeyRequired
+	}
+
+	privateKey, ok := pair.Private().(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrorAlgorithmKeyMismatch
+	}
+
+	contentKey, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey, wrappedKey, []byte(EncryptionAlgorithm))
+	if err != nil {
+		return nil, ErrorDecryptionFailed
+	}
+
+	aead, err := newAEAD(contentKey)
+	if err != nil {
+		return nil, ErrorDecryptionFailed
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrorMalformedEnvelope
+	}
+
+	payload, err := aead.Open(nil, nonce, m.Payload, nil)
+	if err != nil {
+		return nil, ErrorDecryptionFailed
+	}
+
+	if len(payload) == 0 {
+		payload = nil
+	}
+
+	decrypted := copyMessage(m)
+	decrypted.ContentType = m.Metadata[EncryptionContentTypeKey]
+	decrypted.Payload = payload
+	removeMetadata(
+		decrypted,
+		EncryptionKeyIDKey,
+		EncryptionAlgorithmKey,
+		EncryptedKeyKey,
+		EncryptionNonceKey,
+		EncryptionContentTypeKey,
+		SignatureKey,
+		SignatureKeyIDKey,
+		SignatureAlgorithmKey,
+	)
+
+	return decrypted, nil
+}
+
+func newAEAD(contentKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,144 @@
+package wrpsecure
+
+import (
+	"testing"
+
+	"github.com/Comcast/webpa-common/secure/key"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEncryptAndDecrypt(t *testing.T, original *wrp.Message) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		encryptingResolver = new(key.MockResolver)
+		decryptingResolver = new(key.MockResolver)
+
+		encrypter = NewEncrypter("recipient", encryptingResolver)
+		decrypter = NewDecrypter(decryptingResolver)
+	)
+
+	encryptingResolver.On("ResolveKey", "recipient").Return(publicPair(rsaPrivateKey), nil).Once()
+	decryptingResolver.On("ResolveKey", "recipient").Return(privatePair(rsaPrivateKey), nil).Once()
+
+	encrypted, err := encrypter.Encrypt(original)
+	require.NoError(err)
+	require.NotNil(encrypted)
+
+	assert.False(IsEncrypted(original), "the original message should not be modified")
+	assert.True(IsEncrypted(encrypted))
+	assert.Equal(EncryptedContentType, encrypted.ContentType)
+	assert.Equal("recipient", encrypted.Metadata[EncryptionKeyIDKey])
+	assert.Equal(EncryptionAlgorithm, encrypted.Metadata[EncryptionAlgorithmKey])
+	if len(original.Payload) > 0 {
+		assert.NotContains(string(encrypted.Payload), string(original.Payload))
+	}
+
+	decrypted, err := decrypter.Decrypt(encrypted)
+	require.NoError(err)
+	assert.Equal(original, decrypted)
+
+	encryptingResolver.AssertExpectations(t)
+	decryptingResolver.AssertExpectations(t)
+}
+
+func testEncryptAlreadyEncrypted(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		resolver  = new(key.MockResolver)
+		encrypter = NewEncrypter("recipient", resolver)
+	)
+
+	encrypted, err := encrypter.Encrypt(&wrp.Message{Metadata: map[string]string{EncryptionAlgorithmKey: EncryptionAlgorithm}})
+	assert.Nil(encrypted)
+	assert.Equal(ErrorAlreadyEncrypted, err)
+	resolver.AssertExpectations(t)
+}
+
+func testEncryptNotRSA(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		resolver  = new(key.MockResolver)
+		encrypter = NewEncrypter("recipient", resolver)
+	)
+
+	resolver.On("ResolveKey", "recipient").Return(publicPair(ecdsaPrivateKey), nil).Once()
+	encrypted, err := encrypter.Encrypt(testMessage())
+	assert.Nil(encrypted)
+	assert.Equal(ErrorAlgorithmKeyMismatch, err)
+	resolver.AssertExpectations(t)
+}
+
+func TestEncrypter(t *testing.T) {
+	t.Run("Payload", func(t *testing.T) { testEncryptAndDecrypt(t, testMessage()) })
+	t.Run("NoPayload", func(t *testing.T) {
+		testEncryptAndDecrypt(t, &wrp.Message{Type: wrp.SimpleEventMessageType, Source: "mac:112233445566"})
+	})
+
+	t.Run("AlreadyEncrypted", testEncryptAlreadyEncrypted)
+	t.Run("NotRSA", testEncryptNotRSA)
+}
+
+func TestDecrypter(t *testing.T) {
+	encryptingResolver := new(key.MockResolver)
+	encryptingResolver.On("ResolveKey", "recipient").Return(publicPair(rsaPrivateKey), nil)
+	encrypted, err := NewEncrypter("recipient", encryptingResolver).Encrypt(testMessage())
+	require.NoError(t, err)
+
+	t.Run("NotEncrypted", func(t *testing.T) {
+		var (
+			assert    = assert.New(t)
+			decrypter = NewDecrypter(new(key.MockResolver))
+			message   = testMessage()
+		)
+
+		decrypted, err := decrypter.Decrypt(message)
+		assert.True(message == decrypted)
+		assert.NoError(err)
+	})
+
+	t.Run("NoPrivateKey", func(t *testing.T) {
+		var (
+			assert   = assert.New(t)
+			resolver = new(key.MockResolver)
+		)
+
+		resolver.On("ResolveKey", "recipient").Return(publicPair(rsaPrivateKey), nil).Once()
+		decrypted, err := NewDecrypter(resolver).Decrypt(encrypted)
+		assert.Nil(decrypted)
+		assert.Equal(ErrorPrivateKeyRequired, err)
+		resolver.AssertExpectations(t)
+	})
+
+	badEnvelopes := []struct {
+		name     string
+		modify   func(*wrp.Message)
+		expected error
+	}{
+		{"UnknownAlgorithm", func(m *wrp.Message) { m.Metadata[EncryptionAlgorithmKey] = "A128GCM" }, ErrorUnsupportedAlgorithm},
+		{"NoKeyID", func(m *wrp.Message) { delete(m.Metadata, EncryptionKeyIDKey) }, ErrorMissingKeyID},
+		{"NoWrappedKey", func(m *wrp.Message) { delete(m.Metadata, EncryptedKeyKey) }, ErrorMalformedEnvelope},
+		{"BadNonce", func(m *wrp.Message) { m.Metadata[EncryptionNonceKey] = "AAAA" }, ErrorMalformedEnvelope},
+		{"TamperedPayload", func(m *wrp.Message) { m.Payload = append([]byte{0}, m.Payload...) }, ErrorDecryptionFailed},
+		{"WrongKey", func(m *wrp.Message) { m.Metadata[EncryptedKeyKey] = m.Metadata[EncryptionNonceKey] }, ErrorDecryptionFailed},
+	}
+
+	for _, record := range badEnvelopes {
+		t.Run(record.name, func(t *testing.T) {
+			var (
+				assert   = assert.New(t)
+				resolver = new(key.MockResolver)
+				message  = copyMessage(encrypted)
+			)
+
+			resolver.On("ResolveKey", "recipient").Return(privatePair(rsaPrivateKey), nil)
+			record.modify(message)
+			decrypted, err := NewDecrypter(resolver).Decrypt(message)
+			assert.Nil(decrypted)
+			assert.Equal(record.expected, err)
+		})
+	}
+}
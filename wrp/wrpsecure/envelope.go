@@ -0,0 +1,174 @@
+package wrpsecure
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+// Metadata keys used to carry the security envelope of a WRP message
+const (
+	// SignatureKey is the metadata key holding the base64url-encoded signature
+	SignatureKey = "/wrp-signature"
+
+	// SignatureKeyIDKey is the metadata key holding the key id used to verify the signature
+	SignatureKeyIDKey = "/wrp-signature-kid"
+
+	// SignatureAlgorithmKey is the metadata key holding the signature algorithm, e.g. RS256
+	SignatureAlgorithmKey = "/wrp-signature-alg"
+
+	// EncryptionKeyIDKey is the metadata key holding the key id used to unwrap the content key
+	EncryptionKeyIDKey = "/wrp-encryption-kid"
+
+	// EncryptionAlgorithmKey is the metadata key holding the encryption algorithm
+	EncryptionAlgorithmKey = "/wrp-encryption-alg"
+
+	// EncryptedKeyKey is the metadata key holding the base64url-encoded, wrapped content key
+	EncryptedKeyKey = "/wrp-encryption-key"
+
+	// EncryptionNonceKey is the metadata key holding the base64url-encoded nonce
+	EncryptionNonceKey = "/wrp-encryption-nonce"
+
+	// EncryptionContentTypeKey is the metadata key holding the content type of the plaintext payload
+	EncryptionContentTypeKey = "/wrp-encryption-content-type"
+)
+
+// TransportMetadataKeys are the metadata keys which routing software may add or change as a message travels,
+// such as the W3C traceparent added by device.Manager.  These keys are excluded from signatures.
+var TransportMetadataKeys = []string{device.TraceParentKey}
+
+// isSignedMetadata tests if a metadata key is covered by the signature
+func isSignedMetadata(k string) bool {
+	if k == SignatureKey {
+		return false
+	}
+
+	for _, transport := range TransportMetadataKeys {
+		if k == transport {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsSigned tests if the given message carries a signature
+func IsSigned(m *wrp.Message) bool {
+	_, ok := m.Metadata[SignatureKey]
+	return ok
+}
+
+// IsEncrypted tests if the given message has an encrypted payload
+func IsEncrypted(m *wrp.Message) bool {
+	_, ok := m.Metadata[EncryptionAlgorithmKey]
+	return ok
+}
+
+// Canonical produces the canonical encoding of a WRP message, which is the data that gets signed.
+// The end-to-end fields of the message are included in a fixed order, with metadata sorted by key.
+// Fields which change from hop to hop are excluded, so that signatures survive routing:
+//
+//   - Spans and IncludeSpans, as each hop appends spans and some formats truncate their start times
+//   - the TransportMetadataKeys metadata entries
+//   - the SignatureKey metadata entry, as it holds the signature over this encoding
+//
+// The Destination is signed, so rules that rewrite it invalidate the signature.
+//
+// The canonical encoding is not a wire format.  It exists only so that signatures do not depend
+// on the details of any particular wrp.Format.
+func Canonical(m *wrp.Message) []byte {
+	var c canonicalBuffer
+	c.writeInt(int64(m.Type))
+	c.writeString(m.Source)
+	c.writeString(m.Destination)
+	c.writeString(m.TransactionUUID)
+	c.writeString(m.ContentType)
+	c.writeString(m.Accept)
+	c.writeOptionalInt(m.Status)
+	c.writeOptionalInt(m.RequestDeliveryResponse)
+	c.writeStrings(m.Headers)
+
+	keys := make([]string, 0, len(m.Metadata))
+	for k := range m.Metadata {
+		if isSignedMetadata(k) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	c.writeInt(int64(len(keys)))
+	for _, k := range keys {
+		c.writeString(k)
+		c.writeString(m.Metadata[k])
+	}
+
+	c.writeString(m.Path)
+	c.writeBytes(m.Payload)
+	c.writeString(m.ServiceName)
+	c.writeString(m.URL)
+	c.writeStrings(m.PartnerIDs)
+
+	return c.Bytes()
+}
+
+// canonicalBuffer writes length-prefixed values so that no two distinct messages share an encoding
+type canonicalBuffer struct {
+	bytes.Buffer
+}
+
+func (c *canonicalBuffer) writeInt(v int64) {
+	var scratch [binary.MaxVarintLen64]byte
+	c.Write(scratch[:binary.PutVarint(scratch[:], v)])
+}
+
+func (c *canonicalBuffer) writeOptionalInt(v *int64) {
+	if v == nil {
+		c.WriteByte(0)
+	} else {
+		c.WriteByte(1)
+		c.writeInt(*v)
+	}
+}
+
+func (c *canonicalBuffer) writeBytes(v []byte) {
+	c.writeInt(int64(len(v)))
+	c.Write(v)
+}
+
+func (c *canonicalBuffer) writeString(v string) {
+	c.writeInt(int64(len(v)))
+	c.WriteString(v)
+}
+
+func (c *canonicalBuffer) writeStrings(v []string) {
+	c.writeInt(int64(len(v)))
+	for _, s := range v {
+		c.writeString(s)
+	}
+}
+
+// copyMessage produces a shallow copy of a message with its own Metadata map, so that envelope
+// entries can be changed without affecting the original
+func copyMessage(m *wrp.Message) *wrp.Message {
+	copyOf := *m
+	copyOf.Metadata = make(map[string]string, len(m.Metadata)+4)
+	for k, v := range m.Metadata {
+		copyOf.Metadata[k] = v
+	}
+
+	return &copyOf
+}
+
+// removeMetadata deletes the given keys from a message's metadata, leaving Metadata nil if it becomes empty
+func removeMetadata(m *wrp.Message, keys ...string) {
+	for _, k := range keys {
+		delete(m.Metadata, k)
+	}
+
+	if len(m.Metadata) == 0 {
+		m.Metadata = nil
+	}
+}
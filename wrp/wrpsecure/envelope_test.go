@@ -0,0 +1,75 @@
+package wrpsecure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonical(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		original = testMessage()
+		expected = Canonical(original)
+	)
+
+	assert.NotEmpty(expected)
+	assert.Equal(expected, Canonical(testMessage()))
+
+	withSignature := testMessage()
+	withSignature.Metadata[SignatureKey] = "ignored"
+	assert.Equal(expected, Canonical(withSignature), "the signature itself must not be part of the canonical encoding")
+
+	// fields that change in transit are not part of the canonical encoding
+	hops := []func(*wrp.Message){
+		func(m *wrp.Message) { m.Spans = []wrp.Money_Span{{Name: "foo", Start: time.Now()}} },
+		func(m *wrp.Message) { m.SetIncludeSpans(false) },
+		func(m *wrp.Message) { m.Metadata[device.TraceParentKey] = "00-01-02-01" },
+	}
+
+	for i, hop := range hops {
+		changed := testMessage()
+		hop(changed)
+		assert.Equal(expected, Canonical(changed), "transit change %d altered the canonical encoding", i)
+	}
+
+	mutations := []func(*wrp.Message){
+		func(m *wrp.Message) { m.Type = wrp.SimpleEventMessageType },
+		func(m *wrp.Message) { m.Source = "dns:other.com" },
+		func(m *wrp.Message) { m.Destination = "mac:112233445566" },
+		func(m *wrp.Message) { m.TransactionUUID = "" },
+		func(m *wrp.Message) { m.ContentType = "text/plain" },
+		func(m *wrp.Message) { m.Accept = "application/json" },
+		func(m *wrp.Message) { m.Status = nil },
+		func(m *wrp.Message) { m.SetRequestDeliveryResponse(0) },
+		func(m *wrp.Message) { m.Headers = []string{"X-Foo: bar"} },
+		func(m *wrp.Message) { m.Metadata["/fw-name"] = "fw2" },
+		func(m *wrp.Message) { m.Metadata[SignatureKeyIDKey] = "kid" },
+		func(m *wrp.Message) { m.Path = "/config/foo" },
+		func(m *wrp.Message) { m.Payload = append(m.Payload, ' ') },
+		func(m *wrp.Message) { m.ServiceName = "config" },
+		func(m *wrp.Message) { m.URL = "http://foo.com" },
+		func(m *wrp.Message) { m.PartnerIDs = append(m.PartnerIDs, "cox") },
+
+		// length prefixes keep adjacent fields from being confused
+		func(m *wrp.Message) { m.Source, m.Destination = "dns:test.commac:112233445566/config", "" },
+	}
+
+	for i, mutate := range mutations {
+		mutated := testMessage()
+		mutate(mutated)
+		assert.NotEqual(expected, Canonical(mutated), "mutation %d did not change the canonical encoding", i)
+	}
+}
+
+func TestIsSignedIsEncrypted(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(IsSigned(new(wrp.Message)))
+	assert.False(IsEncrypted(new(wrp.Message)))
+	assert.True(IsSigned(&wrp.Message{Metadata: map[string]string{SignatureKey: ""}}))
+	assert.True(IsEncrypted(&wrp.Message{Metadata: map[string]string{EncryptionAlgorithmKey: EncryptionAlgorithm}}))
+}
@@ -0,0 +1,16 @@
+package wrpsecure
+
+import "errors"
+
+var (
+	ErrorMissingSignature      = errors.New("The WRP message is not signed")
+	ErrorMissingKeyID          = errors.New("The WRP envelope has no key id")
+	ErrorInvalidSignature      = errors.New("The WRP message signature is invalid")
+	ErrorUnsupportedAlgorithm  = errors.New("Unsupported WRP envelope algorithm")
+	ErrorAlgorithmKeyMismatch  = errors.New("The WRP envelope algorithm does not match the resolved key")
+	ErrorPrivateKeyRequired    = errors.New("A private key is required")
+	ErrorMalformedEnvelope     = errors.New("The WRP envelope is malformed")
+	ErrorDecryptionFailed      = errors.New("Unable to decrypt the WRP payload")
+	ErrorAlreadyEncrypted      = errors.New("The WRP payload is already encrypted")
+	ErrorUnsupportedMessageKey = errors.New("Only RSA and ECDSA keys are supported")
+)
@@ -0,0 +1,68 @@
+package wrpsecure
+
+import (
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+// NewListener decorates a device.Listener so that messages received from devices are verified and then
+// decrypted before next sees them.  Either v or d may be nil, in which case that step is skipped.
+//
+// Only events for messages that arrived from a device are examined, i.e. MessageReceived, TransactionComplete,
+// and TransactionBroken.  All other events are passed to next unchanged.  Events that fail verification or
+// decryption are passed to rejected, if supplied, and are not passed to next.
+//
+// When a payload is decrypted, next receives a copy of the event whose Message is the decrypted message and
+// whose Contents is that message encoded in the event's Format.
+func NewListener(v Verifier, d Decrypter, rejected func(*device.Event, error), next device.Listener) device.Listener {
+	if v == nil && d == nil {
+		return next
+	}
+
+	if rejected == nil {
+		rejected = func(*device.Event, error) {}
+	}
+
+	return func(e *device.Event) {
+		switch e.Type {
+		case device.MessageReceived, device.TransactionComplete, device.TransactionBroken:
+		default:
+			next(e)
+			return
+		}
+
+		message, ok := e.Message.(*wrp.Message)
+		if !ok {
+			next(e)
+			return
+		}
+
+		if v != nil {
+			if err := v.Verify(message); err != nil {
+				rejected(e, err)
+				return
+			}
+		}
+
+		if d != nil && IsEncrypted(message) {
+			decrypted, err := d.Decrypt(message)
+			if err != nil {
+				rejected(e, err)
+				return
+			}
+
+			var contents []byte
+			if err := wrp.NewEncoderBytes(&contents, e.Format).Encode(decrypted); err != nil {
+				rejected(e, err)
+				return
+			}
+
+			copyOf := *e
+			copyOf.Message = decrypted
+			copyOf.Contents = contents
+			e = &copyOf
+		}
+
+		next(e)
+	}
+}
@@ -0,0 +1,94 @@
+package wrpsecure
+
+import (
+	"testing"
+
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/secure/key"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewListener(t *testing.T) {
+	var (
+		signingResolver = new(key.MockResolver)
+		privateResolver = new(key.MockResolver)
+		publicResolver  = new(key.MockResolver)
+	)
+
+	signingResolver.On("ResolveKey", "device").Return(privatePair(rsaPrivateKey), nil)
+	publicResolver.On("ResolveKey", "device").Return(publicPair(rsaPrivateKey), nil)
+	privateResolver.On("ResolveKey", "device").Return(privatePair(rsaPrivateKey), nil)
+
+	encrypted, err := NewEncrypter("device", publicResolver).Encrypt(testMessage())
+	require.NoError(t, err)
+
+	signed, err := NewSigner("device", signingResolver).Sign(encrypted)
+	require.NoError(t, err)
+
+	var (
+		accepted []*device.Event
+		rejected []error
+
+		listener = NewListener(
+			NewVerifier(publicResolver),
+			NewDecrypter(privateResolver),
+			func(e *device.Event, err error) { rejected = append(rejected, err) },
+			func(e *device.Event) { accepted = append(accepted, e) },
+		)
+	)
+
+	t.Run("PassThrough", func(t *testing.T) {
+		assert := assert.New(t)
+		accepted, rejected = nil, nil
+
+		for _, e := range []*device.Event{
+			{Type: device.Connect},
+			{Type: device.MessageSent, Message: testMessage()},
+			{Type: device.MessageReceived, Message: &wrp.AuthorizationStatus{}},
+		} {
+			listener(e)
+		}
+
+		assert.Len(accepted, 3)
+		assert.Empty(rejected)
+	})
+
+	t.Run("Decrypted", func(t *testing.T) {
+		var (
+			assert  = assert.New(t)
+			require = require.New(t)
+		)
+
+		accepted, rejected = nil, nil
+		for _, eventType := range []device.EventType{device.MessageReceived, device.TransactionComplete, device.TransactionBroken} {
+			listener(&device.Event{
+				Type:     eventType,
+				Message:  signed,
+				Format:   wrp.Msgpack,
+				Contents: wrp.MustEncode(signed, wrp.Msgpack),
+			})
+		}
+
+		assert.Empty(rejected)
+		require.Len(accepted, 3)
+		for _, e := range accepted {
+			assert.Equal(testMessage(), e.Message)
+			assert.Equal(wrp.MustEncode(testMessage(), wrp.Msgpack), e.Contents)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		assert := assert.New(t)
+		accepted, rejected = nil, nil
+
+		tampered := copyMessage(signed)
+		tampered.Source = "mac:665544332211"
+		listener(&device.Event{Type: device.MessageReceived, Message: tampered, Format: wrp.Msgpack})
+		listener(&device.Event{Type: device.MessageReceived, Message: encrypted, Format: wrp.Msgpack})
+
+		assert.Empty(accepted)
+		assert.Equal([]error{ErrorInvalidSignature, ErrorMissingSignature}, rejected)
+	})
+}
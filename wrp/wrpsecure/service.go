@@ -0,0 +1,47 @@
+package wrpsecure
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/wrp/wrpendpoint"
+	"github.com/Comcast/webpa-common/xhttp"
+)
+
+// NewService decorates a wrpendpoint.Service so that requests are verified and then decrypted before
+// being passed to next.  Either v or d may be nil, in which case that step is skipped.
+//
+// Requests that fail verification produce an error with a 403 status code.  Requests that cannot be
+// decrypted produce an error with a 400 status code.  Both errors implement go-kit's StatusCoder.
+func NewService(v Verifier, d Decrypter, next wrpendpoint.Service) wrpendpoint.Service {
+	if v == nil && d == nil {
+		return next
+	}
+
+	return wrpendpoint.ServiceFunc(func(ctx context.Context, request wrpendpoint.Request) (wrpendpoint.Response, error) {
+		message := request.Message()
+		if message == nil {
+			return nil, &xhttp.Error{Code: http.StatusBadRequest, Text: ErrorMalformedEnvelope.Error()}
+		}
+
+		if v != nil {
+			if err := v.Verify(message); err != nil {
+				logging.Error(request.Logger()).Log(logging.MessageKey(), "WRP signature verification failed", logging.ErrorKey(), err)
+				return nil, &xhttp.Error{Code: http.StatusForbidden, Text: err.Error()}
+			}
+		}
+
+		if d != nil && IsEncrypted(message) {
+			decrypted, err := d.Decrypt(message)
+			if err != nil {
+				logging.Error(request.Logger()).Log(logging.MessageKey(), "WRP payload decryption failed", logging.ErrorKey(), err)
+				return nil, &xhttp.Error{Code: http.StatusBadRequest, Text: err.Error()}
+			}
+
+			request = wrpendpoint.WrapAsRequest(request.Logger(), decrypted)
+		}
+
+		return next.ServeWRP(ctx, request)
+	})
+}
@@ -0,0 +1,106 @@
+package wrpsecure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/secure/key"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/Comcast/webpa-common/wrp/wrpendpoint"
+	"github.com/Comcast/webpa-common/xhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewService(t *testing.T) {
+	var (
+		signingResolver = new(key.MockResolver)
+		privateResolver = new(key.MockResolver)
+		publicResolver  = new(key.MockResolver)
+	)
+
+	signingResolver.On("ResolveKey", "signer").Return(privatePair(ecdsaPrivateKey), nil)
+	publicResolver.On("ResolveKey", "signer").Return(publicPair(ecdsaPrivateKey), nil)
+	publicResolver.On("ResolveKey", "recipient").Return(publicPair(rsaPrivateKey), nil)
+	privateResolver.On("ResolveKey", "recipient").Return(privatePair(rsaPrivateKey), nil)
+
+	encrypted, err := NewEncrypter("recipient", publicResolver).Encrypt(testMessage())
+	require.NoError(t, err)
+
+	signed, err := NewSigner("signer", signingResolver).Sign(encrypted)
+	require.NoError(t, err)
+
+	t.Run("Undecorated", func(t *testing.T) {
+		next := wrpendpoint.ServiceFunc(func(context.Context, wrpendpoint.Request) (wrpendpoint.Response, error) {
+			return nil, nil
+		})
+
+		assert.NotNil(t, NewService(nil, nil, next))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var (
+			assert   = assert.New(t)
+			require  = require.New(t)
+			expected = wrpendpoint.WrapAsResponse(new(wrp.Message))
+			called   = false
+
+			service = NewService(
+				NewVerifier(publicResolver),
+				NewDecrypter(privateResolver),
+				wrpendpoint.ServiceFunc(func(ctx context.Context, request wrpendpoint.Request) (wrpendpoint.Response, error) {
+					called = true
+					assert.Equal(testMessage(), request.Message())
+					return expected, nil
+				}),
+			)
+		)
+
+		actual, err := service.ServeWRP(context.Background(), wrpendpoint.WrapAsRequest(logging.NewTestLogger(nil, t), signed))
+		require.NoError(err)
+		assert.True(called)
+		assert.Equal(expected, actual)
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		var (
+			assert  = assert.New(t)
+			service = NewService(
+				NewVerifier(publicResolver),
+				NewDecrypter(privateResolver),
+				wrpendpoint.ServiceFunc(func(context.Context, wrpendpoint.Request) (wrpendpoint.Response, error) {
+					assert.Fail("the next service should not have been called")
+					return nil, nil
+				}),
+			)
+		)
+
+		actual, err := service.ServeWRP(context.Background(), wrpendpoint.WrapAsRequest(logging.NewTestLogger(nil, t), encrypted))
+		assert.Nil(actual)
+		require.IsType(t, (*xhttp.Error)(nil), err)
+		assert.Equal(http.StatusForbidden, err.(*xhttp.Error).Code)
+	})
+
+	t.Run("DecryptionFailure", func(t *testing.T) {
+		var (
+			assert   = assert.New(t)
+			tampered = copyMessage(encrypted)
+			service  = NewService(
+				nil,
+				NewDecrypter(privateResolver),
+				wrpendpoint.ServiceFunc(func(context.Context, wrpendpoint.Request) (wrpendpoint.Response, error) {
+					assert.Fail("the next service should not have been called")
+					return nil, nil
+				}),
+			)
+		)
+
+		tampered.Payload = []byte("tampered")
+		actual, err := service.ServeWRP(context.Background(), wrpendpoint.WrapAsRequest(logging.NewTestLogger(nil, t), tampered))
+		assert.Nil(actual)
+		require.IsType(t, (*xhttp.Error)(nil), err)
+		assert.Equal(http.StatusBadRequest, err.(*xhttp.Error).Code)
+	})
+}
@@ -0,0 +1,69 @@
+package wrpsecure
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+
+	"github.com/Comcast/webpa-common/secure/key"
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+var (
+	rsaPrivateKey   *rsa.PrivateKey
+	ecdsaPrivateKey *ecdsa.PrivateKey
+)
+
+func init() {
+	var err error
+	if rsaPrivateKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		panic(err)
+	}
+
+	if ecdsaPrivateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		panic(err)
+	}
+}
+
+// testPair is a simple key.Pair used to supply generated keys to resolvers
+type testPair struct {
+	public  interface{}
+	private interface{}
+}
+
+func (tp testPair) Purpose() key.Purpose { return key.PurposeVerify }
+func (tp testPair) Public() interface{}  { return tp.public }
+func (tp testPair) HasPrivate() bool     { return tp.private != nil }
+func (tp testPair) Private() interface{} { return tp.private }
+
+func privatePair(privateKey interface{}) key.Pair {
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return testPair{public: k.Public(), private: k}
+	case *ecdsa.PrivateKey:
+		return testPair{public: k.Public(), private: k}
+	default:
+		panic("unsupported key")
+	}
+}
+
+func publicPair(privateKey interface{}) key.Pair {
+	return testPair{public: privatePair(privateKey).Public()}
+}
+
+func testMessage() *wrp.Message {
+	status := int64(200)
+	return &wrp.Message{
+		Type:            wrp.SimpleRequestResponseMessageType,
+		Source:          "dns:test.com",
+		Destination:     "mac:112233445566/config",
+		TransactionUUID: "1234",
+		ContentType:     "application/json",
+		Status:          &status,
+		Metadata:        map[string]string{"/fw-name": "fw1"},
+		Path:            "/config",
+		Payload:         []byte(`{"foo": "bar"}`),
+		PartnerIDs:      []string{"comcast"},
+	}
+}
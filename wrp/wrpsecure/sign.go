@@ -0,0 +1,205 @@
+package wrpsecure
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/Comcast/webpa-common/secure/key"
+	"github.com/Comcast/webpa-common/wrp"
+)
+
+// Signer produces signed WRP messages
+type Signer interface {
+	// Sign returns a copy of the given message with a signature over its canonical encoding.
+	// The original message is not modified.  Any existing signature is replaced.
+	Sign(*wrp.Message) (*wrp.Message, error)
+}
+
+// Verifier checks the signatures of WRP messages
+type Verifier interface {
+	// Verify checks the signature of the given message.  ErrorMissingSignature is returned
+	// if the message is not signed.
+	Verify(*wrp.Message) error
+}
+
+// NewSigner creates a Signer which signs with the private key resolved from keyID.  The key is resolved
+// each time a message is signed, so the resolver is typically a key.Cache.
+func NewSigner(keyID string, resolver key.Resolver) Signer {
+	return &signer{keyID: keyID, resolver: resolver}
+}
+
+type signer struct {
+	keyID    string
+	resolver key.Resolver
+}
+
+func (s *signer) Sign(m *wrp.Message) (*wrp.Message, error) {
+	pair, err := s.resolver.ResolveKey(s.keyID)
+	if err != nil {
+		return nil, err
+	} else if !pair.HasPrivate() {
+		return nil, ErrorPrivateKeyRequired
+	}
+
+	alg, err := signingAlgorithmFor(pair.Private())
+	if err != nil {
+		return nil, err
+	}
+
+	signed := copyMessage(m)
+	signed.Metadata[SignatureKeyIDKey] = s.keyID
+	signed.Metadata[SignatureAlgorithmKey] = alg.name
+	delete(signed.Metadata, SignatureKey)
+
+	signature, err := alg.sign(pair.Private(), Canonical(signed))
+	if err != nil {
+		return nil, err
+	}
+
+	signed.Metadata[SignatureKey] = base64.RawURLEncoding.EncodeToString(signature)
+	return signed, nil
+}
+
+// NewVerifier creates a Verifier which resolves public keys using the key id in each message's envelope
+func NewVerifier(resolver key.Resolver) Verifier {
+	return &verifier{resolver: resolver}
+}
+
+type verifier struct {
+	resolver key.Resolver
+}
+
+func (v *verifier) Verify(m *wrp.Message) error {
+	encoded, ok := m.Metadata[SignatureKey]
+	if !ok {
+		return ErrorMissingSignature
+	}
+
+	keyID := m.Metadata[SignatureKeyIDKey]
+	if len(keyID) == 0 {
+		return ErrorMissingKeyID
+	}
+
+	alg, ok := signingAlgorithms[m.Metadata[SignatureAlgorithmKey]]
+	if !ok {
+		return ErrorUnsupportedAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrorMalformedEnvelope
+	}
+
+	pair, err := v.resolver.ResolveKey(keyID)
+	if err != nil {
+		return err
+	}
+
+	return alg.verify(pair.Public(), Canonical(m), signature)
+}
+
+// signingAlgorithm describes a supported signature scheme.  Names follow the JWS conventions.
+type signingAlgorithm struct {
+	name string
+	hash crypto.Hash
+	size int
+}
+
+var signingAlgorithms = map[string]signingAlgorithm{
+	"RS256": {name: "RS256", hash: crypto.SHA256},
+	"ES256": {name: "ES256", hash: crypto.SHA256, size: 32},
+	"ES384": {name: "ES384", hash: crypto.SHA384, size: 48},
+	"ES512": {name: "ES512", hash: crypto.SHA512, size: 66},
+}
+
+// signingAlgorithmFor selects the signature scheme appropriate for a private key
+func signingAlgorithmFor(privateKey interface{}) (signingAlgorithm, error) {
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return signingAlgorithms["RS256"], nil
+
+	case *ecdsa.PrivateKey:
+		switch k.Curve.Params().BitSize {
+		case 256:
+			return signingAlgorithms["ES256"], nil
+		case 384:
+			return signingAlgorithms["ES384"], nil
+		case 521:
+			return signingAlgorithms["ES512"], nil
+		}
+	}
+
+	return signingAlgorithm{}, ErrorUnsupportedMessageKey
+}
+
+func (sa signingAlgorithm) digest(data []byte) []byte {
+	h := sa.hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (sa signingAlgorithm) sign(privateKey interface{}, data []byte) ([]byte, error) {
+	digest := sa.digest(data)
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if sa.size == 0 {
+			return rsa.SignPKCS1v15(rand.Reader, k, sa.hash, digest)
+		}
+
+	case *ecdsa.PrivateKey:
+		if sa.size > 0 {
+			r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+			if err != nil {
+				return nil, err
+			}
+
+			// JWS-style encoding: fixed-width r followed by fixed-width s
+			var (
+				signature = make([]byte, 2*sa.size)
+				rBytes    = r.Bytes()
+				sBytes    = s.Bytes()
+			)
+
+			copy(signature[sa.size-len(rBytes):sa.size], rBytes)
+			copy(signature[2*sa.size-len(sBytes):], sBytes)
+			return signature, nil
+		}
+	}
+
+	return nil, ErrorAlgorithmKeyMismatch
+}
+
+func (sa signingAlgorithm) verify(publicKey interface{}, data, signature []byte) error {
+	digest := sa.digest(data)
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		if sa.size == 0 {
+			if rsa.VerifyPKCS1v15(k, sa.hash, digest, signature) != nil {
+				return ErrorInvalidSignature
+			}
+
+			return nil
+		}
+
+	case *ecdsa.PublicKey:
+		if sa.size > 0 {
+			if len(signature) != 2*sa.size {
+				return ErrorInvalidSignature
+			}
+
+			r := new(big.Int).SetBytes(signature[:sa.size])
+			s := new(big.Int).SetBytes(signature[sa.size:])
+			if !ecdsa.Verify(k, digest, r, s) {
+				return ErrorInvalidSignature
+			}
+
+			return nil
+		}
+	}
+
+	return ErrorAlgorithmKeyMismatch
+}
@@ -0,0 +1,260 @@
+package wrpsecure
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Comcast/webpa-common/device"
+	"github.com/Comcast/webpa-common/secure/key"
+	"github.com/Comcast/webpa-common/tracing"
+	"github.com/Comcast/webpa-common/wrp"
+	"github.com/Comcast/webpa-common/wrp/wrphttp"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSignAndVerify(t *testing.T, privateKey interface{}, expectedAlgorithm string) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		signingResolver   = new(key.MockResolver)
+		verifyingResolver = new(key.MockResolver)
+
+		signer   = NewSigner("test", signingResolver)
+		verifier = NewVerifier(verifyingResolver)
+		original = testMessage()
+	)
+
+	signingResolver.On("ResolveKey", "test").Return(privatePair(privateKey), nil).Once()
+	verifyingResolver.On("ResolveKey", "test").Return(publicPair(privateKey), nil).Times(3)
+
+	signed, err := signer.Sign(original)
+	require.NoError(err)
+	require.NotNil(signed)
+
+	assert.False(IsSigned(original), "the original message should not be modified")
+	assert.Equal(map[string]string{"/fw-name": "fw1"}, original.Metadata)
+
+	assert.True(IsSigned(signed))
+	assert.Equal("test", signed.Metadata[SignatureKeyIDKey])
+	assert.Equal(expectedAlgorithm, signed.Metadata[SignatureAlgorithmKey])
+	assert.Equal("fw1", signed.Metadata["/fw-name"])
+	assert.NoError(verifier.Verify(signed))
+
+	tampered := copyMessage(signed)
+	tampered.Payload = []byte("tampered")
+	assert.Equal(ErrorInvalidSignature, verifier.Verify(tampered))
+
+	tampered = copyMessage(signed)
+	tampered.Metadata["/fw-name"] = "tampered"
+	assert.Equal(ErrorInvalidSignature, verifier.Verify(tampered))
+
+	signingResolver.AssertExpectations(t)
+	verifyingResolver.AssertExpectations(t)
+}
+
+func testSignNoPrivateKey(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		resolver = new(key.MockResolver)
+		signer   = NewSigner("test", resolver)
+	)
+
+	resolver.On("ResolveKey", "test").Return(publicPair(rsaPrivateKey), nil).Once()
+	signed, err := signer.Sign(testMessage())
+	assert.Nil(signed)
+	assert.Equal(ErrorPrivateKeyRequired, err)
+
+	resolver.AssertExpectations(t)
+}
+
+func testSignResolveError(t *testing.T) {
+	var (
+		assert        = assert.New(t)
+		resolver      = new(key.MockResolver)
+		signer        = NewSigner("test", resolver)
+		expectedError = errors.New("expected")
+	)
+
+	resolver.On("ResolveKey", "test").Return(nil, expectedError).Once()
+	signed, err := signer.Sign(testMessage())
+	assert.Nil(signed)
+	assert.Equal(expectedError, err)
+
+	resolver.AssertExpectations(t)
+}
+
+func testSignUnsupportedCurve(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		resolver = new(key.MockResolver)
+		signer   = NewSigner("test", resolver)
+	)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+
+	resolver.On("ResolveKey", "test").Return(privatePair(privateKey), nil).Once()
+	signed, err := signer.Sign(testMessage())
+	assert.Nil(signed)
+	assert.Equal(ErrorUnsupportedMessageKey, err)
+
+	resolver.AssertExpectations(t)
+}
+
+func TestSigner(t *testing.T) {
+	t.Run("RSA", func(t *testing.T) { testSignAndVerify(t, rsaPrivateKey, "RS256") })
+	t.Run("ECDSA", func(t *testing.T) { testSignAndVerify(t, ecdsaPrivateKey, "ES256") })
+	t.Run("NoPrivateKey", testSignNoPrivateKey)
+	t.Run("ResolveError", testSignResolveError)
+	t.Run("UnsupportedCurve", testSignUnsupportedCurve)
+}
+
+func TestVerifierBadEnvelope(t *testing.T) {
+	signed, err := NewSigner("test", func() key.Resolver {
+		r := new(key.MockResolver)
+		r.On("ResolveKey", "test").Return(privatePair(rsaPrivateKey), nil)
+		return r
+	}()).Sign(testMessage())
+
+	require.NoError(t, err)
+
+	testData := []struct {
+		name     string
+		modify   func(map[string]string)
+		expected error
+	}{
+		{"Unsigned", func(m map[string]string) { delete(m, SignatureKey) }, ErrorMissingSignature},
+		{"NoKeyID", func(m map[string]string) { delete(m, SignatureKeyIDKey) }, ErrorMissingKeyID},
+		{"NoAlgorithm", func(m map[string]string) { delete(m, SignatureAlgorithmKey) }, ErrorUnsupportedAlgorithm},
+		{"UnknownAlgorithm", func(m map[string]string) { m[SignatureAlgorithmKey] = "HS256" }, ErrorUnsupportedAlgorithm},
+		{"BadEncoding", func(m map[string]string) { m[SignatureKey] = "this is not base64!" }, ErrorMalformedEnvelope},
+		{"AlgorithmMismatch", func(m map[string]string) { m[SignatureAlgorithmKey] = "ES256" }, ErrorAlgorithmKeyMismatch},
+	}
+
+	for _, record := range testData {
+		t.Run(record.name, func(t *testing.T) {
+			var (
+				assert   = assert.New(t)
+				resolver = new(key.MockResolver)
+				verifier = NewVerifier(resolver)
+				message  = copyMessage(signed)
+			)
+
+			resolver.On("ResolveKey", "test").Return(publicPair(rsaPrivateKey), nil)
+			record.modify(message.Metadata)
+			assert.Equal(record.expected, verifier.Verify(message))
+		})
+	}
+}
+
+// testSignedMessage produces a signed copy of testMessage with the given type, along with a Verifier for it
+func testSignedMessage(t *testing.T, messageType wrp.MessageType) (*wrp.Message, Verifier) {
+	signingResolver := new(key.MockResolver)
+	signingResolver.On("ResolveKey", "test").Return(privatePair(ecdsaPrivateKey), nil)
+
+	verifyingResolver := new(key.MockResolver)
+	verifyingResolver.On("ResolveKey", "test").Return(publicPair(ecdsaPrivateKey), nil)
+
+	message := testMessage()
+	message.Type = messageType
+	message.Spans = []wrp.Money_Span{{Name: "origin", Start: time.Unix(1234, 5678), Duration: time.Millisecond}}
+
+	signed, err := NewSigner("test", signingResolver).Sign(message)
+	require.NoError(t, err)
+	return signed, NewVerifier(verifyingResolver)
+}
+
+func testVerifyTransitHeaders(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		signed, verifier = testSignedMessage(t, wrp.SimpleRequestResponseMessageType)
+		header           = make(http.Header)
+		body             bytes.Buffer
+	)
+
+	// a hop appends its own span before forwarding the message as HTTP headers, which truncate span starts to seconds
+	hop := copyMessage(signed)
+	hop.Spans = append(hop.Spans, wrp.Money_Span{Name: "hop", Start: time.Now(), Duration: time.Second})
+
+	wrphttp.AddMessageHeaders(header, hop)
+	require.NoError(wrphttp.WriteMessagePayload(header, &body, hop))
+
+	received, err := wrphttp.NewMessageFromHeaders(header, &body)
+	require.NoError(err)
+	require.Len(received.Spans, 2)
+	assert.NotEqual(signed.Spans[0].Start, received.Spans[0].Start)
+	assert.NoError(verifier.Verify(received))
+
+	received.Path = "/tampered"
+	assert.Equal(ErrorInvalidSignature, verifier.Verify(received))
+}
+
+func testVerifyTransitDevice(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		signed, verifier = testSignedMessage(t, wrp.SimpleEventMessageType)
+		connectWait      = new(sync.WaitGroup)
+
+		// the manager logs asynchronously, after this test has finished, so the default logger is used
+		manager = device.NewManager(&device.Options{
+			Listeners: []device.Listener{
+				func(e *device.Event) {
+					if e.Type == device.Connect {
+						connectWait.Done()
+					}
+				},
+			},
+		})
+
+		server = httptest.NewServer(
+			alice.New(device.UseID.FromHeader).Then(&device.ConnectHandler{Connector: manager}),
+		)
+	)
+
+	defer server.Close()
+	connectWait.Add(1)
+
+	connection, _, err := device.DefaultDialer().DialDevice("mac:112233445566", "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(err)
+	defer connection.Close()
+	connectWait.Wait()
+
+	// routing a request that carries a span context must not invalidate the signature
+	ctx := tracing.WithSpanContext(context.Background(), tracing.SpanContext{TraceID: tracing.NewTraceID(), SpanID: tracing.NewSpanID()})
+	_, err = manager.Route((&device.Request{Message: signed, Format: wrp.Msgpack}).WithContext(ctx))
+	require.NoError(err)
+
+	require.NoError(connection.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	_, contents, err := connection.ReadMessage()
+	require.NoError(err)
+
+	var received wrp.Message
+	require.NoError(wrp.NewDecoderBytes(contents, wrp.Msgpack).Decode(&received))
+	assert.NotContains(received.Metadata, device.TraceParentKey, "signed messages should be routed as signed")
+	assert.NoError(verifier.Verify(&received))
+
+	received.Payload = []byte("tampered")
+	assert.Equal(ErrorInvalidSignature, verifier.Verify(&received))
+}
+
+func TestVerifyTransit(t *testing.T) {
+	t.Run("Headers", testVerifyTransitHeaders)
+	t.Run("Device", testVerifyTransitDevice)
+}
@@ -104,6 +104,16 @@ func WithClientAfter(after ...gokithttp.ClientResponseFunc) Option {
 	}
 }
 
+// WithSpanner configures the Spanner used to create a span for each fanout request.  Each span is a child of
+// the original request's span, when the original request carries a W3C traceparent header or a tracing.SpanContext,
+// and each fanout request carries a traceparent header identifying its span.  If spanner is nil, a new
+// tracing.NewSpanner() is used for each original request.
+func WithSpanner(spanner tracing.Spanner) Option {
+	return func(h *Handler) {
+		h.spanner = spanner
+	}
+}
+
 // WithConfiguration uses a set of (typically injected) fanout configuration options to configure a Handler.
 // Use of this option will not override the configured Endpoints instance.
 func WithConfiguration(c Configuration) Option {
@@ -125,6 +135,7 @@ type Handler struct {
 	after           []FanoutResponseFunc
 	shouldTerminate ShouldTerminateFunc
 	transactor      func(*http.Request) (*http.Response, error)
+	spanner         tracing.Spanner
 }
 
 // New creates a fanout Handler.  The Endpoints strategy is required, and this constructor function will
@@ -197,12 +208,12 @@ func (h *Handler) newFanoutRequests(fanoutCtx context.Context, original *http.Re
 // execute performs a single fanout HTTP transaction and sends the result on a channel.  This method is invoked
 // as a goroutine.  It takes care of draining the fanout's response prior to returning.
 func (h *Handler) execute(logger log.Logger, spanner tracing.Spanner, results chan<- Result, request *http.Request) {
-	var (
-		finisher = spanner.Start(request.URL.String())
-		result   = Result{
-			Request: request,
-		}
-	)
+	spanCtx, finisher := tracing.StartSpan(request.Context(), spanner, request.URL.String())
+	tracinghttp.InjectTraceParent(spanCtx, request)
+
+	result := Result{
+		Request: request,
+	}
 
 	result.Response, result.Err = h.transactor(request)
 	switch {
@@ -277,8 +288,12 @@ func (h *Handler) finish(logger log.Logger, response http.ResponseWriter, result
 }
 
 func (h *Handler) ServeHTTP(response http.ResponseWriter, original *http.Request) {
+	fanoutCtx := original.Context()
+	if _, ok := tracing.SpanContextFromContext(fanoutCtx); !ok {
+		fanoutCtx = tracinghttp.ExtractTraceParent(fanoutCtx, original)
+	}
+
 	var (
-		fanoutCtx     = original.Context()
 		logger        = logging.GetLogger(fanoutCtx)
 		requests, err = h.newFanoutRequests(fanoutCtx, original)
 	)
@@ -290,10 +305,14 @@ func (h *Handler) ServeHTTP(response http.ResponseWriter, original *http.Request
 	}
 
 	var (
-		spanner = tracing.NewSpanner()
+		spanner = h.spanner
 		results = make(chan Result, len(requests))
 	)
 
+	if spanner == nil {
+		spanner = tracing.NewSpanner()
+	}
+
 	for _, r := range requests {
 		go h.execute(logger, spanner, results, r)
 	}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -12,6 +13,8 @@ import (
 	"time"
 
 	"github.com/Comcast/webpa-common/logging"
+	"github.com/Comcast/webpa-common/tracing"
+	"github.com/Comcast/webpa-common/tracing/tracinghttp"
 	"github.com/Comcast/webpa-common/xhttp"
 	"github.com/Comcast/webpa-common/xhttp/xhttptest"
 	gokithttp "github.com/go-kit/kit/transport/http"
@@ -334,11 +337,54 @@ func testHandlerTimeout(t *testing.T, endpointCount int) {
 	transactor.AssertExpectations(t)
 }
 
+func testHandlerTraceParent(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		parent   = tracing.SpanContext{TraceID: tracing.NewTraceID(), SpanID: tracing.NewSpanID(), Sampled: true}
+		original = httptest.NewRequest("GET", "/api/v2/something", nil)
+		response = httptest.NewRecorder()
+
+		traceParents = make(chan string, 1)
+		exported     = make(chan tracing.Span, 1)
+
+		handler = New(
+			generateEndpoints(1),
+			WithTransactor(func(request *http.Request) (*http.Response, error) {
+				traceParents <- request.Header.Get(tracinghttp.TraceParentHeader)
+				return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+			}),
+			WithSpanner(tracing.NewSpanner(tracing.ExportTo(tracing.ExporterFunc(func(spans ...tracing.Span) {
+				for _, s := range spans {
+					exported <- s
+				}
+			})))),
+		)
+	)
+
+	require.NotNil(handler)
+	original.Header.Set(tracinghttp.TraceParentHeader, parent.TraceParent())
+	handler.ServeHTTP(response, original)
+	assert.Equal(http.StatusOK, response.Code)
+
+	child, err := tracing.ParseTraceParent(<-traceParents)
+	require.NoError(err)
+	assert.Equal(parent.TraceID, child.TraceID)
+	assert.NotEqual(parent.SpanID, child.SpanID)
+
+	span, ok := (<-exported).(tracing.TracedSpan)
+	require.True(ok)
+	assert.Equal(child, span.SpanContext())
+	assert.Equal(parent.SpanID, span.ParentSpanID())
+}
+
 func TestHandler(t *testing.T) {
 	t.Run("BodyError", testHandlerBodyError)
 	t.Run("NoEndpoints", testHandlerNoEndpoints)
 	t.Run("EndpointsError", testHandlerEndpointsError)
 	t.Run("BadTransactor", testHandlerBadTransactor)
+	t.Run("TraceParent", testHandlerTraceParent)
 
 	t.Run("Fanout", func(t *testing.T) {
 		testData := []struct {
@@ -18,12 +18,14 @@ type ServiceEndpoints struct {
 	lock            sync.RWMutex
 	keyFunc         service.KeyFunc
 	accessorFactory service.AccessorFactory
+	replicas        int
 	accessors       map[string]service.Accessor
 }
 
 // FanoutURLs uses the currently available discovered endpoints to produce a set of URLs.
 // The original request is used to produce a hash key, then each accessor is consulted for
-// the endpoint that matches that key.
+// the endpoint that matches that key.  When configured with more than one replica, each accessor
+// supplies that many endpoints in hash preference order.
 func (se *ServiceEndpoints) FanoutURLs(original *http.Request) ([]*url.URL, error) {
 	hashKey, err := se.keyFunc(original)
 	if err != nil {
@@ -31,15 +33,15 @@ func (se *ServiceEndpoints) FanoutURLs(original *http.Request) ([]*url.URL, erro
 	}
 
 	se.lock.RLock()
-	endpoints := make([]string, 0, len(se.accessors))
+	endpoints := make([]string, 0, len(se.accessors)*se.replicas)
 	for _, a := range se.accessors {
-		e, err := a.Get(hashKey)
+		e, err := service.GetN(a, hashKey, se.replicas)
 		if err != nil {
 			se.lock.RUnlock()
 			return nil, err
 		}
 
-		endpoints = append(endpoints, e)
+		endpoints = append(endpoints, e...)
 	}
 
 	se.lock.RUnlock()
@@ -82,12 +84,27 @@ func WithAccessorFactory(af service.AccessorFactory) ServiceEndpointsOption {
 	}
 }
 
+// WithReplicas configures the number of endpoints, in hash preference order, that each request is sent to
+// for each set of discovered instances.  This allows requests to reach the replicas of the preferred instance.
+// Accessors which do not implement service.OrderedAccessor only ever supply one endpoint.  If n is nonpositive,
+// a single endpoint is used, which is the default.
+func WithReplicas(n int) ServiceEndpointsOption {
+	return func(se *ServiceEndpoints) {
+		if n > 0 {
+			se.replicas = n
+		} else {
+			se.replicas = 1
+		}
+	}
+}
+
 // NewServiceEndpoints creates a ServiceEndpoints instance.  By default, device.IDHashParser is used as the KeyFunc
 // and service.DefaultAccessorFactory is used as the accessor factory.
 func NewServiceEndpoints(options ...ServiceEndpointsOption) *ServiceEndpoints {
 	se := &ServiceEndpoints{
 		keyFunc:         device.IDHashParser,
 		accessorFactory: service.DefaultAccessorFactory,
+		replicas:        1,
 		accessors:       make(map[string]service.Accessor),
 	}
 
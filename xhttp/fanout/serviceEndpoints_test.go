@@ -164,15 +164,49 @@ func testNewServiceEndpointsCustom(t *testing.T) {
 	assert.NoError(err)
 }
 
+func testNewServiceEndpointsReplicas(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		require = require.New(t)
+
+		request = httptest.NewRequest("GET", "/", nil)
+
+		se = NewServiceEndpoints(WithReplicas(2))
+	)
+
+	require.NotNil(se)
+	request.Header.Set(device.DeviceNameHeader, "mac:112233445566")
+
+	se.MonitorEvent(monitor.Event{Key: "key1", Instances: []string{"http://localhost:8080"}})
+	urls, err := se.FanoutURLs(request)
+	assert.Len(urls, 1)
+	assert.Contains(urls, &url.URL{Scheme: "http", Host: "localhost:8080"})
+	assert.NoError(err)
+
+	se.MonitorEvent(monitor.Event{Key: "key1", Instances: []string{"http://one.com", "http://two.com", "http://three.com"}})
+	urls, err = se.FanoutURLs(request)
+	assert.Len(urls, 2)
+	assert.NotEqual(urls[0], urls[1])
+	assert.NoError(err)
+
+	se.MonitorEvent(monitor.Event{Key: "key2", Instances: []string{"http://foobar.net:1234"}})
+	urls, err = se.FanoutURLs(request)
+	assert.Len(urls, 3)
+	assert.Contains(urls, &url.URL{Scheme: "http", Host: "foobar.net:1234"})
+	assert.NoError(err)
+}
+
 func TestNewServiceEndpoints(t *testing.T) {
 	t.Run("KeyFuncError", testNewServiceEndpointsKeyFuncError)
 
 	t.Run("Default", func(t *testing.T) {
 		testNewServiceEndpointsDefault(t, NewServiceEndpoints())
 		testNewServiceEndpointsDefault(t, NewServiceEndpoints(WithAccessorFactory(nil), WithKeyFunc(nil)))
+		testNewServiceEndpointsDefault(t, NewServiceEndpoints(WithReplicas(0)))
 	})
 
 	t.Run("Custom", testNewServiceEndpointsCustom)
+	t.Run("Replicas", testNewServiceEndpointsReplicas)
 }
 
 func TestServiceEndpointsAlternate(t *testing.T) {